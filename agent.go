@@ -0,0 +1,300 @@
+package recovery
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// WithGPGAgent configures the recovered identity to be provisioned into the
+// gpg-agent of the GnuPG home directory home (GNUPGHOME or ~/.gnupg if
+// empty), so that it can be used for signing straight away without any
+// manual gpg commands (see ProvisionGPGAgent).
+//
+// The private key is still printed if WithStdout is also given.
+func WithGPGAgent(home string) Option {
+	return func(r *Recovery) {
+		r.gpgAgent = true
+		r.gpgAgentHome = home
+	}
+}
+
+// WithGPGAgentPassphrase configures the recovery to prompt for a passphrase
+// to protect the keys provisioned into gpg-agent with, presetting it in the
+// agent's passphrase cache so that the identity can be used without being
+// asked for it. Presetting requires allow-preset-passphrase in
+// gpg-agent.conf.
+func WithGPGAgentPassphrase() Option {
+	return func(r *Recovery) {
+		r.gpgAgentPassphrase = true
+	}
+}
+
+// checkGPGAgent checks the gpg-agent options before prompting for anything.
+func (r *Recovery) checkGPGAgent() error {
+	if r.gpgAgentPassphrase && !r.gpgAgent {
+		return errors.New("a gpg-agent passphrase requires WithGPGAgent")
+	}
+	if r.gpgAgent && r.stdout == nil && r.passphraseTTY != nil {
+		return errors.New("the private key can only be encrypted with a one-time passphrase if it is also written to an output")
+	}
+	if r.gpgAgentPassphrase && r.yubiKey {
+		return errors.New("keys moved onto a YubiKey are protected by its PIN rather than a gpg-agent passphrase")
+	}
+	if r.gpgAgentPassphrase && r.nitrokey {
+		return errors.New("keys moved onto a Nitrokey are protected by its PIN rather than a gpg-agent passphrase")
+	}
+	if r.yubiKey && r.nitrokey {
+		return errors.New("WithYubiKey can't be combined with WithNitrokey")
+	}
+	if r.gpgAgent && r.demo {
+		return errors.New("the demo identity must never be provisioned into gpg-agent")
+	}
+	return nil
+}
+
+// provisionGPGAgent provisions entity into the configured gpg-agent,
+// prompting for the passphrase to protect it with if configured.
+func (r *Recovery) provisionGPGAgent(entity *openpgp.Entity) error {
+	var passphrase []byte
+	if r.gpgAgentPassphrase {
+		secret, err := r.readSecret("Please enter a passphrase to protect the keys in gpg-agent:")
+		if err != nil {
+			return err
+		}
+		defer wipe(secret)
+		if len(secret) == 0 {
+			return errors.New("the gpg-agent passphrase must not be empty")
+		}
+		passphrase = secret
+	}
+	r.info("Provisioning the recovered identity into gpg-agent")
+	if err := ProvisionGPGAgent(r.ctx, entity, r.gpgAgentHome, passphrase); err != nil {
+		return fmt.Errorf("could not provision gpg-agent: %s", err)
+	}
+	r.audit(auditEntry{Step: "gpg-agent provisioned"})
+	return nil
+}
+
+// ProvisionGPGAgent imports the private keys of entity into the gpg-agent of
+// the GnuPG home directory home (GNUPGHOME or ~/.gnupg if empty), starting
+// the agent if it isn't running, then imports the public key with ultimate
+// ownertrust so that gpg can sign with it straight away.
+//
+// The keys are sent to the agent over its Assuan socket wrapped with the
+// agent's import key, so they are never written anywhere but the agent's
+// private-keys-v1.d. If passphrase is empty they are stored unprotected,
+// otherwise they are protected with it and it is preset in the agent's
+// passphrase cache, which requires allow-preset-passphrase in
+// gpg-agent.conf. Keys which the agent already has are left as they are.
+func ProvisionGPGAgent(ctx context.Context, entity *openpgp.Entity, home string, passphrase []byte) error {
+	gpg, agent, err := connectGPGAgent(ctx, home)
+	if err != nil {
+		return err
+	}
+	defer agent.Close()
+
+	id := NewIdentity(entity)
+	primaryGrip, subkeyGrip, err := id.Keygrips()
+	if err != nil {
+		return err
+	}
+	keys := []struct {
+		grip string
+		key  interface{}
+	}{
+		{primaryGrip, entity.PrivateKey.PrivateKey},
+		{subkeyGrip, entity.Subkeys[0].PrivateKey.PrivateKey},
+	}
+
+	// preset the passphrase first, so nothing is imported if the agent
+	// doesn't allow it
+	if len(passphrase) > 0 {
+		hexPassphrase := []byte(hex.EncodeToString(passphrase))
+		defer wipe(hexPassphrase)
+		for _, k := range keys {
+			if err := agent.presetPassphrase(k.grip, hexPassphrase); err != nil {
+				return fmt.Errorf("could not preset passphrase (is allow-preset-passphrase set in gpg-agent.conf?): %s", err)
+			}
+		}
+	}
+
+	// answer the agent's passphrase inquiries rather than it running
+	// pinentry
+	if _, err := agent.transact("OPTION pinentry-mode=loopback", nil); err != nil {
+		return err
+	}
+	kek, err := agent.transact("KEYWRAP_KEY --import", nil)
+	if err != nil {
+		return fmt.Errorf("could not get the import key: %s", err)
+	}
+	defer wipe(kek)
+	for _, k := range keys {
+		if agent.haveKey(k.grip) {
+			continue
+		}
+		key, ok := k.key.(*ecdsa.PrivateKey)
+		if !ok {
+			return fmt.Errorf("unexpected private key type %T", k.key)
+		}
+		if err := agent.importKey(kek, key, passphrase); err != nil {
+			return fmt.Errorf("could not import key %s: %s", k.grip, err)
+		}
+	}
+
+	// import the public key and make it ultimately trusted, as gpg does
+	// for keys it generates
+	pubKey, err := id.ArmorPublic()
+	if err != nil {
+		return err
+	}
+	if _, err := gpg.run([]byte(pubKey), "--import"); err != nil {
+		return fmt.Errorf("could not import the public key: %s", err)
+	}
+	ownertrust := fmt.Sprintf("%s:6:\n", id.Fingerprint())
+	// (overriding gpg.run's trust model, which would skip the trustdb)
+	if _, err := gpg.run([]byte(ownertrust), "--trust-model", "pgp", "--import-ownertrust"); err != nil {
+		return fmt.Errorf("could not set ownertrust: %s", err)
+	}
+	return nil
+}
+
+// connectGPGAgent connects to the gpg-agent of the GnuPG home directory home
+// (GNUPGHOME or ~/.gnupg if empty), starting it if it isn't running.
+func connectGPGAgent(ctx context.Context, home string) (*gnupg, *assuanConn, error) {
+	gpg, err := openGnuPG(ctx, home)
+	if err != nil {
+		return nil, nil, err
+	}
+	socket, err := gpg.agentSocket()
+	if err != nil {
+		return nil, nil, err
+	}
+	agent, err := dialAssuan(ctx, socket)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not connect to gpg-agent: %s", err)
+	}
+	return gpg, agent, nil
+}
+
+// haveKey returns whether the agent has the key with the given keygrip.
+func (c *assuanConn) haveKey(grip string) bool {
+	_, err := c.transact("HAVEKEY "+grip, nil)
+	return err == nil
+}
+
+// presetPassphrase presets the hex encoded passphrase of the key with the
+// given keygrip in the agent's cache, without expiry.
+func (c *assuanConn) presetPassphrase(grip string, hexPassphrase []byte) error {
+	command := make([]byte, 0, 64+len(hexPassphrase))
+	command = append(command, "PRESET_PASSPHRASE "+grip+" -1 "...)
+	command = append(command, hexPassphrase...)
+	defer wipe(command)
+	if _, err := c.conn.Write(append(command, '\n')); err != nil {
+		return err
+	}
+	_, err := c.response(nil)
+	return err
+}
+
+// importKey imports a NIST P-256 private key into the agent, wrapped with the
+// agent's key encryption key kek and protected with passphrase if not empty.
+func (c *assuanConn) importKey(kek []byte, key *ecdsa.PrivateKey, passphrase []byte) error {
+	sexp := privateKeySexp(key)
+	defer wipe(sexp)
+	wrapped, err := aesKeyWrap(kek, sexp)
+	if err != nil {
+		return err
+	}
+	_, err = c.transact("IMPORT_KEY", func(keyword string) ([]byte, error) {
+		switch keyword {
+		case "KEYDATA":
+			return wrapped, nil
+		case "PASSPHRASE", "NEW_PASSPHRASE":
+			return passphrase, nil
+		default:
+			return nil, fmt.Errorf("unexpected inquiry %s", keyword)
+		}
+	})
+	return err
+}
+
+// privateKeySexp returns the canonical S-expression of a NIST P-256 private
+// key as gpg-agent stores it, zero padded to the 8 byte multiple needed to
+// wrap it.
+func privateKeySexp(key *ecdsa.PrivateKey) []byte {
+	q := make([]byte, 65)
+	q[0] = 4
+	key.X.FillBytes(q[1:33])
+	key.Y.FillBytes(q[33:])
+	d := make([]byte, 32)
+	key.D.FillBytes(d)
+	defer wipe(d)
+
+	// allocate the padded length up front so d isn't left behind when
+	// appending grows the buffer
+	sexp := make([]byte, 0, 168)
+	sexp = append(sexp, "(11:private-key(3:ecc(5:curve10:NIST P-256)(1:q65:"...)
+	sexp = append(sexp, q...)
+	sexp = append(sexp, ")(1:d32:"...)
+	sexp = append(sexp, d...)
+	sexp = append(sexp, ")))"...)
+	for len(sexp)%8 != 0 {
+		sexp = append(sexp, 0)
+	}
+	return sexp
+}
+
+// openGnuPG returns a gnupg which runs the system gpg with the given home
+// directory (GNUPGHOME or ~/.gnupg if empty), creating it if necessary.
+func openGnuPG(ctx context.Context, home string) (*gnupg, error) {
+	path, err := lookGPG()
+	if err != nil {
+		return nil, err
+	}
+	if home == "" {
+		home = os.Getenv("GNUPGHOME")
+	}
+	if home == "" {
+		userHome, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		home = filepath.Join(userHome, ".gnupg")
+	}
+	if err := os.MkdirAll(home, 0700); err != nil {
+		return nil, err
+	}
+	return &gnupg{ctx: ctx, path: path, home: home}, nil
+}
+
+// agentSocket starts the gpg-agent of the home directory if it isn't
+// running and returns the path of its socket.
+func (g *gnupg) agentSocket() (string, error) {
+	gpgconf, err := exec.LookPath("gpgconf")
+	if err != nil {
+		return filepath.Join(g.home, "S.gpg-agent"), nil
+	}
+	env := append(os.Environ(), "GNUPGHOME="+g.home)
+	launch := exec.CommandContext(g.ctx, gpgconf, "--launch", "gpg-agent")
+	launch.Env = env
+	if out, err := launch.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("could not start gpg-agent: %s: %s", err, strings.TrimSpace(string(out)))
+	}
+	list := exec.CommandContext(g.ctx, gpgconf, "--list-dirs", "agent-socket")
+	list.Env = env
+	out, err := list.Output()
+	if err != nil {
+		return "", fmt.Errorf("could not find the gpg-agent socket: %s", err)
+	}
+	// gpgconf percent escapes the path like an Assuan D line
+	return string(assuanUnescape(strings.TrimSpace(string(out)))), nil
+}
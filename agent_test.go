@@ -0,0 +1,145 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// newAgentHome returns a temporary GnuPG home directory with the given
+// gpg-agent.conf, stopping its agent and removing it once the test is done.
+func newAgentHome(t *testing.T, conf string) *gnupg {
+	t.Helper()
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg not found in PATH")
+	}
+	if _, err := exec.LookPath("gpgconf"); err != nil {
+		t.Skip("gpgconf not found in PATH")
+	}
+	gpg, err := newGnuPG(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { gpg.Close() })
+	if err := ioutil.WriteFile(filepath.Join(gpg.home, "gpg-agent.conf"), []byte(conf), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return gpg
+}
+
+func TestProvisionGPGAgent(t *testing.T) {
+	gpg := newAgentHome(t, "")
+	v := demoVector
+	entity, err := newEntity(v.Mnemonic, v.Passphrase, v.UserID, time.Unix(v.Timestamp, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wipeEntity(entity)
+	if err := ProvisionGPGAgent(context.Background(), entity, gpg.home, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	primary, _, err := NewIdentity(entity).Keygrips()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ioutil.ReadFile(filepath.Join(gpg.home, "private-keys-v1.d", primary+".key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(key, []byte("protected-private-key")) {
+		t.Fatal("expected the key to be unprotected")
+	}
+
+	// provisioning again leaves the keys as they are
+	if err := ProvisionGPGAgent(context.Background(), entity, gpg.home, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// gpg signs with the key without trusting it explicitly
+	msg := []byte("hello")
+	sig, err := gpg.run(msg, "--local-user", v.PrimaryFingerprint, "--detach-sign")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openpgp.CheckDetachedSignature(openpgp.EntityList{entity}, bytes.NewReader(msg), bytes.NewReader(sig)); err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command(gpg.path, "--homedir", gpg.home, "--with-colons", "--list-keys", v.PrimaryFingerprint).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(out), "tru:") || !strings.Contains(string(out), "\npub:u:") {
+		t.Fatalf("expected the key to be ultimately trusted:\n%s", out)
+	}
+}
+
+func TestProvisionGPGAgentPassphrase(t *testing.T) {
+	v := demoVector
+	entity, err := newEntity(v.Mnemonic, v.Passphrase, v.UserID, time.Unix(v.Timestamp, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wipeEntity(entity)
+
+	// presetting fails without allow-preset-passphrase, before importing
+	// anything
+	gpg := newAgentHome(t, "")
+	err = ProvisionGPGAgent(context.Background(), entity, gpg.home, []byte("agent secret"))
+	if err == nil || !strings.Contains(err.Error(), "allow-preset-passphrase") {
+		t.Fatalf("expected a preset error, got %v", err)
+	}
+	if keys, _ := ioutil.ReadDir(filepath.Join(gpg.home, "private-keys-v1.d")); len(keys) != 0 {
+		t.Fatalf("expected no keys to be imported, got %d", len(keys))
+	}
+
+	// the keys are protected with the passphrase, which is cached so gpg
+	// signs without asking for it
+	gpg = newAgentHome(t, "allow-preset-passphrase\n")
+	if err := ProvisionGPGAgent(context.Background(), entity, gpg.home, []byte("agent secret")); err != nil {
+		t.Fatal(err)
+	}
+	primary, _, err := NewIdentity(entity).Keygrips()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ioutil.ReadFile(filepath.Join(gpg.home, "private-keys-v1.d", primary+".key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(key, []byte("protected-private-key")) {
+		t.Fatal("expected the key to be protected")
+	}
+	msg := []byte("hello")
+	sig, err := gpg.run(msg, "--pinentry-mode", "error", "--local-user", v.PrimaryFingerprint, "--detach-sign")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openpgp.CheckDetachedSignature(openpgp.EntityList{entity}, bytes.NewReader(msg), bytes.NewReader(sig)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWithGPGAgent(t *testing.T) {
+	gpg := newAgentHome(t, "")
+	err := Run(WithStdin(strings.NewReader(aliceInput)), WithGPGAgent(gpg.home))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gpg.run(nil, "--list-secret-keys", aliceFingerprint); err != nil {
+		t.Fatal(err)
+	}
+
+	// the demo identity is never provisioned
+	err = Run(WithDemo(), WithStdin(strings.NewReader("")), WithGPGAgent(gpg.home))
+	if err == nil || !strings.Contains(err.Error(), "demo") {
+		t.Fatalf("expected a demo error, got %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package recovery
+
+import (
+	"fmt"
+	"strings"
+)
+
+// secretFlags are flag names which would pass a secret on the command line.
+var secretFlags = map[string]bool{
+	"seed":          true,
+	"recovery-seed": true,
+	"mnemonic":      true,
+	"words":         true,
+	"passphrase":    true,
+	"password":      true,
+	"token":         true,
+}
+
+// CheckArgs returns an error if the given command line arguments appear to
+// contain a secret (i.e. a recovery seed or passphrase), since arguments are
+// visible to other users in ps and saved in shell history. Secrets should
+// instead be entered when prompted, or read from a file.
+func CheckArgs(args []string) error {
+	words := 0
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			name := strings.TrimLeft(arg, "-")
+			if i := strings.Index(name, "="); i != -1 {
+				name = name[:i]
+			}
+			if secretFlags[strings.ToLower(name)] {
+				return fmt.Errorf("refusing to accept --%s: secrets must not be passed on the command line, where they are visible in ps and saved in shell history; enter them when prompted instead%s", name, historyHint)
+			}
+		}
+		for _, word := range strings.Fields(arg) {
+			if _, ok := englishWordlist.index[strings.ToLower(word)]; ok {
+				words++
+			}
+		}
+	}
+	if words >= 12 {
+		return fmt.Errorf("refusing to run: the command line arguments look like a recovery seed, which must not be passed on the command line where it is visible in ps and saved in shell history; enter it when prompted instead%s", historyHint)
+	}
+	return nil
+}
+
+const historyHint = " (if you entered a real secret, remove it from your shell history, e.g. with 'history -c', and consider it compromised)"
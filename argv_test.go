@@ -0,0 +1,50 @@
+package recovery
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckArgs(t *testing.T) {
+	for _, test := range []struct {
+		args []string
+		err  string
+	}{
+		{
+			args: nil,
+		},
+		{
+			args: []string{"--fingerprint", aliceFingerprint, "--passphrase-list", "candidates.txt"},
+		},
+		{
+			args: []string{"--uid-list", "all.txt", "selftest"},
+		},
+		{
+			args: []string{"--passphrase", "s3cr3t"},
+			err:  "refusing to accept --passphrase",
+		},
+		{
+			args: []string{"-Seed=all"},
+			err:  "refusing to accept --Seed",
+		},
+		{
+			args: strings.Fields(strings.Repeat("all ", 12)),
+			err:  "look like a recovery seed",
+		},
+		{
+			args: []string{"--strict", strings.Repeat("zoo ", 11) + "wrong"},
+			err:  "look like a recovery seed",
+		},
+	} {
+		err := CheckArgs(test.args)
+		if test.err == "" {
+			if err != nil {
+				t.Fatalf("unexpected error for %q: %s", test.args, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Fatalf("expected error containing %q for %q, got %v", test.err, test.args, err)
+		}
+	}
+}
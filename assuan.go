@@ -0,0 +1,207 @@
+package recovery
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+	"time"
+)
+
+// assuanConn is a client connection to an Assuan server such as gpg-agent,
+// see https://www.gnupg.org/documentation/manuals/assuan/.
+type assuanConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// assuanLineLength is the maximum length of an Assuan line, excluding the
+// newline.
+const assuanLineLength = 1000
+
+// dialAssuan connects to the Assuan server listening on the unix socket at
+// path, which may instead be a redirection file as written when the socket
+// path is too long.
+func dialAssuan(ctx context.Context, path string) (*assuanConn, error) {
+	if data, err := ioutil.ReadFile(path); err == nil && bytes.HasPrefix(data, []byte("%Assuan%\n")) {
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.HasPrefix(line, "socket=") {
+				path = strings.TrimPrefix(line, "socket=")
+			}
+		}
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	c := &assuanConn{conn: conn, r: bufio.NewReader(conn)}
+
+	// stop waiting for the server if ctx is cancelled
+	go func() {
+		<-ctx.Done()
+		conn.SetDeadline(time.Now())
+	}()
+
+	if _, err := c.response(nil); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// transact sends a command and returns the data it responds with, answering
+// any inquiries with inquire.
+func (c *assuanConn) transact(command string, inquire func(keyword string) ([]byte, error)) ([]byte, error) {
+	data, _, err := c.transactStatus(command, inquire)
+	return data, err
+}
+
+// transactStatus is like transact but also returns the status lines the
+// server sent (without their "S " prefix).
+func (c *assuanConn) transactStatus(command string, inquire func(keyword string) ([]byte, error)) ([]byte, []string, error) {
+	if _, err := fmt.Fprintf(c.conn, "%s\n", command); err != nil {
+		return nil, nil, err
+	}
+	return c.responseStatus(inquire)
+}
+
+// response reads the server's response up to an OK or ERR line.
+func (c *assuanConn) response(inquire func(keyword string) ([]byte, error)) ([]byte, error) {
+	data, _, err := c.responseStatus(inquire)
+	return data, err
+}
+
+func (c *assuanConn) responseStatus(inquire func(keyword string) ([]byte, error)) ([]byte, []string, error) {
+	var (
+		data   []byte
+		status []string
+	)
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return nil, nil, err
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "OK" || strings.HasPrefix(line, "OK "):
+			return data, status, nil
+		case strings.HasPrefix(line, "ERR "):
+			wipe(data)
+			return nil, nil, assuanError(strings.TrimPrefix(line, "ERR "))
+		case strings.HasPrefix(line, "D "):
+			data = append(data, assuanUnescape(line[2:])...)
+		case strings.HasPrefix(line, "S "):
+			status = append(status, string(assuanUnescape(line[2:])))
+		case strings.HasPrefix(line, "INQUIRE "):
+			keyword := strings.Fields(strings.TrimPrefix(line, "INQUIRE "))[0]
+			if err := c.answer(keyword, inquire); err != nil {
+				wipe(data)
+				return nil, nil, err
+			}
+		}
+	}
+}
+
+// answer answers an inquiry, cancelling it if there is nothing to answer it
+// with.
+func (c *assuanConn) answer(keyword string, inquire func(keyword string) ([]byte, error)) error {
+	if inquire == nil {
+		_, err := fmt.Fprint(c.conn, "CAN\n")
+		return err
+	}
+	answer, err := inquire(keyword)
+	if err != nil {
+		// cancel the inquiry, reading the error the server responds with
+		// so the connection can still be used
+		if _, canErr := fmt.Fprint(c.conn, "CAN\n"); canErr == nil {
+			c.response(nil)
+		}
+		return err
+	}
+	return c.sendData(answer)
+}
+
+// sendData sends data in D lines followed by END, wiping the escaped copies
+// since the data is usually secret.
+func (c *assuanConn) sendData(data []byte) error {
+	line := make([]byte, 0, assuanLineLength)
+	defer func() {
+		wipe(line[:cap(line)])
+	}()
+	flush := func() error {
+		line = append(line, '\n')
+		_, err := c.conn.Write(line)
+		line = line[:0]
+		return err
+	}
+	for _, b := range data {
+		if len(line) == 0 {
+			line = append(line, 'D', ' ')
+		}
+		if b == '%' || b == '\r' || b == '\n' {
+			line = append(line, fmt.Sprintf("%%%02X", b)...)
+		} else {
+			line = append(line, b)
+		}
+		if len(line) >= assuanLineLength-4 {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if len(line) > 0 {
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprint(c.conn, "END\n")
+	return err
+}
+
+func (c *assuanConn) Close() error {
+	fmt.Fprint(c.conn, "BYE\n")
+	return c.conn.Close()
+}
+
+// assuanUnescape decodes the percent escapes of a D line.
+func assuanUnescape(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			var b byte
+			if _, err := fmt.Sscanf(s[i+1:i+3], "%02X", &b); err == nil {
+				out = append(out, b)
+				i += 2
+				continue
+			}
+		}
+		out = append(out, s[i])
+	}
+	return out
+}
+
+// assuanError is an error returned by an Assuan server, in the form "<code>
+// <description>".
+type assuanError string
+
+func (e assuanError) Error() string {
+	if fields := strings.SplitN(string(e), " ", 2); len(fields) == 2 {
+		return fmt.Sprintf("%s (code %s)", assuanUnescape(fields[1]), fields[0])
+	}
+	return string(e)
+}
+
+// code returns the GPG error code of the error (the low 16 bits of the
+// error value).
+func (e assuanError) code() int {
+	var value int
+	fmt.Sscanf(string(e), "%d", &value)
+	return value & 0xffff
+}
@@ -0,0 +1,104 @@
+package recovery
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/clearsign"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// WithAttestation configures the recovery to write an attestation to w: a
+// statement recording when and by whom the key was recovered and which
+// checks passed, cleartext signed by the recovered primary key so that anyone
+// with the public key can check it was made by the key holder (e.g. with 'gpg
+// --verify'). operator names who performed the recovery, and is prompted for
+// if empty.
+func WithAttestation(w io.Writer, operator string) Option {
+	return func(r *Recovery) {
+		r.attestationOut = w
+		r.operator = operator
+	}
+}
+
+// readOperator prompts for the operator to name in the attestation if it
+// wasn't given.
+func (r *Recovery) readOperator() error {
+	if r.attestationOut == nil || r.operator != "" {
+		return nil
+	}
+	operator, err := r.readInput("Please enter the name of the operator performing the recovery, for the attestation:", operatorHelp, "Alice")
+	if err != nil {
+		return err
+	}
+	operator = strings.TrimSpace(operator)
+	if operator == "" {
+		return errors.New("the attestation needs the name of the operator")
+	}
+	r.operator = operator
+	return nil
+}
+
+// writeAttestation writes the attestation of the recovery of entity.
+func (r *Recovery) writeAttestation(entity *openpgp.Entity) error {
+	now := r.now()
+	statement := attestationStatement(newResult(entity), r.operator, now, r.report.Verification, r.demo)
+	if err := signAttestation(r.attestationOut, entity, statement, r.keyParams(), now); err != nil {
+		return fmt.Errorf("could not write the attestation: %s", err)
+	}
+	r.info("Wrote the signed attestation of the recovery")
+	r.audit(auditEntry{Step: "attestation written"})
+	return nil
+}
+
+// attestationStatement returns the text of the attestation.
+func attestationStatement(result *Result, operator string, now time.Time, checks []reportCheck, demo bool) string {
+	var b strings.Builder
+	if demo {
+		fmt.Fprintf(&b, "%s\n\n", demoComment)
+	}
+	fmt.Fprintf(&b, "Recovery attestation\n\n")
+	fmt.Fprintf(&b, "The OpenPGP key below was recovered from its BIP39 recovery seed with\n")
+	fmt.Fprintf(&b, "trezor-gpg-recovery, and this statement was signed by the recovered key.\n\n")
+	fmt.Fprintf(&b, "Date:         %s\n", now.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "Operator:     %s\n", operator)
+	fmt.Fprintf(&b, "User ID:      %s\n", result.UserID)
+	fmt.Fprintf(&b, "Fingerprint:  %s\n", spacedFingerprint(result.PrimaryFingerprint))
+	fmt.Fprintf(&b, "Subkey:       %s\n", spacedFingerprint(result.SubkeyFingerprint))
+	if len(checks) > 0 {
+		fmt.Fprintf(&b, "\nChecks:\n")
+		for _, c := range checks {
+			result := "passed"
+			if !c.Passed {
+				result = "FAILED"
+			}
+			fmt.Fprintf(&b, "  %s: %s\n", c.Name, result)
+		}
+	}
+	return b.String()
+}
+
+// signAttestation writes statement to w cleartext signed by the primary key
+// of entity at now, with the configured signature hash.
+func signAttestation(w io.Writer, entity *openpgp.Entity, statement string, params *keyParams, now time.Time) error {
+	var out bytes.Buffer
+	config := &packet.Config{DefaultHash: params.sigHash, Time: func() time.Time { return now }}
+	plaintext, err := clearsign.Encode(&out, entity.PrivateKey, config)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(plaintext, statement); err != nil {
+		return err
+	}
+	if err := plaintext.Close(); err != nil {
+		return err
+	}
+	out.WriteByte('\n')
+	_, err = w.Write(out.Bytes())
+	return err
+}
@@ -0,0 +1,41 @@
+package recovery
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/clearsign"
+)
+
+func TestWithAttestation(t *testing.T) {
+	var out bytes.Buffer
+	entity := recoverEntity(t, aliceInput, WithAttestation(&out, "Bob"))
+
+	// the statement is signed by the recovered primary key
+	block, _ := clearsign.Decode(out.Bytes())
+	if block == nil {
+		t.Fatalf("expected a cleartext signed message, got:\n%s", out.String())
+	}
+	signer, err := openpgp.CheckDetachedSignature(openpgp.EntityList{entity}, bytes.NewReader(block.Bytes), block.ArmoredSignature.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer.PrimaryKey.KeyId != entity.PrimaryKey.KeyId {
+		t.Fatal("expected the attestation to be signed by the primary key")
+	}
+	for _, s := range []string{"Operator:     Bob", "User ID:      Alice <alice@example.com>", spacedFingerprint(aliceFingerprint), "encryption self-test: passed"} {
+		if !strings.Contains(string(block.Plaintext), s) {
+			t.Fatalf("expected the attestation to contain %q:\n%s", s, block.Plaintext)
+		}
+	}
+
+	// the operator is prompted for if not given
+	out.Reset()
+	input := strings.Replace(aliceInput, "1523060353\n", "1523060353\nCarol\n", 1)
+	recoverEntity(t, input, WithAttestation(&out, ""))
+	if !strings.Contains(out.String(), "Operator:     Carol") {
+		t.Fatalf("expected the prompted operator in the attestation:\n%s", out.String())
+	}
+}
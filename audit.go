@@ -0,0 +1,112 @@
+package recovery
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// WithAuditLog configures a log of each step of the recovery to be written to
+// w as it happens, one JSON object per line, for documenting recovery
+// ceremonies.
+//
+// The log never contains secret material: entries only have the fields of
+// auditEntry, which are set from constants, public key material and the
+// timestamp, with the user ID replaced by its SHA-256 hash. In particular,
+// error and warning messages aren't logged since they may quote what was
+// entered, only whether each check passed and the warning's format string.
+func WithAuditLog(w io.Writer) Option {
+	return func(r *Recovery) {
+		r.auditOut = w
+	}
+}
+
+// auditEntry is a line of the audit log.
+type auditEntry struct {
+	Time               time.Time `json:"time"`
+	Step               string    `json:"step"`
+	Check              string    `json:"check,omitempty"`
+	Result             string    `json:"result,omitempty"`
+	Warning            string    `json:"warning,omitempty"`
+	UserIDHash         string    `json:"uid_hash,omitempty"`
+	Timestamp          int64     `json:"timestamp,omitempty"`
+	SeedLength         int       `json:"seed_length,omitempty"`
+	Curve              string    `json:"curve,omitempty"`
+	Device             string    `json:"device,omitempty"`
+	Index              *uint32   `json:"index,omitempty"`
+	PrimaryPath        string    `json:"primary_path,omitempty"`
+	PrimaryFingerprint string    `json:"primary_fingerprint,omitempty"`
+	SubkeyPath         string    `json:"subkey_path,omitempty"`
+	SubkeyFingerprint  string    `json:"subkey_fingerprint,omitempty"`
+	Output             string    `json:"output,omitempty"`
+}
+
+// audit writes the given entry to the audit log if enabled, keeping the first
+// error to return once the recovery finishes. Steps other than checks and
+// warnings are also passed to the OnStep callback.
+func (r *Recovery) audit(entry auditEntry) {
+	if entry.Step != "check" && entry.Step != "warning" {
+		r.debug("Step: "+entry.Step, LogField{"step", entry.Step})
+		if r.onStep != nil {
+			r.onStep(entry.Step)
+		}
+	}
+	if r.auditOut == nil || r.auditErr != nil {
+		return
+	}
+	entry.Time = r.now().UTC()
+	line, err := json.Marshal(entry)
+	if err != nil {
+		r.auditErr = err
+		return
+	}
+	if _, err := r.auditOut.Write(append(line, '\n')); err != nil {
+		r.auditErr = err
+	}
+}
+
+// check records the result of a verification step in the report and the
+// audit log, and passes it to the OnVerified callback.
+func (r *Recovery) check(name string, err error) {
+	r.report.check(name, err)
+	if r.onVerified != nil {
+		r.onVerified(name, err)
+	}
+	result := "pass"
+	if err != nil {
+		result = "fail"
+	}
+	r.audit(auditEntry{Step: "check", Check: name, Result: result})
+}
+
+// userIDHash returns the hex encoded SHA-256 hash of the given user ID.
+func userIDHash(userID string) string {
+	hash := sha256.Sum256([]byte(userID))
+	return hex.EncodeToString(hash[:])
+}
+
+// auditDerived logs the parameters and public keys of the derived identity.
+func (r *Recovery) auditDerived(entity *openpgp.Entity, userID string) {
+	params := r.keyParams()
+	primaryPath, subkeyPath := params.paths(userID)
+	entry := auditEntry{
+		Step:               "identity derived",
+		UserIDHash:         userIDHash(userID),
+		Timestamp:          entity.PrimaryKey.CreationTime.Unix(),
+		Curve:              params.curve,
+		Device:             string(params.device),
+		PrimaryPath:        primaryPath,
+		PrimaryFingerprint: formatFingerprint(entity.PrimaryKey),
+		SubkeyPath:         subkeyPath,
+		SubkeyFingerprint:  formatFingerprint(entity.Subkeys[0].PublicKey),
+	}
+	if params.device != DeviceLedger {
+		index := params.index
+		entry.Index = &index
+	}
+	r.audit(entry)
+}
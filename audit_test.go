@@ -0,0 +1,105 @@
+package recovery
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+func TestAuditLog(t *testing.T) {
+	var stdout, audit bytes.Buffer
+	err := Run(
+		WithStdin(strings.NewReader(aliceInput)),
+		WithStdout(&stdout),
+		WithStderr(&bytes.Buffer{}),
+		WithAuditLog(&audit),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := audit.String()
+
+	// check the expected steps were logged
+	var steps []string
+	var derived auditEntry
+	s := bufio.NewScanner(strings.NewReader(log))
+	for s.Scan() {
+		var entry auditEntry
+		if err := json.Unmarshal(s.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid audit log line %q: %s", s.Text(), err)
+		}
+		if entry.Time.IsZero() {
+			t.Fatalf("expected audit log line to have a time: %q", s.Text())
+		}
+		steps = append(steps, entry.Step)
+		if entry.Step == "identity derived" {
+			derived = entry
+		}
+	}
+	expected := []string{
+		"start",
+		"confirmed",
+		"user ID entered",
+		"timestamp entered",
+		"recovery seed entered",
+		"check",
+		"passphrase entered",
+		"identity derived",
+		"check",
+		"private key written",
+		"finish",
+	}
+	if strings.Join(steps, ",") != strings.Join(expected, ",") {
+		t.Fatalf("unexpected audit log steps:\nexpected: %v\ngot:      %v", expected, steps)
+	}
+	if derived.PrimaryFingerprint != "AB86C8C7B5136D19B0A6AEC0406D7920DCAD67C3" {
+		t.Fatalf("unexpected primary fingerprint %q", derived.PrimaryFingerprint)
+	}
+	if derived.UserIDHash != userIDHash("Alice <alice@example.com>") || derived.Index == nil || derived.Curve != "nist256p1" {
+		t.Fatalf("unexpected derivation parameters: %+v", derived)
+	}
+
+	// check no secrets (or the user ID) were logged
+	entities, err := openpgp.ReadArmoredKeyRing(&stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets := []string{"all all", "s3cr3t", "alice@example.com"}
+	for _, key := range []*packet.PrivateKey{entities[0].PrivateKey, entities[0].Subkeys[0].PrivateKey} {
+		secrets = append(secrets, hex.EncodeToString(key.PrivateKey.(*ecdsa.PrivateKey).D.Bytes()))
+	}
+	for _, secret := range secrets {
+		if strings.Contains(log, secret) {
+			t.Fatalf("audit log contains %q:\n%s", secret, log)
+		}
+	}
+}
+
+func TestAuditLogInvalidSeed(t *testing.T) {
+	// a mistyped seed word is quoted in the error so must not be logged
+	var audit bytes.Buffer
+	input := strings.Replace(aliceInput, "all\ns3cr3t", "alpaca\ns3cr3t", 1)
+	err := Run(
+		WithStdin(strings.NewReader(input)),
+		WithStdout(&bytes.Buffer{}),
+		WithStderr(&bytes.Buffer{}),
+		WithAuditLog(&audit),
+	)
+	if err == nil || !strings.Contains(err.Error(), "alpaca") {
+		t.Fatalf("expected an invalid seed error quoting the word, got %v", err)
+	}
+	log := audit.String()
+	if strings.Contains(log, "alpaca") || strings.Contains(log, "all") {
+		t.Fatalf("audit log contains the seed:\n%s", log)
+	}
+	if !strings.Contains(log, `"check":"recovery seed checksum","result":"fail"`) {
+		t.Fatalf("expected the failed check to be logged:\n%s", log)
+	}
+}
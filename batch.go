@@ -0,0 +1,257 @@
+package recovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BatchIdentity is one of the identities derived by Batch.
+type BatchIdentity struct {
+	UserID    string
+	Timestamp time.Time
+
+	// Index is the SLIP-0013 index of the identity, 0 unless it was
+	// created with another, or the key slot of a Ledger identity
+	Index uint32
+
+	// Device is the device the identity was created with, the one
+	// configured with WithDevice if empty
+	Device Device
+
+	// Fingerprint is the primary key fingerprint the identity must have, if
+	// known
+	Fingerprint string
+
+	// Output is written the identity's private key, or stdout if nil
+	Output io.Writer
+}
+
+// ParseBatch parses a list of identities for Batch, one per line as the user
+// ID, timestamp and index separated by whitespace (e.g. "Alice
+// <alice@example.com> 1523060353 0"). Blank lines and lines starting with #
+// are ignored.
+func ParseBatch(r io.Reader) ([]BatchIdentity, error) {
+	var identities []BatchIdentity
+	s := newLineScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimRight(s.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rest, indexStr := cutLastField(line)
+		userID, timestampStr := cutLastField(rest)
+		if userID == "" {
+			return nil, fmt.Errorf("invalid line %d of the batch file: expected a user ID, timestamp and index", n)
+		}
+		timestamp, err := ParseTimestamp(timestampStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid line %d of the batch file: %s", n, err)
+		}
+		index, err := strconv.ParseUint(indexStr, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid line %d of the batch file: invalid index %q", n, indexStr)
+		}
+		identities = append(identities, BatchIdentity{UserID: userID, Timestamp: timestamp, Index: uint32(index)})
+	}
+	if err := scanErr(s, "the batch file"); err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+// cutLastField splits s before its last whitespace separated field, so the
+// whitespace within a user ID is kept.
+func cutLastField(s string) (rest, field string) {
+	i := strings.LastIndexAny(s, " \t")
+	if i == -1 {
+		return "", s
+	}
+	return strings.TrimRight(s[:i], " \t"), s[i+1:]
+}
+
+// Batch derives each of identities from a single entry of the recovery seed
+// and passphrase, for people who created several identities (e.g. work and
+// personal ones) with one device. Only the seed and passphrase are prompted
+// for, and each identity's private key is written to its Output (or stdout)
+// in the form WithEncoder configures, with their fingerprints displayed. The
+// keys are only written once every identity has been derived and matches its
+// Fingerprint.
+func Batch(ctx context.Context, identities []BatchIdentity, opts ...Option) error {
+	return new(Recovery).Batch(ctx, identities, opts...)
+}
+
+// Batch is like the package level Batch, using the options r was created
+// with followed by opts.
+func (r *Recovery) Batch(ctx context.Context, identities []BatchIdentity, opts ...Option) error {
+	run := r.newRun(ctx, opts)
+	return run.finish(run.batch(identities))
+}
+
+// batch does the work of Batch.
+func (r *Recovery) batch(identities []BatchIdentity) error {
+	if r.stdin == nil && r.prompter == nil {
+		return errors.New("no input configured: use WithStdin or WithPrompter")
+	}
+	if len(identities) == 0 {
+		return errors.New("no identities to derive")
+	}
+	if r.requireOffline {
+		if err := checkOffline(); err != nil {
+			return err
+		}
+	}
+	params := r.keyParams()
+	if err := params.check(); err != nil {
+		return err
+	}
+	if r.fingerprint != "" || r.publicKey != nil || r.trezorConfig != nil {
+		return errors.New("a fingerprint, public key or trezor-agent configuration is for a single identity, so can't be used in a batch")
+	}
+	if r.timestamps != nil || r.userIDs != nil || r.indexes != nil || r.passphrases != nil || r.passphraseTypos {
+		return errors.New("candidates can only be searched for a single identity, not in a batch")
+	}
+	if r.shareOutputs != nil || r.passphraseTTY != nil {
+		return errors.New("the private keys of a batch can't be split into shares or encrypted")
+	}
+	fingerprints := make([]string, len(identities))
+	for i, identity := range identities {
+		if err := checkTimestampRange(identity.Timestamp); err != nil {
+			return fmt.Errorf("identity %d: %s", i+1, err)
+		}
+		p := batchParams(*params, identity)
+		if err := p.check(); err != nil {
+			return fmt.Errorf("identity %d: %s", i+1, err)
+		}
+		// the user ID doesn't feed a Ledger's derivation
+		if p.device != DeviceLedger {
+			if err := r.checkUserID(identity.UserID); err != nil {
+				return err
+			}
+		}
+		if identity.Fingerprint != "" {
+			fingerprint, err := parseFingerprint(identity.Fingerprint)
+			if err != nil {
+				return fmt.Errorf("identity %d: %s", i+1, err)
+			}
+			fingerprints[i] = fingerprint
+		}
+	}
+
+	// protect the seed as a recovery does
+	if err := disableCoreDumps(); err != nil {
+		if err := r.warn("could not disable core dumps: %s", err); err != nil {
+			return err
+		}
+	}
+	if err := disableTracing(); err != nil {
+		if err := r.warn("could not prevent other processes attaching: %s", err); err != nil {
+			return err
+		}
+	}
+	freeInput, err := r.setupInput()
+	if err != nil {
+		return err
+	}
+	defer freeInput()
+	if err := r.warnSwap(); err != nil {
+		return err
+	}
+
+	seed, err := r.readSeed()
+	if err != nil {
+		return err
+	}
+	defer seed.Wipe()
+	if _, ok := seed.(*partialSeed); ok {
+		return errors.New("missing seed words can't be searched in a batch")
+	}
+	secret, err := r.readSecretInput("Please enter your passphrase (leave blank if you don't use one):", demoVector.Passphrase)
+	if err != nil {
+		return err
+	}
+	r.display(`-----------------------------------------------------------------------------`)
+	passphrase := string(secret)
+	wipe(secret)
+	r.audit(auditEntry{Step: "passphrase entered"})
+
+	// derive and check every identity before writing any of them
+	results := make([]*Result, 0, len(identities))
+	defer func() {
+		for _, result := range results {
+			result.Wipe()
+		}
+	}()
+	saved := *params
+	defer func() { *params = saved }()
+	for i, identity := range identities {
+		if err := r.ctx.Err(); err != nil {
+			return err
+		}
+		*params = batchParams(saved, identity)
+		r.fingerprint = fingerprints[i]
+		entity, err := r.search(seed, []string{passphrase}, []string{identity.UserID}, []time.Time{identity.Timestamp})
+		r.fingerprint = ""
+		if fingerprints[i] != "" {
+			r.check(fmt.Sprintf("fingerprint %s of identity %d", fingerprints[i], i+1), err)
+		}
+		if mismatch, ok := err.(*mismatchError); ok && mismatch.entity != nil {
+			wipeEntity(mismatch.entity)
+			return fmt.Errorf("identity %d: %w, so no private keys were written", i+1, mismatch)
+		} else if err != nil {
+			return fmt.Errorf("identity %d: %s", i+1, err)
+		}
+		result := newResult(entity)
+		results = append(results, result)
+		r.auditDerived(entity, identity.UserID)
+		err = checkEncryption(entity)
+		r.check(fmt.Sprintf("encryption self-test of identity %d", i+1), err)
+		if err != nil {
+			return fmt.Errorf("identity %d: encryption self-test failed: %s", i+1, err)
+		}
+		r.display(`
+%-24s %s
+Primary Key Fingerprint: %s
+Subkey Fingerprint:      %s`, fmt.Sprintf("Identity %d of %d:", i+1, len(identities)), result.UserID, result.PrimaryFingerprint, result.SubkeyFingerprint)
+	}
+
+	// write each identity's private key
+	encoder, output := r.encoder, "armored"
+	if encoder == nil {
+		encoder = ArmoredEncoder()
+	} else {
+		output = "encoded"
+	}
+	for i, result := range results {
+		w := identities[i].Output
+		if w == nil {
+			w = r.stdout
+		}
+		if w == nil {
+			continue
+		}
+		if err := encoder.Encode(w, result); err != nil {
+			return fmt.Errorf("identity %d: %s", i+1, err)
+		}
+		r.audit(auditEntry{Step: "private key written", Output: output})
+	}
+	return nil
+}
+
+// batchParams returns the key parameters of identity, which are the
+// configured ones with its device and index.
+func batchParams(params keyParams, identity BatchIdentity) keyParams {
+	if identity.Device != "" {
+		params.device = identity.Device
+	}
+	if params.device == DeviceLedger {
+		params.index, params.ledgerSlot = 0, identity.Index
+	} else {
+		params.index = identity.Index
+	}
+	return params
+}
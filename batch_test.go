@@ -0,0 +1,76 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+func TestParseBatch(t *testing.T) {
+	identities, err := ParseBatch(strings.NewReader("# identities\nAlice <alice@example.com> 1523060353 0\n\n  Alice  Work <alice@work.example>\t1600000000 3\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(identities) != 2 {
+		t.Fatalf("expected 2 identities, got %d", len(identities))
+	}
+	if id := identities[1]; id.UserID != "  Alice  Work <alice@work.example>" || id.Timestamp.Unix() != 1600000000 || id.Index != 3 {
+		t.Fatalf("unexpected second identity: %+v", id)
+	}
+	for _, line := range []string{"Alice <alice@example.com> 1523060353", "Alice <alice@example.com> now 0", "1523060353 0"} {
+		if _, err := ParseBatch(strings.NewReader(line)); err == nil || !strings.Contains(err.Error(), "line 1") {
+			t.Fatalf("expected an error parsing %q, got %v", line, err)
+		}
+	}
+}
+
+func TestBatch(t *testing.T) {
+	identities, err := ParseBatch(strings.NewReader("Alice <alice@example.com> 1523060353 0\nAlice Work <alice@work.example> 1600000000 1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var outputs [2]bytes.Buffer
+	for i := range identities {
+		identities[i].Output = &outputs[i]
+	}
+
+	// the seed and passphrase are entered once for both identities
+	input := aliceInput[strings.Index(aliceInput, "12\n"):]
+	var stderr bytes.Buffer
+	err = Batch(context.Background(), identities,
+		WithStdin(strings.NewReader(input)),
+		WithStderr(&stderr),
+	)
+	if err != nil {
+		t.Fatalf("%s\n%s", err, stderr.String())
+	}
+	var fingerprints []string
+	for i, output := range outputs {
+		entities, err := openpgp.ReadArmoredKeyRing(&output)
+		if err != nil {
+			t.Fatalf("identity %d: %s", i+1, err)
+		}
+		if _, ok := entities[0].Identities[identities[i].UserID]; !ok {
+			t.Fatalf("identity %d: expected user ID %q", i+1, identities[i].UserID)
+		}
+		fingerprints = append(fingerprints, formatFingerprint(entities[0].PrimaryKey))
+	}
+	if fingerprints[0] != aliceFingerprint || fingerprints[1] == aliceFingerprint {
+		t.Fatalf("unexpected fingerprints %v", fingerprints)
+	}
+	if !strings.Contains(stderr.String(), "Identity 2 of 2:         Alice Work <alice@work.example>") {
+		t.Fatalf("expected the identities to be displayed, got:\n%s", stderr.String())
+	}
+
+	// a fingerprint can't apply to every identity
+	err = Batch(context.Background(), identities,
+		WithStdin(strings.NewReader(input)),
+		WithFingerprint(aliceFingerprint),
+	)
+	if err == nil || !strings.Contains(err.Error(), "single identity") {
+		t.Fatalf("expected an error with a fingerprint, got %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+package recovery
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WithSearchWorkers configures the number of goroutines searching candidates
+// in parallel, which defaults to the number of CPUs.
+func WithSearchWorkers(workers int) Option {
+	return func(r *Recovery) {
+		r.searchWorkers = workers
+	}
+}
+
+// searchChunk is how many consecutive candidates a worker takes at a time,
+// so candidates sharing a master key or derived keys are mostly tried by the
+// same worker, and small searches run in order on a single worker.
+const searchChunk = 64
+
+// searchProgressInterval is how often the progress of a search is logged.
+var searchProgressInterval = 10 * time.Second
+
+// candidateWorker tries candidates of a search on a single goroutine, caching
+// whatever it derived for the previous candidate.
+type candidateWorker interface {
+	// try reports whether candidate n is the one being searched for.
+	try(n uint64) (bool, error)
+
+	// wipe wipes anything secret the worker has cached.
+	wipe()
+}
+
+// bruteForce tries the candidates numbered start to total-1 across a pool of
+// workers created with newWorker, returning the lowest numbered candidate
+// which matches (so the result is the same as a sequential search) and
+// periodically logging the progress and rate of the search. If checkpoint
+// isn't nil it's called with the position every candidate before which has
+// been tried, each time the progress is logged and when the search stops
+// without a match.
+func (r *Recovery) bruteForce(start, total uint64, newWorker func() candidateWorker, checkpoint func(position uint64) error) (match uint64, found bool, err error) {
+	workers := r.workers(start, total)
+	r.debug(fmt.Sprintf("Searching candidates %d to %d on %d workers", start+1, total, workers), LogField{"start", start + 1}, LogField{"candidates", total}, LogField{"workers", workers})
+
+	var (
+		mtx      sync.Mutex
+		next     = start             // the first candidate of the next chunk
+		chunks   = map[uint64]bool{} // the first candidates of chunks being tried
+		done     uint64              // the number of candidates tried
+		best     = total
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	// lower updates best to n if it's lower, so workers stop once they get
+	// past the lowest match found so far
+	lower := func(n uint64) {
+		for {
+			b := atomic.LoadUint64(&best)
+			if n >= b || atomic.CompareAndSwapUint64(&best, b, n) {
+				return
+			}
+		}
+	}
+	fail := func(err error) {
+		mtx.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mtx.Unlock()
+		lower(0)
+	}
+	// take returns the next chunk to try once prev has been tried, or false
+	// once there are none left (chunks are abandoned part way through after
+	// an error, so are only removed when there isn't one)
+	take := func(prev uint64) (uint64, bool) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		if firstErr == nil {
+			delete(chunks, prev)
+		}
+		if next >= total || next >= atomic.LoadUint64(&best) {
+			return 0, false
+		}
+		chunk := next
+		chunks[chunk] = true
+		next += searchChunk
+		return chunk, true
+	}
+	// position returns the first candidate which may not have been tried
+	position := func() uint64 {
+		mtx.Lock()
+		defer mtx.Unlock()
+		position := next
+		for chunk := range chunks {
+			if chunk < position {
+				position = chunk
+			}
+		}
+		if position > total {
+			position = total
+		}
+		return position
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := newWorker()
+			defer w.wipe()
+			chunk := total
+			for {
+				var ok bool
+				if chunk, ok = take(chunk); !ok {
+					return
+				}
+				if err := r.ctx.Err(); err != nil {
+					fail(err)
+					return
+				}
+				end := chunk + searchChunk
+				if end > total {
+					end = total
+				}
+				for n := chunk; n < end && n < atomic.LoadUint64(&best); n++ {
+					ok, err := w.try(n)
+					atomic.AddUint64(&done, 1)
+					if err != nil {
+						fail(err)
+						return
+					}
+					if ok {
+						lower(n)
+						break
+					}
+				}
+			}
+		}()
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+	started := time.Now()
+	ticker := time.NewTicker(searchProgressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-finished:
+			if best < total && firstErr == nil {
+				return best, true, nil
+			}
+			if checkpoint != nil && firstErr != nil {
+				if err := checkpoint(position()); err != nil {
+					r.activeLogger().Warn(fmt.Sprintf("could not save the search checkpoint: %s", err))
+				}
+			}
+			return 0, false, firstErr
+		case <-ticker.C:
+			r.logSearchProgress(start+atomic.LoadUint64(&done), total, float64(atomic.LoadUint64(&done))/time.Since(started).Seconds())
+			if checkpoint != nil {
+				if err := checkpoint(position()); err != nil {
+					r.activeLogger().Warn(fmt.Sprintf("could not save the search checkpoint: %s", err))
+				}
+			}
+		}
+	}
+}
+
+// workers returns the number of workers to search the candidates numbered
+// start to total-1 on, which is at most one per chunk.
+func (r *Recovery) workers(start, total uint64) int {
+	workers := r.searchWorkers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if chunks := (total - start + searchChunk - 1) / searchChunk; uint64(workers) > chunks {
+		workers = int(chunks)
+	}
+	return workers
+}
+
+// logSearchProgress logs how many of the candidates have been searched,
+// along with the rate per second and an estimate of the time remaining.
+func (r *Recovery) logSearchProgress(searched, total uint64, rate float64) {
+	msg := fmt.Sprintf("Searched %d of %d candidates (%.1f%%, %.0f per second)", searched, total, 100*float64(searched)/float64(total), rate)
+	if rate > 0 {
+		remaining := time.Duration(float64(total-searched) / rate * float64(time.Second))
+		msg += fmt.Sprintf(", about %s remaining", formatDuration(remaining))
+	}
+	r.info(msg, LogField{"searched", searched}, LogField{"candidates", total}, LogField{"rate", rate})
+}
+
+// formatDuration formats d to the nearest second, or minute once it's over
+// an hour, since estimates aren't more precise than that.
+func formatDuration(d time.Duration) string {
+	if d >= time.Hour {
+		return d.Round(time.Minute).String()
+	}
+	return d.Round(time.Second).String()
+}
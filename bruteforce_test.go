@@ -0,0 +1,83 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// funcWorker is a candidateWorker calling fn for each candidate.
+type funcWorker func(n uint64) (bool, error)
+
+func (fn funcWorker) try(n uint64) (bool, error) { return fn(n) }
+func (funcWorker) wipe()                         {}
+
+func TestBruteForce(t *testing.T) {
+	r := &Recovery{ctx: context.Background(), searchWorkers: 8}
+
+	// the lowest match is returned however the candidates are split
+	// between the workers
+	var tried uint64
+	match, found, err := r.bruteForce(0, 100000, func() candidateWorker {
+		return funcWorker(func(n uint64) (bool, error) {
+			atomic.AddUint64(&tried, 1)
+			return n%1000 == 999 && n > 50000, nil
+		})
+	}, nil)
+	if err != nil || !found || match != 50999 {
+		t.Fatalf("expected candidate 50999 to match, got %d, %v, %v", match, found, err)
+	}
+	if tried >= 100000 {
+		t.Fatalf("expected the search to stop at the match, tried %d candidates", tried)
+	}
+
+	// a search without a match tries every candidate
+	tried = 0
+	_, found, err = r.bruteForce(0, 1000, func() candidateWorker {
+		return funcWorker(func(n uint64) (bool, error) {
+			atomic.AddUint64(&tried, 1)
+			return false, nil
+		})
+	}, nil)
+	if err != nil || found || tried != 1000 {
+		t.Fatalf("expected all 1000 candidates to be tried without a match, tried %d, %v, %v", tried, found, err)
+	}
+
+	// errors stop the search
+	_, _, err = r.bruteForce(0, 100000, func() candidateWorker {
+		return funcWorker(func(n uint64) (bool, error) {
+			if n == 500 {
+				return false, errors.New("derivation failed")
+			}
+			return false, nil
+		})
+	}, nil)
+	if err == nil || err.Error() != "derivation failed" {
+		t.Fatalf("expected the derivation error, got %v", err)
+	}
+}
+
+func TestBruteForceProgress(t *testing.T) {
+	interval := searchProgressInterval
+	searchProgressInterval = 10 * time.Millisecond
+	defer func() { searchProgressInterval = interval }()
+
+	var stderr bytes.Buffer
+	r := &Recovery{ctx: context.Background(), stderr: &stderr, searchWorkers: 2}
+	_, _, err := r.bruteForce(0, 200, func() candidateWorker {
+		return funcWorker(func(n uint64) (bool, error) {
+			time.Sleep(time.Millisecond)
+			return false, nil
+		})
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stderr.String(), "of 200 candidates (") || !strings.Contains(stderr.String(), "remaining") {
+		t.Fatalf("expected the progress to be logged, got:\n%s", stderr.String())
+	}
+}
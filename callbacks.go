@@ -0,0 +1,33 @@
+package recovery
+
+// The callbacks below let GUI wrappers display progress during the recovery.
+// They are called synchronously from the recovery, so should return quickly.
+
+// WithOnStep configures fn to be called as each step of the recovery
+// happens, with the step names of the audit log (e.g. "confirmed", "recovery
+// seed entered" and "identity derived").
+func WithOnStep(fn func(step string)) Option {
+	return func(r *Recovery) {
+		r.onStep = fn
+	}
+}
+
+// WithOnKeyDerived configures fn to be called with the primary key
+// fingerprint of each identity derived, along with its position among the
+// candidates being searched (which are both 1 without a search), so that
+// long searches can display a progress bar. Searches on several workers
+// call fn from each worker in turn, so the candidates aren't in order.
+func WithOnKeyDerived(fn func(candidate, candidates int, fingerprint string)) Option {
+	return func(r *Recovery) {
+		r.onKeyDerived = fn
+	}
+}
+
+// WithOnVerified configures fn to be called with the name and result of each
+// verification check (e.g. "recovery seed checksum" and "encryption
+// self-test"), where err is nil if the check passed.
+func WithOnVerified(fn func(check string, err error)) Option {
+	return func(r *Recovery) {
+		r.onVerified = fn
+	}
+}
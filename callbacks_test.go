@@ -0,0 +1,58 @@
+package recovery
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCallbacks(t *testing.T) {
+	var steps, derived, verified []string
+	input := strings.TrimSuffix(aliceInput, "s3cr3t\n")
+	recoverEntity(t, input,
+		WithFingerprint(aliceFingerprint),
+		WithPassphraseCandidates([]string{"", "secret", "s3cr3t", "S3cr3t"}),
+		WithOnStep(func(step string) {
+			steps = append(steps, step)
+		}),
+		WithOnKeyDerived(func(candidate, candidates int, fingerprint string) {
+			derived = append(derived, fmt.Sprintf("%d/%d %s", candidate, candidates, fingerprint))
+		}),
+		WithOnVerified(func(check string, err error) {
+			verified = append(verified, fmt.Sprintf("%s: %v", check, err))
+		}),
+	)
+
+	expectedSteps := []string{
+		"start",
+		"confirmed",
+		"user ID entered",
+		"timestamp entered",
+		"recovery seed entered",
+		"identity derived",
+		"private key written",
+		"finish",
+	}
+	if strings.Join(steps, ",") != strings.Join(expectedSteps, ",") {
+		t.Fatalf("unexpected steps:\nexpected: %v\ngot:      %v", expectedSteps, steps)
+	}
+
+	// the search stops at the third candidate which matches
+	if len(derived) != 3 || derived[2] != "3/4 "+aliceFingerprint {
+		t.Fatalf("unexpected derived keys: %v", derived)
+	}
+	for _, d := range derived[:2] {
+		if !strings.HasPrefix(d, fmt.Sprintf("%c/4 ", d[0])) || strings.HasSuffix(d, aliceFingerprint) {
+			t.Fatalf("unexpected derived key: %s", d)
+		}
+	}
+
+	expectedChecks := []string{
+		"recovery seed checksum: <nil>",
+		"fingerprint " + aliceFingerprint + ": <nil>",
+		"encryption self-test: <nil>",
+	}
+	if strings.Join(verified, ",") != strings.Join(expectedChecks, ",") {
+		t.Fatalf("unexpected checks:\nexpected: %v\ngot:      %v", expectedChecks, verified)
+	}
+}
@@ -0,0 +1,206 @@
+package recovery
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// WithSequoiaStore configures the recovery to write the recovered
+// certificate into the OpenPGP certificate directory (cert-d) certD and the
+// secret key into the directory keystore of Sequoia's softkeys keystore, as
+// sq uses them, with empty directories meaning Sequoia's defaults (see
+// DefaultSequoiaDirs).
+//
+// The private key is still printed if WithStdout is also given.
+func WithSequoiaStore(certD, keystore string) Option {
+	return func(r *Recovery) {
+		r.sequoiaStore = true
+		r.sequoiaCertD = certD
+		r.sequoiaKeystore = keystore
+	}
+}
+
+// DefaultSequoiaDirs returns the cert-d and softkeys keystore directories sq
+// uses by default: those in $SEQUOIA_HOME/data if set, otherwise
+// pgp.cert.d and sequoia/keystore/softkeys in the user's data directory
+// (e.g. ~/.local/share), with $PGP_CERT_D overriding the cert-d.
+func DefaultSequoiaDirs() (certD, keystore string, err error) {
+	if home := os.Getenv("SEQUOIA_HOME"); home != "" {
+		data := filepath.Join(home, "data")
+		certD = filepath.Join(data, "pgp.cert.d")
+		keystore = filepath.Join(data, "keystore", "softkeys")
+	} else {
+		data, err := userDataDir()
+		if err != nil {
+			return "", "", err
+		}
+		certD = filepath.Join(data, "pgp.cert.d")
+		keystore = filepath.Join(data, "sequoia", "keystore", "softkeys")
+	}
+	if dir := os.Getenv("PGP_CERT_D"); dir != "" {
+		certD = dir
+	}
+	return certD, keystore, nil
+}
+
+// userDataDir returns the user's data directory as the dirs crate Sequoia
+// uses does.
+func userDataDir() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		if dir := os.Getenv("APPDATA"); dir != "" {
+			return dir, nil
+		}
+		return "", errors.New("%APPDATA% is not set")
+	case "darwin":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, "Library", "Application Support"), nil
+	default:
+		if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+			return dir, nil
+		}
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, ".local", "share"), nil
+	}
+}
+
+// checkSequoiaStore checks the Sequoia store options before prompting for
+// anything, filling in the default directories.
+func (r *Recovery) checkSequoiaStore() error {
+	if !r.sequoiaStore {
+		return nil
+	}
+	if r.demo {
+		return errors.New("the demo identity must never be written to a Sequoia keystore")
+	}
+	if r.stdout == nil && r.passphraseTTY != nil {
+		return errors.New("the private key can only be encrypted with a one-time passphrase if it is also written to an output")
+	}
+	if r.sequoiaCertD == "" || r.sequoiaKeystore == "" {
+		certD, keystore, err := DefaultSequoiaDirs()
+		if err != nil {
+			return fmt.Errorf("could not determine Sequoia's directories: %s", err)
+		}
+		if r.sequoiaCertD == "" {
+			r.sequoiaCertD = certD
+		}
+		if r.sequoiaKeystore == "" {
+			r.sequoiaKeystore = keystore
+		}
+	}
+	return nil
+}
+
+// writeSequoiaStore writes result to the configured Sequoia directories.
+func (r *Recovery) writeSequoiaStore(result *Result) error {
+	inserted, err := WriteSequoiaStore(r.sequoiaCertD, r.sequoiaKeystore, result)
+	if err != nil {
+		return fmt.Errorf("could not write the key to the Sequoia keystore: %s", err)
+	}
+	if !inserted {
+		r.info(fmt.Sprintf("The certificate is already in %s, so was left as it is", r.sequoiaCertD), LogField{"cert_d", r.sequoiaCertD})
+	}
+	r.info(fmt.Sprintf("Wrote the secret key to the Sequoia keystore in %s, check it with 'sq key list'", r.sequoiaKeystore), LogField{"keystore", r.sequoiaKeystore})
+	r.audit(auditEntry{Step: "private key written", Output: "sequoia"})
+	return nil
+}
+
+// WriteSequoiaStore writes the certificate of result into the cert-d certD
+// and its unprotected secret key into the softkeys keystore directory
+// keystore, creating them if needed. It returns whether the certificate was
+// inserted: one already in the cert-d is left as it is, since it may have
+// signatures this one doesn't (sq merges them on 'sq cert import'). The
+// secret key is never overwritten.
+func WriteSequoiaStore(certD, keystore string, result *Result) (inserted bool, err error) {
+	inserted, err = insertCert(certD, result.Entity)
+	if err != nil {
+		return false, err
+	}
+	secretKey, err := result.ArmoredPrivateKey()
+	if err != nil {
+		return false, err
+	}
+	defer wipe(secretKey)
+	if err := os.MkdirAll(keystore, 0700); err != nil {
+		return false, err
+	}
+	path := filepath.Join(keystore, result.PrimaryFingerprint+".pgp")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		return false, fmt.Errorf("%s already exists", path)
+	} else if err != nil {
+		return false, err
+	}
+	if _, err := f.Write(secretKey); err != nil {
+		f.Close()
+		os.Remove(path)
+		return false, err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return false, err
+	}
+	return inserted, nil
+}
+
+// certDPath returns the path of the certificate with the given fingerprint
+// in a cert-d, which is named by its lower case hex fingerprint split after
+// the first two characters.
+func certDPath(certD string, cert *openpgp.Entity) string {
+	fingerprint := hex.EncodeToString(cert.PrimaryKey.Fingerprint[:])
+	return filepath.Join(certD, fingerprint[:2], fingerprint[2:])
+}
+
+// insertCert inserts the binary certificate of cert into the cert-d certD
+// as the pgp-cert-d specification describes, holding the directory's write
+// lock and renaming a temporary file into place so readers never see a
+// partial certificate. It returns false if the certificate was already
+// there.
+func insertCert(certD string, cert *openpgp.Entity) (bool, error) {
+	// the self-signatures are only made when serializing the private key
+	if cert.PrivateKey != nil {
+		if err := cert.SerializePrivate(ioutil.Discard, nil); err != nil {
+			return false, err
+		}
+	}
+	var buf bytes.Buffer
+	if err := cert.Serialize(&buf); err != nil {
+		return false, err
+	}
+	if err := os.MkdirAll(certD, 0700); err != nil {
+		return false, err
+	}
+	unlock, err := lockCertD(certD)
+	if err != nil {
+		return false, fmt.Errorf("could not lock %s: %s", certD, err)
+	}
+	defer unlock()
+
+	path := certDPath(certD, cert)
+	if _, err := os.Stat(path); err == nil {
+		return false, nil
+	} else if !os.IsNotExist(err) {
+		return false, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return false, err
+	}
+	if err := writeFileAtomic(path, buf.Bytes(), 0644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,19 @@
+//go:build !darwin && !linux
+// +build !darwin,!linux
+
+package recovery
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// lockCertD creates the writelock file of a cert-d, but can't lock it
+// without flock, so concurrent writers aren't excluded.
+func lockCertD(certD string) (func(), error) {
+	f, err := os.OpenFile(filepath.Join(certD, "writelock"), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return func() { f.Close() }, nil
+}
@@ -0,0 +1,104 @@
+package recovery
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+func TestWriteSequoiaStore(t *testing.T) {
+	v := demoVector
+	entity, err := newEntity(v.Mnemonic, v.Passphrase, v.UserID, time.Unix(v.Timestamp, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := newResult(entity)
+	defer result.Wipe()
+	dir := t.TempDir()
+	certD := filepath.Join(dir, "pgp.cert.d")
+	keystore := filepath.Join(dir, "keystore", "softkeys")
+	inserted, err := WriteSequoiaStore(certD, keystore, result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !inserted {
+		t.Fatal("expected the certificate to be inserted")
+	}
+
+	// the certificate is the binary public key, named by its fingerprint
+	fingerprint := strings.ToLower(v.PrimaryFingerprint)
+	f, err := os.Open(filepath.Join(certD, fingerprint[:2], fingerprint[2:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	keyring, err := openpgp.ReadKeyRing(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keyring) != 1 || keyring[0].PrivateKey != nil || formatFingerprint(keyring[0].PrimaryKey) != v.PrimaryFingerprint {
+		t.Fatal("expected the certificate to be the public key")
+	}
+	if _, err := os.Stat(filepath.Join(certD, "writelock")); err != nil {
+		t.Fatalf("expected the write lock file: %s", err)
+	}
+
+	// the secret key is only readable by the user
+	path := filepath.Join(keystore, v.PrimaryFingerprint+".pgp")
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("unexpected secret key permissions %s", info.Mode())
+	}
+	key, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer key.Close()
+	keyring, err = openpgp.ReadArmoredKeyRing(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyring[0].PrivateKey == nil || keyring[0].PrivateKey.Encrypted {
+		t.Fatal("expected an unprotected secret key")
+	}
+
+	// an existing certificate is kept, but the secret key isn't overwritten
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if inserted, err := WriteSequoiaStore(certD, keystore, result); err != nil || inserted {
+		t.Fatalf("expected the existing certificate to be kept, got %v, %v", inserted, err)
+	}
+	if _, err := WriteSequoiaStore(certD, keystore, result); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected an exists error, got %v", err)
+	}
+}
+
+func TestDefaultSequoiaDirs(t *testing.T) {
+	t.Setenv("SEQUOIA_HOME", "/tmp/sq")
+	t.Setenv("PGP_CERT_D", "")
+	certD, keystore, err := DefaultSequoiaDirs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if certD != filepath.Join("/tmp/sq", "data", "pgp.cert.d") || keystore != filepath.Join("/tmp/sq", "data", "keystore", "softkeys") {
+		t.Fatalf("unexpected directories %s and %s", certD, keystore)
+	}
+	t.Setenv("PGP_CERT_D", "/tmp/certd")
+	if certD, _, _ := DefaultSequoiaDirs(); certD != "/tmp/certd" {
+		t.Fatalf("expected PGP_CERT_D to override the cert-d, got %s", certD)
+	}
+
+	// the demo identity is never written
+	err = Run(WithDemo(), WithStdin(strings.NewReader("")), WithSequoiaStore(t.TempDir(), t.TempDir()))
+	if err == nil || !strings.Contains(err.Error(), "demo identity") {
+		t.Fatalf("expected a demo error, got %v", err)
+	}
+}
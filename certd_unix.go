@@ -0,0 +1,27 @@
+//go:build darwin || linux
+// +build darwin linux
+
+package recovery
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// lockCertD takes the exclusive write lock of a cert-d, which is a flock on
+// its writelock file, returning a function which releases it.
+func lockCertD(certD string) (func(), error) {
+	f, err := os.OpenFile(filepath.Join(certD, "writelock"), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,156 @@
+package recovery
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// WithSearchCheckpoint configures the recovery to save the position of a
+// search to the file at path as it runs, and to resume the search from the
+// position saved there if the file exists, so an interrupted search doesn't
+// have to start again. The file only holds the position and a digest of the
+// non-secret search parameters (the fingerprint, user IDs, indexes and
+// timestamps, and the number of passphrases and missing words), and is
+// removed once the search finishes.
+func WithSearchCheckpoint(path string) Option {
+	return func(r *Recovery) {
+		r.checkpointPath = path
+	}
+}
+
+// searchCheckpoint is the contents of a checkpoint file.
+type searchCheckpoint struct {
+	Search     string `json:"search"`
+	Candidates uint64 `json:"candidates"`
+	Position   uint64 `json:"position"`
+}
+
+// openCheckpoint opens the checkpoint file before prompting for anything
+// (and so before the process is sandboxed, after which files can't be
+// opened), returning a function which closes it.
+func (r *Recovery) openCheckpoint() (func(), error) {
+	if r.checkpointPath == "" {
+		return func() {}, nil
+	}
+	if r.fingerprint == "" {
+		return nil, errors.New("a search checkpoint requires an expected fingerprint")
+	}
+	f, err := os.OpenFile(r.checkpointPath, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("could not open the search checkpoint: %s", err)
+	}
+	r.checkpoint = f
+	return func() {
+		// remove the file if the search never started
+		if info, err := f.Stat(); err == nil && info.Size() == 0 {
+			os.Remove(r.checkpointPath)
+		}
+		f.Close()
+		r.checkpoint = nil
+	}, nil
+}
+
+// resumeSearch returns the position to start the search of space from,
+// which is 0 unless a checkpoint of the same search was saved.
+func (r *Recovery) resumeSearch(space *searchSpace, candidates uint64) (uint64, error) {
+	if r.checkpoint == nil {
+		return 0, nil
+	}
+	data, err := ioutil.ReadAll(r.checkpoint)
+	if err != nil {
+		return 0, fmt.Errorf("could not read the search checkpoint: %s", err)
+	} else if len(data) == 0 {
+		return 0, nil
+	}
+	var checkpoint searchCheckpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return 0, fmt.Errorf("could not read the search checkpoint %s (delete it to start the search again): %s", r.checkpointPath, err)
+	}
+	if checkpoint.Search != space.digest() || checkpoint.Candidates != candidates {
+		return 0, fmt.Errorf("the search checkpoint %s is for a different search (delete it to start the search again)", r.checkpointPath)
+	}
+	if checkpoint.Position > candidates {
+		return 0, fmt.Errorf("the search checkpoint %s is invalid (delete it to start the search again)", r.checkpointPath)
+	}
+	r.info(fmt.Sprintf("Resuming the search from candidate %d of %d", checkpoint.Position+1, candidates), LogField{"candidate", checkpoint.Position + 1}, LogField{"candidates", candidates})
+	return checkpoint.Position, nil
+}
+
+// saveCheckpoint returns the function which saves the position of the
+// search of space, or nil if there isn't a checkpoint file.
+func (r *Recovery) saveCheckpoint(space *searchSpace, candidates uint64) func(position uint64) error {
+	if r.checkpoint == nil {
+		return nil
+	}
+	f, digest := r.checkpoint, space.digest()
+	return func(position uint64) error {
+		data, err := json.Marshal(searchCheckpoint{Search: digest, Candidates: candidates, Position: position})
+		if err != nil {
+			return err
+		}
+		return rewriteFile(f, append(data, '\n'))
+	}
+}
+
+// rewriteFile replaces the contents of f with data in place (since files
+// can't be replaced once the process is sandboxed), syncing it to disk.
+func rewriteFile(f *os.File, data []byte) error {
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := f.WriteAt(data, 0); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// removeCheckpoint removes the checkpoint file once the search has finished.
+func (r *Recovery) removeCheckpoint() {
+	if r.checkpoint == nil {
+		return
+	}
+	if err := os.Remove(r.checkpointPath); err != nil {
+		r.activeLogger().Warn(fmt.Sprintf("could not remove the search checkpoint: %s", err))
+	}
+}
+
+// digest returns a digest of the non-secret parameters of the search, so
+// that a checkpoint is only resumed by the same search.
+func (s *searchSpace) digest() string {
+	h := sha256.New()
+	write := func(v interface{}) {
+		binary.Write(h, binary.BigEndian, v)
+	}
+	writeString := func(v string) {
+		write(uint64(len(v)))
+		h.Write([]byte(v))
+	}
+	writeString(s.r.fingerprint)
+	writeString(string(s.r.keyParams().device))
+	write(uint64(s.seeds()))
+	if s.partial != nil {
+		for _, i := range s.partial.missing {
+			write(uint64(i))
+		}
+	}
+	write(uint64(len(s.passphrases)))
+	write(uint64(len(s.userIDs)))
+	for _, userID := range s.userIDs {
+		writeString(userID)
+	}
+	write(uint64(len(s.indexes)))
+	for _, index := range s.indexes {
+		write(index)
+	}
+	write(uint64(len(s.timestamps)))
+	for _, timestamp := range s.timestamps {
+		write(timestamp.Unix())
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,131 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBruteForceCheckpoint(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := &Recovery{ctx: ctx, searchWorkers: 4}
+
+	// cancel the search part way through, checking every candidate before
+	// the saved position was tried
+	var (
+		mtx      sync.Mutex
+		tried    = make(map[uint64]bool)
+		position uint64
+	)
+	_, _, err := r.bruteForce(0, 100000, func() candidateWorker {
+		return funcWorker(func(n uint64) (bool, error) {
+			mtx.Lock()
+			tried[n] = true
+			if len(tried) == 5000 {
+				cancel()
+			}
+			mtx.Unlock()
+			return false, nil
+		})
+	}, func(p uint64) error {
+		position = p
+		return nil
+	})
+	if err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if position == 0 || position >= 100000 {
+		t.Fatalf("unexpected checkpoint position %d", position)
+	}
+	for n := uint64(0); n < position; n++ {
+		if !tried[n] {
+			t.Fatalf("candidate %d before the checkpoint position %d wasn't tried", n, position)
+		}
+	}
+
+	// the search resumes from the position
+	r.ctx = context.Background()
+	match, found, err := r.bruteForce(position, 100000, func() candidateWorker {
+		return funcWorker(func(n uint64) (bool, error) {
+			if n < position {
+				t.Errorf("candidate %d before the checkpoint was tried again", n)
+			}
+			return n == 99999, nil
+		})
+	}, nil)
+	if err != nil || !found || match != 99999 {
+		t.Fatalf("expected the search to resume and match, got %d, %v, %v", match, found, err)
+	}
+}
+
+func TestSearchCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trezor-gpg-recovery-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "search.json")
+
+	timestamps := make([]time.Time, 5000)
+	for i := range timestamps {
+		timestamps[i] = time.Unix(demoVector.Timestamp-2500+int64(i), 0)
+	}
+	input := strings.Replace(aliceInput, "1523060353\n", "", 1)
+	opts := []Option{
+		WithFingerprint(aliceFingerprint),
+		WithTimestampCandidates(timestamps),
+		WithSearchCheckpoint(path),
+	}
+	writeCheckpoint := func(search string, position uint64) {
+		data, _ := json.Marshal(searchCheckpoint{Search: search, Candidates: 5000, Position: position})
+		if err := ioutil.WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	space := &searchSpace{r: &Recovery{fingerprint: aliceFingerprint}, passphrases: []string{""}, userIDs: []string{demoVector.UserID}, indexes: []uint32{0}, timestamps: timestamps}
+
+	// a finished search removes the checkpoint
+	recoverEntity(t, input, opts...)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected the checkpoint to be removed, got %v", err)
+	}
+
+	// a search resumes from the checkpoint
+	var stderr bytes.Buffer
+	writeCheckpoint(space.digest(), 2000)
+	recoverEntity(t, input, append(opts, WithStderr(&stderr))...)
+	if !strings.Contains(stderr.String(), "Resuming the search from candidate 2001 of 5000") {
+		t.Fatalf("expected the search to resume, got:\n%s", stderr.String())
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected the checkpoint to be removed, got %v", err)
+	}
+
+	// candidates before the checkpoint aren't tried again
+	writeCheckpoint(space.digest(), 2501)
+	err = Run(append([]Option{WithStdin(strings.NewReader(input)), WithStdout(&bytes.Buffer{})}, opts...)...)
+	if err == nil || !strings.Contains(err.Error(), "none of the 5000 candidate timestamps match") {
+		t.Fatalf("expected the search to fail, got %v", err)
+	}
+
+	// a checkpoint of a different search isn't resumed
+	writeCheckpoint(strings.Repeat("0", 64), 2000)
+	err = Run(append([]Option{WithStdin(strings.NewReader(input)), WithStdout(&bytes.Buffer{})}, opts...)...)
+	if err == nil || !strings.Contains(err.Error(), "is for a different search") {
+		t.Fatalf("expected a different search error, got %v", err)
+	}
+
+	// the checkpoint requires a fingerprint
+	err = Run(WithStdin(strings.NewReader(aliceInput)), WithStdout(&bytes.Buffer{}), WithSearchCheckpoint(path))
+	if err == nil || !strings.Contains(err.Error(), "requires an expected fingerprint") {
+		t.Fatalf("expected a missing fingerprint error, got %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package recovery
+
+import "time"
+
+// Clock tells the recovery the current time, which it uses to check the
+// timestamp isn't in the future, to skip expired subkeys of password store
+// recipients, and to date the report, audit log and attestation.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function to a Clock.
+type ClockFunc func() time.Time
+
+// Now returns f().
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// FixedClock returns a Clock which always returns t, so that tests are
+// deterministic.
+func FixedClock(t time.Time) Clock {
+	return ClockFunc(func() time.Time { return t })
+}
+
+// WithClock configures the clock the recovery reads the time from, which
+// defaults to the system clock.
+func WithClock(clock Clock) Option {
+	return func(r *Recovery) {
+		r.clock = clock
+	}
+}
+
+// now returns the current time from the configured clock.
+func (r *Recovery) now() time.Time {
+	if r.clock == nil {
+		return time.Now()
+	}
+	return r.clock.Now()
+}
@@ -0,0 +1,73 @@
+package recovery
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/openpgp/clearsign"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+func TestWithClock(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	var rep, audit, attestation bytes.Buffer
+	recoverEntity(t, aliceInput,
+		WithClock(FixedClock(now)),
+		WithReport(&rep, ReportJSON),
+		WithAuditLog(&audit),
+		WithAttestation(&attestation, "Bob"),
+	)
+
+	// the report
+	var r report
+	if err := json.Unmarshal(rep.Bytes(), &r); err != nil {
+		t.Fatal(err)
+	}
+	if !r.Started.Equal(now) || !r.Finished.Equal(now) {
+		t.Fatalf("expected the report to be dated %s, got %s to %s", now, r.Started, r.Finished)
+	}
+
+	// the audit log
+	s := bufio.NewScanner(&audit)
+	for s.Scan() {
+		var entry auditEntry
+		if err := json.Unmarshal(s.Bytes(), &entry); err != nil {
+			t.Fatal(err)
+		}
+		if !entry.Time.Equal(now) {
+			t.Fatalf("expected the audit log to be dated %s, got %q", now, s.Text())
+		}
+	}
+
+	// the attestation and its signature
+	block, _ := clearsign.Decode(attestation.Bytes())
+	if block == nil {
+		t.Fatalf("expected a cleartext signed message, got:\n%s", attestation.String())
+	}
+	if !strings.Contains(string(block.Plaintext), "Date:         2020-01-02T03:04:05Z") {
+		t.Fatalf("expected the attestation to be dated %s:\n%s", now, block.Plaintext)
+	}
+	p, err := packet.Read(block.ArmoredSignature.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig, ok := p.(*packet.Signature); !ok || !sig.CreationTime.Equal(now) {
+		t.Fatalf("expected the attestation to be signed at %s, got %#v", now, p)
+	}
+}
+
+func TestClockTimestamp(t *testing.T) {
+	// the timestamp is only in the future relative to the clock
+	var stderr bytes.Buffer
+	r := &Recovery{stderr: &stderr, clock: FixedClock(time.Unix(1523060353-3600, 0))}
+	if err := r.checkTimestamp(time.Unix(1523060353, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stderr.String(), "the timestamp is in the future") {
+		t.Fatalf("expected a warning the timestamp is in the future, got %q", stderr.String())
+	}
+}
@@ -0,0 +1,155 @@
+//go:build cgo
+// +build cgo
+
+// Command libtrezor-gpg-recovery is a C shared library exposing the
+// derivation, so that tools written in other languages (e.g. Python ceremony
+// scripts or GUI apps) can link against it rather than reimplementing it:
+//
+//	go build -buildmode=c-shared -o libtrezor-gpg-recovery.so ./cmd/libtrezor-gpg-recovery
+//
+// This also writes libtrezor-gpg-recovery.h declaring the functions below.
+// Functions return 0 on success, or -1 and set *err to the error message.
+// Strings returned through pointers are allocated with malloc and should be
+// released with tgr_free, which wipes them first.
+package main
+
+/*
+#include <stdint.h>
+#include <stdlib.h>
+#include <string.h>
+*/
+import "C"
+
+import (
+	"bytes"
+	"errors"
+	"time"
+	"unsafe"
+
+	recovery "github.com/lmars/trezor-gpg-recovery"
+)
+
+func main() {}
+
+// tgr_recover derives the identity for the given recovery seed, passphrase,
+// user ID and timestamp, setting *privateKey to the ascii armored private
+// key. If fingerprint isn't NULL or empty, the identity must match it.
+//
+//export tgr_recover
+func tgr_recover(mnemonic, passphrase, userID *C.char, timestamp C.int64_t, fingerprint *C.char, privateKey, err **C.char) C.int {
+	result, e := derive(mnemonic, passphrase, userID, timestamp, fingerprint)
+	if e != nil {
+		return setErr(err, e)
+	}
+	defer result.Wipe()
+	key, e := result.ArmoredPrivateKey()
+	if e != nil {
+		return setErr(err, e)
+	}
+	*privateKey = cBytes(key)
+	wipe(key)
+	return 0
+}
+
+// tgr_verify derives the identity like tgr_recover, but only checks it
+// matches fingerprint (which is required), so that the private key never
+// leaves the library.
+//
+//export tgr_verify
+func tgr_verify(mnemonic, passphrase, userID *C.char, timestamp C.int64_t, fingerprint *C.char, err **C.char) C.int {
+	if fingerprint == nil || *fingerprint == 0 {
+		return setErr(err, errors.New("missing fingerprint"))
+	}
+	result, e := derive(mnemonic, passphrase, userID, timestamp, fingerprint)
+	if e != nil {
+		return setErr(err, e)
+	}
+	result.Wipe()
+	return 0
+}
+
+// tgr_fingerprints derives the identity like tgr_recover, setting
+// *primaryFingerprint and *subkeyFingerprint.
+//
+//export tgr_fingerprints
+func tgr_fingerprints(mnemonic, passphrase, userID *C.char, timestamp C.int64_t, primaryFingerprint, subkeyFingerprint, err **C.char) C.int {
+	result, e := derive(mnemonic, passphrase, userID, timestamp, nil)
+	if e != nil {
+		return setErr(err, e)
+	}
+	result.Wipe()
+	*primaryFingerprint = C.CString(result.PrimaryFingerprint)
+	*subkeyFingerprint = C.CString(result.SubkeyFingerprint)
+	return 0
+}
+
+// tgr_self_test runs the known-answer tests, setting *output to their
+// results.
+//
+//export tgr_self_test
+func tgr_self_test(output, err **C.char) C.int {
+	var out bytes.Buffer
+	e := recovery.SelfTest(&out)
+	*output = C.CString(out.String())
+	if e != nil {
+		return setErr(err, e)
+	}
+	return 0
+}
+
+// tgr_free wipes and frees a string returned by the library.
+//
+//export tgr_free
+func tgr_free(s *C.char) {
+	if s == nil {
+		return
+	}
+	C.memset(unsafe.Pointer(s), 0, C.strlen(s))
+	C.free(unsafe.Pointer(s))
+}
+
+func derive(mnemonic, passphrase, userID *C.char, timestamp C.int64_t, fingerprint *C.char) (*recovery.Result, error) {
+	var opts []recovery.Option
+	if fp := goString(fingerprint); fp != "" {
+		opts = append(opts, recovery.WithFingerprint(fp))
+	}
+	r, err := recovery.New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return r.Derive(recovery.Params{
+		Mnemonic:   goString(mnemonic),
+		Passphrase: goString(passphrase),
+		UserID:     goString(userID),
+		Timestamp:  time.Unix(int64(timestamp), 0),
+	})
+}
+
+func setErr(err **C.char, e error) C.int {
+	if err != nil {
+		*err = C.CString(e.Error())
+	}
+	return -1
+}
+
+func goString(s *C.char) string {
+	if s == nil {
+		return ""
+	}
+	return C.GoString(s)
+}
+
+// cBytes copies b into a NUL terminated C string.
+func cBytes(b []byte) *C.char {
+	s := C.malloc(C.size_t(len(b) + 1))
+	buf := unsafe.Slice((*byte)(s), len(b)+1)
+	copy(buf, b)
+	buf[len(b)] = 0
+	return (*C.char)(s)
+}
+
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,104 @@
+//go:build js && wasm
+// +build js,wasm
+
+// Command trezor-gpg-recovery-wasm runs the recovery in a browser, for
+// air-gapped machines where the trezor-gpg-recovery binary can't be
+// installed. It registers a trezorGPGRecovery object with the page (see
+// index.html) rather than reading the standard streams.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"syscall/js"
+	"time"
+
+	recovery "github.com/lmars/trezor-gpg-recovery"
+)
+
+func main() {
+	js.Global().Set("trezorGPGRecovery", js.ValueOf(map[string]interface{}{
+		"recover":  js.FuncOf(recoverFunc),
+		"selfTest": js.FuncOf(selfTestFunc),
+	}))
+
+	// keep running so the page can call the functions
+	select {}
+}
+
+// recoverFunc derives the identity for the parameters object given by the
+// page, returning an object with the fingerprints and the armored keys, or
+// an object with an error.
+func recoverFunc(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 || args[0].Type() != js.TypeObject {
+		return errorResult(errors.New("expected a parameters object"))
+	}
+	params := args[0]
+	result, err := derive(params)
+	if err != nil {
+		return errorResult(err)
+	}
+	defer result.Wipe()
+	pubKey, err := result.ArmoredPublicKey()
+	if err != nil {
+		return errorResult(err)
+	}
+	privKey, err := result.ArmoredPrivateKey()
+	if err != nil {
+		return errorResult(err)
+	}
+	return map[string]interface{}{
+		"userID":             result.UserID,
+		"primaryFingerprint": result.PrimaryFingerprint,
+		"subkeyFingerprint":  result.SubkeyFingerprint,
+		"publicKey":          pubKey,
+		"privateKey":         string(privKey),
+	}
+}
+
+func derive(params js.Value) (*recovery.Result, error) {
+	var opts []recovery.Option
+	if index := params.Get("index"); index.Type() == js.TypeNumber {
+		opts = append(opts, recovery.WithIndex(uint32(index.Int())))
+	}
+	if fingerprint := stringParam(params, "fingerprint"); fingerprint != "" {
+		opts = append(opts, recovery.WithFingerprint(fingerprint))
+	}
+	r, err := recovery.New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	timestamp := params.Get("timestamp")
+	if timestamp.Type() != js.TypeNumber || math.IsNaN(timestamp.Float()) {
+		return nil, errors.New("missing timestamp")
+	}
+	return r.Derive(recovery.Params{
+		Mnemonic:   stringParam(params, "mnemonic"),
+		Passphrase: stringParam(params, "passphrase"),
+		UserID:     stringParam(params, "userID"),
+		Timestamp:  time.Unix(int64(timestamp.Int()), 0),
+	})
+}
+
+// selfTestFunc runs the known-answer tests, returning an object with the
+// output and whether they passed.
+func selfTestFunc(this js.Value, args []js.Value) interface{} {
+	var out bytes.Buffer
+	err := recovery.SelfTest(&out)
+	return map[string]interface{}{
+		"passed": err == nil,
+		"output": out.String(),
+	}
+}
+
+func errorResult(err error) interface{} {
+	return map[string]interface{}{"error": err.Error()}
+}
+
+func stringParam(params js.Value, name string) string {
+	if v := params.Get(name); v.Type() == js.TypeString {
+		return v.String()
+	}
+	return ""
+}
@@ -0,0 +1,144 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+func TestRecoveryConcurrentRuns(t *testing.T) {
+	r, err := New(WithFingerprint(aliceFingerprint), WithStrict())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			input := aliceInput
+			if i%2 == 1 {
+				input = strings.Replace(input, "s3cr3t", "wrong", 1)
+			}
+			var stdout bytes.Buffer
+			if err := r.Run(context.Background(), WithStdin(strings.NewReader(input)), WithStdout(&stdout)); err != nil {
+				errs[i] = err
+				return
+			}
+			entities, err := openpgp.ReadArmoredKeyRing(&stdout)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			if fingerprint := fmt.Sprintf("%X", entities[0].PrimaryKey.Fingerprint); fingerprint != aliceFingerprint {
+				errs[i] = fmt.Errorf("unexpected fingerprint %s", fingerprint)
+			}
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if i%2 == 0 && err != nil {
+			t.Fatalf("run %d: unexpected error: %s", i, err)
+		}
+		if i%2 == 1 && !errors.Is(err, ErrFingerprintMismatch) {
+			t.Fatalf("run %d: expected a fingerprint mismatch, got %v", i, err)
+		}
+	}
+}
+
+func TestRecoveryRunOptions(t *testing.T) {
+	r, err := New(WithIndex(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// options given to a run apply to that run only
+	var stdout bytes.Buffer
+	if err := r.Run(context.Background(), WithStdin(strings.NewReader(aliceInput)), WithStdout(&stdout), WithIndex(0), WithFingerprint(aliceFingerprint)); err != nil {
+		t.Fatal(err)
+	}
+	result, err := r.Derive(Params{
+		Mnemonic:   demoVector.Mnemonic,
+		Passphrase: demoVector.Passphrase,
+		UserID:     demoVector.UserID,
+		Timestamp:  time.Unix(demoVector.Timestamp, 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer result.Wipe()
+	if result.PrimaryFingerprint == demoVector.PrimaryFingerprint {
+		t.Fatal("expected the recovery to still derive index 1")
+	}
+}
+
+func TestRecoveryNewInvalid(t *testing.T) {
+	if _, err := New(WithCurve("ed25519")); err == nil {
+		t.Fatal("expected an error for an unsupported curve")
+	}
+}
+
+func TestRecoveryConcurrentDerive(t *testing.T) {
+	r, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	errs := make([]error, len(testVectors))
+	for i, v := range testVectors {
+		wg.Add(1)
+		go func(i int, v testVector) {
+			defer wg.Done()
+			result, err := r.Derive(Params{
+				Mnemonic:   v.Mnemonic,
+				Passphrase: v.Passphrase,
+				UserID:     v.UserID,
+				Timestamp:  time.Unix(v.Timestamp, 0),
+			})
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			defer result.Wipe()
+			if result.PrimaryFingerprint != v.PrimaryFingerprint {
+				errs[i] = fmt.Errorf("expected fingerprint %s, got %s", v.PrimaryFingerprint, result.PrimaryFingerprint)
+			}
+		}(i, v)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("vector %d: %s", i, err)
+		}
+	}
+}
+
+func TestRecoveryDeriveFingerprint(t *testing.T) {
+	r, err := New(WithFingerprint(strings.ToLower(aliceFingerprint)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := Params{
+		Mnemonic:   demoVector.Mnemonic,
+		Passphrase: demoVector.Passphrase,
+		UserID:     demoVector.UserID,
+		Timestamp:  time.Unix(demoVector.Timestamp, 0),
+	}
+	result, err := r.Derive(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result.Wipe()
+	params.Passphrase = "wrong"
+	if _, err := r.Derive(params); !errors.Is(err, ErrFingerprintMismatch) {
+		t.Fatalf("expected a fingerprint mismatch, got %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunContextCancel(t *testing.T) {
+	// enter the first few words and then stop as if waiting for the user
+	stdin, w := io.Pipe()
+	defer w.Close()
+	go io.WriteString(w, strings.Join(strings.Split(aliceInput, "\n")[:8], "\n")+"\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var stderr bytes.Buffer
+	done := make(chan error, 1)
+	go func() {
+		done <- RunContext(ctx,
+			WithStdin(stdin),
+			WithStdout(&bytes.Buffer{}),
+			WithStderr(&stderr),
+		)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the recovery to be cancelled")
+	}
+}
+
+func TestSearchContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := &Recovery{ctx: ctx, stderr: ioutil.Discard, fingerprint: demoVector.PrimaryFingerprint}
+	_, err := r.search(&mnemonicSeed{mnemonic: []byte(demoVector.Mnemonic)}, []string{"a", "b"}, []string{demoVector.UserID}, []time.Time{time.Unix(demoVector.Timestamp, 0)})
+	if err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!windows
+
+package recovery
+
+import "errors"
+
+func disableCoreDumps() error {
+	return errors.New("disabling core dumps is not supported on this platform")
+}
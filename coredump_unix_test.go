@@ -0,0 +1,22 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package recovery
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestDisableCoreDumps(t *testing.T) {
+	if err := disableCoreDumps(); err != nil {
+		t.Fatal(err)
+	}
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_CORE, &limit); err != nil {
+		t.Fatal(err)
+	}
+	if limit.Cur != 0 || limit.Max != 0 {
+		t.Fatalf("expected RLIMIT_CORE to be zero, got %+v", limit)
+	}
+}
@@ -0,0 +1,19 @@
+package recovery
+
+import "syscall"
+
+const (
+	semFailCriticalErrors = 0x0001
+	semNoGPFaultErrorBox  = 0x0002
+)
+
+// disableCoreDumps stops Windows Error Reporting from being invoked when the
+// process crashes, so that it can't write the seed into a crash dump.
+func disableCoreDumps() error {
+	proc := syscall.NewLazyDLL("kernel32.dll").NewProc("SetErrorMode")
+	if err := proc.Find(); err != nil {
+		return err
+	}
+	proc.Call(semFailCriticalErrors | semNoGPFaultErrorBox)
+	return nil
+}
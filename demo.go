@@ -0,0 +1,64 @@
+package recovery
+
+import "fmt"
+
+// demoVector is the well-known test vector used in demo mode.
+var demoVector = testVectors[0]
+
+// demoComment watermarks the output of demo mode.
+const demoComment = `DEMO KEY derived from the public "all all all" test seed, do not use`
+
+// WithDemo configures a practice run of the recovery, where the user ID,
+// timestamp, recovery seed and passphrase are pre-filled from the public "all
+// all all ..." test vector and the output is clearly watermarked, so users can
+// rehearse the recovery before using their real seed.
+func WithDemo() Option {
+	return func(r *Recovery) {
+		r.demo = true
+	}
+}
+
+// readInput reads the answer to a prompt for one of the recovery inputs,
+// which is pre-filled with answer in demo mode.
+func (r *Recovery) readInput(prompt, help, answer string) (string, error) {
+	if !r.demo {
+		return r.readLine(prompt, help)
+	}
+	if r.largePrint {
+		fmt.Fprintf(r.stderr, "\n%s\n> %s (demo)\n", largeText(prompt), answer)
+		return answer, nil
+	}
+	if r.screenReader {
+		fmt.Fprintf(r.stderr, "%s\n> %s (demo)\n", prompt, answer)
+		return answer, nil
+	}
+	fmt.Fprintf(r.stderr, "%-77s\n> %s (demo)\n", prompt, answer)
+	fmt.Fprintln(r.stderr, "-----------------------------------------------------------------------------")
+	return answer, nil
+}
+
+// readSecretInput is like readInput but for secret inputs, returning a copy
+// in the locked memory which the caller should wipe.
+func (r *Recovery) readSecretInput(prompt, answer string) ([]byte, error) {
+	if !r.demo {
+		return r.readSecret(prompt)
+	}
+	fmt.Fprintf(r.stderr, "%s %s (demo)\n", prompt, answer)
+	return r.mem.copy([]byte(answer)), nil
+}
+
+// armorHeaders returns the armor headers of the private key output, which
+// are watermarked in demo mode.
+func (r *Recovery) armorHeaders() map[string]string {
+	if !r.demo {
+		return nil
+	}
+	return map[string]string{"Comment": demoComment}
+}
+
+func (r *Recovery) logDemo() {
+	r.display(`
+ DEMO MODE: this is a practice run using the public "all all all ..." test
+ seed. The recovered key is NOT secret and must never be used.
+-----------------------------------------------------------------------------`)
+}
@@ -0,0 +1,34 @@
+package recovery
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDemo(t *testing.T) {
+	// only the confirmation is read from stdin
+	var stdout, stderr bytes.Buffer
+	err := Run(
+		WithStdin(strings.NewReader("yes\n")),
+		WithStdout(&stdout),
+		WithStderr(&stderr),
+		WithDemo(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stderr.String(), "> Alice <alice@example.com> (demo)") || !strings.Contains(stderr.String(), "12: all") {
+		t.Fatalf("expected the pre-filled inputs to be shown, got:\n%s", stderr.String())
+	}
+	if n := strings.Count(stderr.String(), "DEMO MODE"); n != 2 {
+		t.Fatalf("expected the demo notice before and after the recovery, got %d", n)
+	}
+	if !strings.Contains(stdout.String(), "Comment: "+demoComment) {
+		t.Fatalf("expected the private key to be watermarked, got:\n%s", stdout.String())
+	}
+	entity := recoverEntity(t, "yes\n", WithDemo())
+	if fp := formatFingerprint(entity.PrimaryKey); fp != demoVector.PrimaryFingerprint {
+		t.Fatalf("unexpected fingerprint %s", fp)
+	}
+}
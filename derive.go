@@ -0,0 +1,32 @@
+package recovery
+
+import (
+	"crypto/ecdsa"
+
+	"github.com/lmars/trezor-gpg-recovery/derive"
+)
+
+// DeriveGPGPrimaryKey derives the NIST P-256 primary (signing) key of the
+// Trezor GPG identity with the given URI (e.g. "gpg://Alice
+// <alice@example.com>") from a 64 byte BIP39 seed, using the SLIP-0013 path
+// trezor-agent uses. The caller should wipe the returned key once done with
+// it.
+func DeriveGPGPrimaryKey(seed []byte, uri string) (*ecdsa.PrivateKey, error) {
+	return deriveGPGKey(seed, uri, false)
+}
+
+// DeriveGPGSubkey derives the NIST P-256 encryption (ECDH) subkey of the Trezor
+// GPG identity with the given URI from a 64 byte BIP39 seed, like
+// DeriveGPGPrimaryKey.
+func DeriveGPGSubkey(seed []byte, uri string) (*ecdsa.PrivateKey, error) {
+	return deriveGPGKey(seed, uri, true)
+}
+
+func deriveGPGKey(seed []byte, uri string, subkey bool) (*ecdsa.PrivateKey, error) {
+	masterKey, err := derive.MasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	defer derive.WipeMasterKey(masterKey)
+	return derive.Key(masterKey, uri, 0, subkey)
+}
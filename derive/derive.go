@@ -0,0 +1,222 @@
+// Package derive implements the SLIP-0010/SLIP-0013 derivation of the NIST
+// P-256 keys of a Trezor GPG identity, as trezor-agent does, and of a
+// Ledger OpenPGP app key slot, without any OpenPGP dependency. The parent
+// recovery package builds the GPG identity from these keys, while wallet
+// and agent projects can use this package directly (e.g. to derive the
+// public keys).
+package derive
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	slip10 "github.com/lmars/go-slip10"
+	slip13 "github.com/lmars/go-slip13"
+)
+
+const (
+	// PrimaryPurpose is the SLIP-0013 purpose used to derive the primary
+	// (signing) key.
+	PrimaryPurpose = slip13.Purpose
+
+	// SubkeyPurpose is the purpose trezor-agent uses to derive the ECDH
+	// (encryption) subkey.
+	SubkeyPurpose = 17
+)
+
+// URI returns the SLIP-0013 URI of the GPG identity with the given user ID.
+func URI(userID string) string {
+	return "gpg://" + userID
+}
+
+// Indexes returns the hardened BIP32 child indexes derived by SLIP-0013 for
+// the given purpose, URI and index.
+func Indexes(purpose uint32, uri string, index uint32) []uint32 {
+	buf := make([]byte, 4, 4+len(uri))
+	binary.LittleEndian.PutUint32(buf, index)
+	hash := sha256.Sum256(append(buf, uri...))
+	return []uint32{
+		purpose | slip10.FirstHardenedChild,
+		binary.LittleEndian.Uint32(hash[0:4]) | slip10.FirstHardenedChild,
+		binary.LittleEndian.Uint32(hash[4:8]) | slip10.FirstHardenedChild,
+		binary.LittleEndian.Uint32(hash[8:12]) | slip10.FirstHardenedChild,
+		binary.LittleEndian.Uint32(hash[12:16]) | slip10.FirstHardenedChild,
+	}
+}
+
+// Path returns the BIP32 path derived by SLIP-0013 for the given purpose,
+// URI and index (e.g. "m/13'/...").
+func Path(purpose uint32, uri string, index uint32) string {
+	path := Indexes(purpose, uri, index)
+	return fmt.Sprintf("m/%d'/%d'/%d'/%d'/%d'",
+		path[0]&^slip10.FirstHardenedChild,
+		path[1]&^slip10.FirstHardenedChild,
+		path[2]&^slip10.FirstHardenedChild,
+		path[3]&^slip10.FirstHardenedChild,
+		path[4]&^slip10.FirstHardenedChild,
+	)
+}
+
+// MasterKey generates the SLIP-0010 NIST P-256 master key from a 64 byte
+// BIP39 seed. The caller should wipe it with WipeMasterKey once done with it.
+func MasterKey(seed []byte) (*slip10.Key, error) {
+	return slip10.NewMasterKeyWithCurve(seed, slip10.CurveP256)
+}
+
+// Key derives the primary key, or the subkey if subkey is set, of the
+// identity with the given URI and SLIP-0013 index from a SLIP-0010 master
+// key. The caller should wipe the returned key with WipeKey once done with
+// it.
+func Key(masterKey *slip10.Key, uri string, index uint32, subkey bool) (*ecdsa.PrivateKey, error) {
+	// determine what purpose field to use
+	var purpose uint32 = PrimaryPurpose
+	if subkey {
+		purpose = SubkeyPurpose
+	}
+	return PurposeKey(masterKey, purpose, uri, index)
+}
+
+// PurposeKey is like Key but derives the key with the given SLIP-0013
+// purpose, for identities created by agents patched to use other purposes
+// than PrimaryPurpose and SubkeyPurpose.
+func PurposeKey(masterKey *slip10.Key, purpose uint32, uri string, index uint32) (*ecdsa.PrivateKey, error) {
+	// derive the SLIP13 authentication key, wiping the intermediate keys
+	// (which is why slip13.DeriveWithPurpose isn't used)
+	var (
+		key       = make([]byte, 32)
+		chainCode = make([]byte, 32)
+		k, il     big.Int
+	)
+	defer wipe(key)
+	defer wipe(chainCode)
+	defer wipeInt(&k)
+	defer wipeInt(&il)
+	copy(chainCode, masterKey.ChainCode)
+	k.SetBytes(masterKey.Key)
+	k.FillBytes(key)
+	for _, child := range Indexes(purpose, uri, index) {
+		if err := hardenedChild(key, chainCode, child, &k, &il); err != nil {
+			return nil, err
+		}
+	}
+	return newPrivateKey(key)
+}
+
+// hardenedChild replaces the 32 byte private key (whose value is also in k)
+// and chain code of a SLIP-0010 NIST P-256 key with those of its hardened
+// child index, giving the same keys as slip10.Key.NewChildKey. NewChildKey
+// also computes the parent's public key for the BIP32 fingerprint, which
+// nothing here uses and which is most of the cost of deriving a path.
+func hardenedChild(key, chainCode []byte, index uint32, k, il *big.Int) error {
+	var data [37]byte
+	copy(data[1:], key)
+	binary.BigEndian.PutUint32(data[33:], index)
+	defer wipe(data[:])
+	mac := hmac.New(sha512.New, chainCode)
+	mac.Write(data[:])
+	var sum [sha512.Size]byte
+	defer wipe(sum[:])
+	mac.Sum(sum[:0])
+
+	il.SetBytes(sum[:32])
+	k.Add(k, il)
+	k.Mod(k, elliptic.P256().Params().N)
+	if k.Sign() == 0 {
+		return slip10.ErrInvalidPrivateKey
+	}
+	k.FillBytes(key)
+	copy(chainCode, sum[32:])
+	return nil
+}
+
+// newPrivateKey returns the NIST P-256 key with the private scalar d, which
+// must be less than the order of the curve.
+func newPrivateKey(d []byte) (*ecdsa.PrivateKey, error) {
+	// compute the public key using crypto/ecdh, which unlike
+	// elliptic.Curve.ScalarBaseMult is constant time
+	ecdhKey, err := ecdh.P256().NewPrivateKey(d)
+	if err != nil {
+		return nil, err
+	}
+	pub := ecdhKey.PublicKey().Bytes()
+
+	// convert to an ecdsa.PrivateKey (which the openpgp package requires)
+	priv := new(ecdsa.PrivateKey)
+	priv.PublicKey.Curve = elliptic.P256()
+	priv.PublicKey.X = new(big.Int).SetBytes(pub[1:33])
+	priv.PublicKey.Y = new(big.Int).SetBytes(pub[33:])
+	priv.D = new(big.Int).SetBytes(d)
+	return priv, nil
+}
+
+// Keys derives the primary key and subkey of the identity with the given URI
+// and SLIP-0013 index from a 64 byte BIP39 seed. The caller should wipe them
+// with WipeKey once done with them.
+func Keys(seed []byte, uri string, index uint32) (primaryKey, subkey *ecdsa.PrivateKey, err error) {
+	masterKey, err := MasterKey(seed)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer WipeMasterKey(masterKey)
+	primaryKey, err = Key(masterKey, uri, index, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	subkey, err = Key(masterKey, uri, index, true)
+	if err != nil {
+		WipeKey(primaryKey)
+		return nil, nil, err
+	}
+	return primaryKey, subkey, nil
+}
+
+// PublicKeys derives the public keys of the identity like Keys, wiping the
+// private keys, for public-key-only operations such as checking an identity
+// matches a seed.
+func PublicKeys(seed []byte, uri string, index uint32) (primaryKey, subkey *ecdsa.PublicKey, err error) {
+	primary, sub, err := Keys(seed, uri, index)
+	if err != nil {
+		return nil, nil, err
+	}
+	WipeKey(primary)
+	WipeKey(sub)
+	return &primary.PublicKey, &sub.PublicKey, nil
+}
+
+// WipeKey wipes the private part of a derived key.
+func WipeKey(key *ecdsa.PrivateKey) {
+	if key != nil && key.D != nil {
+		wipeInt(key.D)
+	}
+}
+
+// WipeMasterKey wipes the private key and chain code of a SLIP-0010 key.
+func WipeMasterKey(key *slip10.Key) {
+	if key != nil {
+		wipe(key.Key)
+		wipe(key.ChainCode)
+	}
+}
+
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// wipeInt wipes the words backing i, which big.Int.SetInt64(0) would leave
+// in place.
+func wipeInt(i *big.Int) {
+	words := i.Bits()
+	for j := range words {
+		words[j] = 0
+	}
+	i.SetInt64(0)
+}
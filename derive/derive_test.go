@@ -0,0 +1,172 @@
+package derive
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/sha512"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// alicePrimaryKey is the primary public key of the "all all all" identity
+// with passphrase "s3cr3t" and user ID "Alice <alice@example.com>", as shown
+// by 'gpg --export-ssh-key'
+const alicePrimaryKey = "04427956e42de4eddc522b32fdc2bbe7a02d8078e82160a44881788b59e5bc47e89a37ad074484d1ab354c5a0fa1dd87e8dd0a200f3e9b80429c0d39dd85522b29"
+
+func aliceSeed() []byte {
+	return pbkdf2.Key([]byte("all all all all all all all all all all all all"), []byte("mnemonics3cr3t"), 2048, 64, sha512.New)
+}
+
+func TestPublicKeys(t *testing.T) {
+	primary, subkey, err := PublicKeys(aliceSeed(), URI("Alice <alice@example.com>"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub := hex.EncodeToString(elliptic.Marshal(primary.Curve, primary.X, primary.Y)); pub != alicePrimaryKey {
+		t.Fatalf("unexpected primary key %s", pub)
+	}
+	if primary.X.Cmp(subkey.X) == 0 {
+		t.Fatal("expected the subkey to differ from the primary key")
+	}
+}
+
+func TestKeys(t *testing.T) {
+	primary, subkey, err := Keys(aliceSeed(), URI("Alice <alice@example.com>"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !primary.Curve.IsOnCurve(primary.X, primary.Y) || !subkey.Curve.IsOnCurve(subkey.X, subkey.Y) {
+		t.Fatal("expected the derived keys to be on the curve")
+	}
+	WipeKey(primary)
+	WipeKey(subkey)
+	if primary.D.Sign() != 0 || subkey.D.Sign() != 0 {
+		t.Fatal("expected the keys to be wiped")
+	}
+
+	// a different index derives a different identity
+	other, _, err := PublicKeys(aliceSeed(), URI("Alice <alice@example.com>"), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.X.Cmp(primary.X) == 0 {
+		t.Fatal("expected index 1 to derive a different key")
+	}
+}
+
+func TestPath(t *testing.T) {
+	uri := URI("Alice <alice@example.com>")
+	primary := Path(PrimaryPurpose, uri, 0)
+	subkey := Path(SubkeyPurpose, uri, 0)
+	if !strings.HasPrefix(primary, "m/13'/") || !strings.HasPrefix(subkey, "m/17'/") {
+		t.Fatalf("unexpected paths %s and %s", primary, subkey)
+	}
+	if strings.TrimPrefix(primary, "m/13'") != strings.TrimPrefix(subkey, "m/17'") {
+		t.Fatalf("expected the paths to only differ in purpose: %s and %s", primary, subkey)
+	}
+	if len(Indexes(PrimaryPurpose, uri, 0)) != 5 {
+		t.Fatal("expected 5 indexes")
+	}
+}
+
+func TestLedgerKeys(t *testing.T) {
+	// the master key is BIP32's, as in its first test vector
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	masterKey, err := LedgerMasterKey(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key := hex.EncodeToString(masterKey.Key); key != "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35" {
+		t.Fatalf("unexpected master key %s", key)
+	}
+
+	signatureKey, decryptionKey, err := LedgerKeys(aliceSeed(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !signatureKey.Curve.IsOnCurve(signatureKey.X, signatureKey.Y) || signatureKey.Curve != elliptic.P256() {
+		t.Fatal("expected a NIST P-256 signature key")
+	}
+	if signatureKey.X.Cmp(decryptionKey.X) == 0 {
+		t.Fatal("expected the decryption key to differ from the signature key")
+	}
+	other, _, err := LedgerKeys(aliceSeed(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.X.Cmp(signatureKey.X) == 0 {
+		t.Fatal("expected slot 2 to derive a different key")
+	}
+	trezor, _, err := Keys(aliceSeed(), URI("Alice <alice@example.com>"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trezor.X.Cmp(signatureKey.X) == 0 {
+		t.Fatal("expected the Ledger key to differ from the Trezor key")
+	}
+
+	for _, slot := range []uint32{0, 4} {
+		if _, _, err := LedgerKeys(aliceSeed(), slot); err == nil {
+			t.Fatalf("expected an error for slot %d", slot)
+		}
+	}
+	if path := LedgerPath(1); path != "m/4673607'/1" {
+		t.Fatalf("unexpected path %s", path)
+	}
+}
+
+func BenchmarkMasterKey(b *testing.B) {
+	seed := aliceSeed()
+	for i := 0; i < b.N; i++ {
+		key, err := MasterKey(seed)
+		if err != nil {
+			b.Fatal(err)
+		}
+		WipeMasterKey(key)
+	}
+}
+
+func BenchmarkKeys(b *testing.B) {
+	masterKey, err := MasterKey(aliceSeed())
+	if err != nil {
+		b.Fatal(err)
+	}
+	uri := URI("Alice <alice@example.com>")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, subkey := range []bool{false, true} {
+			key, err := Key(masterKey, uri, 0, subkey)
+			if err != nil {
+				b.Fatal(err)
+			}
+			WipeKey(key)
+		}
+	}
+}
+
+func TestHardenedChild(t *testing.T) {
+	masterKey, err := MasterKey(aliceSeed())
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := append([]byte(nil), masterKey.Key...)
+	chainCode := append([]byte(nil), masterKey.ChainCode...)
+	var k, il big.Int
+	k.SetBytes(key)
+	expected := masterKey
+	for _, index := range Indexes(PrimaryPurpose, URI("Alice <alice@example.com>"), 0) {
+		if err := hardenedChild(key, chainCode, index, &k, &il); err != nil {
+			t.Fatal(err)
+		}
+		if expected, err = expected.NewChildKey(index); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(key, expected.Key) || !bytes.Equal(chainCode, expected.ChainCode) {
+			t.Fatalf("unexpected child %d", index)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package derive
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	slip10 "github.com/lmars/go-slip10"
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	// LedgerPurpose is the hardened BIP32 index ("GPG" in ASCII) the Ledger
+	// OpenPGP app derives the seed of each key slot under.
+	LedgerPurpose = 0x475047
+
+	// LedgerSignatureKey, LedgerDecryptionKey and LedgerAuthenticationKey
+	// are the names of the keys of a Ledger OpenPGP key slot.
+	LedgerSignatureKey      = "sig "
+	LedgerDecryptionKey     = "dec "
+	LedgerAuthenticationKey = "aut "
+
+	// LedgerSlots is the number of key slots of the Ledger OpenPGP app.
+	LedgerSlots = 3
+)
+
+// ledgerKeyIndex is the index of ECC keys in the Ledger OpenPGP app's key
+// derivation, which stretches it further for each of the RSA key's primes.
+const ledgerKeyIndex = 1
+
+// LedgerMasterKey generates the BIP32 (secp256k1) master key the Ledger
+// OpenPGP app's seed mode derives from, from a 64 byte BIP39 seed. The
+// caller should wipe it with WipeMasterKey once done with it.
+func LedgerMasterKey(seed []byte) (*slip10.Key, error) {
+	return slip10.NewMasterKeyWithCurve(seed, slip10.CurveBitcoin)
+}
+
+// LedgerPath returns the BIP32 path of the seed of the Ledger OpenPGP key
+// slot (numbered from 1, as the app displays it), e.g. "m/4673607'/1".
+func LedgerPath(slot uint32) string {
+	return fmt.Sprintf("m/%d'/%d", LedgerPurpose, slot)
+}
+
+// LedgerKey derives the NIST P-256 key with the given name (e.g.
+// LedgerSignatureKey) of a Ledger OpenPGP key slot from a BIP32 master key,
+// as the app does in seed mode: the slot's seed Sn is the private key at
+// LedgerPath(slot), and the private key is the first 32 bytes of
+// SHAKE256(SHA256(Sn || name || 0x0001)). The caller should wipe the
+// returned key with WipeKey once done with it.
+func LedgerKey(masterKey *slip10.Key, slot uint32, name string) (*ecdsa.PrivateKey, error) {
+	if slot < 1 || slot > LedgerSlots {
+		return nil, fmt.Errorf("invalid Ledger key slot %d: must be 1 to %d", slot, LedgerSlots)
+	}
+	if len(name) != 4 {
+		return nil, fmt.Errorf("invalid Ledger key name %q", name)
+	}
+	purposeKey, err := masterKey.NewChildKey(LedgerPurpose | slip10.FirstHardenedChild)
+	if err != nil {
+		return nil, err
+	}
+	slotKey, err := purposeKey.NewChildKey(slot)
+	WipeMasterKey(purposeKey)
+	if err != nil {
+		return nil, err
+	}
+	defer WipeMasterKey(slotKey)
+
+	buf := make([]byte, 0, len(slotKey.Key)+len(name)+2)
+	buf = append(buf, slotKey.Key...)
+	buf = append(buf, name...)
+	buf = binary.BigEndian.AppendUint16(buf, ledgerKeyIndex)
+	hash := sha256.Sum256(buf)
+	wipe(buf)
+	defer wipe(hash[:])
+
+	d := make([]byte, 32)
+	defer wipe(d)
+	sha3.ShakeSum256(d, hash[:])
+	key, err := newPrivateKey(d)
+	if err != nil {
+		// the chance of this is negligible, and the app would reject the
+		// key too
+		return nil, errors.New("the derived Ledger key is outside the curve's order")
+	}
+	return key, nil
+}
+
+// LedgerKeys derives the signature and decryption keys of a Ledger OpenPGP
+// key slot from a 64 byte BIP39 seed. The caller should wipe them with
+// WipeKey once done with them.
+func LedgerKeys(seed []byte, slot uint32) (signatureKey, decryptionKey *ecdsa.PrivateKey, err error) {
+	masterKey, err := LedgerMasterKey(seed)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer WipeMasterKey(masterKey)
+	signatureKey, err = LedgerKey(masterKey, slot, LedgerSignatureKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	decryptionKey, err = LedgerKey(masterKey, slot, LedgerDecryptionKey)
+	if err != nil {
+		WipeKey(signatureKey)
+		return nil, nil, err
+	}
+	return signatureKey, decryptionKey, nil
+}
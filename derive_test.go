@@ -0,0 +1,31 @@
+package recovery
+
+import (
+	"crypto/sha512"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+func TestDeriveGPGKeys(t *testing.T) {
+	for _, v := range testVectors {
+		seed := pbkdf2.Key([]byte(v.Mnemonic), []byte("mnemonic"+v.Passphrase), 2048, 64, sha512.New)
+		uri := "gpg://" + v.UserID
+		primaryKey, err := DeriveGPGPrimaryKey(seed, uri)
+		if err != nil {
+			t.Fatal(err)
+		}
+		subKey, err := DeriveGPGSubkey(seed, uri)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entity := buildEntity(primaryKey, subKey, v.UserID, time.Unix(v.Timestamp, 0), &defaultKeyParams)
+		if fp := formatFingerprint(entity.PrimaryKey); fp != v.PrimaryFingerprint {
+			t.Fatalf("%s: unexpected primary fingerprint %s", v.Name, fp)
+		}
+		if fp := formatFingerprint(entity.Subkeys[0].PublicKey); fp != v.SubkeyFingerprint {
+			t.Fatalf("%s: unexpected subkey fingerprint %s", v.Name, fp)
+		}
+	}
+}
@@ -0,0 +1,565 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lmars/trezor-gpg-recovery/derive"
+	"golang.org/x/crypto/openpgp/packet"
+	"golang.org/x/crypto/openpgp/s2k"
+)
+
+// CheckDevice reads the public keys of the identity from a Trezor connected
+// over USB (through Trezor Bridge if it's running) and writes their
+// fingerprints to stdout (or displays them if WithStdout isn't given), so the
+// user ID, timestamp and key parameters can be checked before the recovery
+// seed is ever entered. Only the user ID and timestamp are prompted for (and
+// the Trezor's PIN and passphrase if it asks for them), and the expected
+// fingerprint given with WithFingerprint, WithPublicKey or
+// WithTrezorConfig is checked, searching any candidate user IDs and
+// timestamps for it.
+func CheckDevice(ctx context.Context, opts ...Option) error {
+	return new(Recovery).CheckDevice(ctx, opts...)
+}
+
+// CheckDevice is like the package level CheckDevice, using the options r was
+// created with followed by opts.
+func (r *Recovery) CheckDevice(ctx context.Context, opts ...Option) error {
+	run := r.newRun(ctx, opts)
+	return run.finish(run.checkDevice())
+}
+
+// checkDevice does the work of CheckDevice.
+func (r *Recovery) checkDevice() error {
+	if r.stdin == nil && r.prompter == nil {
+		return errors.New("no input configured: use WithStdin or WithPrompter")
+	}
+	if r.demo {
+		return errors.New("the demo identity isn't on a Trezor, so can't be checked against one")
+	}
+	if r.keyParams().device == DeviceLedger {
+		return errors.New("only Trezors can be checked, not Ledgers")
+	}
+	if err := r.keyParams().check(); err != nil {
+		return err
+	}
+	if err := r.checkSearch(); err != nil {
+		return err
+	}
+	if r.passphrases != nil || r.passphraseTypos {
+		return errors.New("candidate passphrases can't be searched on a Trezor, which is given a single passphrase")
+	}
+	if err := r.applyTrezorConfig(); err != nil {
+		return err
+	}
+	freeInput, err := r.setupInput()
+	if err != nil {
+		return err
+	}
+	defer freeInput()
+
+	userIDs := r.userIDs
+	if userIDs == nil {
+		userID, err := r.readUserID()
+		if err != nil {
+			return err
+		}
+		userIDs = []string{userID}
+	}
+	timestamps := r.timestamps
+	if timestamps == nil {
+		timestamp, err := r.readTimestamp()
+		if err != nil {
+			return err
+		}
+		timestamps = []time.Time{timestamp}
+	}
+	if err := r.readExpertParams(); err != nil {
+		return err
+	}
+
+	r.info("Connecting to the Trezor...")
+	dev, err := openDevice(r.ctx)
+	if err != nil {
+		return fmt.Errorf("could not connect to the Trezor: %s", err)
+	}
+	defer dev.Close()
+	if err := r.initDevice(dev); err != nil {
+		return err
+	}
+	r.audit(auditEntry{Step: "device connected"})
+
+	// the keys only depend on the user ID, so are read once for each
+	// candidate
+	params := r.keyParams()
+	var primaryKey, subkey *packet.PublicKey
+	var matchedUserID string
+search:
+	for _, userID := range userIDs {
+		primary, sub, err := r.devicePublicKeys(dev, userID)
+		if err != nil {
+			return err
+		}
+		for _, timestamp := range timestamps {
+			primaryKey, subkey = buildPublicKeys(primary, sub, timestamp, params)
+			matchedUserID = userID
+			if r.fingerprint == "" || formatFingerprint(primaryKey) == r.fingerprint {
+				break search
+			}
+		}
+	}
+	fingerprint := formatFingerprint(primaryKey)
+	if r.fingerprint != "" && fingerprint != r.fingerprint && (len(userIDs) > 1 || len(timestamps) > 1) {
+		err := &mismatchError{expected: r.fingerprint, candidates: len(userIDs) * len(timestamps), noun: candidateNoun(1, 1, len(userIDs), 1, len(timestamps))}
+		r.check("device fingerprint "+r.fingerprint, err)
+		return err
+	}
+	if len(userIDs) > 1 {
+		r.info(fmt.Sprintf("Found matching user ID: %q", matchedUserID), LogField{"user_id", matchedUserID})
+	}
+	if len(timestamps) > 1 {
+		r.info(fmt.Sprintf("Found matching timestamp: %d", primaryKey.CreationTime.Unix()), LogField{"timestamp", primaryKey.CreationTime.Unix()})
+	}
+	r.report.UserID = matchedUserID
+	r.report.Timestamp = primaryKey.CreationTime.Unix()
+	r.report.Curve = params.curve
+
+	summary := fmt.Sprintf("User ID:      %s\nTimestamp:    %d (%s)\nCurve:        %s\nPrimary key:  %s\nSubkey:       %s\n",
+		matchedUserID, primaryKey.CreationTime.Unix(), primaryKey.CreationTime.UTC().Format(time.RFC1123), params.curve, fingerprint, formatFingerprint(subkey))
+	if r.stdout != nil {
+		if _, err := fmt.Fprint(r.stdout, summary); err != nil {
+			return err
+		}
+	} else {
+		r.display("%s", summary)
+	}
+	if r.fingerprint == "" {
+		return nil
+	}
+	if fingerprint != r.fingerprint {
+		err := &mismatchError{actual: fingerprint, expected: r.fingerprint}
+		r.check("device fingerprint "+r.fingerprint, err)
+		if r.pubEntity != nil {
+			r.diagnoseDeviceMismatch(matchedUserID, primaryKey)
+		}
+		return err
+	}
+	r.check("device fingerprint "+r.fingerprint, nil)
+	if r.pubEntity != nil && len(r.pubEntity.Subkeys) > 0 && formatFingerprint(subkey) != formatFingerprint(r.pubEntity.Subkeys[0].PublicKey) {
+		return r.warn("the Trezor's subkey %s doesn't match the public key's %s, so check the ECDH KDF parameters", formatFingerprint(subkey), formatFingerprint(r.pubEntity.Subkeys[0].PublicKey))
+	}
+	r.info("The Trezor's identity matches the expected fingerprint, so the same inputs will recover it from the seed", LogField{"fingerprint", fingerprint})
+	return nil
+}
+
+// diagnoseDeviceMismatch explains why the keys read from the Trezor don't
+// match the public key: the fingerprint covers the creation time, so if the
+// key itself matches only the timestamp is wrong.
+func (r *Recovery) diagnoseDeviceMismatch(userID string, primaryKey *packet.PublicKey) {
+	expected, ok := r.pubEntity.PrimaryKey.PublicKey.(*ecdsa.PublicKey)
+	actual := primaryKey.PublicKey.(*ecdsa.PublicKey)
+	if ok && expected.X.Cmp(actual.X) == 0 && expected.Y.Cmp(actual.Y) == 0 {
+		created := r.pubEntity.PrimaryKey.CreationTime
+		r.display("The Trezor has the public key's key, but it was created at timestamp %d (%s) rather than the entered %d.\n",
+			created.Unix(), created.UTC().Format(time.RFC3339), primaryKey.CreationTime.Unix())
+		return
+	}
+	r.diagnoseMismatch(userID, primaryKey.CreationTime)
+	r.display("  If the user ID and timestamp are right, check the Trezor's passphrase, the SLIP-0013 index and that it's the Trezor the identity was created with.\n")
+}
+
+// buildPublicKeys constructs the public primary key and subkey trezor-gpg
+// creates from the derived keys and timestamp, like buildEntity.
+func buildPublicKeys(primary, sub *ecdsa.PublicKey, timestamp time.Time, params *keyParams) (primaryKey, subkey *packet.PublicKey) {
+	kdfHash, _ := s2k.HashToHashId(params.kdfHash)
+	primaryKey = packet.NewECDSAPublicKey(timestamp, primary)
+	subkey = packet.NewECDHPublicKey(timestamp, sub, kdfHash, params.kdfCipher)
+	subkey.IsSubkey = true
+	return primaryKey, subkey
+}
+
+// The Trezor messages used to read public keys, see messages.proto in
+// trezor-firmware.
+const (
+	msgInitialize         = 0
+	msgFailure            = 3
+	msgGetPublicKey       = 11
+	msgPublicKey          = 12
+	msgFeatures           = 17
+	msgPinMatrixRequest   = 18
+	msgPinMatrixAck       = 19
+	msgButtonRequest      = 26
+	msgButtonAck          = 27
+	msgPassphraseRequest  = 41
+	msgPassphraseAck      = 42
+	msgPassphraseStateReq = 77
+	msgPassphraseStateAck = 78
+)
+
+// deviceTransport exchanges messages with a Trezor.
+type deviceTransport interface {
+	// call sends a message and returns the Trezor's response.
+	call(ctx context.Context, msgType uint16, data []byte) (uint16, []byte, error)
+	Close() error
+}
+
+// openDevice connects to the Trezor through Trezor Bridge if it's running,
+// or directly over USB otherwise. Tests replace it with a fake Trezor.
+var openDevice = func(ctx context.Context) (deviceTransport, error) {
+	dev, err := openBridge(ctx, bridgeURL)
+	if err != errNoBridge {
+		return dev, err
+	}
+	return openUSBDevice()
+}
+
+// initDevice starts a new session, as trezorlib does before each command.
+func (r *Recovery) initDevice(dev deviceTransport) error {
+	msgType, data, err := dev.call(r.ctx, msgInitialize, nil)
+	if err != nil {
+		return fmt.Errorf("could not initialize the Trezor: %s", err)
+	}
+	if msgType == msgFailure {
+		return deviceFailure(data)
+	} else if msgType != msgFeatures {
+		return fmt.Errorf("unexpected response from the Trezor: message type %d", msgType)
+	}
+	return nil
+}
+
+// devicePublicKeys reads the public keys of the identity with the given user
+// ID from the Trezor, without showing them on its display.
+func (r *Recovery) devicePublicKeys(dev deviceTransport, userID string) (primaryKey, subkey *ecdsa.PublicKey, err error) {
+	uri := derive.URI(userID)
+	params := r.keyParams()
+	primaryKey, err = r.devicePublicKey(dev, derive.Indexes(params.primaryPurpose, uri, params.index))
+	if err != nil {
+		return nil, nil, err
+	}
+	subkey, err = r.devicePublicKey(dev, derive.Indexes(params.subkeyPurpose, uri, params.index))
+	if err != nil {
+		return nil, nil, err
+	}
+	r.audit(auditEntry{Step: "device public keys read", UserIDHash: userIDHash(userID)})
+	return primaryKey, subkey, nil
+}
+
+// devicePublicKey sends GetPublicKey for path, answering the Trezor's PIN,
+// passphrase and button requests.
+func (r *Recovery) devicePublicKey(dev deviceTransport, path []uint32) (*ecdsa.PublicKey, error) {
+	var msg []byte
+	for _, child := range path {
+		msg = appendProtoVarint(msg, 1, uint64(child))
+	}
+	msg = appendProtoBytes(msg, 2, []byte(r.keyParams().curve))
+	msgType, data, err := dev.call(r.ctx, msgGetPublicKey, msg)
+	for err == nil {
+		switch msgType {
+		case msgPublicKey:
+			return parsePublicKeyMessage(data)
+		case msgFailure:
+			return nil, deviceFailure(data)
+		case msgButtonRequest:
+			r.display("Please confirm on the Trezor.")
+			msgType, data, err = dev.call(r.ctx, msgButtonAck, nil)
+		case msgPinMatrixRequest:
+			// the Trezor shows the digits scrambled, and is sent their
+			// positions on this layout
+			r.display("The Trezor is asking for its PIN. Enter the positions of its digits on the Trezor's screen, where the positions are:\n\n    7 8 9\n    4 5 6\n    1 2 3\n")
+			pin, readErr := r.readSecret("Please enter the Trezor's PIN:")
+			if readErr != nil {
+				return nil, readErr
+			}
+			ack := appendProtoBytes(nil, 1, pin)
+			wipe(pin)
+			msgType, data, err = dev.call(r.ctx, msgPinMatrixAck, ack)
+			wipe(ack)
+		case msgPassphraseRequest:
+			passphrase, readErr := r.readSecret("Please enter the Trezor's passphrase (leave blank if you don't use one):")
+			if readErr != nil {
+				return nil, readErr
+			}
+			ack := appendProtoBytes(nil, 1, passphrase)
+			wipe(passphrase)
+			msgType, data, err = dev.call(r.ctx, msgPassphraseAck, ack)
+			wipe(ack)
+		case msgPassphraseStateReq:
+			msgType, data, err = dev.call(r.ctx, msgPassphraseStateAck, nil)
+		default:
+			return nil, fmt.Errorf("unexpected response from the Trezor: message type %d", msgType)
+		}
+	}
+	return nil, fmt.Errorf("could not read the public key from the Trezor: %s", err)
+}
+
+// parsePublicKeyMessage returns the key of a PublicKey message, the
+// compressed point in its node's public_key field.
+func parsePublicKeyMessage(data []byte) (*ecdsa.PublicKey, error) {
+	node, err := protoField(data, 1)
+	if err != nil {
+		return nil, err
+	}
+	point, err := protoField(node, 6)
+	if err != nil {
+		return nil, err
+	}
+	x, y := elliptic.UnmarshalCompressed(elliptic.P256(), point)
+	if x == nil {
+		return nil, errors.New("the Trezor returned an invalid NIST P-256 public key")
+	}
+	return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+}
+
+// deviceFailure returns the error of a Failure message.
+func deviceFailure(data []byte) error {
+	msg, err := protoField(data, 2)
+	if err != nil || len(msg) == 0 {
+		return errors.New("the Trezor returned a failure")
+	}
+	return fmt.Errorf("the Trezor returned a failure: %s", msg)
+}
+
+// appendProtoVarint appends a protobuf varint field.
+func appendProtoVarint(b []byte, field int, v uint64) []byte {
+	b = binary.AppendUvarint(b, uint64(field)<<3)
+	return binary.AppendUvarint(b, v)
+}
+
+// appendProtoBytes appends a protobuf length-delimited field.
+func appendProtoBytes(b []byte, field int, v []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(field)<<3|2)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// protoField returns the first length-delimited field with the given number
+// of a protobuf message, skipping the other fields.
+func protoField(data []byte, field int) ([]byte, error) {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, errors.New("invalid message from the Trezor")
+		}
+		data = data[n:]
+		var value []byte
+		switch key & 7 {
+		case 0:
+			if _, n = binary.Uvarint(data); n <= 0 {
+				return nil, errors.New("invalid message from the Trezor")
+			}
+		case 2:
+			length, m := binary.Uvarint(data)
+			if m <= 0 || length > uint64(len(data)-m) {
+				return nil, errors.New("invalid message from the Trezor")
+			}
+			value = data[m : m+int(length)]
+			n = m + int(length)
+		case 5:
+			n = 4
+		case 1:
+			n = 8
+		default:
+			return nil, errors.New("invalid message from the Trezor")
+		}
+		if n > len(data) {
+			return nil, errors.New("invalid message from the Trezor")
+		}
+		if int(key>>3) == field && key&7 == 2 {
+			return value, nil
+		}
+		data = data[n:]
+	}
+	return nil, fmt.Errorf("invalid message from the Trezor: missing field %d", field)
+}
+
+// reportSize is the size of the Trezor's USB reports.
+const reportSize = 64
+
+// reportConn reads and writes the Trezor's 64 byte USB reports.
+type reportConn interface {
+	readReport(ctx context.Context) ([]byte, error)
+	writeReport(report []byte) error
+	Close() error
+}
+
+// wireTransport speaks the Trezor's USB wire protocol over a reportConn: a
+// message is sent as "?##", its type and length, then its data, split into
+// reports each starting with "?".
+type wireTransport struct {
+	conn reportConn
+}
+
+func (t *wireTransport) call(ctx context.Context, msgType uint16, data []byte) (uint16, []byte, error) {
+	msg := make([]byte, 0, 8+len(data))
+	msg = append(msg, '#', '#')
+	msg = binary.BigEndian.AppendUint16(msg, msgType)
+	msg = binary.BigEndian.AppendUint32(msg, uint32(len(data)))
+	msg = append(msg, data...)
+	defer wipe(msg)
+	for len(msg) > 0 {
+		report := make([]byte, reportSize)
+		report[0] = '?'
+		n := copy(report[1:], msg)
+		msg = msg[n:]
+		err := t.conn.writeReport(report)
+		wipe(report)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	report, err := t.conn.readReport(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(report) < 9 || string(report[:3]) != "?##" {
+		return 0, nil, errors.New("invalid response from the Trezor")
+	}
+	respType := binary.BigEndian.Uint16(report[3:5])
+	length := int(binary.BigEndian.Uint32(report[5:9]))
+	if length > 1<<20 {
+		return 0, nil, errors.New("invalid response from the Trezor: message too long")
+	}
+	resp := append(make([]byte, 0, length+reportSize), report[9:]...)
+	for len(resp) < length {
+		report, err := t.conn.readReport(ctx)
+		if err != nil {
+			return 0, nil, err
+		}
+		if len(report) < 1 || report[0] != '?' {
+			return 0, nil, errors.New("invalid response from the Trezor")
+		}
+		resp = append(resp, report[1:]...)
+	}
+	return respType, resp[:length], nil
+}
+
+func (t *wireTransport) Close() error {
+	return t.conn.Close()
+}
+
+// bridgeURL is the address Trezor Bridge listens on.
+var bridgeURL = "http://127.0.0.1:21325"
+
+// errNoBridge is returned by openBridge if Trezor Bridge isn't running.
+var errNoBridge = errors.New("Trezor Bridge is not running")
+
+// bridgeTransport sends messages to a Trezor through Trezor Bridge (or the
+// bridge built into Trezor Suite), which is needed if another program has
+// the Trezor open.
+type bridgeTransport struct {
+	url     string
+	session string
+}
+
+// openBridge acquires the first Trezor known to the bridge at url, returning
+// errNoBridge if there's nothing listening there.
+func openBridge(ctx context.Context, url string) (*bridgeTransport, error) {
+	b := &bridgeTransport{url: url}
+	var devices []struct {
+		Path string `json:"path"`
+	}
+	enumerateCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := b.post(enumerateCtx, "/enumerate", nil, &devices); err != nil {
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return nil, errNoBridge
+		}
+		return nil, err
+	}
+	switch len(devices) {
+	case 0:
+		return nil, errors.New("no Trezor found: connect it and unlock it")
+	case 1:
+	default:
+		return nil, fmt.Errorf("found %d Trezors: only connect the one to check", len(devices))
+	}
+	var session struct {
+		Session string `json:"session"`
+	}
+	if err := b.post(ctx, "/acquire/"+devices[0].Path+"/null", nil, &session); err != nil {
+		return nil, err
+	}
+	b.session = session.Session
+	return b, nil
+}
+
+func (b *bridgeTransport) call(ctx context.Context, msgType uint16, data []byte) (uint16, []byte, error) {
+	msg := make([]byte, 0, 6+len(data))
+	msg = binary.BigEndian.AppendUint16(msg, msgType)
+	msg = binary.BigEndian.AppendUint32(msg, uint32(len(data)))
+	msg = append(msg, data...)
+	body := []byte(hex.EncodeToString(msg))
+	wipe(msg)
+	var resp string
+	err := b.post(ctx, "/call/"+b.session, body, &resp)
+	wipe(body)
+	if err != nil {
+		return 0, nil, err
+	}
+	respMsg, err := hex.DecodeString(strings.TrimSpace(resp))
+	if err != nil || len(respMsg) < 6 {
+		return 0, nil, errors.New("invalid response from Trezor Bridge")
+	}
+	length := binary.BigEndian.Uint32(respMsg[2:6])
+	if uint32(len(respMsg)-6) < length {
+		return 0, nil, errors.New("invalid response from Trezor Bridge: truncated message")
+	}
+	return binary.BigEndian.Uint16(respMsg[:2]), respMsg[6 : 6+length], nil
+}
+
+func (b *bridgeTransport) Close() error {
+	return b.post(context.Background(), "/release/"+b.session, nil, nil)
+}
+
+// post makes a request to the bridge, decoding a JSON response into out, or
+// setting it to the body if it's a *string.
+func (b *bridgeTransport) post(ctx context.Context, path string, body []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", b.url+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	// the bridge only accepts requests from Trezor's origins
+	req.Header.Set("Origin", "https://python.trezor.io")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		var errRes struct {
+			Error string `json:"error"`
+		}
+		msg := strings.TrimSpace(string(resBody))
+		if err := json.Unmarshal(resBody, &errRes); err == nil && errRes.Error != "" {
+			msg = errRes.Error
+		}
+		return fmt.Errorf("Trezor Bridge responded %s: %s", res.Status, msg)
+	}
+	switch out := out.(type) {
+	case nil:
+		return nil
+	case *string:
+		*out = string(resBody)
+		return nil
+	default:
+		return json.Unmarshal(resBody, out)
+	}
+}
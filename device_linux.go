@@ -0,0 +1,145 @@
+package recovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// sysBusUSB is a variable so tests can fake the connected USB devices.
+var sysBusUSB = "/sys/bus/usb/devices"
+
+// usbdevfsBulkTransfer is struct usbdevfs_bulktransfer from
+// linux/usbdevice_fs.h.
+type usbdevfsBulkTransfer struct {
+	ep      uint32
+	len     uint32
+	timeout uint32
+	data    unsafe.Pointer
+}
+
+// The usbfs ioctls, _IOR('U', 15, unsigned int), _IOR('U', 16, unsigned
+// int) and _IOWR('U', 2, struct usbdevfs_bulktransfer).
+const (
+	usbdevfsClaimInterface   = 2<<30 | 4<<16 | 'U'<<8 | 15
+	usbdevfsReleaseInterface = 2<<30 | 4<<16 | 'U'<<8 | 16
+	usbdevfsBulk             = 3<<30 | uintptr(unsafe.Sizeof(usbdevfsBulkTransfer{}))<<16 | 'U'<<8 | 2
+)
+
+// The Trezor's WebUSB interface and its interrupt endpoints.
+const (
+	trezorInterface   = 0
+	trezorEndpointOut = 0x01
+	trezorEndpointIn  = 0x81
+)
+
+// openUSBDevice opens the connected Trezor with usbfs.
+func openUSBDevice() (deviceTransport, error) {
+	dirs, err := filepath.Glob(filepath.Join(sysBusUSB, "*"))
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, dir := range dirs {
+		vendor := readSysfs(dir, "idVendor")
+		product := readSysfs(dir, "idProduct")
+		switch {
+		case vendor == "534c" && product == "0001":
+			return nil, errors.New("found a Trezor One whose firmware is too old to be used without Trezor Bridge: update its firmware or install Trezor Bridge")
+		case vendor == "1209" && product == "53c0":
+			return nil, errors.New("the Trezor is in bootloader mode: reconnect it without touching the screen")
+		case vendor == "1209" && product == "53c1":
+			bus, _ := strconv.Atoi(readSysfs(dir, "busnum"))
+			dev, _ := strconv.Atoi(readSysfs(dir, "devnum"))
+			paths = append(paths, fmt.Sprintf("/dev/bus/usb/%03d/%03d", bus, dev))
+		}
+	}
+	switch len(paths) {
+	case 0:
+		return nil, errors.New("no Trezor found: connect it and unlock it")
+	case 1:
+	default:
+		return nil, fmt.Errorf("found %d Trezors: only connect the one to check", len(paths))
+	}
+	f, err := os.OpenFile(paths[0], os.O_RDWR, 0)
+	if os.IsPermission(err) {
+		return nil, fmt.Errorf("%s (install Trezor's udev rules, see https://trezor.io/learn/a/udev-rules)", err)
+	} else if err != nil {
+		return nil, err
+	}
+	iface := uint32(trezorInterface)
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), usbdevfsClaimInterface, uintptr(unsafe.Pointer(&iface))); errno != 0 {
+		f.Close()
+		if errno == syscall.EBUSY {
+			return nil, errors.New("the Trezor is in use by another program (e.g. Trezor Suite): close it and try again")
+		}
+		return nil, fmt.Errorf("could not claim the Trezor's USB interface: %s", errno)
+	}
+	return &wireTransport{conn: &usbConn{f: f}}, nil
+}
+
+// readSysfs returns the trimmed contents of the sysfs attribute name of the
+// device in dir, or an empty string if it can't be read.
+func readSysfs(dir, name string) string {
+	data, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// usbConn transfers reports to and from the Trezor's interrupt endpoints.
+type usbConn struct {
+	f *os.File
+}
+
+// usbReadTimeout is how long each read waits for a report before checking
+// whether the context is done, in milliseconds.
+const usbReadTimeout = 250
+
+func (c *usbConn) readReport(ctx context.Context) ([]byte, error) {
+	report := make([]byte, reportSize)
+	for {
+		n, err := c.transfer(trezorEndpointIn, report, usbReadTimeout)
+		if err == syscall.ETIMEDOUT {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("could not read from the Trezor: %s", err)
+		}
+		return report[:n], nil
+	}
+}
+
+func (c *usbConn) writeReport(report []byte) error {
+	if _, err := c.transfer(trezorEndpointOut, report, 5000); err != nil {
+		return fmt.Errorf("could not write to the Trezor: %s", err)
+	}
+	return nil
+}
+
+// transfer makes an interrupt transfer with the USBDEVFS_BULK ioctl, which
+// the kernel turns into an interrupt transfer for interrupt endpoints.
+func (c *usbConn) transfer(ep uint32, data []byte, timeout uint32) (int, error) {
+	bulk := usbdevfsBulkTransfer{ep: ep, len: uint32(len(data)), timeout: timeout, data: unsafe.Pointer(&data[0])}
+	n, _, errno := syscall.Syscall(syscall.SYS_IOCTL, c.f.Fd(), usbdevfsBulk, uintptr(unsafe.Pointer(&bulk)))
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(n), nil
+}
+
+func (c *usbConn) Close() error {
+	iface := uint32(trezorInterface)
+	syscall.Syscall(syscall.SYS_IOCTL, c.f.Fd(), usbdevfsReleaseInterface, uintptr(unsafe.Pointer(&iface)))
+	return c.f.Close()
+}
@@ -0,0 +1,43 @@
+package recovery
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpenUSBDevice(t *testing.T) {
+	defer func(dir string) { sysBusUSB = dir }(sysBusUSB)
+	sysBusUSB = t.TempDir()
+	addDevice := func(name, vendor, product string) {
+		dir := filepath.Join(sysBusUSB, name)
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for attr, value := range map[string]string{"idVendor": vendor, "idProduct": product, "busnum": "1", "devnum": "2"} {
+			if err := ioutil.WriteFile(filepath.Join(dir, attr), []byte(value+"\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	expectErr := func(msg string) {
+		t.Helper()
+		if _, err := openUSBDevice(); err == nil || !strings.Contains(err.Error(), msg) {
+			t.Fatalf("expected an error containing %q, got %v", msg, err)
+		}
+	}
+
+	addDevice("1-1", "1d6b", "0002")
+	expectErr("no Trezor found")
+	addDevice("1-2", "1209", "53c0")
+	expectErr("bootloader mode")
+	os.RemoveAll(filepath.Join(sysBusUSB, "1-2"))
+	addDevice("1-3", "534c", "0001")
+	expectErr("firmware is too old")
+	os.RemoveAll(filepath.Join(sysBusUSB, "1-3"))
+	addDevice("1-4", "1209", "53c1")
+	addDevice("1-5", "1209", "53c1")
+	expectErr("found 2 Trezors")
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package recovery
+
+import "errors"
+
+// openUSBDevice is only implemented on Linux, other platforms need Trezor
+// Bridge (or Trezor Suite) to talk to the Trezor.
+func openUSBDevice() (deviceTransport, error) {
+	return nil, errors.New("Trezor Bridge is not running: install it (or open Trezor Suite) to connect to the Trezor")
+}
@@ -0,0 +1,290 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"crypto/elliptic"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeTrezor emulates a Trezor loaded with a test vector's seed, protected
+// with a PIN and the vector's passphrase.
+type fakeTrezor struct {
+	t          *testing.T
+	vector     testVector
+	pin        string
+	unlocked   bool
+	passphrase *string
+	closed     bool
+
+	// pendingPath is the path of the GetPublicKey being answered
+	pendingPath []uint32
+}
+
+func (d *fakeTrezor) call(ctx context.Context, msgType uint16, data []byte) (uint16, []byte, error) {
+	switch msgType {
+	case msgInitialize:
+		// each check sees a freshly connected Trezor
+		d.unlocked = false
+		d.passphrase = nil
+		return msgFeatures, nil, nil
+	case msgPinMatrixAck:
+		pin, _ := protoField(data, 1)
+		if string(pin) != d.pin {
+			return msgFailure, appendProtoBytes(nil, 2, []byte("PIN invalid")), nil
+		}
+		d.unlocked = true
+	case msgPassphraseAck:
+		passphrase, _ := protoField(data, 1)
+		s := string(passphrase)
+		d.passphrase = &s
+	case msgGetPublicKey:
+		if curve, _ := protoField(data, 2); string(curve) != CurveNIST256P1 {
+			d.t.Errorf("unexpected curve %q", curve)
+		}
+		var path []uint32
+		for len(data) > 0 {
+			key, n := binary.Uvarint(data)
+			data = data[n:]
+			if key == 1<<3 {
+				child, n := binary.Uvarint(data)
+				path = append(path, uint32(child))
+				data = data[n:]
+				continue
+			}
+			length, n := binary.Uvarint(data)
+			data = data[n+int(length):]
+		}
+		d.pendingPath = path
+	default:
+		d.t.Fatalf("unexpected message type %d", msgType)
+	}
+	if !d.unlocked {
+		return msgPinMatrixRequest, nil, nil
+	}
+	if d.passphrase == nil {
+		return msgPassphraseRequest, nil, nil
+	}
+	return d.publicKey()
+}
+
+func (d *fakeTrezor) publicKey() (uint16, []byte, error) {
+	key, err := newMasterKey([]byte(d.vector.Mnemonic), *d.passphrase)
+	if err != nil {
+		d.t.Fatal(err)
+	}
+	for _, child := range d.pendingPath {
+		if key, err = key.NewChildKey(child); err != nil {
+			d.t.Fatal(err)
+		}
+	}
+	priv, err := ecdh.P256().NewPrivateKey(key.Key)
+	if err != nil {
+		d.t.Fatal(err)
+	}
+	x, y := elliptic.Unmarshal(elliptic.P256(), priv.PublicKey().Bytes())
+	node := appendProtoBytes(nil, 6, elliptic.MarshalCompressed(elliptic.P256(), x, y))
+	return msgPublicKey, appendProtoBytes(nil, 1, node), nil
+}
+
+func (d *fakeTrezor) Close() error {
+	d.closed = true
+	return nil
+}
+
+// withFakeTrezor makes CheckDevice connect to a fake Trezor loaded with the
+// first test vector, returning it.
+func withFakeTrezor(t *testing.T) *fakeTrezor {
+	dev := &fakeTrezor{t: t, vector: testVectors[0], pin: "1234"}
+	openDevice = func(context.Context) (deviceTransport, error) {
+		return dev, nil
+	}
+	t.Cleanup(func() { openDevice = defaultOpenDevice })
+	return dev
+}
+
+var defaultOpenDevice = openDevice
+
+func TestCheckDevice(t *testing.T) {
+	dev := withFakeTrezor(t)
+	vector := testVectors[0]
+	aliceDevice := vector.UserID + "\n1523060353\n1234\n" + vector.Passphrase + "\n"
+	checkDevice := func(stdin string, opts ...Option) (string, string, error) {
+		var stdout, stderr bytes.Buffer
+		opts = append([]Option{WithStdin(strings.NewReader(stdin)), WithStdout(&stdout), WithStderr(&stderr)}, opts...)
+		err := CheckDevice(context.Background(), opts...)
+		return stdout.String(), stderr.String(), err
+	}
+
+	// the fingerprints are printed, prompting for the PIN and passphrase
+	stdout, _, err := checkDevice(aliceDevice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout, vector.PrimaryFingerprint) || !strings.Contains(stdout, vector.SubkeyFingerprint) {
+		t.Fatalf("expected the fingerprints to be printed, got %q", stdout)
+	}
+	if !dev.closed {
+		t.Fatal("expected the device to be closed")
+	}
+
+	// the expected fingerprint is checked
+	if _, _, err := checkDevice(aliceDevice, WithFingerprint(aliceFingerprint)); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = checkDevice(vector.UserID+"\n1523060354\n1234\n"+vector.Passphrase+"\n", WithFingerprint(aliceFingerprint))
+	if !errors.Is(err, ErrFingerprintMismatch) {
+		t.Fatalf("expected a fingerprint mismatch, got %v", err)
+	}
+
+	// comparing with the public key explains the mismatch
+	entity := recoverEntity(t, aliceInput)
+	publicKey, err := newResult(entity).ArmoredPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, stderr, err := checkDevice(vector.UserID+"\n1523060354\n1234\n"+vector.Passphrase+"\n", WithPublicKey(strings.NewReader(publicKey)))
+	if !errors.Is(err, ErrFingerprintMismatch) || !strings.Contains(stderr, "created at timestamp 1523060353") {
+		t.Fatalf("expected a timestamp mismatch, got %v: %s", err, stderr)
+	}
+	_, stderr, err = checkDevice(vector.UserID+"\n1523060353\n1234\nwrong\n", WithPublicKey(strings.NewReader(publicKey)))
+	if !errors.Is(err, ErrFingerprintMismatch) || !strings.Contains(stderr, "check the Trezor's passphrase") {
+		t.Fatalf("expected a key mismatch, got %v: %s", err, stderr)
+	}
+
+	// candidate timestamps are searched
+	timestamps := []time.Time{time.Unix(1523060352, 0), time.Unix(1523060353, 0)}
+	stdout, _, err = checkDevice("1234\n"+vector.Passphrase+"\n", WithFingerprint(aliceFingerprint), WithUserIDCandidates([]string{vector.UserID}), WithTimestampCandidates(timestamps))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout, "1523060353") {
+		t.Fatalf("expected the matching timestamp to be printed, got %q", stdout)
+	}
+
+	// the Trezor's failures are returned
+	_, _, err = checkDevice(vector.UserID + "\n1523060353\n0000\n")
+	if err == nil || !strings.Contains(err.Error(), "PIN invalid") {
+		t.Fatalf("expected a PIN error, got %v", err)
+	}
+
+	// the seed isn't involved so demo mode makes no sense
+	if _, _, err := checkDevice("", WithDemo()); err == nil {
+		t.Fatal("expected an error checking the demo identity")
+	}
+}
+
+// fakeReportConn records written reports and returns queued ones.
+type fakeReportConn struct {
+	written [][]byte
+	reports [][]byte
+}
+
+func (c *fakeReportConn) readReport(ctx context.Context) ([]byte, error) {
+	if len(c.reports) == 0 {
+		return nil, errors.New("no more reports")
+	}
+	report := c.reports[0]
+	c.reports = c.reports[1:]
+	return report, nil
+}
+
+func (c *fakeReportConn) writeReport(report []byte) error {
+	c.written = append(c.written, append([]byte(nil), report...))
+	return nil
+}
+
+func (c *fakeReportConn) Close() error {
+	return nil
+}
+
+func TestWireTransport(t *testing.T) {
+	msg := bytes.Repeat([]byte{0xab}, 100)
+	resp := make([]byte, 2*reportSize)
+	resp[0] = '?'
+	copy(resp[1:], []byte{'#', '#', 0, msgPublicKey, 0, 0, 0, 100})
+	copy(resp[9:reportSize], msg)
+	resp[reportSize] = '?'
+	copy(resp[reportSize+1:], msg[reportSize-9:])
+	conn := &fakeReportConn{reports: [][]byte{resp[:reportSize], resp[reportSize:]}}
+	msgType, data, err := (&wireTransport{conn: conn}).call(context.Background(), msgGetPublicKey, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msgType != msgPublicKey || !bytes.Equal(data, msg) {
+		t.Fatalf("unexpected response %d %x", msgType, data)
+	}
+
+	// the request is split into reports the same way
+	if len(conn.written) != 2 || !bytes.Equal(conn.written[0][:9], []byte{'?', '#', '#', 0, msgGetPublicKey, 0, 0, 0, 100}) || conn.written[1][0] != '?' {
+		t.Fatalf("unexpected reports %x", conn.written)
+	}
+	for _, report := range conn.written {
+		if len(report) != reportSize {
+			t.Fatalf("expected %d byte reports, got %d", reportSize, len(report))
+		}
+	}
+}
+
+func TestBridgeTransport(t *testing.T) {
+	released := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Origin") != "https://python.trezor.io" {
+			http.Error(w, `{"error":"Invalid origin"}`, http.StatusForbidden)
+			return
+		}
+		switch req.URL.Path {
+		case "/enumerate":
+			w.Write([]byte(`[{"path":"1","session":null}]`))
+		case "/acquire/1/null":
+			w.Write([]byte(`{"session":"7"}`))
+		case "/call/7":
+			body, _ := ioutil.ReadAll(req.Body)
+			if string(body) != "000000000000" {
+				t.Errorf("unexpected call %s", body)
+			}
+			w.Write([]byte(hex.EncodeToString([]byte{0, msgFeatures, 0, 0, 0, 2, 0x08, 0x01})))
+		case "/release/7":
+			released = true
+			w.Write([]byte(`{}`))
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	defer srv.Close()
+	dev, err := openBridge(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgType, data, err := dev.call(context.Background(), msgInitialize, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msgType != msgFeatures || !bytes.Equal(data, []byte{0x08, 0x01}) {
+		t.Fatalf("unexpected response %d %x", msgType, data)
+	}
+	if err := dev.Close(); err != nil || !released {
+		t.Fatalf("expected the session to be released, got %v", err)
+	}
+
+	// nothing listening means there's no bridge
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	if _, err := openBridge(context.Background(), "http://"+addr); err != errNoBridge {
+		t.Fatalf("expected errNoBridge, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package recovery
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// errNotSupported is returned by doctor checks which can't be run on the
+// current platform.
+var errNotSupported = errors.New("not supported on this platform")
+
+// doctorCheck is a check of the environment the recovery runs in, returning
+// an error if it is unsuitable along with an optional detail if not.
+type doctorCheck struct {
+	name  string
+	check func() (string, error)
+}
+
+var doctorChecks = []doctorCheck{
+	{"unencrypted swap is disabled", checkSwap},
+	{"core dumps are disabled", checkCoreDumps},
+	{"network is offline", func() (string, error) { return "", checkOffline() }},
+	{"not running in a terminal multiplexer or recorder", checkMultiplexer},
+	{"stdin is a terminal with echo on", checkEcho},
+}
+
+// Doctor inspects the environment before a recovery, writing a pass/fail line
+// for each check to w. It returns an error if any check fails.
+func Doctor(w io.Writer) error {
+	failed := 0
+	for _, c := range doctorChecks {
+		detail, err := c.check()
+		switch {
+		case err == errNotSupported:
+			fmt.Fprintf(w, "SKIP  %s: %s\n", c.name, err)
+		case err != nil:
+			fmt.Fprintf(w, "FAIL  %s: %s\n", c.name, err)
+			failed++
+		case detail != "":
+			fmt.Fprintf(w, "PASS  %s (%s)\n", c.name, detail)
+		default:
+			fmt.Fprintf(w, "PASS  %s\n", c.name)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("doctor found %d problem(s) with the environment", failed)
+	}
+	return nil
+}
+
+// multiplexerEnv are environment variables set by terminal multiplexers and
+// recorders, which may be logging everything typed into the terminal.
+var multiplexerEnv = []struct {
+	env  string
+	name string
+}{
+	{"TMUX", "tmux"},
+	{"STY", "GNU screen"},
+	{"ASCIINEMA_REC", "asciinema"},
+}
+
+// checkMultiplexer returns an error if running inside a terminal multiplexer
+// or recorder, since it may log the recovery seed (e.g. tmux's pipe-pane or
+// screen's log command).
+func checkMultiplexer() (string, error) {
+	for _, m := range multiplexerEnv {
+		if os.Getenv(m.env) != "" {
+			return "", fmt.Errorf("running inside %s (%s is set), which may be logging the terminal", m.name, m.env)
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,36 @@
+package recovery
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// checkCoreDumps returns an error if core dumps are enabled. The recovery
+// disables them for itself, but they would still be enabled for anything else
+// run from the same shell (e.g. gpg --import).
+func checkCoreDumps() (string, error) {
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_CORE, &limit); err != nil {
+		return "", fmt.Errorf("could not check core dumps: %s", err)
+	}
+	if limit.Cur != 0 {
+		return "", errors.New("core dumps are enabled, run 'ulimit -c 0'")
+	}
+	return "", nil
+}
+
+// checkEcho returns an error unless stdin is a terminal with echo on, so that
+// the prompts work as expected.
+func checkEcho() (string, error) {
+	var termios syscall.Termios
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, os.Stdin.Fd(), syscall.TCGETS, uintptr(unsafe.Pointer(&termios))); errno != 0 {
+		return "", errors.New("stdin is not a terminal")
+	}
+	if termios.Lflag&syscall.ECHO == 0 {
+		return "", errors.New("terminal echo is off, run 'stty echo'")
+	}
+	return "the recovery seed is visible as it is typed, consider --clear-screen", nil
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package recovery
+
+func checkCoreDumps() (string, error) {
+	return "", errNotSupported
+}
+
+func checkEcho() (string, error) {
+	return "", errNotSupported
+}
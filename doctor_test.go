@@ -0,0 +1,47 @@
+package recovery
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDoctor(t *testing.T) {
+	defer func(checks []doctorCheck) { doctorChecks = checks }(doctorChecks)
+	doctorChecks = []doctorCheck{
+		{"passes", func() (string, error) { return "", nil }},
+		{"passes with detail", func() (string, error) { return "some detail", nil }},
+		{"is skipped", func() (string, error) { return "", errNotSupported }},
+	}
+	var out bytes.Buffer
+	if err := Doctor(&out); err != nil {
+		t.Fatal(err)
+	}
+	expected := "PASS  passes\nPASS  passes with detail (some detail)\nSKIP  is skipped: not supported on this platform\n"
+	if out.String() != expected {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+
+	doctorChecks = append(doctorChecks, doctorCheck{"fails", func() (string, error) { return "", errors.New("oops") }})
+	out.Reset()
+	if err := Doctor(&out); err == nil {
+		t.Fatal("expected an error")
+	}
+	if !strings.HasSuffix(out.String(), "FAIL  fails: oops\n") {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestCheckMultiplexer(t *testing.T) {
+	for _, m := range multiplexerEnv {
+		t.Setenv(m.env, "")
+	}
+	if _, err := checkMultiplexer(); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TMUX", "/tmp/tmux-0/default,1234,0")
+	if _, err := checkMultiplexer(); err == nil || !strings.Contains(err.Error(), "tmux") {
+		t.Fatalf("expected a tmux error, got %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package recovery
+
+import "fmt"
+
+// WithDualOperator configures a dual control ceremony, where two operators
+// each enter half of the recovery seed words and the screen is cleared
+// before handing over, so that neither operator sees the other's words.
+func WithDualOperator() Option {
+	return func(r *Recovery) {
+		r.dualOperator = true
+	}
+}
+
+// readDualOperatorWords reads the seed words into words, with the first half
+// entered by operator A and the second half by operator B.
+func (r *Recovery) readDualOperatorWords(words [][]byte) error {
+	half := len(words) / 2
+	for _, op := range []struct {
+		name       string
+		start, end int
+	}{
+		{"A", 0, half},
+		{"B", half, len(words)},
+	} {
+		prompt := fmt.Sprintf("Operator %s: make sure the other operator can't see the screen, then press enter to enter words %d to %d:", op.name, op.start+1, op.end)
+		if _, err := r.readLine(prompt, dualOperatorHelp); err != nil {
+			return err
+		}
+		for i := op.start; i < op.end; i++ {
+			word, err := r.readWord(i+1, len(words))
+			if err != nil {
+				return err
+			}
+			words[i] = word
+		}
+		if err := r.clearTerminal(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package recovery
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDualOperator(t *testing.T) {
+	// each operator presses enter before entering their half of the words
+	words := strings.Repeat("all\n", 6)
+	input := "yes\nAlice <alice@example.com>\n1523060353\n12\n\n" + words + "\n" + words + "s3cr3t\n"
+	var stderr bytes.Buffer
+	entity := recoverEntity(t, input, WithStderr(&stderr), WithDualOperator())
+	if fp := formatFingerprint(entity.PrimaryKey); fp != "AB86C8C7B5136D19B0A6AEC0406D7920DCAD67C3" {
+		t.Fatalf("unexpected fingerprint %s", fp)
+	}
+
+	// check the screen is cleared after each operator's words
+	out := stderr.String()
+	a := strings.Index(out, "Operator A")
+	b := strings.Index(out, "Operator B")
+	if a == -1 || b == -1 {
+		t.Fatalf("expected prompts for both operators, got:\n%s", out)
+	}
+	if !strings.Contains(out[a:b], " 6: "+clearScreen) || strings.Contains(out[a:b], " 7: ") {
+		t.Fatalf("expected the screen to be cleared after operator A's words, got:\n%q", out[a:b])
+	}
+	if !strings.Contains(out[b:], "12: "+clearScreen) {
+		t.Fatalf("expected the screen to be cleared after operator B's words, got:\n%q", out[b:])
+	}
+
+	// check an invalid word isn't shown to the other operator
+	input = strings.Replace(input, "all\ns3cr3t", "alpaca\ns3cr3t", 1)
+	err := Run(
+		WithStdin(strings.NewReader(input)),
+		WithStdout(&bytes.Buffer{}),
+		WithStderr(&bytes.Buffer{}),
+		WithDualOperator(),
+	)
+	if err == nil || !strings.Contains(err.Error(), "word 12 is not in") || strings.Contains(err.Error(), "alpaca") {
+		t.Fatalf("expected an invalid word error without the word, got %v", err)
+	}
+}
@@ -0,0 +1,444 @@
+package recovery
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/ecdsa"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/bits"
+
+	"golang.org/x/crypto/openpgp/packet"
+	"golang.org/x/crypto/openpgp/s2k"
+)
+
+// The x/crypto/openpgp fork we depend on can serialize ECDH keys but cannot
+// encrypt or decrypt with them, so this file implements the RFC 6637 session
+// key wrapping needed to check that a recovered subkey actually works.
+
+const (
+	packetTagEncryptedKey = 1
+
+	encryptedKeyVersion = 3
+)
+
+// keyWrapIV is the default initial value from RFC 3394, section 2.2.3.1.
+var keyWrapIV = []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+
+// ecdhParams are the RFC 6637 parameters of an ECDH public key.
+type ecdhParams struct {
+	oid     []byte
+	kdfHash crypto.Hash
+	kdfAlgo packet.CipherFunction
+	kdf     []byte
+}
+
+// readECDHParams reads the curve OID and KDF parameters back out of the
+// serialized form of pub, so that encryption uses exactly the parameters
+// which end up in the exported key rather than the ones we think we set.
+func readECDHParams(pub *packet.PublicKey) (*ecdhParams, error) {
+	if pub.PubKeyAlgo != packet.PubKeyAlgoECDH {
+		return nil, fmt.Errorf("unexpected public key algorithm %d", pub.PubKeyAlgo)
+	}
+	var buf bytes.Buffer
+	if err := pub.Serialize(&buf); err != nil {
+		return nil, err
+	}
+	op, err := packet.NewOpaqueReader(&buf).Next()
+	if err != nil {
+		return nil, err
+	}
+	contents := op.Contents
+
+	// skip the version, creation time and algorithm
+	if len(contents) < 7 {
+		return nil, errors.New("ECDH public key too short")
+	}
+	contents = contents[6:]
+
+	// read the curve OID
+	oidLen := int(contents[0])
+	if len(contents) < 1+oidLen+2 {
+		return nil, errors.New("ECDH public key too short")
+	}
+	params := &ecdhParams{oid: contents[1 : 1+oidLen]}
+	contents = contents[1+oidLen:]
+
+	// skip the public point MPI
+	mpiLen := (int(binary.BigEndian.Uint16(contents)) + 7) / 8
+	if len(contents) < 2+mpiLen+4 {
+		return nil, errors.New("ECDH public key too short")
+	}
+	contents = contents[2+mpiLen:]
+
+	// read the KDF parameters
+	if contents[0] != 3 || contents[1] != 1 {
+		return nil, fmt.Errorf("unsupported ECDH KDF parameters %x", contents)
+	}
+	params.kdf = contents[:4]
+	hash, ok := s2k.HashIdToHash(contents[2])
+	if !ok || !hash.Available() {
+		return nil, fmt.Errorf("unsupported ECDH KDF hash %d", contents[2])
+	}
+	params.kdfHash = hash
+	params.kdfAlgo = packet.CipherFunction(contents[3])
+	switch params.kdfAlgo {
+	case packet.CipherAES128, packet.CipherAES192, packet.CipherAES256:
+	default:
+		return nil, fmt.Errorf("unsupported ECDH KEK algorithm %d", params.kdfAlgo)
+	}
+	return params, nil
+}
+
+// kek derives the key encryption key from the shared secret x-coordinate as
+// described in RFC 6637, sections 7 and 8.
+func (p *ecdhParams) kek(pub *packet.PublicKey, zz []byte) []byte {
+	h := p.kdfHash.New()
+	h.Write([]byte{0, 0, 0, 1})
+	h.Write(zz)
+	h.Write([]byte{byte(len(p.oid))})
+	h.Write(p.oid)
+	h.Write([]byte{byte(packet.PubKeyAlgoECDH)})
+	h.Write(p.kdf)
+	h.Write([]byte("Anonymous Sender    "))
+	h.Write(pub.Fingerprint[:])
+	return h.Sum(nil)[:p.kdfAlgo.KeySize()]
+}
+
+// ecdhEncrypt wraps sessionKey for the ECDH public key pub, returning the
+// encoded ephemeral public point and the wrapped key.
+func ecdhEncrypt(random io.Reader, pub *packet.PublicKey, cipherFunc packet.CipherFunction, sessionKey []byte) (point, wrapped []byte, err error) {
+	params, err := readECDHParams(pub)
+	if err != nil {
+		return nil, nil, err
+	}
+	ecPub, ok := pub.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, nil, errors.New("ECDH public key is not an EC key")
+	}
+	remote, err := ecPub.ECDH()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// generate an ephemeral key and compute the shared secret
+	ephemeral, err := remote.Curve().GenerateKey(random)
+	if err != nil {
+		return nil, nil, err
+	}
+	zz, err := ephemeral.ECDH(remote)
+	if err != nil {
+		return nil, nil, err
+	}
+	kek := params.kek(pub, zz)
+	defer wipe(kek)
+	wipe(zz)
+
+	// wrap the cipher algorithm, session key and checksum
+	m := make([]byte, 0, 1+len(sessionKey)+2+8)
+	m = append(m, byte(cipherFunc))
+	m = append(m, sessionKey...)
+	m = append(m, byte(checksum(sessionKey)>>8), byte(checksum(sessionKey)))
+	pad := 8 - len(m)%8
+	for i := 0; i < pad; i++ {
+		m = append(m, byte(pad))
+	}
+	wrapped, err = aesKeyWrap(kek, m)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ephemeral.PublicKey().Bytes(), wrapped, nil
+}
+
+// ecdhDecrypt unwraps a session key encrypted to the ECDH private key priv.
+func ecdhDecrypt(priv *packet.PrivateKey, point, wrapped []byte) (packet.CipherFunction, []byte, error) {
+	params, err := readECDHParams(&priv.PublicKey)
+	if err != nil {
+		return 0, nil, err
+	}
+	ecPriv, ok := priv.PrivateKey.(*ecdsa.PrivateKey)
+	if !ok {
+		return 0, nil, errors.New("ECDH private key is not an EC key")
+	}
+
+	// compute the shared secret from the ephemeral key using crypto/ecdh,
+	// which is constant time
+	local, err := ecPriv.ECDH()
+	if err != nil {
+		return 0, nil, err
+	}
+	ephemeral, err := local.Curve().NewPublicKey(point)
+	if err != nil {
+		return 0, nil, errors.New("invalid ECDH ephemeral point")
+	}
+	zz, err := local.ECDH(ephemeral)
+	if err != nil {
+		return 0, nil, err
+	}
+	kek := params.kek(&priv.PublicKey, zz)
+	defer wipe(kek)
+	wipe(zz)
+
+	// unwrap and check the padding and checksum
+	m, err := aesKeyUnwrap(kek, wrapped)
+	if err != nil {
+		return 0, nil, err
+	}
+	pad := int(m[len(m)-1])
+	if pad == 0 || pad > 8 || len(m) < 1+2+pad {
+		return 0, nil, errors.New("invalid ECDH session key padding")
+	}
+	m = m[:len(m)-pad]
+	sessionKey := m[1 : len(m)-2]
+	if checksum(sessionKey) != binary.BigEndian.Uint16(m[len(m)-2:]) {
+		return 0, nil, errors.New("ECDH session key checksum incorrect")
+	}
+	return packet.CipherFunction(m[0]), sessionKey, nil
+}
+
+// encryptMessage writes an OpenPGP message containing msg encrypted to the
+// ECDH public key pub.
+func encryptMessage(w io.Writer, random io.Reader, pub *packet.PublicKey, msg []byte) error {
+	return encryptMessageTo(w, random, []*packet.PublicKey{pub}, msg)
+}
+
+// encryptMessageTo writes an OpenPGP message containing msg encrypted to
+// each of the recipients, which are ECDH keys or keys the openpgp package can
+// encrypt to (e.g. RSA).
+func encryptMessageTo(w io.Writer, random io.Reader, recipients []*packet.PublicKey, msg []byte) error {
+	cipherFunc := packet.CipherAES128
+	sessionKey := make([]byte, cipherFunc.KeySize())
+	if _, err := io.ReadFull(random, sessionKey); err != nil {
+		return err
+	}
+	defer wipe(sessionKey)
+
+	// write a public-key encrypted session key packet for each recipient
+	for _, pub := range recipients {
+		if pub.PubKeyAlgo != packet.PubKeyAlgoECDH {
+			if err := packet.SerializeEncryptedKey(w, pub, cipherFunc, sessionKey, &packet.Config{Rand: random}); err != nil {
+				return err
+			}
+			continue
+		}
+		point, wrapped, err := ecdhEncrypt(random, pub, cipherFunc, sessionKey)
+		if err != nil {
+			return err
+		}
+		var body bytes.Buffer
+		body.WriteByte(encryptedKeyVersion)
+		binary.Write(&body, binary.BigEndian, pub.KeyId)
+		body.WriteByte(byte(packet.PubKeyAlgoECDH))
+		writeMPI(&body, point)
+		body.WriteByte(byte(len(wrapped)))
+		body.Write(wrapped)
+		pkesk := &packet.OpaquePacket{Tag: packetTagEncryptedKey, Contents: body.Bytes()}
+		if err := pkesk.Serialize(w); err != nil {
+			return err
+		}
+	}
+
+	// write the encrypted literal data
+	encrypted, err := packet.SerializeSymmetricallyEncrypted(w, cipherFunc, sessionKey, nil)
+	if err != nil {
+		return err
+	}
+	literal, err := packet.SerializeLiteral(encrypted, true, "", 0)
+	if err != nil {
+		return err
+	}
+	if _, err := literal.Write(msg); err != nil {
+		return err
+	}
+	// closing the literal data also closes the encrypted data beneath it
+	return literal.Close()
+}
+
+// errNotRecipient is returned when decrypting a message which isn't encrypted
+// to the given key.
+var errNotRecipient = errors.New("message is not encrypted to key")
+
+// decryptMessage decrypts an OpenPGP message encrypted to the ECDH private
+// key priv, returning the literal data.
+func decryptMessage(r io.Reader, priv *packet.PrivateKey) ([]byte, error) {
+	var (
+		cipherFunc packet.CipherFunction
+		sessionKey []byte
+		encrypted  *packet.SymmetricallyEncrypted
+	)
+	packets := packet.NewOpaqueReader(r)
+	for encrypted == nil {
+		op, err := packets.Next()
+		if err == io.EOF {
+			return nil, errors.New("no encrypted data found")
+		} else if err != nil {
+			return nil, err
+		}
+		switch op.Tag {
+		case packetTagEncryptedKey:
+			// ignore session keys for other recipients
+			if sessionKey != nil {
+				continue
+			}
+			keyID, point, wrapped, err := parseEncryptedKey(op.Contents)
+			if err != nil {
+				return nil, err
+			}
+			if keyID != priv.KeyId && keyID != 0 {
+				continue
+			}
+			cipherFunc, sessionKey, err = ecdhDecrypt(priv, point, wrapped)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			p, err := op.Parse()
+			if err != nil {
+				return nil, err
+			}
+			if se, ok := p.(*packet.SymmetricallyEncrypted); ok {
+				encrypted = se
+			}
+		}
+	}
+	if sessionKey == nil {
+		return nil, fmt.Errorf("%w %X", errNotRecipient, priv.KeyId)
+	}
+
+	// decrypt the data and read the literal data packet
+	plaintext, err := encrypted.Decrypt(cipherFunc, sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	var msg []byte
+	inner := packet.NewReader(plaintext)
+	for msg == nil {
+		p, err := inner.Next()
+		if err == io.EOF {
+			return nil, errors.New("no literal data found")
+		} else if err != nil {
+			return nil, err
+		}
+		switch p := p.(type) {
+		case *packet.Compressed:
+			inner.Push(p.Body)
+		case *packet.LiteralData:
+			if msg, err = ioutil.ReadAll(p.Body); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// read the remainder so that the MDC gets checked
+	if _, err := io.Copy(ioutil.Discard, plaintext); err != nil {
+		return nil, err
+	}
+	if err := plaintext.Close(); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// parseEncryptedKey parses the contents of an ECDH public-key encrypted
+// session key packet (RFC 4880, section 5.1 and RFC 6637, section 10).
+func parseEncryptedKey(contents []byte) (keyID uint64, point, wrapped []byte, err error) {
+	if len(contents) < 12 || contents[0] != encryptedKeyVersion {
+		return 0, nil, nil, errors.New("invalid encrypted session key packet")
+	}
+	keyID = binary.BigEndian.Uint64(contents[1:9])
+	if algo := packet.PublicKeyAlgorithm(contents[9]); algo != packet.PubKeyAlgoECDH {
+		return 0, nil, nil, fmt.Errorf("message encrypted with unsupported algorithm %d", algo)
+	}
+	contents = contents[10:]
+	pointLen := (int(binary.BigEndian.Uint16(contents)) + 7) / 8
+	if len(contents) < 2+pointLen+1 {
+		return 0, nil, nil, errors.New("invalid encrypted session key packet")
+	}
+	point = contents[2 : 2+pointLen]
+	contents = contents[2+pointLen:]
+	wrappedLen := int(contents[0])
+	if len(contents) != 1+wrappedLen {
+		return 0, nil, nil, errors.New("invalid encrypted session key packet")
+	}
+	return keyID, point, contents[1:], nil
+}
+
+func writeMPI(w io.Writer, mpi []byte) {
+	bitLength := uint16((len(mpi)-1)*8 + bits.Len8(mpi[0]))
+	binary.Write(w, binary.BigEndian, bitLength)
+	w.Write(mpi)
+}
+
+func checksum(key []byte) uint16 {
+	var sum uint16
+	for _, b := range key {
+		sum += uint16(b)
+	}
+	return sum
+}
+
+// aesKeyWrap implements the AES key wrap algorithm from RFC 3394.
+func aesKeyWrap(kek, plaintext []byte) ([]byte, error) {
+	return aesKeyWrapIV(kek, keyWrapIV, plaintext)
+}
+
+// aesKeyWrapIV is aesKeyWrap with the given initial value.
+func aesKeyWrapIV(kek, iv, plaintext []byte) ([]byte, error) {
+	if len(plaintext)%8 != 0 || len(plaintext) < 16 {
+		return nil, errors.New("key wrap input must be a multiple of 8 bytes")
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	n := len(plaintext) / 8
+	out := make([]byte, 8+len(plaintext))
+	copy(out, iv)
+	copy(out[8:], plaintext)
+	var buf [16]byte
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(buf[:8], out[:8])
+			copy(buf[8:], out[i*8:])
+			block.Encrypt(buf[:], buf[:])
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(out[:8], binary.BigEndian.Uint64(buf[:8])^t)
+			copy(out[i*8:], buf[8:])
+		}
+	}
+	return out, nil
+}
+
+// aesKeyUnwrap implements the AES key unwrap algorithm from RFC 3394.
+func aesKeyUnwrap(kek, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext)%8 != 0 || len(ciphertext) < 24 {
+		return nil, errors.New("key unwrap input must be a multiple of 8 bytes")
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	n := len(ciphertext)/8 - 1
+	out := make([]byte, len(ciphertext))
+	copy(out, ciphertext)
+	var buf [16]byte
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(buf[:8], binary.BigEndian.Uint64(out[:8])^t)
+			copy(buf[8:], out[i*8:])
+			block.Decrypt(buf[:], buf[:])
+			copy(out[:8], buf[:8])
+			copy(out[i*8:], buf[8:])
+		}
+	}
+	if subtle.ConstantTimeCompare(out[:8], keyWrapIV) != 1 {
+		return nil, errors.New("key unwrap integrity check failed")
+	}
+	return out[8:], nil
+}
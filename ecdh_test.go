@@ -0,0 +1,82 @@
+package recovery
+
+import (
+	"bytes"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+// gpgMessage was encrypted to the test identity by GnuPG 2.2.
+const gpgMessage = `-----BEGIN PGP MESSAGE-----
+
+hHYDzfKMfTbz9PUSAgMETj3FDChSdrHBEUD71dhsKJlkvz+DRHdSfq2kMrvI2H08
+r7bCQQiEMWDJqUVaqSyYnq66U/ySqKagRM4gBBek0ihahsKJ3xNIOO+qoV4L58/J
+QFP3+klDXW2YGQrGqmIemMTvoJVurPWC0j4BKKUmm7QxsdOAKeF2KWxe+woy9EFT
+4ov8ZU0jmIGNzXLBGQdGS98aN86ywn/JrdCR8ERD9Z/lVwMlNxU2IQ==
+=yFQT
+-----END PGP MESSAGE-----`
+
+func TestDecryptGnuPGMessage(t *testing.T) {
+	entity := recoverEntity(t, aliceInput)
+	block, err := armor.Decode(strings.NewReader(gpgMessage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := decryptMessage(block.Body, entity.Subkeys[0].PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(msg) != "hello from gnupg\n" {
+		t.Fatalf("unexpected message: %q", msg)
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	entity := recoverEntity(t, aliceInput)
+	subkey := entity.Subkeys[0]
+	var buf bytes.Buffer
+	if err := encryptMessage(&buf, rand.Reader, subkey.PublicKey, []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	// flip a bit in the session key packet and check decryption fails
+	tampered := append([]byte(nil), buf.Bytes()...)
+	tampered[len(tampered)/4] ^= 1
+	if _, err := decryptMessage(bytes.NewReader(tampered), subkey.PrivateKey); err == nil {
+		t.Fatal("expected tampered message to fail to decrypt")
+	}
+
+	msg, err := decryptMessage(&buf, subkey.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(msg) != "hello" {
+		t.Fatalf("unexpected message: %q", msg)
+	}
+}
+
+func TestRecoveryTestDecrypt(t *testing.T) {
+	var stderr bytes.Buffer
+	recoverEntity(t, aliceInput,
+		WithStderr(&stderr),
+		WithTestDecrypt(strings.NewReader(gpgMessage)),
+	)
+	if !strings.Contains(stderr.String(), "Successfully decrypted the test message (17 bytes)") {
+		t.Fatalf("expected test decryption to succeed, got:\n%s", stderr.String())
+	}
+
+	// a message for another key should fail
+	input := strings.Replace(aliceInput, "s3cr3t", "", 1)
+	err := Run(
+		WithStdin(strings.NewReader(input)),
+		WithStdout(&bytes.Buffer{}),
+		WithStderr(&bytes.Buffer{}),
+		WithTestDecrypt(strings.NewReader(gpgMessage)),
+	)
+	if err == nil || !strings.Contains(err.Error(), "test decryption failed") {
+		t.Fatalf("expected test decryption error, got %v", err)
+	}
+}
@@ -0,0 +1,276 @@
+package recovery
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/openpgp/packet"
+	"golang.org/x/crypto/ssh"
+)
+
+// Encoder encodes the private key of a recovered identity for output.
+type Encoder interface {
+	Encode(w io.Writer, result *Result) error
+}
+
+// WithEncoder configures the format the private key is written to stdout in,
+// defaulting to an ascii armored OpenPGP private key.
+func WithEncoder(e Encoder) Option {
+	return func(r *Recovery) {
+		r.encoder = e
+	}
+}
+
+// encoders are the built-in encoders by the name given to NewEncoder.
+var encoders = map[string]func() Encoder{
+	"armor":  ArmoredEncoder,
+	"binary": BinaryEncoder,
+	"ssh":    SSHEncoder,
+	"pem":    PEMEncoder,
+	"json":   JSONEncoder,
+	"pkcs11": func() Encoder { return PKCS11Encoder(nil) },
+}
+
+// NewEncoder returns the built-in encoder with the given name (armor, binary,
+// ssh, pem, json or pkcs11).
+func NewEncoder(name string) (Encoder, error) {
+	newEncoder, ok := encoders[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q: must be armor, binary, ssh, pem, json or pkcs11", name)
+	}
+	return newEncoder(), nil
+}
+
+// checkEncoder checks the encoder is consistent with the other output options
+// before prompting for anything.
+func (r *Recovery) checkEncoder() error {
+	if r.encoder != nil && (r.shareOutputs != nil || r.passphraseTTY != nil) {
+		return errors.New("an output format can't be used when the private key is split into shares or encrypted, which are always ascii armored")
+	}
+	return nil
+}
+
+// ArmoredEncoder returns an Encoder which writes an ascii armored OpenPGP
+// private key, as imported with 'gpg --import'.
+func ArmoredEncoder() Encoder {
+	return armoredEncoder{}
+}
+
+type armoredEncoder struct{}
+
+func (armoredEncoder) Encode(w io.Writer, result *Result) error {
+	var headers map[string]string
+	if result.Demo {
+		headers = map[string]string{"Comment": demoComment}
+	}
+	if err := writePrivate(w, result.Entity, headers); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// BinaryEncoder returns an Encoder which writes the private key as binary
+// OpenPGP packets.
+func BinaryEncoder() Encoder {
+	return binaryEncoder{}
+}
+
+type binaryEncoder struct{}
+
+func (binaryEncoder) Encode(w io.Writer, result *Result) error {
+	return result.Entity.SerializePrivate(w, nil)
+}
+
+// SSHEncoder returns an Encoder which writes the primary key as an OpenSSH
+// private key (e.g. to authenticate with the same key as 'gpg
+// --export-ssh-key' exports).
+func SSHEncoder() Encoder {
+	return sshEncoder{}
+}
+
+type sshEncoder struct{}
+
+func (sshEncoder) Encode(w io.Writer, result *Result) error {
+	key, err := privateECDSAKey(result.Entity.PrivateKey)
+	if err != nil {
+		return err
+	}
+	pub, err := ssh.NewPublicKey(&key.PublicKey)
+	if err != nil {
+		return err
+	}
+	comment := result.UserID
+	if result.Demo {
+		comment = demoComment
+	}
+
+	// encode the unencrypted key in the openssh-key-v1 format, see
+	// https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.key
+	var check [4]byte
+	if _, err := rand.Read(check[:]); err != nil {
+		return err
+	}
+	priv := ssh.Marshal(struct {
+		Check1  uint32
+		Check2  uint32
+		KeyType string
+		Curve   string
+		Q       []byte
+		D       []byte
+		Comment string
+	}{
+		Check1:  binary.BigEndian.Uint32(check[:]),
+		Check2:  binary.BigEndian.Uint32(check[:]),
+		KeyType: ssh.KeyAlgoECDSA256,
+		Curve:   "nistp256",
+		Q:       elliptic.Marshal(key.Curve, key.X, key.Y),
+		D:       mpint(key.D.Bytes()),
+		Comment: comment,
+	})
+	defer wipe(priv)
+	for i := byte(1); len(priv)%8 != 0; i++ {
+		priv = append(priv, i)
+	}
+	body := append([]byte("openssh-key-v1\x00"), ssh.Marshal(struct {
+		CipherName   string
+		KdfName      string
+		KdfOptions   string
+		NumKeys      uint32
+		PubKey       []byte
+		PrivKeyBlock []byte
+	}{
+		CipherName:   "none",
+		KdfName:      "none",
+		NumKeys:      1,
+		PubKey:       pub.Marshal(),
+		PrivKeyBlock: priv,
+	})...)
+	defer wipe(body)
+	return pem.Encode(w, &pem.Block{Type: "OPENSSH PRIVATE KEY", Bytes: body})
+}
+
+// mpint returns the SSH mpint encoding of the big endian unsigned integer b
+// (without the length prefix).
+func mpint(b []byte) []byte {
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		return append([]byte{0}, b...)
+	}
+	return b
+}
+
+// PEMEncoder returns an Encoder which writes the primary key and subkey as
+// PKCS #8 PEM blocks, for tools which don't read OpenPGP keys (e.g.
+// openssl).
+func PEMEncoder() Encoder {
+	return pemEncoder{}
+}
+
+type pemEncoder struct{}
+
+func (pemEncoder) Encode(w io.Writer, result *Result) error {
+	if result.Demo {
+		fmt.Fprintf(w, "%s\n\n", demoComment)
+	}
+	for _, k := range []struct {
+		name        string
+		key         *packet.PrivateKey
+		fingerprint string
+	}{
+		{"Primary key", result.Entity.PrivateKey, result.PrimaryFingerprint},
+		{"Subkey", result.Entity.Subkeys[0].PrivateKey, result.SubkeyFingerprint},
+	} {
+		key, err := privateECDSAKey(k.key)
+		if err != nil {
+			return err
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s %s:\n", k.name, k.fingerprint)
+		err = pem.Encode(w, &pem.Block{Type: "PRIVATE KEY", Bytes: der})
+		wipe(der)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// JSONEncoder returns an Encoder which writes the user ID, the fingerprint,
+// key ID and hex encoded private key of each key, and the ascii armored
+// private key as a JSON object, for other programs to read.
+func JSONEncoder() Encoder {
+	return jsonEncoder{}
+}
+
+type jsonEncoder struct{}
+
+type jsonKey struct {
+	Fingerprint string `json:"fingerprint"`
+	KeyID       string `json:"key_id"`
+	PrivateKey  string `json:"private_key"`
+}
+
+func (jsonEncoder) Encode(w io.Writer, result *Result) error {
+	out := struct {
+		UserID            string   `json:"user_id"`
+		Comment           string   `json:"comment,omitempty"`
+		PrimaryKey        *jsonKey `json:"primary_key"`
+		Subkey            *jsonKey `json:"subkey"`
+		ArmoredPrivateKey string   `json:"armored_private_key"`
+	}{
+		UserID:     result.UserID,
+		PrimaryKey: &jsonKey{Fingerprint: result.PrimaryFingerprint, KeyID: result.PrimaryKeyID},
+		Subkey:     &jsonKey{Fingerprint: result.SubkeyFingerprint, KeyID: result.SubkeyID},
+	}
+	if result.Demo {
+		out.Comment = demoComment
+	}
+	for _, k := range []struct {
+		out *jsonKey
+		key *packet.PrivateKey
+	}{
+		{out.PrimaryKey, result.Entity.PrivateKey},
+		{out.Subkey, result.Entity.Subkeys[0].PrivateKey},
+	} {
+		key, err := privateECDSAKey(k.key)
+		if err != nil {
+			return err
+		}
+		k.out.PrivateKey = hex.EncodeToString(key.D.FillBytes(make([]byte, 32)))
+	}
+	armored := bytes.NewBuffer(make([]byte, 0, armoredPrivateKeySize))
+	defer func() {
+		wipe(armored.Bytes())
+	}()
+	if err := (armoredEncoder{}).Encode(armored, result); err != nil {
+		return err
+	}
+	out.ArmoredPrivateKey = armored.String()
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// privateECDSAKey returns the P-256 private key of a derived key, which is
+// stored as an ECDSA key for both the primary key and the ECDH subkey.
+func privateECDSAKey(key *packet.PrivateKey) (*ecdsa.PrivateKey, error) {
+	k, ok := key.PrivateKey.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("unexpected private key type %T", key.PrivateKey)
+	}
+	return k, nil
+}
@@ -0,0 +1,87 @@
+package recovery
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// ephemeralPassphraseWords is the number of BIP39 words in a one-time
+// passphrase, giving 66 bits of entropy.
+const ephemeralPassphraseWords = 6
+
+// WithEphemeralPassphrase configures the recovery to encrypt the private key
+// with a random one-time passphrase before writing it to stdout, displaying
+// the passphrase only on tty (which should be the interactive terminal), so
+// that the private key and the passphrase never travel over the same
+// channel.
+func WithEphemeralPassphrase(tty io.Writer) Option {
+	return func(r *Recovery) {
+		r.passphraseTTY = tty
+	}
+}
+
+// checkEphemeral checks the ephemeral passphrase option is consistent with
+// the other output options before prompting for anything.
+func (r *Recovery) checkEphemeral() error {
+	if r.passphraseTTY != nil && r.shareOutputs != nil {
+		return errors.New("the private key can't be both encrypted with a one-time passphrase and split into shares")
+	}
+	return nil
+}
+
+// writeEncrypted writes the private key of entity to stdout encrypted with a
+// new one-time passphrase, which is then displayed on the configured tty.
+func (r *Recovery) writeEncrypted(entity *openpgp.Entity) error {
+	passphrase, err := r.newEphemeralPassphrase(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("could not generate passphrase: %s", err)
+	}
+	defer wipe(passphrase)
+	enc, err := armor.Encode(r.stdout, "PGP MESSAGE", r.armorHeaders())
+	if err != nil {
+		return err
+	}
+	config := &packet.Config{DefaultCipher: packet.CipherAES256}
+	plaintext, err := openpgp.SymmetricallyEncrypt(enc, passphrase, &openpgp.FileHints{IsBinary: true}, config)
+	if err != nil {
+		return err
+	}
+	if err := entity.SerializePrivate(plaintext, nil); err != nil {
+		return err
+	}
+	if err := plaintext.Close(); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	fmt.Fprint(r.stdout, "\n\n")
+	fmt.Fprintln(r.passphraseTTY, "The private key was encrypted with the following one-time passphrase,")
+	fmt.Fprintln(r.passphraseTTY, "which is not shown again (decrypt it with 'gpg --decrypt | gpg --import'):")
+	fmt.Fprintln(r.passphraseTTY)
+	fmt.Fprintf(r.passphraseTTY, "    %s\n\n", passphrase)
+	return nil
+}
+
+// newEphemeralPassphrase returns a passphrase of random words from the BIP39
+// English wordlist, allocated in the secure memory.
+func (r *Recovery) newEphemeralPassphrase(random io.Reader) ([]byte, error) {
+	words := make([][]byte, ephemeralPassphraseWords)
+	var buf [2]byte
+	defer wipe(buf[:])
+	for i := range words {
+		if _, err := io.ReadFull(random, buf[:]); err != nil {
+			return nil, err
+		}
+		// the wordlist has 2048 words so masking 11 bits is uniform
+		index := (int(buf[0])<<8 | int(buf[1])) & 0x7ff
+		words[i] = []byte(englishWordlist.words[index])
+	}
+	return r.mem.join(words, ' '), nil
+}
@@ -0,0 +1,63 @@
+package recovery
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+func TestEphemeralPassphrase(t *testing.T) {
+	var stdout, tty bytes.Buffer
+	err := Run(
+		WithStdin(strings.NewReader(aliceInput)),
+		WithStdout(&stdout),
+		WithStderr(&bytes.Buffer{}),
+		WithEphemeralPassphrase(&tty),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(stdout.String(), "PRIVATE KEY") {
+		t.Fatal("expected the private key to be encrypted")
+	}
+
+	// get the passphrase from the tty output
+	var passphrase string
+	for _, line := range strings.Split(tty.String(), "\n") {
+		if strings.HasPrefix(line, "    ") {
+			passphrase = strings.TrimSpace(line)
+		}
+	}
+	if n := len(strings.Fields(passphrase)); n != ephemeralPassphraseWords {
+		t.Fatalf("expected a %d word passphrase, got %q", ephemeralPassphraseWords, passphrase)
+	}
+	if strings.Contains(stdout.String(), passphrase) {
+		t.Fatal("expected the passphrase to not be written to stdout")
+	}
+
+	// decrypt the private key with the passphrase
+	block, err := armor.Decode(&stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prompt := func([]openpgp.Key, bool) ([]byte, error) {
+		return []byte(passphrase), nil
+	}
+	md, err := openpgp.ReadMessage(block.Body, nil, prompt, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entities, err := openpgp.ReadKeyRing(md.UnverifiedBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fp := formatFingerprint(entities[0].PrimaryKey); fp != "AB86C8C7B5136D19B0A6AEC0406D7920DCAD67C3" {
+		t.Fatalf("unexpected fingerprint %s", fp)
+	}
+	if entities[0].PrivateKey == nil {
+		t.Fatal("expected the decrypted key to include the private key")
+	}
+}
@@ -0,0 +1,28 @@
+package recovery
+
+import "errors"
+
+// Errors returned by the library so that embedders can branch on the cause of
+// a failure. They are usually wrapped with more detail, so check for them
+// with errors.Is.
+var (
+	// ErrAborted is returned when the user declines to continue.
+	ErrAborted = errors.New("aborting at user's request")
+
+	// ErrInvalidMnemonic is returned when the recovery seed isn't a valid
+	// English BIP39 mnemonic.
+	ErrInvalidMnemonic = errors.New("invalid recovery seed")
+
+	// ErrInvalidTimestamp is returned when the timestamp can't be parsed
+	// or, in strict mode, is implausible.
+	ErrInvalidTimestamp = errors.New("invalid timestamp")
+
+	// ErrFingerprintMismatch is returned when the recovered identity doesn't
+	// match the expected fingerprint (in strict mode, or when none of the
+	// searched candidates match).
+	ErrFingerprintMismatch = errors.New("fingerprint mismatch")
+
+	// ErrEndOfInput is returned when stdin ends before every prompt has been
+	// answered.
+	ErrEndOfInput = errors.New("unexpected end of input")
+)
@@ -0,0 +1,67 @@
+package recovery
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSentinelErrors(t *testing.T) {
+	run := func(input string, opts ...Option) error {
+		return Run(append([]Option{
+			WithStdin(strings.NewReader(input)),
+			WithStdout(&bytes.Buffer{}),
+			WithStderr(&bytes.Buffer{}),
+		}, opts...)...)
+	}
+	for _, test := range []struct {
+		name     string
+		input    string
+		opts     []Option
+		expected error
+	}{
+		{
+			name:     "aborted",
+			input:    "no\n",
+			expected: ErrAborted,
+		},
+		{
+			name:     "invalid mnemonic",
+			input:    strings.Replace(aliceInput, "all\ns3cr3t", "zoo\ns3cr3t", 1),
+			expected: ErrInvalidMnemonic,
+		},
+		{
+			name:     "invalid seed length",
+			input:    strings.Replace(aliceInput, "\n12\n", "\n13\n", 1),
+			expected: ErrInvalidMnemonic,
+		},
+		{
+			name:     "unparseable timestamp",
+			input:    strings.Replace(aliceInput, "1523060353", "yesterday", 1),
+			expected: ErrInvalidTimestamp,
+		},
+		{
+			name:     "implausible timestamp in strict mode",
+			input:    strings.Replace(aliceInput, "1523060353", "0", 1),
+			opts:     []Option{WithStrict()},
+			expected: ErrInvalidTimestamp,
+		},
+		{
+			name:     "fingerprint mismatch in strict mode",
+			input:    aliceInput,
+			opts:     []Option{WithStrict(), WithFingerprint("AB56AE89922A6BB4DCC7F7A6BEFE43CEA0BEC4E5")},
+			expected: ErrFingerprintMismatch,
+		},
+		{
+			name:     "no matching candidates",
+			input:    aliceInput,
+			opts:     []Option{WithFingerprint("AB56AE89922A6BB4DCC7F7A6BEFE43CEA0BEC4E5"), WithPassphraseTypos()},
+			expected: ErrFingerprintMismatch,
+		},
+	} {
+		if err := run(test.input, test.opts...); !errors.Is(err, test.expected) {
+			t.Fatalf("%s: expected %q, got %v", test.name, test.expected, err)
+		}
+	}
+}
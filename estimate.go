@@ -0,0 +1,119 @@
+package recovery
+
+import (
+	"fmt"
+	"time"
+
+	slip10 "github.com/lmars/go-slip10"
+)
+
+// searchConfirmDuration is how long a search has to be estimated to take
+// before the user is asked whether to go ahead with it.
+var searchConfirmDuration = 10 * time.Minute
+
+// calibrationSamples is how many master keys and fingerprints are timed to
+// estimate how long a search takes.
+const calibrationSamples = 16
+
+// maxCalibrationSeeds is the most candidate seeds tried while looking for
+// one with a valid checksum to time deriving keys with (1 in 256 candidates
+// of a 24 word seed are valid).
+const maxCalibrationSeeds = 4096
+
+// calibrationStride spreads the master keys timed across the search, since
+// consecutive ones mostly share a candidate seed.
+const calibrationStride = 0x9e3779b97f4a7c15
+
+// confirmSearch displays the size of the search of the candidates of space
+// from start and an estimate of how long it takes, asking whether to go
+// ahead if it's estimated to take longer than searchConfirmDuration (unless
+// there's no prompter, as in a Session).
+func (r *Recovery) confirmSearch(space *searchSpace, start, total uint64) error {
+	if start >= total {
+		return nil
+	}
+	workers := r.workers(start, total)
+	estimate, err := space.estimate(start, total, workers)
+	if err != nil {
+		return err
+	}
+	about := "about " + formatDuration(estimate)
+	if estimate < time.Second {
+		about = "less than a second"
+	}
+	noun := "workers"
+	if workers == 1 {
+		noun = "worker"
+	}
+	r.info(fmt.Sprintf("The search of %d candidates is estimated to take %s on %d %s", total-start, about, workers, noun), LogField{"candidates", total - start}, LogField{"estimate", estimate.Seconds()}, LogField{"workers", workers})
+	if estimate <= searchConfirmDuration || r.prompter == nil {
+		return nil
+	}
+	if ok, err := r.confirm(fmt.Sprintf("The search is estimated to take about %s. Start it?", formatDuration(estimate)), searchHelp); err != nil {
+		return err
+	} else if !ok {
+		return ErrAborted
+	}
+	return nil
+}
+
+// estimate returns how long searching the candidates of s from start to
+// total on workers is estimated to take, by timing deriving a sample of
+// their master keys, derived keys and fingerprints.
+func (s *searchSpace) estimate(start, total uint64, workers int) (time.Duration, error) {
+	perMasterKey, perKey := s.keysPerMasterKey(), s.keysPerKey()
+	masterKeys := (total - start + perMasterKey - 1) / perMasterKey
+
+	// time deriving a spread of the master keys, counting how many have a
+	// candidate seed with a valid checksum and keeping the first valid one
+	var (
+		masterKeyTime time.Duration
+		samples       uint64
+		valid         uint64
+		key           *slip10.Key
+		keyCandidate  searchCandidate
+	)
+	defer func() { wipeSlip10Key(key) }()
+	for ; samples < masterKeys && samples < maxCalibrationSeeds && (samples < calibrationSamples || key == nil); samples++ {
+		c := s.candidate(start + (samples*calibrationStride)%masterKeys*perMasterKey)
+		began := time.Now()
+		masterKey, err := s.masterKey(c)
+		masterKeyTime += time.Since(began)
+		if err != nil {
+			return 0, err
+		} else if masterKey == nil {
+			continue
+		}
+		valid++
+		if key == nil {
+			key, keyCandidate = masterKey, c
+		} else {
+			wipeSlip10Key(masterKey)
+		}
+	}
+	cost := float64(masterKeys) * float64(masterKeyTime) / float64(samples)
+	if key == nil {
+		return time.Duration(cost / float64(workers)), nil
+	}
+
+	// time deriving the keys and fingerprints of the valid seed
+	began := time.Now()
+	primaryKey, subKey, err := s.params(keyCandidate).deriveKeys(key, s.userIDs[keyCandidate.userID])
+	if err != nil {
+		return 0, err
+	}
+	keyTime := time.Since(began)
+	defer wipeKey(primaryKey)
+	defer wipeKey(subKey)
+	fingerprints := newFingerprinter(primaryKey)
+	began = time.Now()
+	for i := 0; i < calibrationSamples; i++ {
+		fingerprints.sum(s.timestamps[i%len(s.timestamps)])
+	}
+	fingerprintTime := time.Since(began) / calibrationSamples
+
+	validFraction := float64(valid) / float64(samples)
+	cost += float64(masterKeys) * validFraction * float64(perMasterKey/perKey) * float64(keyTime)
+	cost += float64(total-start) * validFraction * float64(fingerprintTime)
+	return time.Duration(cost / float64(workers)), nil
+}
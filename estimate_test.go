@@ -0,0 +1,82 @@
+package recovery
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfirmSearch(t *testing.T) {
+	duration := searchConfirmDuration
+	searchConfirmDuration = -1
+	defer func() { searchConfirmDuration = duration }()
+
+	timestamps := make([]time.Time, 1000)
+	for i := range timestamps {
+		timestamps[i] = time.Unix(1523060353-500+int64(i), 0)
+	}
+	input := strings.Replace(aliceInput, "Alice <alice@example.com>\n1523060353\n", "", 1)
+	opts := []Option{
+		WithFingerprint(aliceFingerprint),
+		WithUserIDCandidates([]string{"Alice <alice@example.com>"}),
+		WithTimestampCandidates(timestamps),
+	}
+
+	// the search is estimated and confirmed before it starts
+	var stderr bytes.Buffer
+	entity := recoverEntity(t, input+"yes\n", append(opts, WithStderr(&stderr))...)
+	if fpr := formatFingerprint(entity.PrimaryKey); fpr != aliceFingerprint {
+		t.Fatalf("unexpected fingerprint %s", fpr)
+	}
+	if !strings.Contains(stderr.String(), "The search of 1000 candidates is estimated to take") || !strings.Contains(stderr.String(), "Start it? (yes/no)") {
+		t.Fatalf("expected the search to be estimated and confirmed, got:\n%s", stderr.String())
+	}
+
+	// declining stops the recovery
+	err := Run(append(opts,
+		WithStdin(strings.NewReader(input+"no\n")),
+		WithStdout(&bytes.Buffer{}),
+		WithStderr(&bytes.Buffer{}),
+	)...)
+	if !errors.Is(err, ErrAborted) {
+		t.Fatalf("expected ErrAborted, got %v", err)
+	}
+}
+
+func TestSearchEstimate(t *testing.T) {
+	estimate := func(mnemonic string) time.Duration {
+		r := &Recovery{fingerprint: aliceFingerprint, mem: &secureMemory{}}
+		words := bytes.Fields([]byte(mnemonic))
+		seed, err := r.newPartialSeed(words, missingWords(words))
+		if err != nil {
+			t.Fatal(err)
+		}
+		space := &searchSpace{
+			r:           r,
+			seed:        seed,
+			partial:     seed,
+			passphrases: []string{"s3cr3t"},
+			userIDs:     []string{"Alice <alice@example.com>"},
+			indexes:     []uint32{0},
+			timestamps:  []time.Time{time.Unix(1523060353, 0)},
+		}
+		total, err := space.size()
+		if err != nil {
+			t.Fatal(err)
+		}
+		estimate, err := space.estimate(0, total, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return estimate
+	}
+
+	// a second missing word multiplies the search by 2048
+	one := estimate("all all all all all all all all all all all *")
+	two := estimate("all all all all all all all all all all * *")
+	if one <= 0 || two < 100*one {
+		t.Fatalf("expected a second missing word to take much longer, got %s and %s", one, two)
+	}
+}
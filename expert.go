@@ -0,0 +1,145 @@
+package recovery
+
+import (
+	"crypto"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// WithExpertMode configures the recovery to prompt for every derivation
+// parameter which is normally fixed or only set with options (the SLIP-0013
+// purposes, ECDH KDF parameters, key flags and self-signature subpackets)
+// after the user ID and timestamp, for reproducing unusual keys created by
+// patched agents. Pressing enter keeps the value shown.
+func WithExpertMode() Option {
+	return func(r *Recovery) {
+		r.expert = true
+	}
+}
+
+// readExpertParams prompts for each derivation parameter in expert mode,
+// checking the result.
+func (r *Recovery) readExpertParams() error {
+	if !r.expert {
+		return nil
+	}
+	params := r.keyParams()
+	r.display(`
+Expert mode: press enter to keep the value shown for each parameter. The key
+and signature packets are always version 4, the only version trezor-agent
+creates.
+-----------------------------------------------------------------------------`)
+	if params.device != DeviceLedger {
+		purpose, err := r.readPurpose("SLIP-0013 purpose of the primary key", params.primaryPurpose)
+		if err != nil {
+			return err
+		}
+		params.primaryPurpose = purpose
+		if purpose, err = r.readPurpose("SLIP-0013 purpose of the subkey", params.subkeyPurpose); err != nil {
+			return err
+		}
+		params.subkeyPurpose = purpose
+	}
+	hash, err := r.readHash("Hash of the self-signatures", params.sigHash)
+	if err != nil {
+		return err
+	}
+	params.sigHash = hash
+	if hash, err = r.readHash("ECDH KDF hash of the subkey", params.kdfHash); err != nil {
+		return err
+	}
+	params.kdfHash = hash
+	answer, err := r.readParam("ECDH KDF cipher of the subkey", strings.ToLower(cipherName(params.kdfCipher)))
+	if err != nil {
+		return err
+	}
+	if params.kdfCipher, err = ParseCipher(answer); err != nil {
+		return err
+	}
+	flags, err := r.readKeyFlags("Key flags of the primary key", params.primaryFlags)
+	if err != nil {
+		return err
+	}
+	params.primaryFlags = flags
+	if flags, err = r.readKeyFlags("Key flags of the subkey", params.subkeyFlags); err != nil {
+		return err
+	}
+	params.subkeyFlags = flags
+	primary := "no"
+	if params.primaryUserID {
+		primary = "yes"
+	}
+	if answer, err = r.readParam("Mark the user ID as primary in its self-signature (yes or no)", primary); err != nil {
+		return err
+	}
+	switch strings.ToLower(answer) {
+	case "yes":
+		params.primaryUserID = true
+	case "no":
+		params.primaryUserID = false
+	default:
+		return fmt.Errorf("invalid answer %q: must be yes or no", answer)
+	}
+	sigTime := "the key timestamp"
+	if !params.sigTime.IsZero() {
+		sigTime = strconv.FormatInt(params.sigTime.Unix(), 10)
+	}
+	if answer, err = r.readParam("Creation time of the self-signatures (Unix time)", sigTime); err != nil {
+		return err
+	}
+	if answer != sigTime {
+		if params.sigTime, err = ParseTimestamp(answer); err != nil {
+			return err
+		}
+	}
+	if err := params.check(); err != nil {
+		return err
+	}
+	r.info("Deriving with "+params.String(), LogField{"params", params.String()})
+	return nil
+}
+
+// readParam prompts for a parameter in expert mode, returning current if
+// the answer is blank.
+func (r *Recovery) readParam(prompt, current string) (string, error) {
+	answer, err := r.readInput(fmt.Sprintf("%s [%s]:", prompt, current), expertHelp, current)
+	if err != nil {
+		return "", err
+	}
+	if answer = strings.TrimSpace(answer); answer == "" {
+		return current, nil
+	}
+	return answer, nil
+}
+
+// readPurpose prompts for a SLIP-0013 purpose in expert mode.
+func (r *Recovery) readPurpose(prompt string, current uint32) (uint32, error) {
+	answer, err := r.readParam(prompt, strconv.FormatUint(uint64(current), 10))
+	if err != nil {
+		return 0, err
+	}
+	purpose, err := strconv.ParseUint(answer, 10, 31)
+	if err != nil {
+		return 0, fmt.Errorf("invalid SLIP-0013 purpose %q: must be a number less than 2147483648", answer)
+	}
+	return uint32(purpose), nil
+}
+
+// readHash prompts for a hash algorithm in expert mode.
+func (r *Recovery) readHash(prompt string, current crypto.Hash) (crypto.Hash, error) {
+	answer, err := r.readParam(prompt, strings.ToLower(strings.Replace(current.String(), "-", "", 1)))
+	if err != nil {
+		return 0, err
+	}
+	return ParseHash(answer)
+}
+
+// readKeyFlags prompts for key flags in expert mode.
+func (r *Recovery) readKeyFlags(prompt string, current KeyFlags) (KeyFlags, error) {
+	answer, err := r.readParam(prompt, current.String())
+	if err != nil {
+		return 0, err
+	}
+	return ParseKeyFlags(answer)
+}
@@ -0,0 +1,40 @@
+package recovery
+
+import (
+	"crypto"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+func TestExpertMode(t *testing.T) {
+	expertInput := func(answers ...string) string {
+		return strings.Replace(aliceInput, "1523060353\n", "1523060353\n"+strings.Join(answers, "\n")+"\n", 1)
+	}
+
+	// keeping every parameter recovers the usual identity
+	entity := recoverEntity(t, expertInput("", "", "", "", "", "", "", "", ""), WithExpertMode())
+	if fingerprint := formatFingerprint(entity.PrimaryKey); fingerprint != aliceFingerprint {
+		t.Fatalf("expected fingerprint %s, got %s", aliceFingerprint, fingerprint)
+	}
+	usual := formatFingerprint(entity.Subkeys[0].PublicKey)
+
+	// the answers are the same as giving the options
+	entity = recoverEntity(t, expertInput("", "18", "sha512", "", "aes256", "", "encrypt-storage", "no", "1523060400"), WithExpertMode())
+	expected := recoverEntity(t, aliceInput, WithPurposes(13, 18), WithECDHParams(crypto.SHA256, packet.CipherAES256))
+	if fingerprint := formatFingerprint(entity.PrimaryKey); fingerprint != aliceFingerprint {
+		t.Fatalf("expected the primary key to keep fingerprint %s, got %s", aliceFingerprint, fingerprint)
+	}
+	subkey := formatFingerprint(entity.Subkeys[0].PublicKey)
+	if subkey == usual || subkey != formatFingerprint(expected.Subkeys[0].PublicKey) {
+		t.Fatalf("expected the subkey to be derived with purpose 18, got %s", subkey)
+	}
+	sig := entity.Identities["Alice <alice@example.com>"].SelfSignature
+	if sig.IsPrimaryId != nil || sig.Hash != crypto.SHA512 || sig.CreationTime.Unix() != 1523060400 {
+		t.Fatalf("unexpected self-signature %+v", sig)
+	}
+	if sub := entity.Subkeys[0].Sig; !sub.FlagEncryptStorage || sub.FlagEncryptCommunications {
+		t.Fatalf("unexpected subkey flags %+v", sub)
+	}
+}
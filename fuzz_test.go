@@ -0,0 +1,144 @@
+package recovery
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// The fuzz targets below exercise the parsing of hand-typed input. Their seed
+// corpora run with the other tests, and 'go test -fuzz FuzzMnemonic' etc.
+// searches further.
+
+func FuzzMnemonic(f *testing.F) {
+	for _, v := range bip39Vectors {
+		f.Add(v.Mnemonic)
+	}
+	f.Add(demoVector.Mnemonic)
+	f.Add("ALL all All all all all all all all all all all")
+	f.Add(" all  all all all all all all all all all all all ")
+	f.Add("zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo")
+	f.Add("abaco abaco abaco abaco abaco abaco abaco abaco abaco abaco abaco abaco")
+	f.Fuzz(func(t *testing.T, mnemonic string) {
+		words := bytes.Fields([]byte(mnemonic))
+		r := &Recovery{stderr: ioutil.Discard}
+		err := r.checkMnemonic(words)
+		if err != nil {
+			return
+		}
+
+		// a valid mnemonic re-encodes to the same words
+		entropy, err := englishWordlist.entropy(words)
+		if err != nil {
+			t.Fatalf("checkMnemonic accepted %q, but entropy failed: %s", mnemonic, err)
+		}
+		if again := englishWordlist.mnemonic(entropy); !bytes.Equal(again, bytes.Join(words, []byte(" "))) {
+			t.Fatalf("%q re-encoded as %q", mnemonic, again)
+		}
+	})
+}
+
+func FuzzNormalizeWord(f *testing.F) {
+	f.Add("abandon")
+	f.Add(" Abandon\t")
+	f.Add("ZOO")
+	f.Add("café")
+	f.Add("가격")
+	f.Add("\xff\xe9")
+	f.Fuzz(func(t *testing.T, word string) {
+		normalized := normalizeWord([]byte(word))
+		if again := normalizeWord(append([]byte(nil), normalized...)); !bytes.Equal(again, normalized) {
+			t.Fatalf("normalizing %q isn't idempotent: %q then %q", word, normalized, again)
+		}
+		if _, ok := englishWordlist.index[strings.ToLower(strings.TrimSpace(word))]; ok && englishWordlist.unknown([][]byte{normalized}) != -1 {
+			t.Fatalf("%q normalized to %q, which isn't in the wordlist", word, normalized)
+		}
+	})
+}
+
+func FuzzParseTimestamp(f *testing.F) {
+	for _, s := range []string{"1523060353", " 1523060353\t", "0", "-1", "4294967295", "4294967296", "1523060353000", "+12", "yesterday", ""} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		timestamp, err := ParseTimestamp(s)
+		if err != nil {
+			return
+		}
+		// an accepted timestamp survives OpenPGP's 32 bit creation time
+		if unix := timestamp.Unix(); unix < 0 || unix != int64(uint32(unix)) {
+			t.Fatalf("accepted %q as %d, which doesn't fit an OpenPGP timestamp", s, unix)
+		}
+	})
+}
+
+func FuzzParseSeedLength(f *testing.F) {
+	for _, s := range []string{"12", " 18 ", "24", "13", "-12", "twelve", ""} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		n, err := parseSeedLength(s)
+		if err == nil && n != 12 && n != 18 && n != 24 {
+			t.Fatalf("accepted %q as seed length %d", s, n)
+		}
+	})
+}
+
+func FuzzUserID(f *testing.F) {
+	f.Add("Alice <alice@example.com>", "Alice <alice@example.com>")
+	f.Add("alice <alice@example.com>", "Alice <alice@example.com>")
+	f.Add("Alice  <alice@example.com> ", "Alice <alice@example.com>")
+	f.Add("Alice <alice@example.com", "Alice <alice@example.com>")
+	f.Add("Ali​ce <alice@example.com>", "Alice <alice@example.com>")
+	f.Add("\xff", "")
+	f.Fuzz(func(t *testing.T, entered, expected string) {
+		r := &Recovery{stderr: ioutil.Discard}
+		if err := r.checkUserID(entered); err != nil {
+			t.Fatalf("checkUserID returned an error outside strict mode: %s", err)
+		}
+		r.strict = true
+		if err := r.checkUserID(entered); err == nil && (!utf8.ValidString(entered) || strings.TrimSpace(entered) != entered) {
+			t.Fatalf("checkUserID accepted %q", entered)
+		}
+		userIDEmail(entered)
+		if entered != expected && describeDifference(entered, expected) == "" {
+			t.Fatalf("no difference described between %q and %q", entered, expected)
+		}
+	})
+}
+
+func FuzzCombineShares(f *testing.F) {
+	v := demoVector
+	entity, err := newEntity(v.Mnemonic, v.Passphrase, v.UserID, time.Unix(v.Timestamp, 0))
+	if err != nil {
+		f.Fatal(err)
+	}
+	var a, b, c bytes.Buffer
+	r := &Recovery{stderr: ioutil.Discard, shareThreshold: 2, shareOutputs: []io.Writer{&a, &b, &c}}
+	if err := r.writeShares(entity); err != nil {
+		f.Fatal(err)
+	}
+	f.Add(a.String() + b.String())
+	f.Add(a.String() + c.String() + b.String())
+	f.Add(a.String())
+	f.Add(a.String() + a.String())
+	f.Add(strings.Replace(a.String()+b.String(), "Threshold: 2", "Threshold: -1", -1))
+	f.Add(strings.Replace(a.String(), shareBlockType, "PGP MESSAGE", -1))
+	f.Fuzz(func(t *testing.T, input string) {
+		var out bytes.Buffer
+		if err := CombineShares(&out, strings.NewReader(input)); err != nil {
+			return
+		}
+		// combined shares are always checked against the fingerprint
+		keyring, err := openpgp.ReadArmoredKeyRing(&out)
+		if err != nil || len(keyring) != 1 || keyring[0].PrivateKey == nil {
+			t.Fatalf("CombineShares succeeded without writing a private key: %v", err)
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package recovery
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Generate creates a new GPG identity from a recovery seed, as 'trezor-gpg
+// init' does but without trezor-agent: it prompts like Run, except that the
+// timestamp isn't asked for but taken from the clock (see WithClock) and
+// displayed prominently, since it is needed along with the user ID and seed
+// to recover the identity later. With WithSessionFile, a resumed generation
+// keeps the timestamp first generated.
+func Generate(ctx context.Context, opts ...Option) error {
+	return new(Recovery).Generate(ctx, opts...)
+}
+
+// Generate is like the package level Generate, using the options r was
+// created with followed by opts.
+func (r *Recovery) Generate(ctx context.Context, opts ...Option) error {
+	return r.Run(ctx, append(opts[:len(opts):len(opts)], func(r *Recovery) {
+		r.generate = true
+	})...)
+}
+
+// checkGenerate checks the options make sense for generating a new
+// identity before prompting for anything.
+func (r *Recovery) checkGenerate() error {
+	if !r.generate {
+		return nil
+	}
+	if r.fingerprint != "" || r.publicKey != nil {
+		return errors.New("a new identity has no fingerprint or public key to check it against")
+	}
+	if r.trezorConfig != nil {
+		return errors.New("a trezor-agent configuration is for an existing identity, so can't be used when generating a new one")
+	}
+	if r.timestamps != nil || r.userIDs != nil || r.indexes != nil || r.passphrases != nil || r.passphraseTypos {
+		return errors.New("candidates can only be searched when recovering an identity, not when generating one")
+	}
+	return nil
+}
+
+// generateTimestamp returns the timestamp of a new identity, which is the
+// current time unless a generation saved in the session file is being
+// resumed.
+func (r *Recovery) generateTimestamp() (time.Time, error) {
+	timestamp := r.now().Truncate(time.Second)
+	if saved := r.sessionTimestamp(); saved != "" {
+		r.info("Using the timestamp "+saved+" generated in the session file", LogField{"timestamp", saved})
+		t, err := ParseTimestamp(saved)
+		if err != nil {
+			return time.Time{}, err
+		}
+		timestamp = t
+	}
+	r.report.Timestamp = timestamp.Unix()
+	r.audit(auditEntry{Step: "timestamp generated", Timestamp: timestamp.Unix()})
+	r.saveAnswer(func(s *SessionFile) { s.Timestamp = timestamp.Unix() })
+	r.displayTimestamp(timestamp)
+	return timestamp, nil
+}
+
+// displayTimestamp displays the timestamp of a generated identity, asking
+// for it to be kept with the recovery seed.
+func (r *Recovery) displayTimestamp(timestamp time.Time) {
+	r.display(`
+-----------------------------------------------------------------------------
+ WRITE DOWN THE TIMESTAMP OF THE NEW IDENTITY AND KEEP IT WITH THE SEED:
+
+     %d   (%s)
+
+ It is needed, along with the user ID and recovery seed, to recover the
+ identity with this program, or to use it with trezor-agent by running
+ 'trezor-gpg init --time %d' with the user ID.
+-----------------------------------------------------------------------------`, timestamp.Unix(), timestamp.UTC().Format(time.RFC1123), timestamp.Unix())
+}
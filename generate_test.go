@@ -0,0 +1,50 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+func TestGenerate(t *testing.T) {
+	// generating at Alice's timestamp creates her identity, without asking
+	// for the timestamp
+	input := strings.Replace(aliceInput, "1523060353\n", "", 1)
+	var stdout, stderr bytes.Buffer
+	err := Generate(context.Background(),
+		WithStdin(strings.NewReader(input)),
+		WithStdout(&stdout),
+		WithStderr(&stderr),
+		WithClock(FixedClock(time.Unix(1523060353, 0))),
+	)
+	if err != nil {
+		t.Fatalf("%s\n%s", err, stderr.String())
+	}
+	entities, err := openpgp.ReadArmoredKeyRing(&stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fingerprint := formatFingerprint(entities[0].PrimaryKey); fingerprint != aliceFingerprint {
+		t.Fatalf("expected fingerprint %s, got %s", aliceFingerprint, fingerprint)
+	}
+	if strings.Contains(stderr.String(), "timestamp from the original") {
+		t.Fatalf("expected the timestamp not to be asked for, got:\n%s", stderr.String())
+	}
+	if strings.Count(stderr.String(), "WRITE DOWN THE TIMESTAMP") != 2 || !strings.Contains(stderr.String(), "'trezor-gpg init --time 1523060353'") {
+		t.Fatalf("expected the timestamp to be displayed before and after, got:\n%s", stderr.String())
+	}
+
+	// a new identity can't be checked against a fingerprint
+	err = Generate(context.Background(),
+		WithStdin(strings.NewReader(input)),
+		WithStdout(&bytes.Buffer{}),
+		WithFingerprint(aliceFingerprint),
+	)
+	if err == nil || !strings.Contains(err.Error(), "a new identity has no fingerprint") {
+		t.Fatalf("expected an error generating with a fingerprint, got %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// WithGitSigning configures the recovery to offer to configure git to sign
+// commits with the recovered identity once it has been imported into GnuPG
+// with WithGPGAgent, WithYubiKey or WithTailsPersistence (see
+// ConfigureGitSigning).
+func WithGitSigning() Option {
+	return func(r *Recovery) {
+		r.gitSigning = true
+	}
+}
+
+// checkGitSigning checks the git signing options before prompting for
+// anything.
+func (r *Recovery) checkGitSigning() error {
+	if r.gitSigning && !r.gpgAgent && r.tailsHome == "" {
+		return errors.New("git signing requires the identity to be imported with WithGPGAgent, WithYubiKey or WithTailsPersistence")
+	}
+	return nil
+}
+
+// offerGitSigning asks whether to configure git to sign commits with entity,
+// doing so if confirmed.
+func (r *Recovery) offerGitSigning(entity *openpgp.Entity) error {
+	fingerprint := formatFingerprint(entity.PrimaryKey)
+	if ok, err := r.confirm(fmt.Sprintf("Configure git to sign commits with %s?", fingerprint), gitSigningHelp); err != nil {
+		return err
+	} else if !ok {
+		return nil
+	}
+	if err := ConfigureGitSigning(r.ctx, fingerprint); err != nil {
+		return fmt.Errorf("could not configure git: %s", err)
+	}
+	r.info("Configured git to sign commits with the recovered identity", LogField{"fingerprint", fingerprint})
+	r.audit(auditEntry{Step: "git signing configured"})
+
+	// hosts only show commits as verified if the committer's email is on
+	// the key
+	email, err := gitConfig(r.ctx, "--get", "user.email")
+	if err != nil {
+		return nil
+	}
+	var keyEmails []string
+	for name := range entity.Identities {
+		keyEmail := userIDEmail(name)
+		if keyEmail == "" {
+			continue
+		}
+		if strings.EqualFold(email, keyEmail) {
+			return nil
+		}
+		keyEmails = append(keyEmails, keyEmail)
+	}
+	if len(keyEmails) == 0 {
+		return nil
+	}
+	return r.warn("git's user.email (%s) isn't the recovered identity's (%s), so hosts such as GitHub won't show your commits as verified", email, strings.Join(keyEmails, ", "))
+}
+
+// ConfigureGitSigning sets user.signingkey to fingerprint and commit.gpgsign
+// in the global git configuration, so that git signs commits with the key
+// (which must be in the default GnuPG home) from then on.
+func ConfigureGitSigning(ctx context.Context, fingerprint string) error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return errors.New("git not found in PATH")
+	}
+	fingerprint, err := parseFingerprint(fingerprint)
+	if err != nil {
+		return err
+	}
+	if _, err := gitConfig(ctx, "user.signingkey", fingerprint); err != nil {
+		return err
+	}
+	_, err = gitConfig(ctx, "commit.gpgsign", "true")
+	return err
+}
+
+// gitConfig runs 'git config --global' with args, returning its output.
+func gitConfig(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"config", "--global"}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git config: %s", msg)
+		}
+		return "", fmt.Errorf("git config: %s", err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,59 @@
+package recovery
+
+import (
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWithGitSigning(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found in PATH")
+	}
+	gpg := newAgentHome(t, "")
+	dir := t.TempDir()
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(dir, "gitconfig"))
+	t.Setenv("GNUPGHOME", gpg.home)
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s: %s: %s", strings.Join(args, " "), err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("config", "--global", "user.name", "Alice")
+	git("config", "--global", "user.email", "alice@example.net")
+
+	// git is only configured if confirmed
+	recoverEntity(t, aliceInput+"no\n", WithGPGAgent(gpg.home), WithGitSigning())
+	if out, _ := exec.Command("git", "config", "--global", "commit.gpgsign").Output(); len(out) != 0 {
+		t.Fatalf("expected commit.gpgsign not to be set, got %q", out)
+	}
+
+	// a different user.email is warned about
+	var stderr bytes.Buffer
+	recoverEntity(t, aliceInput+"yes\n", WithGPGAgent(gpg.home), WithGitSigning(), WithStderr(&stderr))
+	if key := git("config", "--global", "user.signingkey"); key != aliceFingerprint {
+		t.Fatalf("unexpected user.signingkey %q", key)
+	}
+	if !strings.Contains(stderr.String(), "WARNING: git's user.email (alice@example.net) isn't the recovered identity's (alice@example.com)") {
+		t.Fatalf("expected a user.email warning, got:\n%s", stderr.String())
+	}
+
+	// commits are signed with the recovered key
+	git("init", "-q")
+	git("commit", "-q", "--allow-empty", "-m", "signed")
+	if out := git("log", "--format=%G? %GF"); out != "U "+aliceFingerprint && out != "G "+aliceFingerprint {
+		t.Fatalf("expected a good signature from %s, got %q", aliceFingerprint, out)
+	}
+
+	// and the identity has to be imported first
+	if err := Run(WithStdin(strings.NewReader(aliceInput)), WithStdout(&bytes.Buffer{}), WithGitSigning()); err == nil || !strings.Contains(err.Error(), "requires the identity to be imported") {
+		t.Fatalf("expected an import error, got %v", err)
+	}
+}
@@ -1,6 +1,6 @@
 module github.com/lmars/trezor-gpg-recovery
 
-go 1.12
+go 1.20
 
 require (
 	github.com/lmars/go-slip10 v0.0.0-20190606092855-400ba44fee12
@@ -9,4 +9,11 @@ require (
 	golang.org/x/crypto v0.0.0-20190605123033-f99c8df09eb5
 )
 
+require (
+	github.com/FactomProject/basen v0.0.0-20150613233007-fe3947df716e // indirect
+	github.com/FactomProject/btcutilecc v0.0.0-20130527213604-d3a63a5752ec // indirect
+	github.com/cmars/basen v0.0.0-20150613233007-fe3947df716e // indirect
+	launchpad.net/gocheck v0.0.0-20140225173054-000000000087 // indirect
+)
+
 replace golang.org/x/crypto => github.com/lmars/crypto v0.0.0-20190611121552-821fa1c75010
@@ -0,0 +1,147 @@
+package recovery
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// helpAnswer is typed at a prompt to display help about it rather than
+// answering it. Secrets other than seed words (e.g. the passphrase) could be
+// "?" themselves, so their prompts don't offer help.
+const helpAnswer = "?"
+
+// errHelp is returned by terminalPrompter.Confirm when the answer is
+// helpAnswer rather than yes or no.
+var errHelp = errors.New("help requested")
+
+// The help displayed at each prompt.
+const (
+	continueHelp = ` Answer yes to start the recovery. You will be asked for the user ID and
+ timestamp the identity was created with by 'trezor-gpg init', then the
+ words of your recovery seed and its passphrase, and the private key is
+ then printed (or written wherever the options given say). Answer no to stop
+ without entering anything.`
+
+	rootHelp = ` The recovery is running as root (e.g. with sudo). It doesn't need to be,
+ and secrets are more likely to be written to disk by root's shell history,
+ auditd or core dumps, so answer no and run it as an ordinary user unless
+ you can't.`
+
+	userIDHelp = ` The user ID is the name and email address given to 'trezor-gpg init',
+ e.g. "Alice <alice@example.com>". It is part of the key derivation, so enter
+ it exactly as it was given: case, spaces and punctuation all matter, and a
+ different user ID silently recovers a different key. If you have the public
+ key, 'gpg --list-keys' shows it.
+
+ The index (--index) is a number also given to the derivation with the user
+ ID, so that one user ID can have several identities. It is 0 unless the
+ identity was created with a different one.`
+
+	timestampHelp = ` The timestamp is the Unix time (in seconds, e.g. 1523060353) the identity
+ was created with: the --time given to 'trezor-gpg init', or the time it ran
+ if none was given. It is the creation time of the public key, so if you have
+ the key, 'gpg --list-keys --with-colons' shows it as the 6th field of the
+ pub line. A wrong timestamp recovers the right keys with a different
+ fingerprint.`
+
+	ledgerTimestampHelp = ` The timestamp is the Unix time (in seconds, e.g. 1523060353) the keys were
+ created at, which is the creation time of the public key: 'gpg --list-keys
+ --with-colons' shows it as the 6th field of the pub line. A wrong timestamp
+ recovers the right keys with a different fingerprint.`
+
+	operatorHelp = ` The attestation is a statement of the recovery signed by the recovered
+ key, which names the person performing it. Enter your name as it should
+ appear there.`
+
+	seedLengthHelp = ` Enter the number of words written on your recovery seed card when the
+ Trezor was set up: 12, 18 or 24. Each word is then asked for in turn.`
+
+	seedWordHelp = ` Enter the next word of your recovery seed, in the order written on your
+ card (wrong or misspelt words are reported once they're all entered). If
+ you can't read a word, enter * in its place and it will be searched for,
+ which needs the expected fingerprint (--fingerprint or --pubkey).`
+
+	dualOperatorHelp = ` In a dual-operator recovery each operator enters half of the recovery
+ seed words, and the screen is cleared in between so neither sees the
+ other's words. Press enter once only the named operator can see the screen.`
+
+	clearScreenHelp = ` Save the private key printed above (e.g. by importing it with 'gpg
+ --import'), then press enter to clear it from the screen and the terminal's
+ scrollback.`
+
+	insertCardHelp = ` Plug the card in and press enter. The keys are only moved onto it once
+ you have confirmed its serial number.`
+
+	moveKeysHelp = ` Answer yes to move the recovered keys onto the card with this serial
+ number, replacing any keys already on it, or no to stop.`
+
+	importHelp = ` Answer yes to import the recovered identity into the keyring named, or no
+ to skip it.`
+
+	gitSigningHelp = ` Answer yes to set user.signingkey and commit.gpgsign in your global git
+ configuration so commits are signed with the recovered key, or no to
+ leave git as it is.`
+
+	passwordStoreHelp = ` Answer yes to decrypt each password in the store with the recovered key
+ and encrypt it again to the keys named, or no to leave the store as it is.
+ Passwords shared with other keys are skipped.`
+
+	searchHelp = ` The estimate is based on timing a sample of the candidates on this
+ machine. Answer yes to start the search (which can be interrupted with
+ ctrl-c, and resumed if --checkpoint was given), or no to stop and narrow
+ the search down, e.g. with fewer candidates or missing words.`
+
+	expertHelp = ` Press enter to keep the value shown, which is what 'trezor-gpg init' uses
+ unless other options were given. The purposes, KDF parameters and key flags
+ are part of the derivation or fingerprint, so must match those of the
+ original key; the self-signature hash, time and primary user ID flag only
+ change the signatures.`
+
+	resolveHelp = ` The recovered identity doesn't match the public key, so each likely cause
+ is offered in turn. Changing an answer derives the identity again from the
+ seed already entered; press enter (or answer no) to keep it and move on to
+ the next cause.`
+
+	defaultConfirmHelp = ` Answer yes or no.`
+)
+
+// showHelp displays help about a prompt, before it is asked again.
+func (r *Recovery) showHelp(help string) {
+	r.display("%s\n%s", help, `-----------------------------------------------------------------------------`)
+}
+
+// isHelp returns whether answer asks for help.
+func isHelp(answer string) bool {
+	return strings.TrimSpace(answer) == helpAnswer
+}
+
+// readLine prompts for a line of input, displaying help and prompting again
+// if the answer is helpAnswer.
+func (r *Recovery) readLine(prompt, help string) (string, error) {
+	for {
+		answer, err := r.prompter.ReadLine(r.ctx, prompt)
+		if err != nil || !isHelp(answer) {
+			return answer, err
+		}
+		r.showHelp(help)
+	}
+}
+
+// confirm asks a yes/no question, displaying help and asking again if the
+// prompter reports the answer asked for it.
+func (r *Recovery) confirm(prompt, help string) (bool, error) {
+	return confirmWithHelp(r.ctx, r.prompter, prompt, func() { r.showHelp(help) })
+}
+
+// confirmWithHelp asks p a yes/no question, calling help and asking again
+// while the answer asks for help.
+func confirmWithHelp(ctx context.Context, p Prompter, prompt string, help func()) (bool, error) {
+	for {
+		ok, err := p.Confirm(ctx, prompt)
+		if err != errHelp {
+			return ok, err
+		}
+		help()
+	}
+}
@@ -0,0 +1,42 @@
+package recovery
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+func TestPromptHelp(t *testing.T) {
+	// ask for help at each prompt before answering it (the passphrase
+	// prompt doesn't offer help, since the passphrase could be "?")
+	input := "?\nyes\n?\nAlice <alice@example.com>\n?\n1523060353\n?\n12\n?\n" + strings.Repeat("all\n", 12) + "s3cr3t\n"
+	var stdout, stderr bytes.Buffer
+	err := Run(
+		WithStdin(strings.NewReader(input)),
+		WithStdout(&stdout),
+		WithStderr(&stderr),
+	)
+	if err != nil {
+		t.Fatalf("%s\n%s", err, stderr.String())
+	}
+	for _, help := range []string{continueHelp, userIDHelp, timestampHelp, seedLengthHelp, seedWordHelp} {
+		if strings.Count(stderr.String(), help) != 1 {
+			t.Fatalf("expected the help to be displayed once:\n%s\n\ngot:\n%s", help, stderr.String())
+		}
+	}
+	entities, err := openpgp.ReadArmoredKeyRing(&stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fingerprint := formatFingerprint(entities[0].PrimaryKey); fingerprint != aliceFingerprint {
+		t.Fatalf("expected fingerprint %s, got %s", aliceFingerprint, fingerprint)
+	}
+
+	// the passphrase can be a question mark
+	entity := recoverEntity(t, strings.Replace(aliceInput, "s3cr3t\n", "?\n", 1))
+	if formatFingerprint(entity.PrimaryKey) == aliceFingerprint {
+		t.Fatal("expected a different identity with the passphrase ?")
+	}
+}
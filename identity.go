@@ -0,0 +1,162 @@
+package recovery
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/packet"
+	"golang.org/x/crypto/ssh"
+)
+
+// Identity wraps a recovered GPG identity with helpers for the things
+// consumers usually need, so they don't need to know the openpgp packet
+// internals.
+type Identity struct {
+	// Entity is the identity, including its private keys if recovered.
+	Entity *openpgp.Entity
+}
+
+// NewIdentity wraps an identity, such as one returned by Recover.
+func NewIdentity(entity *openpgp.Entity) *Identity {
+	return &Identity{Entity: entity}
+}
+
+// UserID returns the user ID of the identity.
+func (id *Identity) UserID() string {
+	return entityUserID(id.Entity)
+}
+
+// Fingerprint returns the primary key fingerprint as 40 upper case hex
+// characters.
+func (id *Identity) Fingerprint() string {
+	return formatFingerprint(id.Entity.PrimaryKey)
+}
+
+// KeyID returns the 16 character (long) key ID of the primary key.
+func (id *Identity) KeyID() string {
+	return formatKeyID(id.Entity.PrimaryKey)
+}
+
+// SubkeyFingerprint returns the fingerprint of the encryption subkey.
+func (id *Identity) SubkeyFingerprint() string {
+	return formatFingerprint(id.Entity.Subkeys[0].PublicKey)
+}
+
+// SubkeyID returns the 16 character (long) key ID of the encryption subkey.
+func (id *Identity) SubkeyID() string {
+	return formatKeyID(id.Entity.Subkeys[0].PublicKey)
+}
+
+// ArmorPublic returns the ascii armored public key, as exported with 'gpg
+// --export --armor'.
+func (id *Identity) ArmorPublic() (string, error) {
+	// the self-signatures are only made when serializing the private key
+	if id.Entity.PrivateKey != nil {
+		if err := id.Entity.SerializePrivate(ioutil.Discard, nil); err != nil {
+			return "", err
+		}
+	}
+	var out bytes.Buffer
+	enc, err := armor.Encode(&out, openpgp.PublicKeyType, nil)
+	if err != nil {
+		return "", err
+	}
+	if err := id.Entity.Serialize(enc); err != nil {
+		return "", err
+	}
+	enc.Close()
+	out.WriteByte('\n')
+	return out.String(), nil
+}
+
+// ArmorPrivate returns the ascii armored private key. It is returned as bytes
+// rather than a string so that the caller can wipe it once written.
+func (id *Identity) ArmorPrivate() ([]byte, error) {
+	return serializePrivate(id.Entity, nil)
+}
+
+// SSHPublicKey returns the primary key as an OpenSSH authorized_keys line,
+// exactly as 'gpg --export-ssh-key' exports it.
+func (id *Identity) SSHPublicKey() (string, error) {
+	key, err := publicECDSAKey(id.Entity.PrimaryKey)
+	if err != nil {
+		return "", err
+	}
+	pub, err := ssh.NewPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+	line := strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(pub)), "\n")
+	return line + " openpgp:0x" + formatShortKeyID(id.Entity.PrimaryKey), nil
+}
+
+// Keygrips returns the GnuPG keygrips of the primary key and the subkey,
+// which name their files in private-keys-v1.d and are used by gpg-agent and
+// trezor-agent to refer to them.
+func (id *Identity) Keygrips() (primary, subkey string, err error) {
+	primary, err = keygrip(id.Entity.PrimaryKey)
+	if err != nil {
+		return "", "", err
+	}
+	subkey, err = keygrip(id.Entity.Subkeys[0].PublicKey)
+	if err != nil {
+		return "", "", err
+	}
+	return primary, subkey, nil
+}
+
+// Wipe wipes the private keys of the identity.
+func (id *Identity) Wipe() {
+	wipeEntity(id.Entity)
+}
+
+// keygrip computes the keygrip of a NIST P-256 key as libgcrypt does, which
+// is the SHA-1 hash of the curve parameters and the public point as
+// S-expressions (skipping the cofactor).
+func keygrip(key *packet.PublicKey) (string, error) {
+	pub, err := publicECDSAKey(key)
+	if err != nil {
+		return "", err
+	}
+	params := pub.Curve.Params()
+	if params.Name != elliptic.P256().Params().Name {
+		return "", fmt.Errorf("unsupported curve %s", params.Name)
+	}
+	a := new(big.Int).Sub(params.P, big.NewInt(3))
+	h := sha1.New()
+	for _, c := range []struct {
+		name  string
+		value []byte
+	}{
+		{"p", params.P.Bytes()},
+		{"a", a.Bytes()},
+		{"b", params.B.Bytes()},
+		{"g", elliptic.Marshal(pub.Curve, params.Gx, params.Gy)},
+		{"n", params.N.Bytes()},
+		{"q", elliptic.Marshal(pub.Curve, pub.X, pub.Y)},
+	} {
+		fmt.Fprintf(h, "(1:%s%d:", c.name, len(c.value))
+		h.Write(c.value)
+		h.Write([]byte(")"))
+	}
+	return strings.ToUpper(hex.EncodeToString(h.Sum(nil))), nil
+}
+
+// publicECDSAKey returns the P-256 public key of a derived key, which is an
+// ECDSA key for both the primary key and the ECDH subkey.
+func publicECDSAKey(key *packet.PublicKey) (*ecdsa.PublicKey, error) {
+	pub, ok := key.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unexpected public key type %T", key.PublicKey)
+	}
+	return pub, nil
+}
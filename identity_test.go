@@ -0,0 +1,93 @@
+package recovery
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+func demoIdentity(t *testing.T) *Identity {
+	t.Helper()
+	entity, err := Recover(Params{
+		Mnemonic:   demoVector.Mnemonic,
+		Passphrase: demoVector.Passphrase,
+		UserID:     demoVector.UserID,
+		Timestamp:  time.Unix(demoVector.Timestamp, 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewIdentity(entity)
+}
+
+func TestIdentity(t *testing.T) {
+	id := demoIdentity(t)
+	defer id.Wipe()
+	if id.UserID() != demoVector.UserID {
+		t.Fatalf("unexpected user ID %q", id.UserID())
+	}
+	if id.Fingerprint() != demoVector.PrimaryFingerprint || id.KeyID() != "406D7920DCAD67C3" {
+		t.Fatalf("unexpected primary key %s (%s)", id.Fingerprint(), id.KeyID())
+	}
+	if id.SubkeyFingerprint() != demoVector.SubkeyFingerprint || !strings.HasSuffix(demoVector.SubkeyFingerprint, id.SubkeyID()) {
+		t.Fatalf("unexpected subkey %s (%s)", id.SubkeyFingerprint(), id.SubkeyID())
+	}
+
+	// the keygrips and SSH key are those shown by gpg for the demo identity
+	primary, subkey, err := id.Keygrips()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if primary != "A5DB24D96E62046DE6E5E5D6EAB66F65CDC182D1" || subkey != "B5649A746CCB8ECCB057BA0D620A18B2CAA8755E" {
+		t.Fatalf("unexpected keygrips %s and %s", primary, subkey)
+	}
+	sshKey, err := id.SSHPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "ecdsa-sha2-nistp256 AAAAE2VjZHNhLXNoYTItbmlzdHAyNTYAAAAIbmlzdHAyNTYAAABBBEJ5VuQt5O3cUisy/cK756AtgHjoIWCkSIF4i1nlvEfomjetB0SE0as1TFoPod2H6N0KIA8+m4BCnA053YVSKyk= openpgp:0xDCAD67C3"
+	if sshKey != expected {
+		t.Fatalf("unexpected SSH public key:\nexpected: %s\ngot:      %s", expected, sshKey)
+	}
+}
+
+func TestIdentityArmor(t *testing.T) {
+	id := demoIdentity(t)
+	defer id.Wipe()
+	pub, err := id.ArmorPublic()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(pub))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entities[0].PrivateKey != nil {
+		t.Fatal("expected the public key to not contain the private key")
+	}
+
+	// a public identity can still be armored and has the same keygrips
+	pubID := NewIdentity(entities[0])
+	if pubArmor, err := pubID.ArmorPublic(); err != nil || pubArmor != pub {
+		t.Fatalf("unexpected public key re-armoring (%v):\n%s", err, pubArmor)
+	}
+	primary, _, err := pubID.Keygrips()
+	if err != nil || primary != "A5DB24D96E62046DE6E5E5D6EAB66F65CDC182D1" {
+		t.Fatalf("unexpected keygrip %s: %v", primary, err)
+	}
+
+	priv, err := id.ArmorPrivate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wipe(priv)
+	entities, err = openpgp.ReadArmoredKeyRing(strings.NewReader(string(priv)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entities[0].PrivateKey == nil {
+		t.Fatal("expected the private key")
+	}
+}
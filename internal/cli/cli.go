@@ -0,0 +1,1027 @@
+// Package cli implements the trezor-gpg-recovery command, keeping the
+// terminal handling (flags, signals, output files and the standard streams)
+// out of the recovery library.
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	recovery "github.com/lmars/trezor-gpg-recovery"
+	"golang.org/x/crypto/openpgp"
+)
+
+// maxLineSize is the longest line read from stdin or a candidates file.
+const maxLineSize = 4096
+
+// Main runs the command with the given arguments (excluding the program
+// name), prompting on the terminal and writing to stdout and stderr.
+func Main(args []string) (err error) {
+	// refuse to continue if given secrets on the command line
+	if err := recovery.CheckArgs(args); err != nil {
+		return err
+	}
+
+	// parse flags
+	flags := flag.NewFlagSet("trezor-gpg-recovery", flag.ExitOnError)
+	output := flags.String("output", "", "write the private key to this file rather than stdout (removed if the recovery or any verification check fails)")
+	shares := flags.String("shares", "", "split the private key into Shamir shares in this tool's own format (not SLIP-39), e.g. 3-of-5 (written to --output with a .N suffix if given)")
+	format := flags.String("format", "armor", "the format of the private key: armor, binary (OpenPGP packets), ssh (OpenSSH primary key), pem (PKCS #8 keys), json or pkcs11 (PKCS #8 keys with PKCS #11 attributes)")
+	pkcs11WrapKey := flags.String("pkcs11-wrap-key", "", "wrap the keys written with --format pkcs11 with the AES key in this file (raw or hex) for importing into an HSM with C_UnwrapKey")
+	encrypt := flags.Bool("encrypt", false, "encrypt the private key with a random one-time passphrase which is only displayed on the terminal")
+	testDecrypt := flags.String("test-decrypt", "", "decrypt the OpenPGP message in this file with the recovered key")
+	fingerprint := flags.String("fingerprint", "", "the expected primary key fingerprint")
+	pubkey := flags.String("pubkey", "", "verify the recovered identity against the public key in this file")
+	manifestFile := flags.String("manifest", "", "recover every identity in this recovery manifest (see 'manifest init'), checking each against its fingerprint")
+	fromPubkey := flags.String("from-pubkey", "", "like --pubkey, also pre-filling the user ID and timestamp from the public key in this file and walking through the likely causes of a mismatch")
+	passphraseList := flags.String("passphrase-list", "", "search the candidate passphrases in this file (one per line) for the expected fingerprint")
+	uidList := flags.String("uid-list", "", "search the candidate user IDs in this file (one per line) for the expected fingerprint")
+	timestampList := flags.String("timestamp-list", "", "search the candidate timestamps in this file (one per line) for the expected fingerprint")
+	indexRange := flags.String("index-range", "", "search the SLIP-0013 indexes FIRST-LAST (e.g. 0-9) for the expected fingerprint")
+	searchWorkers := flags.Int("search-workers", 0, "the number of CPUs to search candidates with (default all of them)")
+	checkpoint := flags.String("checkpoint", "", "save the position of a search to this file as it runs, resuming from it if it exists")
+	session := flags.String("session", "", "save the user ID, timestamp and options to this file as they're entered, resuming with them if it exists so only the seed has to be entered again (removed once the recovery succeeds)")
+	interop := flags.Bool("interop", false, "check the recovered identity works with the system gpg (using a temporary GNUPGHOME)")
+	sequoia := flags.Bool("sequoia-check", false, "check the recovered identity against Sequoia-PGP's certificate rules (using sq if installed)")
+	thunderbird := flags.String("thunderbird", "", "write a bundle for importing the recovered identity into Thunderbird (with instructions) to this new directory rather than printing the private key, unless --output is given")
+	vault := flags.String("vault", "", "write the private key into the HashiCorp Vault at VAULT_ADDR (with VAULT_TOKEN, or prompted for) rather than printing it, unless --output is given: kv:PATH, transit:MOUNT/NAME or ssh:MOUNT")
+	sequoiaStore := flags.Bool("sequoia-store", false, "write the recovered certificate into sq's certificate store (PGP_CERT_D) and the secret key into its keystore (SEQUOIA_HOME) rather than printing the private key, unless --output is given")
+	passwordStore := flags.String("password-store", "", "offer to re-encrypt the pass(1) password store in this directory once the key is recovered")
+	passRecipients := flags.String("pass-recipients", "", "re-encrypt the password store to the public keys in these files (comma separated) rather than the recovered key")
+	defaultTrezorHome, _ := recovery.DefaultTrezorHome()
+	trezorHome := flags.String("trezor-home", defaultTrezorHome, "pre-fill the user ID and timestamp from the trezor-agent configuration in this directory if present (empty to disable)")
+	gpgAgent := flags.Bool("gpg-agent", false, "import the recovered identity into the gpg-agent of GNUPGHOME (or ~/.gnupg) rather than printing the private key, unless --output is given")
+	gpgAgentPassphrase := flags.Bool("gpg-agent-passphrase", false, "protect the keys imported with --gpg-agent with a passphrase, presetting it in the agent's cache (needs allow-preset-passphrase)")
+	gitSigning := flags.Bool("git-signing", true, "once the identity is imported with --gpg-agent, --yubikey, --nitrokey or on Tails, offer to configure git to sign commits with it")
+	yubiKey := flags.Bool("yubikey", false, "import the recovered identity into gpg-agent like --gpg-agent, then move its keys onto a YubiKey leaving only stubs on disk")
+	nitrokey := flags.Bool("nitrokey", false, "like --yubikey, but for a Nitrokey Pro 2, Storage 2, Nitrokey 3 or Nitrokey Start")
+	tails := flags.Bool("tails", true, "when running on Tails with GnuPG persistence, offer to import the recovered identity into the persistent GnuPG keyring")
+	requireOffline := flags.Bool("require-offline", false, "refuse to run while a network interface is up (the default when run interactively)")
+	allowNetwork := flags.Bool("allow-network", false, "allow running while a network interface is up")
+	preventSleep := flags.Bool("prevent-sleep", true, "stop the machine suspending or hibernating during the recovery (with systemd-inhibit, caffeinate or SetThreadExecutionState)")
+	sandbox := flags.Bool("sandbox", true, "sandbox the process with seccomp once all input has been read (Linux only)")
+	clearScreen := flags.Bool("clear-screen", false, "clear the terminal (including the scrollback where supported) once you have saved the private key")
+	screenReader := flags.Bool("screen-reader", false, "make the prompts easier to follow with a screen reader: no banner or separator lines, and seed words asked for as \"Word five of twenty-four\"")
+	largePrint := flags.Bool("large-print", false, "ask short questions one step at a time, clearing the screen after each answer, for following written instructions (e.g. as an heir)")
+	demo := flags.Bool("demo", false, "rehearse the recovery using the public \"all all all ...\" test seed (the output is watermarked)")
+	dualOperator := flags.Bool("dual-operator", false, "have two operators each enter half of the recovery seed, clearing the screen in between")
+	strict := flags.Bool("strict", false, "treat validation warnings (e.g. implausible timestamps or unusual user IDs) as errors")
+	language := flags.String("language", localeLanguage(), "the language to explain problems with the inputs in: "+strings.Join(recovery.Languages(), ", ")+" or en (defaults to the locale's language if supported)")
+	logLevel := flags.String("log-level", "info", "the least severe messages to log: debug (adding traces of the derivation, without any secrets), info or warn")
+	report := flags.String("report", "", "write a report of the recovery (without any secrets) to this file")
+	attestation := flags.String("attestation", "", "write a statement of the recovery, cleartext signed by the recovered key, to this file")
+	operator := flags.String("operator", "", "the name of the operator to record in the --attestation (prompted for if not given)")
+	auditLog := flags.String("audit-log", "", "append a log of each step of the recovery (without any secrets) to this file")
+	reportFormat := flags.String("report-format", "json", "the format of the report: json, text or html (a self-contained page with QR codes of the public key)")
+	seedFile := flags.String("seed-file", "", "read the recovery seed words from this file rather than prompting for them")
+	hexEntropy := flags.Bool("hex-entropy", false, "enter the entropy of the recovery seed in hex rather than its words")
+	passphraseTypos := flags.Bool("passphrase-typos", false, "search common typos of the entered passphrase for the expected fingerprint")
+	curve := flags.String("curve", recovery.CurveNIST256P1, "the curve of the identity (only nist256p1 is supported)")
+	index := flags.Uint("index", 0, "the SLIP-0013 index of the identity")
+	device := flags.String("device", "trezor", "the hardware wallet whose derivation the identity was created with: trezor, or ledger for the Ledger OpenPGP app's seed mode")
+	ledgerSlot := flags.Uint("ledger-slot", 0, "the Ledger OpenPGP app key slot the keys were generated in (1 to 3, default 1)")
+	sigHash := flags.String("sig-hash", "sha256", "the hash of the self-signatures: sha256, sha384 or sha512")
+	ecdhKDF := flags.String("ecdh-kdf", "sha256,aes128", "the KDF hash and cipher of the encryption subkey, e.g. sha512,aes256")
+	expert := flags.Bool("expert", false, "prompt for every derivation parameter (SLIP-0013 purposes, KDF parameters, key flags and self-signature subpackets) after the timestamp, for keys created by patched agents")
+	flags.Parse(args)
+
+	// restore the options of an interrupted session which aren't given again
+	var sessionOptions map[string]string
+	if *session != "" {
+		var err error
+		if sessionOptions, err = restoreSession(flags, *session); err != nil {
+			return err
+		}
+	}
+
+	// explain errors in the chosen language
+	if *language != "en" && !isLanguage(*language) {
+		return fmt.Errorf("unsupported language %q: must be %s or en", *language, strings.Join(recovery.Languages(), ", "))
+	}
+	defer func() {
+		if err != nil {
+			err = errors.New(recovery.Localize(err, *language))
+		}
+	}()
+
+	// run a subcommand if given, ssh-agent, check-device, plan, generate,
+	// passphrase-table and batch using the options below
+	serveSSH := flags.Arg(0) == "ssh-agent"
+	checkDevice := flags.Arg(0) == "check-device"
+	plan := flags.Arg(0) == "plan"
+	generate := flags.Arg(0) == "generate"
+	passphraseTable := flags.Arg(0) == "passphrase-table"
+	batch := flags.Arg(0) == "batch"
+	var batchIdentities []recovery.BatchIdentity
+	if *manifestFile != "" {
+		if flags.NArg() > 0 {
+			return fmt.Errorf("--manifest can't be combined with the %s command", flags.Arg(0))
+		}
+		if *shares != "" || *encrypt || *gpgAgent || *yubiKey || *nitrokey || *thunderbird != "" || *vault != "" || *sequoiaStore || *passwordStore != "" {
+			return errors.New("--manifest only writes the private keys, so cannot be combined with --shares, --encrypt, --gpg-agent, --yubikey, --nitrokey, --thunderbird, --vault, --sequoia-store or --password-store")
+		}
+		if *fingerprint != "" || *pubkey != "" || *fromPubkey != "" {
+			return errors.New("--manifest records the fingerprint of each identity, so cannot be combined with --fingerprint, --pubkey or --from-pubkey")
+		}
+		f, err := os.Open(*manifestFile)
+		if err != nil {
+			return err
+		}
+		identities, err := recovery.ParseManifest(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		for _, id := range identities {
+			batchIdentities = append(batchIdentities, id.BatchIdentity())
+		}
+		batch = true
+	} else if plan || generate {
+		if flags.NArg() > 1 {
+			return fmt.Errorf("usage: trezor-gpg-recovery [flags] %s", flags.Arg(0))
+		}
+	} else if passphraseTable {
+		if flags.NArg() > 1 {
+			return errors.New("usage: trezor-gpg-recovery [flags] passphrase-table")
+		}
+		if *output != "" || *shares != "" || *encrypt || *gpgAgent || *yubiKey || *nitrokey || *thunderbird != "" || *vault != "" || *sequoiaStore || *passwordStore != "" {
+			return errors.New("passphrase-table only prints fingerprints, so cannot be combined with --output, --shares, --encrypt, --gpg-agent, --yubikey, --nitrokey, --thunderbird, --vault, --sequoia-store or --password-store")
+		}
+	} else if batch {
+		if flags.NArg() != 2 {
+			return errors.New("usage: trezor-gpg-recovery [flags] batch FILE")
+		}
+		if *shares != "" || *encrypt || *gpgAgent || *yubiKey || *nitrokey || *thunderbird != "" || *vault != "" || *sequoiaStore || *passwordStore != "" {
+			return errors.New("batch only writes the private keys, so cannot be combined with --shares, --encrypt, --gpg-agent, --yubikey, --nitrokey, --thunderbird, --vault, --sequoia-store or --password-store")
+		}
+		f, err := os.Open(flags.Arg(1))
+		if err != nil {
+			return err
+		}
+		batchIdentities, err = recovery.ParseBatch(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	} else if checkDevice {
+		if flags.NArg() > 1 {
+			return errors.New("usage: trezor-gpg-recovery [flags] check-device")
+		}
+		if *output != "" || *shares != "" || *encrypt || *gpgAgent || *yubiKey || *nitrokey || *thunderbird != "" || *vault != "" || *sequoiaStore || *passwordStore != "" || *seedFile != "" || *hexEntropy {
+			return errors.New("check-device only reads the public keys from the Trezor, so cannot be combined with --output, --shares, --encrypt, --gpg-agent, --yubikey, --nitrokey, --thunderbird, --vault, --sequoia-store, --password-store, --seed-file or --hex-entropy")
+		}
+	} else if serveSSH {
+		if flags.NArg() > 1 {
+			return errors.New("usage: trezor-gpg-recovery [flags] ssh-agent")
+		}
+		if *output != "" || *shares != "" || *encrypt || *gpgAgent || *yubiKey || *nitrokey || *thunderbird != "" || *vault != "" || *sequoiaStore || *passwordStore != "" {
+			return errors.New("ssh-agent cannot be combined with --output, --shares, --encrypt, --gpg-agent, --yubikey, --nitrokey, --thunderbird, --vault, --sequoia-store or --password-store")
+		}
+	} else if flags.NArg() > 0 {
+		switch cmd := flags.Arg(0); cmd {
+		case "selftest":
+			return recovery.SelfTest(os.Stdout)
+		case "vectors":
+			return recovery.CheckVectors(os.Stdout)
+		case "interop":
+			return recovery.GnuPGInterop(os.Stdout)
+		case "doctor":
+			return recovery.Doctor(os.Stdout)
+		case "combine":
+			return combine(flags.Args()[1:])
+		case "publish":
+			return publish(flags.Args()[1:])
+		case "manifest":
+			return manifest(flags.Args()[1:])
+		default:
+			return fmt.Errorf("unknown command %q", cmd)
+		}
+	}
+
+	// create the output files before anything is read so they can be wiped
+	// if the recovery fails or is aborted
+	var threshold, numShares int
+	if *shares != "" {
+		if _, err := fmt.Sscanf(*shares, "%d-of-%d", &threshold, &numShares); err != nil {
+			return fmt.Errorf("invalid --shares %q: expected M-of-N (e.g. 3-of-5)", *shares)
+		}
+	}
+	var outputFiles []*os.File
+	var plannedOutputs []io.Writer
+	if *output != "" && plan {
+		plannedOutputs = []io.Writer{plannedFile(*output)}
+		if numShares > 0 {
+			plannedOutputs = make([]io.Writer, numShares)
+			for i := range plannedOutputs {
+				plannedOutputs[i] = plannedFile(fmt.Sprintf("%s.%d", *output, i+1))
+			}
+		}
+	} else if *output != "" {
+		paths := []string{*output}
+		if numShares > 0 {
+			paths = make([]string, numShares)
+			for i := range paths {
+				paths[i] = fmt.Sprintf("%s.%d", *output, i+1)
+			}
+		} else if batch {
+			paths = make([]string, len(batchIdentities))
+			for i := range paths {
+				paths[i] = fmt.Sprintf("%s.%d", *output, i+1)
+			}
+		}
+		for _, path := range paths {
+			f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+			if err != nil {
+				for _, f := range outputFiles {
+					wipeFile(f)
+				}
+				return err
+			}
+			outputFiles = append(outputFiles, f)
+		}
+	}
+
+	// cancel the recovery on Ctrl-C or termination so that secrets are wiped,
+	// quitting immediately on a second signal
+	ctx, stop := signal.NotifyContext(context.Background(), shutdownSignals()...)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	// run recovery
+	opts := []recovery.Option{
+		recovery.WithStdin(os.Stdin),
+		recovery.WithStdout(os.Stdout),
+		recovery.WithStderr(os.Stderr),
+		recovery.WithRootCheck(),
+	}
+	if numShares > 0 {
+		outputs := make([]io.Writer, numShares)
+		for i := range outputs {
+			outputs[i] = os.Stdout
+			if outputFiles != nil {
+				outputs[i] = outputFiles[i]
+			} else if plannedOutputs != nil {
+				outputs[i] = plannedOutputs[i]
+			}
+		}
+		opts = append(opts, recovery.WithShamirShares(threshold, outputs...))
+	} else if plannedOutputs != nil {
+		opts = append(opts, recovery.WithStdout(plannedOutputs[0]))
+	} else if batch && outputFiles != nil {
+		for i := range batchIdentities {
+			batchIdentities[i].Output = outputFiles[i]
+		}
+	} else if outputFiles != nil {
+		opts = append(opts, recovery.WithStdout(outputFiles[0]))
+	} else if *gpgAgent || *yubiKey || *nitrokey || *thunderbird != "" || *vault != "" || *sequoiaStore {
+		opts = append(opts, recovery.WithStdout(nil))
+	}
+	if *pkcs11WrapKey != "" {
+		if *format != "pkcs11" {
+			return errors.New("--pkcs11-wrap-key requires --format pkcs11")
+		}
+		kek, err := readWrapKey(*pkcs11WrapKey)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, recovery.WithEncoder(recovery.PKCS11Encoder(kek)))
+	} else if *format != "armor" {
+		encoder, err := recovery.NewEncoder(*format)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, recovery.WithEncoder(encoder))
+	}
+	if *strict {
+		opts = append(opts, recovery.WithStrict())
+	}
+	// remember failed checks, including ones which only warn, so the output
+	// files aren't kept if any of them fail
+	var failedCheck string
+	opts = append(opts, recovery.WithOnVerified(func(check string, err error) {
+		if err != nil && failedCheck == "" {
+			failedCheck = check
+		}
+	}))
+	if *language != "en" {
+		opts = append(opts, recovery.WithLanguage(*language))
+	}
+	if *logLevel != "info" {
+		level, err := recovery.ParseLogLevel(*logLevel)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, recovery.WithLogLevel(level))
+	}
+	// the SSH agent is for regaining network access, so only refuses to run
+	// online if asked to
+	if (*requireOffline || (isTerminal(os.Stdin) && !serveSSH)) && !*allowNetwork {
+		opts = append(opts, recovery.WithRequireOffline())
+	}
+	if *preventSleep {
+		opts = append(opts, recovery.WithSleepInhibitor())
+	}
+	if *clearScreen {
+		opts = append(opts, recovery.WithClearScreen())
+	}
+	if *screenReader {
+		opts = append(opts, recovery.WithScreenReader())
+	}
+	if *largePrint {
+		opts = append(opts, recovery.WithLargePrint())
+	}
+	if *demo {
+		opts = append(opts, recovery.WithDemo())
+	}
+	if *dualOperator {
+		opts = append(opts, recovery.WithDualOperator())
+	}
+	if *seedFile != "" && *hexEntropy {
+		return errors.New("--seed-file and --hex-entropy cannot be combined")
+	} else if *seedFile != "" {
+		opts = append(opts, recovery.WithSeedProvider(recovery.MnemonicFile(*seedFile)))
+	} else if *hexEntropy {
+		opts = append(opts, recovery.WithSeedProvider(recovery.HexEntropy()))
+	}
+	if *curve != recovery.CurveNIST256P1 {
+		opts = append(opts, recovery.WithCurve(*curve))
+	}
+	if *index != 0 {
+		opts = append(opts, recovery.WithIndex(uint32(*index)))
+	}
+	if *device != "trezor" {
+		d, err := recovery.ParseDevice(*device)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, recovery.WithDevice(d))
+	}
+	if *ledgerSlot != 0 {
+		opts = append(opts, recovery.WithLedgerSlot(uint32(*ledgerSlot)))
+	}
+	if *sigHash != "sha256" {
+		hash, err := recovery.ParseHash(*sigHash)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, recovery.WithSigHash(hash))
+	}
+	if *ecdhKDF != "sha256,aes128" {
+		kdf := strings.SplitN(*ecdhKDF, ",", 2)
+		if len(kdf) != 2 {
+			return fmt.Errorf("invalid --ecdh-kdf %q: must be HASH,CIPHER, e.g. sha512,aes256", *ecdhKDF)
+		}
+		hash, err := recovery.ParseHash(kdf[0])
+		if err != nil {
+			return err
+		}
+		cipher, err := recovery.ParseCipher(kdf[1])
+		if err != nil {
+			return err
+		}
+		opts = append(opts, recovery.WithECDHParams(hash, cipher))
+	}
+	if *expert {
+		opts = append(opts, recovery.WithExpertMode())
+	}
+	if *encrypt && plan {
+		opts = append(opts, recovery.WithEphemeralPassphrase(plannedFile("/dev/tty")))
+	} else if *encrypt {
+		// write the passphrase to the controlling terminal rather than
+		// stderr, which may be redirected along with stdout
+		tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+		if err != nil {
+			return fmt.Errorf("--encrypt needs a terminal to display the passphrase on: %s", err)
+		}
+		defer tty.Close()
+		opts = append(opts, recovery.WithEphemeralPassphrase(tty))
+	}
+	// the sandbox would stop the SSH agent accepting connections
+	if *sandbox && !serveSSH {
+		opts = append(opts, recovery.WithSandbox())
+	}
+	if *interop {
+		opts = append(opts, recovery.WithGnuPGInterop())
+	}
+	if *sequoia {
+		opts = append(opts, recovery.WithSequoiaCheck())
+	}
+	if *trezorHome != "" && !*demo && !generate && !batch && *fromPubkey == "" && *device == "trezor" {
+		config, err := recovery.ReadTrezorConfig(*trezorHome)
+		if err == nil {
+			fmt.Fprintf(os.Stderr, "Using the trezor-agent configuration in %s (pass --trezor-home= to ignore it)\n", *trezorHome)
+			opts = append(opts, recovery.WithTrezorConfig(config))
+		} else if !errors.Is(err, os.ErrNotExist) || *trezorHome != defaultTrezorHome {
+			return fmt.Errorf("could not read the trezor-agent configuration: %s", err)
+		}
+	}
+	imports := *gpgAgent || *yubiKey || *nitrokey
+	if *tails && !*demo && !imports && !serveSSH {
+		home, err := recovery.TailsGnuPGHome()
+		if err == nil {
+			opts = append(opts, recovery.WithTailsPersistence(home))
+			imports = true
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("could not check for Tails persistent storage: %s", err)
+		}
+	}
+	if _, err := exec.LookPath("git"); err == nil && *gitSigning && imports {
+		opts = append(opts, recovery.WithGitSigning())
+	}
+	if *gpgAgent {
+		opts = append(opts, recovery.WithGPGAgent(""))
+	}
+	if *gpgAgentPassphrase {
+		opts = append(opts, recovery.WithGPGAgentPassphrase())
+	}
+	if *yubiKey {
+		opts = append(opts, recovery.WithYubiKey(""))
+	}
+	if *nitrokey {
+		opts = append(opts, recovery.WithNitrokey(""))
+	}
+	if *thunderbird != "" {
+		opts = append(opts, recovery.WithThunderbirdBundle(*thunderbird))
+	}
+	if *vault != "" {
+		addr := os.Getenv("VAULT_ADDR")
+		if addr == "" {
+			return errors.New("--vault requires VAULT_ADDR to be set to the address of the Vault server")
+		}
+		opts = append(opts, recovery.WithVault(addr, os.Getenv("VAULT_TOKEN"), *vault))
+	}
+	if *sequoiaStore {
+		opts = append(opts, recovery.WithSequoiaStore("", ""))
+	}
+	if *testDecrypt != "" {
+		f, err := os.Open(*testDecrypt)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		opts = append(opts, recovery.WithTestDecrypt(f))
+	}
+	if *fingerprint != "" {
+		opts = append(opts, recovery.WithFingerprint(*fingerprint))
+	}
+	if *pubkey != "" {
+		f, err := os.Open(*pubkey)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		opts = append(opts, recovery.WithPublicKey(f))
+	}
+	if *fromPubkey != "" {
+		if *pubkey != "" {
+			return errors.New("--from-pubkey and --pubkey can't be combined")
+		}
+		f, err := os.Open(*fromPubkey)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		opts = append(opts, recovery.WithFromPublicKey(f))
+	}
+	if *passwordStore != "" {
+		recipients, err := readPublicKeys(*passRecipients)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, recovery.WithPasswordStore(*passwordStore, recipients...))
+	} else if *passRecipients != "" {
+		return errors.New("--pass-recipients requires --password-store")
+	}
+	if *passphraseList != "" {
+		passphrases, err := readLines(*passphraseList)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, recovery.WithPassphraseCandidates(passphrases))
+	}
+	if *uidList != "" {
+		userIDs, err := readLines(*uidList)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, recovery.WithUserIDCandidates(userIDs))
+	}
+	if *timestampList != "" {
+		timestamps, err := readTimestamps(*timestampList)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, recovery.WithTimestampCandidates(timestamps))
+	}
+	if *indexRange != "" {
+		indexes, err := parseIndexRange(*indexRange)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, recovery.WithIndexCandidates(indexes))
+	}
+	if *searchWorkers != 0 {
+		opts = append(opts, recovery.WithSearchWorkers(*searchWorkers))
+	}
+	if *checkpoint != "" {
+		opts = append(opts, recovery.WithSearchCheckpoint(*checkpoint))
+	}
+	if *session != "" {
+		opts = append(opts, recovery.WithSessionFile(*session, sessionOptions))
+	}
+	if *passphraseTypos {
+		opts = append(opts, recovery.WithPassphraseTypos())
+	}
+	if *report != "" && plan {
+		opts = append(opts, recovery.WithReport(plannedFile(*report), recovery.ReportFormat(*reportFormat)))
+	} else if *report != "" {
+		f, err := os.Create(*report)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		opts = append(opts, recovery.WithReport(f, recovery.ReportFormat(*reportFormat)))
+	}
+	if *attestation != "" && plan {
+		opts = append(opts, recovery.WithAttestation(plannedFile(*attestation), *operator))
+	} else if *attestation != "" {
+		f, err := os.Create(*attestation)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		opts = append(opts, recovery.WithAttestation(f, *operator))
+	} else if *operator != "" {
+		return errors.New("--operator requires --attestation")
+	}
+	if *auditLog != "" && plan {
+		opts = append(opts, recovery.WithAuditLog(plannedFile(*auditLog)))
+	} else if *auditLog != "" {
+		f, err := os.OpenFile(*auditLog, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		opts = append(opts, recovery.WithAuditLog(f))
+	}
+	if serveSSH {
+		return serveSSHAgent(ctx, opts)
+	}
+	if plan {
+		err := recovery.Plan(ctx, os.Stdout, opts...)
+		if ctx.Err() != nil {
+			err = errors.New("interrupted, aborting the plan")
+		}
+		return err
+	}
+	if passphraseTable {
+		err := recovery.PassphraseTable(ctx, opts...)
+		if ctx.Err() != nil {
+			err = errors.New("interrupted, aborting the passphrase table")
+		}
+		return err
+	}
+	if checkDevice {
+		err := recovery.CheckDevice(ctx, opts...)
+		if ctx.Err() != nil {
+			err = errors.New("interrupted, aborting the device check")
+		}
+		return err
+	}
+	if generate {
+		err = recovery.Generate(ctx, opts...)
+	} else if batch {
+		err = recovery.Batch(ctx, batchIdentities, opts...)
+	} else {
+		err = recovery.RunContext(ctx, opts...)
+	}
+	if ctx.Err() != nil {
+		err = errors.New("interrupted, aborting recovery")
+	}
+	return closeOutputs(outputFiles, err, failedCheck)
+}
+
+// closeOutputs syncs and closes the output files once the recovery has
+// finished, or wipes them if it failed with err or the failedCheck
+// verification check failed (even if the check only warned).
+func closeOutputs(files []*os.File, err error, failedCheck string) error {
+	if err == nil && failedCheck != "" && len(files) > 0 {
+		err = fmt.Errorf("the %s check failed, so the private key was not kept", failedCheck)
+	}
+	for _, f := range files {
+		if err != nil {
+			if wipeErr := wipeFile(f); wipeErr != nil {
+				err = fmt.Errorf("%s (and could not wipe %s: %s)", err, f.Name(), wipeErr)
+			}
+			continue
+		}
+		if err := f.Sync(); err != nil {
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// plannedFile stands in for a file which plan describes without creating
+// it.
+type plannedFile string
+
+func (f plannedFile) Name() string {
+	return string(f)
+}
+
+func (f plannedFile) Write([]byte) (int, error) {
+	return 0, fmt.Errorf("%s is not written by plan", string(f))
+}
+
+// manifest writes a recovery manifest template, pre-filled from the public
+// keys given and the trezor-agent configuration if present.
+func manifest(args []string) error {
+	if len(args) == 0 || args[0] != "init" {
+		return errors.New("usage: trezor-gpg-recovery manifest init [--pubkey FILE,...] [--trezor-home DIR] [--output FILE]")
+	}
+	flags := flag.NewFlagSet("trezor-gpg-recovery manifest init", flag.ExitOnError)
+	defaultTrezorHome, _ := recovery.DefaultTrezorHome()
+	pubkeys := flags.String("pubkey", "", "pre-fill the identities of the public keys in these comma separated files")
+	trezorHome := flags.String("trezor-home", defaultTrezorHome, "pre-fill the identity of the trezor-agent configuration in this directory if present (empty to disable)")
+	output := flags.String("output", "", "write the manifest to this file rather than stdout")
+	flags.Parse(args[1:])
+	if flags.NArg() > 0 {
+		return errors.New("usage: trezor-gpg-recovery manifest init [--pubkey FILE,...] [--trezor-home DIR] [--output FILE]")
+	}
+	entities, err := readPublicKeys(*pubkeys)
+	if err != nil {
+		return err
+	}
+	var identities []recovery.ManifestIdentity
+	for _, entity := range entities {
+		identities = append(identities, recovery.NewManifestIdentity(entity))
+	}
+	if *trezorHome != "" {
+		config, err := recovery.ReadTrezorConfig(*trezorHome)
+		if err == nil {
+			fmt.Fprintf(os.Stderr, "Using the trezor-agent configuration in %s (pass --trezor-home= to ignore it)\n", *trezorHome)
+			id := recovery.ManifestIdentity{UserID: config.UserID, Device: recovery.DeviceTrezor, Curve: recovery.CurveNIST256P1}
+			if config.PublicKey != nil {
+				id = recovery.NewManifestIdentity(config.PublicKey)
+			}
+			identities = append(identities, id)
+		} else if !errors.Is(err, os.ErrNotExist) || *trezorHome != defaultTrezorHome {
+			return fmt.Errorf("could not read the trezor-agent configuration: %s", err)
+		}
+	}
+	if *output == "" {
+		return recovery.WriteManifestTemplate(os.Stdout, identities...)
+	}
+	f, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if err := recovery.WriteManifestTemplate(f, identities...); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// combine combines the Shamir shares in the given files, printing the
+// private key.
+func combine(paths []string) error {
+	if len(paths) == 0 {
+		return errors.New("usage: trezor-gpg-recovery combine SHARE...")
+	}
+	var readers []io.Reader
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		readers = append(readers, f)
+	}
+	return recovery.CombineShares(os.Stdout, readers...)
+}
+
+// publish uploads the public key in a file to GitHub or GitLab, reading the
+// API token from the environment or stdin. It never asks for the recovery
+// seed, so is meant to be run on an online machine.
+func publish(args []string) error {
+	flags := flag.NewFlagSet("trezor-gpg-recovery publish", flag.ExitOnError)
+	apiURL := flags.String("api-url", "", "the API URL of a self-hosted forge, e.g. https://gitlab.example.com/api/v4")
+	ssh := flags.Bool("ssh", true, "also upload the primary key as an SSH public key")
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		return errors.New("usage: trezor-gpg-recovery publish [--api-url URL] [--ssh=false] github|gitlab PUBKEY")
+	}
+	forge := recovery.Forge(flags.Arg(0))
+	tokenEnv := map[recovery.Forge]string{recovery.GitHub: "GITHUB_TOKEN", recovery.GitLab: "GITLAB_TOKEN"}[forge]
+	if tokenEnv == "" {
+		return fmt.Errorf("unknown forge %q: must be github or gitlab", forge)
+	}
+	entities, err := readPublicKeys(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	if len(entities) != 1 {
+		return fmt.Errorf("expected one public key in %s, got %d", flags.Arg(1), len(entities))
+	}
+	if entities[0].PrivateKey != nil {
+		return fmt.Errorf("%s contains a private key: export just the public key (e.g. with 'gpg --export --armor') so the private key never touches an online machine", flags.Arg(1))
+	}
+	id := recovery.NewIdentity(entities[0])
+
+	token := os.Getenv(tokenEnv)
+	if token == "" {
+		if !isTerminal(os.Stdin) {
+			return fmt.Errorf("%s is not set", tokenEnv)
+		}
+		scopes := "the write:gpg_key and write:public_key scopes"
+		if forge == recovery.GitLab {
+			scopes = "the api scope"
+		}
+		fmt.Fprintf(os.Stderr, "Please enter a %s personal access token with %s (or set %s):\n", forge.Name(), scopes, tokenEnv)
+		s := bufio.NewScanner(os.Stdin)
+		s.Buffer(make([]byte, maxLineSize), maxLineSize)
+		if !s.Scan() {
+			if err := s.Err(); err == bufio.ErrTooLong {
+				return fmt.Errorf("the token is longer than %d bytes", maxLineSize)
+			} else if err != nil {
+				return err
+			}
+			return io.ErrUnexpectedEOF
+		}
+		token = strings.TrimSpace(s.Text())
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), shutdownSignals()...)
+	defer stop()
+	fmt.Fprintf(os.Stderr, "Publishing the public key of %s (%s) to %s\n", id.UserID(), id.Fingerprint(), forge.Name())
+	keys := []struct {
+		name    string
+		publish func(context.Context, recovery.Forge, string, string, *recovery.Identity) (bool, error)
+	}{
+		{"GPG key", recovery.PublishGPGKey},
+		{"SSH key", recovery.PublishSSHKey},
+	}
+	if !*ssh {
+		keys = keys[:1]
+	}
+	for _, key := range keys {
+		existed, err := key.publish(ctx, forge, *apiURL, token, id)
+		if err != nil {
+			return fmt.Errorf("could not upload the %s: %s", key.name, err)
+		}
+		if existed {
+			fmt.Fprintf(os.Stderr, "The %s is already on your %s account\n", key.name, forge.Name())
+		} else {
+			fmt.Fprintf(os.Stderr, "Uploaded the %s to your %s account\n", key.name, forge.Name())
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Commits signed with the key are shown as verified if their committer email is in its user ID and a verified email address of your account\n")
+	return nil
+}
+
+// serveSSHAgent recovers the identity and serves its SSH key over a unix
+// socket in a private temporary directory until ctx is cancelled (e.g. on
+// SIGINT), so the key is never written to disk.
+func serveSSHAgent(ctx context.Context, opts []recovery.Option) error {
+	// listen before recovering so the recovery isn't wasted if this fails
+	dir, err := ioutil.TempDir("", "trezor-gpg-recovery-ssh-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "agent.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+
+	result, err := recovery.RecoverInteractive(ctx, opts...)
+	if ctx.Err() != nil {
+		return errors.New("interrupted, aborting recovery")
+	} else if err != nil {
+		return err
+	}
+	defer result.Wipe()
+	sshKey, err := result.Identity().SSHPublicKey()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "\nServing the SSH key of %s until interrupted (Ctrl-C):\n\n  %s\n\nTo use it, run the following in another shell:\n\n", result.UserID, sshKey)
+	fmt.Printf("SSH_AUTH_SOCK=%s; export SSH_AUTH_SOCK;\n", path)
+	fmt.Fprintln(os.Stderr)
+	err = recovery.ServeSSHAgent(ctx, l, result, func(fingerprint string) {
+		fmt.Fprintf(os.Stderr, "%s: signed with %s\n", time.Now().Format("15:04:05"), fingerprint)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "Stopped the SSH agent and wiped the key")
+	return nil
+}
+
+// wipeFile overwrites a partially written output file with zeros and removes
+// it, so that fragments of the private key aren't left on disk.
+func wipeFile(f *os.File) error {
+	defer os.Remove(f.Name())
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteAt(make([]byte, info.Size()), 0); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// restoreSession sets the flags saved in the session file at path which
+// weren't given on the command line, returning the flags to save in it.
+func restoreSession(flags *flag.FlagSet, path string) (map[string]string, error) {
+	options := make(map[string]string)
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name != "session" {
+			options[f.Name] = f.Value.String()
+		}
+	})
+	saved, err := recovery.ReadSessionFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return options, nil
+	} else if err != nil {
+		return nil, err
+	}
+	for name, value := range saved.Options {
+		if _, ok := options[name]; ok {
+			continue
+		}
+		if err := flags.Set(name, value); err != nil {
+			return nil, fmt.Errorf("could not restore --%s from the session file %s: %s", name, path, err)
+		}
+		options[name] = value
+	}
+	return options, nil
+}
+
+// localeLanguage returns the language of the locale set in the environment
+// (e.g. "es" for LANG=es_ES.UTF-8) if messages are translated into it, or en.
+func localeLanguage() string {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		locale := os.Getenv(env)
+		if locale == "" {
+			continue
+		}
+		if i := strings.IndexAny(locale, "_.@"); i != -1 {
+			locale = locale[:i]
+		}
+		if isLanguage(locale) {
+			return locale
+		}
+		return "en"
+	}
+	return "en"
+}
+
+// isLanguage reports whether messages are translated into lang.
+func isLanguage(lang string) bool {
+	for _, l := range recovery.Languages() {
+		if l == lang {
+			return true
+		}
+	}
+	return false
+}
+
+// readLines reads the lines of the given file, keeping any leading or
+// trailing whitespace since it may be significant.
+func readLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var lines []string
+	s := bufio.NewScanner(f)
+	s.Buffer(make([]byte, maxLineSize), maxLineSize)
+	for s.Scan() {
+		lines = append(lines, strings.TrimSuffix(s.Text(), "\r"))
+	}
+	if err := s.Err(); err == bufio.ErrTooLong {
+		return nil, fmt.Errorf("could not read %s: line %d is longer than %d bytes", path, len(lines)+1, maxLineSize)
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read %s: %s", path, err)
+	}
+	return lines, nil
+}
+
+// readPublicKeys reads the armored or binary public keys in the comma
+// separated files in paths.
+func readPublicKeys(paths string) ([]*openpgp.Entity, error) {
+	if paths == "" {
+		return nil, nil
+	}
+	var entities []*openpgp.Entity
+	for _, path := range strings.Split(paths, ",") {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+		if err != nil {
+			keyring, err = openpgp.ReadKeyRing(bytes.NewReader(data))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read public key %s: %s", path, err)
+		}
+		entities = append(entities, keyring...)
+	}
+	return entities, nil
+}
+
+// readWrapKey reads a 128, 192 or 256 bit AES key from the given file,
+// either as raw bytes or hex encoded.
+func readWrapKey(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if key, err := hex.DecodeString(strings.TrimSpace(string(data))); err == nil {
+		data = key
+	}
+	switch len(data) {
+	case 16, 24, 32:
+		return data, nil
+	default:
+		return nil, fmt.Errorf("invalid wrapping key in %s: must be a 16, 24 or 32 byte AES key", path)
+	}
+}
+
+// readTimestamps reads the Unix timestamps in the given file, one per line,
+// ignoring blank lines.
+func readTimestamps(path string) ([]time.Time, error) {
+	lines, err := readLines(path)
+	if err != nil {
+		return nil, err
+	}
+	var timestamps []time.Time
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		timestamp, err := recovery.ParseTimestamp(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d of %s: %s", i+1, path, err)
+		}
+		timestamps = append(timestamps, timestamp)
+	}
+	return timestamps, nil
+}
+
+// parseIndexRange parses a range of SLIP-0013 indexes FIRST-LAST, or a
+// single index.
+func parseIndexRange(s string) ([]uint32, error) {
+	parts := strings.SplitN(s, "-", 2)
+	if len(parts) == 1 {
+		parts = append(parts, parts[0])
+	}
+	first, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid index range %q: must be FIRST-LAST", s)
+	}
+	last, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil || last < first {
+		return nil, fmt.Errorf("invalid index range %q: must be FIRST-LAST", s)
+	}
+	if last-first >= 1<<16 {
+		return nil, fmt.Errorf("invalid index range %q: too many indexes", s)
+	}
+	indexes := make([]uint32, 0, last-first+1)
+	for i := first; i <= last; i++ {
+		indexes = append(indexes, uint32(i))
+	}
+	return indexes, nil
+}
@@ -0,0 +1,21 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package cli
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// shutdownSignals returns the signals which cancel the recovery: Ctrl-C,
+// termination, and the terminal being closed unless SIGHUP is ignored (e.g.
+// by nohup, to keep a long search running after logging out).
+func shutdownSignals() []os.Signal {
+	signals := []os.Signal{os.Interrupt, syscall.SIGTERM}
+	if !signal.Ignored(syscall.SIGHUP) {
+		signals = append(signals, syscall.SIGHUP)
+	}
+	return signals
+}
@@ -0,0 +1,13 @@
+package cli
+
+import (
+	"os"
+	"syscall"
+)
+
+// shutdownSignals returns the signals which cancel the recovery: Ctrl-C and
+// Ctrl-Break (os.Interrupt), and the console window being closed or the user
+// logging off or shutting down (which Go delivers as SIGTERM).
+func shutdownSignals() []os.Signal {
+	return []os.Signal{os.Interrupt, syscall.SIGTERM}
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+// +build !windows
+
+package cli
+
+import "os"
+
+// isTerminal returns whether the given file is a terminal (i.e. the recovery
+// is being run interactively).
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
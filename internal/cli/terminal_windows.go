@@ -0,0 +1,16 @@
+package cli
+
+import (
+	"os"
+	"syscall"
+)
+
+// isTerminal returns whether the given file is a console (i.e. the recovery
+// is being run interactively). Unlike on Unix this can't check for a
+// character device, since NUL is one too. Terminals such as mintty which
+// connect programs with pipes aren't consoles, so run the recovery there with
+// winpty.
+func isTerminal(f *os.File) bool {
+	var mode uint32
+	return syscall.GetConsoleMode(syscall.Handle(f.Fd()), &mode) == nil
+}
@@ -0,0 +1,236 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// WithGnuPGInterop configures the recovered identity to be checked with the
+// system gpg once it has been recovered (see GnuPGInterop).
+//
+// Note that this writes the private key to a temporary GNUPGHOME, which is
+// removed once the check completes.
+func WithGnuPGInterop() Option {
+	return func(r *Recovery) {
+		r.gnupgInterop = true
+	}
+}
+
+// GnuPGInterop checks that identities derived from the built-in test vectors
+// work with the system gpg, writing a pass/fail line for each step to w. For
+// each identity, the private key is imported into a temporary GNUPGHOME and
+// messages are signed, verified, encrypted and decrypted by both gpg and this
+// program.
+func GnuPGInterop(w io.Writer) error {
+	failed := 0
+	for _, v := range testVectors {
+		fmt.Fprintf(w, "%s:\n", v.Name)
+		entity, err := newEntity(v.Mnemonic, v.Passphrase, v.UserID, time.Unix(v.Timestamp, 0))
+		if err != nil {
+			return err
+		}
+		if err := checkGnuPG(context.Background(), entity, w); err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("GnuPG interop failed for %d of %d test vectors", failed, len(testVectors))
+	}
+	fmt.Fprintf(w, "GnuPG interop passed: %d test vectors\n", len(testVectors))
+	return nil
+}
+
+// checkGnuPG imports entity into a temporary GNUPGHOME and checks gpg can
+// use it, writing the result of each step to w and returning the first
+// error. Running gpg is stopped if ctx is cancelled.
+func checkGnuPG(ctx context.Context, entity *openpgp.Entity, w io.Writer) error {
+	gpg, err := newGnuPG(ctx)
+	if err != nil {
+		fmt.Fprintf(w, "  FAIL  %s\n", err)
+		return err
+	}
+	defer gpg.Close()
+
+	c := &checklist{w: w}
+	step := c.step
+	fingerprint := formatFingerprint(entity.PrimaryKey)
+	msg := []byte("Trezor GPG Recovery interop test\n")
+
+	step("gpg imports the private key", func() error {
+		privKey, err := serializePrivate(entity, nil)
+		if err != nil {
+			return err
+		}
+		defer wipe(privKey)
+		if _, err := gpg.run(privKey, "--import"); err != nil {
+			return err
+		}
+		out, err := gpg.run(nil, "--with-colons", "--list-secret-keys", fingerprint)
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(string(out), fingerprint) {
+			return errors.New("imported key not listed")
+		}
+		return nil
+	})
+
+	var gpgSig []byte
+	step("gpg signs a message", func() (err error) {
+		gpgSig, err = gpg.run(msg, "--local-user", fingerprint, "--detach-sign")
+		return err
+	})
+	step("gpg verifies its signature", func() error {
+		return gpg.verify(msg, gpgSig)
+	})
+	step("gpg's signature verifies here", func() error {
+		_, err := openpgp.CheckDetachedSignature(openpgp.EntityList{entity}, bytes.NewReader(msg), bytes.NewReader(gpgSig))
+		return err
+	})
+	step("gpg verifies a signature made here", func() error {
+		var sig bytes.Buffer
+		if err := openpgp.DetachSign(&sig, entity, bytes.NewReader(msg), nil); err != nil {
+			return err
+		}
+		return gpg.verify(msg, sig.Bytes())
+	})
+
+	var gpgEncrypted []byte
+	step("gpg encrypts a message", func() (err error) {
+		gpgEncrypted, err = gpg.run(msg, "--recipient", fingerprint, "--encrypt")
+		return err
+	})
+	step("gpg decrypts its message", func() error {
+		return gpg.decrypt(gpgEncrypted, msg)
+	})
+	step("gpg's message decrypts here", func() error {
+		decrypted, err := decryptMessage(bytes.NewReader(gpgEncrypted), entity.Subkeys[0].PrivateKey)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(decrypted, msg) {
+			return errors.New("decrypted message does not match")
+		}
+		return nil
+	})
+	step("gpg decrypts a message encrypted here", func() error {
+		var encrypted bytes.Buffer
+		if err := encryptMessage(&encrypted, rand.Reader, entity.Subkeys[0].PublicKey, msg); err != nil {
+			return err
+		}
+		return gpg.decrypt(encrypted.Bytes(), msg)
+	})
+	return c.err
+}
+
+// checklist runs a series of checks, writing a pass/fail line for each one
+// and skipping the remaining checks after the first failure.
+type checklist struct {
+	w   io.Writer
+	err error
+}
+
+func (c *checklist) step(name string, fn func() error) {
+	if c.err != nil {
+		return
+	}
+	if err := fn(); err != nil {
+		fmt.Fprintf(c.w, "  FAIL  %s: %s\n", name, err)
+		c.err = fmt.Errorf("%s: %s", name, err)
+		return
+	}
+	fmt.Fprintf(c.w, "  PASS  %s\n", name)
+}
+
+// gnupg runs the system gpg with a home directory, which is temporary unless
+// opened with openGnuPG.
+type gnupg struct {
+	ctx  context.Context
+	path string
+	home string
+}
+
+func newGnuPG(ctx context.Context) (*gnupg, error) {
+	path, err := lookGPG()
+	if err != nil {
+		return nil, err
+	}
+	home, err := ioutil.TempDir("", "trezor-gpg-recovery-")
+	if err != nil {
+		return nil, err
+	}
+	return &gnupg{ctx: ctx, path: path, home: home}, nil
+}
+
+// lookGPG returns the path of the system gpg.
+func lookGPG() (string, error) {
+	path, err := exec.LookPath("gpg")
+	if err != nil {
+		if path, err = exec.LookPath("gpg2"); err != nil {
+			return "", errors.New("gpg not found in PATH")
+		}
+	}
+	return path, nil
+}
+
+func (g *gnupg) run(stdin []byte, args ...string) ([]byte, error) {
+	args = append([]string{"--homedir", g.home, "--batch", "--no-tty", "--quiet", "--trust-model", "always"}, args...)
+	cmd := exec.CommandContext(g.ctx, g.path, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %s", err, msg)
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
+
+func (g *gnupg) verify(msg, sig []byte) error {
+	sigFile, err := ioutil.TempFile(g.home, "sig-")
+	if err != nil {
+		return err
+	}
+	defer sigFile.Close()
+	if _, err := sigFile.Write(sig); err != nil {
+		return err
+	}
+	_, err = g.run(msg, "--verify", sigFile.Name(), "-")
+	return err
+}
+
+func (g *gnupg) decrypt(encrypted, expected []byte) error {
+	decrypted, err := g.run(encrypted, "--decrypt")
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(decrypted, expected) {
+		return errors.New("decrypted message does not match")
+	}
+	return nil
+}
+
+// Close stops any gpg-agent started for the temporary home directory and
+// removes it.
+func (g *gnupg) Close() error {
+	if gpgconf, err := exec.LookPath("gpgconf"); err == nil {
+		cmd := exec.Command(gpgconf, "--kill", "gpg-agent")
+		cmd.Env = append(os.Environ(), "GNUPGHOME="+g.home)
+		cmd.Run()
+	}
+	return os.RemoveAll(g.home)
+}
@@ -0,0 +1,21 @@
+package recovery
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestGnuPGInterop(t *testing.T) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg not found in PATH")
+	}
+	var out bytes.Buffer
+	if err := GnuPGInterop(&out); err != nil {
+		t.Fatalf("%s\n%s", err, out.String())
+	}
+	if strings.Contains(out.String(), "FAIL") {
+		t.Fatalf("unexpected failure:\n%s", out.String())
+	}
+}
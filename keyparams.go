@@ -0,0 +1,424 @@
+package recovery
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	slip10 "github.com/lmars/go-slip10"
+	"github.com/lmars/trezor-gpg-recovery/derive"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// KeyFlags are the OpenPGP key flags of a key's self-signature, see RFC 4880,
+// section 5.2.3.21.
+type KeyFlags uint8
+
+const (
+	KeyFlagCertify               KeyFlags = packet.KeyFlagCertify
+	KeyFlagSign                  KeyFlags = packet.KeyFlagSign
+	KeyFlagEncryptCommunications KeyFlags = packet.KeyFlagEncryptCommunications
+	KeyFlagEncryptStorage        KeyFlags = packet.KeyFlagEncryptStorage
+)
+
+// Device is the hardware wallet whose scheme the identity was derived with.
+type Device string
+
+const (
+	// DeviceTrezor derives the identity as trezor-agent does, from the user
+	// ID with SLIP-0013 (the default).
+	DeviceTrezor Device = "trezor"
+
+	// DeviceLedger derives the identity as the Ledger OpenPGP app does in
+	// seed mode, from the key slot (see WithLedgerSlot) rather than the user
+	// ID.
+	DeviceLedger Device = "ledger"
+)
+
+// keyParams are the parameters used to derive and serialize the identity.
+type keyParams struct {
+	curve          string
+	device         Device
+	index          uint32
+	ledgerSlot     uint32
+	primaryPurpose uint32
+	subkeyPurpose  uint32
+	sigHash        crypto.Hash
+	kdfHash        crypto.Hash
+	kdfCipher      packet.CipherFunction
+	primaryFlags   KeyFlags
+	subkeyFlags    KeyFlags
+	primaryUserID  bool
+
+	// sigTime is the creation time of the self-signatures, which is the
+	// timestamp of the keys if zero
+	sigTime time.Time
+}
+
+// defaultKeyParams are the parameters 'trezor-gpg init' uses.
+var defaultKeyParams = keyParams{
+	curve:          CurveNIST256P1,
+	primaryPurpose: derive.PrimaryPurpose,
+	subkeyPurpose:  derive.SubkeyPurpose,
+	sigHash:        crypto.SHA256,
+	kdfHash:        crypto.SHA256,
+	kdfCipher:      packet.CipherAES128,
+	primaryFlags:   KeyFlagCertify | KeyFlagSign,
+	subkeyFlags:    KeyFlagEncryptCommunications | KeyFlagEncryptStorage,
+	primaryUserID:  true,
+}
+
+// keyParams returns the parameters to derive the identity with, which can be
+// changed by options.
+func (r *Recovery) keyParams() *keyParams {
+	if r.keys == nil {
+		params := defaultKeyParams
+		r.keys = &params
+	}
+	return r.keys
+}
+
+// WithCurve configures the curve of the derived keys. Only CurveNIST256P1
+// (the default) is currently supported.
+func WithCurve(curve string) Option {
+	return func(r *Recovery) {
+		r.keyParams().curve = curve
+	}
+}
+
+// WithIndex configures the SLIP-0013 index of the identity, which is 0 for
+// identities created by 'trezor-gpg init'.
+func WithIndex(index uint32) Option {
+	return func(r *Recovery) {
+		r.keyParams().index = index
+	}
+}
+
+// WithDevice configures the hardware wallet whose derivation scheme the
+// identity is recovered with (DeviceTrezor by default).
+//
+// With DeviceLedger the signature key of the Ledger OpenPGP app's key slot
+// is the primary key and its decryption key is the subkey (the
+// authentication key isn't recovered). Only NIST P-256 keys are supported,
+// so the app must have been configured with them before generating the
+// keys, and as the keys don't depend on the user ID, any user ID recreates
+// the same keys, so it is best to also give WithFingerprint or
+// WithPublicKey.
+func WithDevice(device Device) Option {
+	return func(r *Recovery) {
+		r.keyParams().device = device
+	}
+}
+
+// WithLedgerSlot configures the key slot of the Ledger OpenPGP app the keys
+// were generated in, numbered from 1 as the app displays them (1 by
+// default). It requires WithDevice(DeviceLedger).
+func WithLedgerSlot(slot uint32) Option {
+	return func(r *Recovery) {
+		r.keyParams().ledgerSlot = slot
+	}
+}
+
+// ParseDevice parses the name of a device supported by WithDevice (e.g.
+// "ledger").
+func ParseDevice(name string) (Device, error) {
+	switch device := Device(strings.ToLower(name)); device {
+	case DeviceTrezor, DeviceLedger:
+		return device, nil
+	case "onlykey":
+		// an OnlyKey's keys come from secrets held on the device, which are
+		// restored from its encrypted backup rather than from a seed
+		return "", errors.New("OnlyKey identities can't be derived from a recovery seed: restore the OnlyKey's encrypted backup with the OnlyKey app instead")
+	default:
+		return "", fmt.Errorf("unknown device %q: must be trezor or ledger", name)
+	}
+}
+
+// WithSigHash configures the hash algorithm of the self-signatures (SHA256
+// by default, or SHA384 or SHA512).
+func WithSigHash(hash crypto.Hash) Option {
+	return func(r *Recovery) {
+		r.keyParams().sigHash = hash
+	}
+}
+
+// WithECDHParams configures the RFC 6637 KDF parameters of the encryption
+// subkey (SHA256 and AES128 by default). They are part of the subkey's
+// fingerprint, so must match those of the original key.
+func WithECDHParams(hash crypto.Hash, cipher packet.CipherFunction) Option {
+	return func(r *Recovery) {
+		params := r.keyParams()
+		params.kdfHash = hash
+		params.kdfCipher = cipher
+	}
+}
+
+// WithKeyFlags configures the key flags of the primary key (certify and sign
+// by default) and the subkey (encrypt communications and storage by
+// default).
+func WithKeyFlags(primary, subkey KeyFlags) Option {
+	return func(r *Recovery) {
+		params := r.keyParams()
+		params.primaryFlags = primary
+		params.subkeyFlags = subkey
+	}
+}
+
+// WithPurposes configures the SLIP-0013 purposes the primary key and subkey
+// are derived with (13 and 17 by default, as trezor-agent uses), for
+// identities created by agents patched to use others.
+func WithPurposes(primary, subkey uint32) Option {
+	return func(r *Recovery) {
+		params := r.keyParams()
+		params.primaryPurpose = primary
+		params.subkeyPurpose = subkey
+	}
+}
+
+// WithPrimaryUserID configures whether the self-signature of the user ID
+// has the primary user ID subpacket (true by default).
+func WithPrimaryUserID(primary bool) Option {
+	return func(r *Recovery) {
+		r.keyParams().primaryUserID = primary
+	}
+}
+
+// WithSignatureTime configures the creation time of the self-signatures,
+// which is the timestamp of the keys by default. It isn't part of the
+// fingerprint, but agents which signed at a later time give different
+// signatures.
+func WithSignatureTime(t time.Time) Option {
+	return func(r *Recovery) {
+		r.keyParams().sigTime = t
+	}
+}
+
+// check checks the parameters are supported before prompting for anything.
+func (p *keyParams) check() error {
+	if p.curve != CurveNIST256P1 {
+		return fmt.Errorf("unsupported curve %q: only %s is supported", p.curve, CurveNIST256P1)
+	}
+	switch p.device {
+	case "", DeviceTrezor:
+		if p.ledgerSlot != 0 {
+			return fmt.Errorf("a Ledger key slot was given, but %s keys are being recovered", p.deviceName())
+		}
+	case DeviceLedger:
+		if p.index != 0 {
+			return errors.New("the SLIP-0013 index only applies to Trezor identities, the Ledger key slot is given with WithLedgerSlot")
+		}
+		if p.ledgerSlot > derive.LedgerSlots {
+			return fmt.Errorf("invalid Ledger key slot %d: must be 1 to %d", p.ledgerSlot, derive.LedgerSlots)
+		}
+	default:
+		return fmt.Errorf("unknown device %q: must be %s or %s", p.device, DeviceTrezor, DeviceLedger)
+	}
+	if p.primaryPurpose >= slip10.FirstHardenedChild || p.subkeyPurpose >= slip10.FirstHardenedChild {
+		return fmt.Errorf("invalid SLIP-0013 purposes %d and %d: must be less than %d", p.primaryPurpose, p.subkeyPurpose, uint32(slip10.FirstHardenedChild))
+	}
+	if !isStrongHash(p.sigHash) {
+		return fmt.Errorf("unsupported signature hash %s: must be SHA256, SHA384 or SHA512", p.sigHash)
+	}
+	if !isStrongHash(p.kdfHash) {
+		return fmt.Errorf("unsupported ECDH KDF hash %s: must be SHA256, SHA384 or SHA512", p.kdfHash)
+	}
+	switch p.kdfCipher {
+	case packet.CipherAES128, packet.CipherAES192, packet.CipherAES256:
+	default:
+		return fmt.Errorf("unsupported ECDH KDF cipher %s: must be AES128, AES192 or AES256", cipherName(p.kdfCipher))
+	}
+	if p.primaryFlags&KeyFlagCertify == 0 {
+		return fmt.Errorf("invalid primary key flags %s: the primary key must be able to certify", p.primaryFlags)
+	}
+	if p.primaryFlags&(KeyFlagEncryptCommunications|KeyFlagEncryptStorage) != 0 {
+		return fmt.Errorf("invalid primary key flags %s: the ECDSA primary key can't encrypt", p.primaryFlags)
+	}
+	if p.subkeyFlags&(KeyFlagCertify|KeyFlagSign) != 0 {
+		return fmt.Errorf("invalid subkey flags %s: the ECDH subkey can't certify or sign", p.subkeyFlags)
+	}
+	return nil
+}
+
+// checkLedger checks the options are consistent with recovering a Ledger
+// identity before prompting for anything.
+func (r *Recovery) checkLedger() error {
+	if r.keyParams().device != DeviceLedger {
+		return nil
+	}
+	switch {
+	case r.demo:
+		return errors.New("the demo identity is a Trezor identity, so can't be recovered as a Ledger one")
+	case r.trezorConfig != nil:
+		return errors.New("a trezor-agent configuration can't be used to recover a Ledger identity")
+	case len(r.userIDs) > 1:
+		return errors.New("the keys of a Ledger identity don't depend on the user ID, so there are no user IDs to search")
+	case r.indexes != nil:
+		return errors.New("Ledger identities don't have a SLIP-0013 index to search")
+	}
+	return nil
+}
+
+// deviceName returns the name of the device the identity is derived for.
+func (p *keyParams) deviceName() string {
+	if p.device == DeviceLedger {
+		return "Ledger"
+	}
+	return "Trezor"
+}
+
+// slot returns the Ledger key slot, which is 1 unless configured.
+func (p *keyParams) slot() uint32 {
+	if p.ledgerSlot == 0 {
+		return 1
+	}
+	return p.ledgerSlot
+}
+
+// masterKey generates the master key of the device's scheme for a seed and
+// passphrase, which the caller should wipe.
+func (p *keyParams) masterKey(seed Seed, passphrase string) (*slip10.Key, error) {
+	masterSeed, err := seed.MasterSeed(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer wipe(masterSeed)
+	if p.device == DeviceLedger {
+		return derive.LedgerMasterKey(masterSeed)
+	}
+	return derive.MasterKey(masterSeed)
+}
+
+// deriveKeys derives the GPG primary and sub keys for the given user ID from
+// a master key generated by masterKey.
+func (p *keyParams) deriveKeys(masterKey *slip10.Key, userID string) (primaryKey, subKey *ecdsa.PrivateKey, err error) {
+	if p.device == DeviceLedger {
+		primaryKey, err = derive.LedgerKey(masterKey, p.slot(), derive.LedgerSignatureKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		subKey, err = derive.LedgerKey(masterKey, p.slot(), derive.LedgerDecryptionKey)
+		if err != nil {
+			wipeKey(primaryKey)
+			return nil, nil, err
+		}
+		return primaryKey, subKey, nil
+	}
+	uri := derive.URI(userID)
+	primaryKey, err = derive.PurposeKey(masterKey, p.primaryPurpose, uri, p.index)
+	if err != nil {
+		return nil, nil, err
+	}
+	subKey, err = derive.PurposeKey(masterKey, p.subkeyPurpose, uri, p.index)
+	if err != nil {
+		wipeKey(primaryKey)
+		return nil, nil, err
+	}
+	return primaryKey, subKey, nil
+}
+
+// String describes the parameters for debug messages.
+func (p *keyParams) String() string {
+	desc := fmt.Sprintf("device %s, curve %s", p.deviceName(), p.curve)
+	if p.device == DeviceLedger {
+		desc += fmt.Sprintf(", slot %d", p.slot())
+	} else {
+		desc += fmt.Sprintf(", index %d", p.index)
+		if p.primaryPurpose != derive.PrimaryPurpose || p.subkeyPurpose != derive.SubkeyPurpose {
+			desc += fmt.Sprintf(", purposes %d and %d", p.primaryPurpose, p.subkeyPurpose)
+		}
+	}
+	return desc + fmt.Sprintf(", signature hash %s, ECDH KDF %s with %s, primary key flags %s, subkey flags %s", p.sigHash, p.kdfHash, cipherName(p.kdfCipher), p.primaryFlags, p.subkeyFlags)
+}
+
+// paths returns the derivation paths of the primary key and subkey for the
+// given user ID, for the report and audit log.
+func (p *keyParams) paths(userID string) (primary, subkey string) {
+	if p.device == DeviceLedger {
+		path := derive.LedgerPath(p.slot())
+		return path + " (sig)", path + " (dec)"
+	}
+	uri := derive.URI(userID)
+	return derive.Path(p.primaryPurpose, uri, p.index), derive.Path(p.subkeyPurpose, uri, p.index)
+}
+
+func isStrongHash(hash crypto.Hash) bool {
+	return hash == crypto.SHA256 || hash == crypto.SHA384 || hash == crypto.SHA512
+}
+
+func (f KeyFlags) String() string {
+	var names []string
+	for _, flag := range []struct {
+		flag KeyFlags
+		name string
+	}{
+		{KeyFlagCertify, "certify"},
+		{KeyFlagSign, "sign"},
+		{KeyFlagEncryptCommunications, "encrypt-communications"},
+		{KeyFlagEncryptStorage, "encrypt-storage"},
+	} {
+		if f&flag.flag != 0 {
+			names = append(names, flag.name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ",")
+}
+
+// ParseKeyFlags parses comma separated key flags as KeyFlags.String formats
+// them (e.g. "certify,sign", or "none").
+func ParseKeyFlags(s string) (KeyFlags, error) {
+	var flags KeyFlags
+	if strings.TrimSpace(s) == "none" {
+		return 0, nil
+	}
+	for _, name := range strings.Split(s, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "certify":
+			flags |= KeyFlagCertify
+		case "sign":
+			flags |= KeyFlagSign
+		case "encrypt-communications":
+			flags |= KeyFlagEncryptCommunications
+		case "encrypt-storage":
+			flags |= KeyFlagEncryptStorage
+		default:
+			return 0, fmt.Errorf("unknown key flag %q: must be certify, sign, encrypt-communications or encrypt-storage", name)
+		}
+	}
+	return flags, nil
+}
+
+// ParseHash parses the name of a hash algorithm supported by WithSigHash and
+// WithECDHParams (e.g. "sha256").
+func ParseHash(name string) (crypto.Hash, error) {
+	switch strings.ToUpper(name) {
+	case "SHA256":
+		return crypto.SHA256, nil
+	case "SHA384":
+		return crypto.SHA384, nil
+	case "SHA512":
+		return crypto.SHA512, nil
+	default:
+		return 0, fmt.Errorf("unknown hash %q: must be sha256, sha384 or sha512", name)
+	}
+}
+
+// ParseCipher parses the name of an ECDH KDF cipher supported by
+// WithECDHParams (e.g. "aes128").
+func ParseCipher(name string) (packet.CipherFunction, error) {
+	switch strings.ToUpper(name) {
+	case "AES128":
+		return packet.CipherAES128, nil
+	case "AES192":
+		return packet.CipherAES192, nil
+	case "AES256":
+		return packet.CipherAES256, nil
+	default:
+		return 0, fmt.Errorf("unknown cipher %q: must be aes128, aes192 or aes256", name)
+	}
+}
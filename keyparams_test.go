@@ -0,0 +1,181 @@
+package recovery
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/lmars/trezor-gpg-recovery/derive"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+func TestKeyParamsIndex(t *testing.T) {
+	var out bytes.Buffer
+	entity := recoverEntity(t, aliceInput, WithIndex(1), WithReport(&out, ReportJSON))
+	if fingerprint := fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint); fingerprint == aliceFingerprint {
+		t.Fatal("expected a different fingerprint at index 1")
+	}
+	var rep report
+	if err := json.Unmarshal(out.Bytes(), &rep); err != nil {
+		t.Fatal(err)
+	}
+	uri := "gpg://Alice <alice@example.com>"
+	if path := derive.Path(derive.PrimaryPurpose, uri, 1); rep.PrimaryKey.Path != path {
+		t.Fatalf("expected primary key path %s, got %s", path, rep.PrimaryKey.Path)
+	}
+	if path := derive.Path(derive.SubkeyPurpose, uri, 1); rep.Subkey.Path != path {
+		t.Fatalf("expected subkey path %s, got %s", path, rep.Subkey.Path)
+	}
+}
+
+func TestKeyParamsSigHash(t *testing.T) {
+	entity := recoverEntity(t, aliceInput, WithSigHash(crypto.SHA512))
+	if fingerprint := fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint); fingerprint != aliceFingerprint {
+		t.Fatalf("expected fingerprint %s, got %s", aliceFingerprint, fingerprint)
+	}
+	for _, id := range entity.Identities {
+		if id.SelfSignature.Hash != crypto.SHA512 {
+			t.Fatalf("expected a SHA512 self-signature, got %s", id.SelfSignature.Hash)
+		}
+	}
+	if hash := entity.Subkeys[0].Sig.Hash; hash != crypto.SHA512 {
+		t.Fatalf("expected a SHA512 subkey binding signature, got %s", hash)
+	}
+}
+
+func TestKeyParamsECDH(t *testing.T) {
+	entity := recoverEntity(t, aliceInput, WithECDHParams(crypto.SHA512, packet.CipherAES256))
+	if fingerprint := fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint); fingerprint != aliceFingerprint {
+		t.Fatalf("expected fingerprint %s, got %s", aliceFingerprint, fingerprint)
+	}
+	params, err := readECDHParams(entity.Subkeys[0].PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.kdfHash != crypto.SHA512 || params.kdfAlgo != packet.CipherAES256 {
+		t.Fatalf("unexpected ECDH KDF parameters: %s, %s", params.kdfHash, cipherName(params.kdfAlgo))
+	}
+}
+
+func TestKeyParamsKeyFlags(t *testing.T) {
+	entity := recoverEntity(t, aliceInput, WithKeyFlags(KeyFlagCertify, KeyFlagEncryptCommunications))
+	for _, id := range entity.Identities {
+		if !id.SelfSignature.FlagCertify || id.SelfSignature.FlagSign {
+			t.Fatal("expected the primary key to only be able to certify")
+		}
+	}
+	sig := entity.Subkeys[0].Sig
+	if !sig.FlagEncryptCommunications || sig.FlagEncryptStorage {
+		t.Fatal("expected the subkey to only be able to encrypt communications")
+	}
+}
+
+func TestKeyParamsLedger(t *testing.T) {
+	var out bytes.Buffer
+	entity := recoverEntity(t, aliceInput, WithDevice(DeviceLedger), WithLedgerSlot(2), WithReport(&out, ReportJSON))
+	seed, err := NewMnemonicSeed(bytes.Fields([]byte(strings.Repeat("all ", 12))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	masterSeed, err := seed.MasterSeed("s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signatureKey, decryptionKey, err := derive.LedgerKeys(masterSeed, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	primaryKey, err := privateECDSAKey(entity.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !primaryKey.Equal(signatureKey) {
+		t.Fatal("expected the primary key to be the slot's signature key")
+	}
+	subkey, err := privateECDSAKey(entity.Subkeys[0].PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !subkey.Equal(decryptionKey) {
+		t.Fatal("expected the subkey to be the slot's decryption key")
+	}
+	var rep report
+	if err := json.Unmarshal(out.Bytes(), &rep); err != nil {
+		t.Fatal(err)
+	}
+	if path := "m/4673607'/2 (sig)"; rep.PrimaryKey.Path != path {
+		t.Fatalf("expected primary key path %s, got %s", path, rep.PrimaryKey.Path)
+	}
+
+	// the user ID doesn't change the keys
+	other := recoverEntity(t, strings.Replace(aliceInput, "Alice", "Bob", 1), WithDevice(DeviceLedger), WithLedgerSlot(2))
+	if fingerprint := formatFingerprint(other.PrimaryKey); fingerprint != formatFingerprint(entity.PrimaryKey) {
+		t.Fatalf("expected the same fingerprint for a different user ID, got %s", fingerprint)
+	}
+
+	for _, test := range []struct {
+		opts []Option
+		err  string
+	}{
+		{[]Option{WithDevice("nitrokey")}, "unknown device"},
+		{[]Option{WithLedgerSlot(2)}, "a Ledger key slot was given"},
+		{[]Option{WithDevice(DeviceLedger), WithLedgerSlot(4)}, "invalid Ledger key slot 4"},
+		{[]Option{WithDevice(DeviceLedger), WithIndex(1)}, "only applies to Trezor identities"},
+		{[]Option{WithDevice(DeviceLedger), WithDemo()}, "the demo identity is a Trezor identity"},
+		{[]Option{WithDevice(DeviceLedger), WithFingerprint(aliceFingerprint), WithUserIDCandidates([]string{"Alice", "Bob"})}, "no user IDs to search"},
+	} {
+		err := Run(append(test.opts, WithStdin(strings.NewReader(aliceInput)), WithStdout(ioutil.Discard))...)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Fatalf("expected error containing %q, got %v", test.err, err)
+		}
+	}
+	if device, err := ParseDevice("Ledger"); err != nil || device != DeviceLedger {
+		t.Fatalf("unexpected device %s: %v", device, err)
+	}
+	if _, err := ParseDevice("onlykey"); err == nil || !strings.Contains(err.Error(), "encrypted backup") {
+		t.Fatalf("expected an OnlyKey backup error, got %v", err)
+	}
+}
+
+func TestKeyParamsInvalid(t *testing.T) {
+	for _, test := range []struct {
+		opt Option
+		err string
+	}{
+		{WithCurve("ed25519"), `unsupported curve "ed25519"`},
+		{WithSigHash(crypto.SHA1), "unsupported signature hash"},
+		{WithECDHParams(crypto.MD5, packet.CipherAES128), "unsupported ECDH KDF hash"},
+		{WithECDHParams(crypto.SHA256, packet.CipherCAST5), "unsupported ECDH KDF cipher"},
+		{WithKeyFlags(KeyFlagSign, KeyFlagEncryptStorage), "the primary key must be able to certify"},
+		{WithKeyFlags(KeyFlagCertify|KeyFlagEncryptStorage, KeyFlagEncryptStorage), "the ECDSA primary key can't encrypt"},
+		{WithKeyFlags(KeyFlagCertify, KeyFlagSign), "the ECDH subkey can't certify or sign"},
+	} {
+		var stdout strings.Builder
+		err := Run(WithStdin(strings.NewReader(aliceInput)), WithStdout(&stdout), test.opt)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Fatalf("expected error containing %q, got %v", test.err, err)
+		}
+		if stdout.Len() > 0 {
+			t.Fatal("expected no output")
+		}
+	}
+}
+
+func TestParseHashAndCipher(t *testing.T) {
+	if hash, err := ParseHash("sha384"); err != nil || hash != crypto.SHA384 {
+		t.Fatalf("unexpected hash %s: %v", hash, err)
+	}
+	if _, err := ParseHash("sha1"); err == nil {
+		t.Fatal("expected an error parsing sha1")
+	}
+	if cipher, err := ParseCipher("AES192"); err != nil || cipher != packet.CipherAES192 {
+		t.Fatalf("unexpected cipher %s: %v", cipherName(cipher), err)
+	}
+	if _, err := ParseCipher("cast5"); err == nil {
+		t.Fatal("expected an error parsing cast5")
+	}
+}
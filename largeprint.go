@@ -0,0 +1,102 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// WithLargePrint configures simplified terminal prompts for someone
+// following written instructions, such as an heir: each question is short,
+// numbered as a step and spaced out on its own, and the screen is cleared
+// once it has been answered. A terminal's text can't be enlarged by the
+// program itself, so the banner explains how to do it. Prompters given with
+// WithPrompter are unaffected.
+func WithLargePrint() Option {
+	return func(r *Recovery) {
+		r.largePrint = true
+	}
+}
+
+// largePrintBanner replaces the warning banner in large print mode.
+const largePrintBanner = `
+  TREZOR GPG RECOVERY
+
+  This gets your GPG key back from its recovery card.
+  Answer one question at a time, then press Enter.
+  Type ? and press Enter if you need help with a question.
+
+  To make the text bigger, press Ctrl and + together.
+`
+
+// shortPrompts are the simplified forms of the prompts of a recovery in
+// large print mode, with prompts not listed shown as they are.
+var shortPrompts = map[string]string{
+	"Are you sure you want to continue with the recovery?":                                           "Start the recovery?",
+	"Are you sure you want to continue the recovery as root?":                                        "Carry on as the root user?",
+	`Please enter your GPG User ID (ex: "Alice <alice@example.com>"):`:                               "Type the user ID from your instructions,\nfor example: Alice <alice@example.com>",
+	"Please enter the timestamp from the original 'trezor-gpg init' command:":                        "Type the timestamp from your instructions\n(a long number, for example 1523060353)",
+	"Please enter the creation timestamp of the keys (as shown by 'gpg --list-keys --with-colons'):": "Type the timestamp from your instructions\n(a long number, for example 1523060353)",
+	"How many words are in your Recovery Seed? (12, 18 or 24):":                                      "How many words are on the recovery card?\nType 12, 18 or 24",
+	"Please enter your passphrase (leave blank if you don't use one):":                               "Type the passphrase.\nIf there isn't one, just press Enter",
+	"Press enter once you have saved the private key to clear the screen:":                           "Press Enter once the key is saved",
+}
+
+// shortPrompt returns the simplified form of prompt, keeping the value
+// offered by a prompt ending in " [VALUE]" and the answers of a yes/no
+// question.
+func shortPrompt(prompt string) string {
+	var offered, answers string
+	if strings.HasSuffix(prompt, " (yes/no):") {
+		prompt, answers = strings.TrimSuffix(prompt, " (yes/no):"), "\nType yes or no"
+	}
+	if i := strings.LastIndex(prompt, " ["); i != -1 && strings.HasSuffix(prompt, "]") {
+		prompt, offered = prompt[:i], prompt[i+2:len(prompt)-1]
+	}
+	if short, ok := shortPrompts[prompt]; ok {
+		prompt = short
+	}
+	if offered != "" {
+		prompt += "\nOr just press Enter to use: " + offered
+	}
+	return prompt + answers
+}
+
+// largePrompter lays out the prompts of a terminalPrompter in large print
+// mode.
+type largePrompter struct {
+	// steps is the number of prompts answered, not counting asking for
+	// help, so that the steps match written instructions
+	steps int
+}
+
+// prompt writes prompt as the next step.
+func (l *largePrompter) prompt(t *terminalPrompter, prompt string) {
+	fmt.Fprintf(t.w, "\n  STEP %d\n\n%s\n> ", l.steps+1, largeText(prompt))
+}
+
+// largeText returns the simplified form of prompt, indented.
+func largeText(prompt string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(shortPrompt(prompt), "\n") {
+		fmt.Fprintf(&b, "  %s\n", line)
+	}
+	return b.String()
+}
+
+// readLine prompts for and reads a line, clearing the screen once it has
+// been answered.
+func (l *largePrompter) readLine(ctx context.Context, t *terminalPrompter, prompt string) ([]byte, error) {
+	l.prompt(t, prompt)
+	line, err := t.scanLine(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	// compared as bytes since line may be a seed word, which mustn't be
+	// copied into a string
+	if !bytes.Equal(bytes.TrimSpace(line), []byte(helpAnswer)) {
+		l.steps++
+	}
+	return line, clearTerminal(t.w)
+}
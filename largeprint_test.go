@@ -0,0 +1,46 @@
+package recovery
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+func TestLargePrint(t *testing.T) {
+	// asking for help at the user ID prompt doesn't count as a step
+	input := strings.Replace(aliceInput, "Alice", "?\nAlice", 1)
+	var stdout, stderr bytes.Buffer
+	err := Run(
+		WithStdin(strings.NewReader(input)),
+		WithStdout(&stdout),
+		WithStderr(&stderr),
+		WithLargePrint(),
+	)
+	if err != nil {
+		t.Fatalf("%s\n%s", err, stderr.String())
+	}
+	if _, err := openpgp.ReadArmoredKeyRing(&stdout); err != nil {
+		t.Fatal(err)
+	}
+	out := stderr.String()
+	for _, expected := range []string{
+		largePrintBanner,
+		"STEP 1\n\n  Start the recovery?\n  Type yes or no\n",
+		"STEP 2\n\n  Type the user ID from your instructions,",
+		"STEP 3\n\n  Type the timestamp from your instructions",
+		"STEP 5\n\n  Type word 1 of 12 from the recovery card\n",
+		"STEP 17\n\n  Type the passphrase.",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Fatalf("expected %q, got:\n%s", expected, out)
+		}
+	}
+	if strings.Count(out, "STEP 2\n") != 2 {
+		t.Fatalf("expected the user ID step to be asked again after the help, got:\n%s", out)
+	}
+	if strings.Count(out, clearScreen) != 18 {
+		t.Fatalf("expected the screen to be cleared after each answer, got %d times", strings.Count(out, clearScreen))
+	}
+}
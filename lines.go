@@ -0,0 +1,33 @@
+package recovery
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// maxLineSize is the longest line read from a configuration file such as a
+// password store's .gpg-id or trezor-agent's gpg.conf, which is far longer
+// than any line they should have.
+const maxLineSize = 4096
+
+// newLineScanner returns a scanner of the lines of r which fails on lines
+// longer than maxLineSize rather than growing its buffer.
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 512), maxLineSize)
+	return s
+}
+
+// scanErr returns the error scanning the file at path stopped with, if any,
+// explaining a line which was too long.
+func scanErr(s *bufio.Scanner, path string) error {
+	switch err := s.Err(); err {
+	case nil:
+		return nil
+	case bufio.ErrTooLong:
+		return fmt.Errorf("could not read %s: a line is longer than %d bytes", path, maxLineSize)
+	default:
+		return fmt.Errorf("could not read %s: %s", path, err)
+	}
+}
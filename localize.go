@@ -0,0 +1,123 @@
+package recovery
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// WithLanguage configures the language warnings are displayed in, as an ISO
+// 639-1 code (one of Languages), so that a problem with the inputs is
+// explained in the user's own language. Errors are returned in English, and
+// can be explained in the same language with Localize. Messages which haven't
+// been translated, and the report and audit log, stay in English.
+func WithLanguage(lang string) Option {
+	return func(r *Recovery) {
+		r.language = lang
+	}
+}
+
+// Languages returns the languages messages are translated into, other than
+// English.
+func Languages() []string {
+	langs := make([]string, 0, len(translations))
+	for lang := range translations {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Localize returns the message of err translated into lang, leaving any part
+// of it which hasn't been translated in English.
+func Localize(err error, lang string) string {
+	catalog, ok := translations[lang]
+	if !ok {
+		return err.Error()
+	}
+	return localize(catalog, err)
+}
+
+// localizable is implemented by errors whose message can be translated, by
+// returning the English format and arguments it is built from.
+type localizable interface {
+	message() (format string, args []interface{})
+}
+
+// message is an argument of a localizable error which is translated too, such
+// as the name of a wordlist.
+type message string
+
+// localizedError is an error built like fmt.Errorf, keeping the format and
+// arguments so that it can be translated.
+type localizedError struct {
+	err    error
+	format string
+	args   []interface{}
+}
+
+// errorf returns an error like fmt.Errorf (so it wraps any %w argument)
+// which Localize can translate.
+func errorf(format string, args ...interface{}) error {
+	return &localizedError{err: fmt.Errorf(format, args...), format: format, args: args}
+}
+
+func (e *localizedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *localizedError) Unwrap() error {
+	return errors.Unwrap(e.err)
+}
+
+func (e *localizedError) message() (string, []interface{}) {
+	return e.format, e.args
+}
+
+// localize translates err with catalog: localizable errors are translated
+// by their format and arguments, and other errors (such as the exported
+// sentinel errors) if their whole message has been translated.
+func localize(catalog map[string]string, err error) string {
+	if l, ok := err.(localizable); ok {
+		format, args := l.message()
+		return sprintf(catalog, format, args)
+	}
+	if translated, ok := catalog[err.Error()]; ok {
+		return translated
+	}
+	return err.Error()
+}
+
+// sprintf formats the translation of format in catalog with args, with their
+// errors and messages translated too.
+func sprintf(catalog map[string]string, format string, args []interface{}) string {
+	if translated, ok := catalog[format]; ok {
+		format = translated
+	}
+	localized := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch arg := arg.(type) {
+		case error:
+			localized[i] = localize(catalog, arg)
+		case message:
+			if translated, ok := catalog[string(arg)]; ok {
+				localized[i] = translated
+			} else {
+				localized[i] = string(arg)
+			}
+		default:
+			localized[i] = arg
+		}
+	}
+	return fmt.Sprintf(strings.Replace(format, "%w", "%s", -1), localized...)
+}
+
+// translate formats a message for the user in the configured language.
+func (r *Recovery) translate(format string, args ...interface{}) string {
+	catalog, ok := translations[r.language]
+	if !ok {
+		return fmt.Sprintf(format, args...)
+	}
+	return sprintf(catalog, format, args)
+}
@@ -0,0 +1,77 @@
+package recovery
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLocalize(t *testing.T) {
+	r := &Recovery{}
+	words := bytes.Fields([]byte("all all all all all all all all all all all able"))
+	err := r.checkMnemonic(words)
+	if !errors.Is(err, ErrInvalidMnemonic) {
+		t.Fatalf("expected ErrInvalidMnemonic, got %v", err)
+	}
+	if msg := Localize(err, "es"); msg != "semilla de recuperación no válida: la suma de verificación es incorrecta (compruebe que las palabras se introdujeron correctamente y en el orden correcto)" {
+		t.Fatalf("unexpected Spanish message %q", msg)
+	}
+	if msg := Localize(err, "en"); msg != err.Error() {
+		t.Fatalf("expected the English message, got %q", msg)
+	}
+
+	// check arguments which are messages are translated
+	italian := otherWordlists[1]
+	for i := range words {
+		words[i] = []byte(italian.words[i])
+	}
+	err = r.checkMnemonic(words)
+	if msg := Localize(err, "de"); !strings.Contains(msg, "aus der BIP39-Wortliste Italienisch zu stammen") {
+		t.Fatalf("expected the wordlist name to be translated, got %q", msg)
+	}
+
+	// check untranslated parts stay in English
+	err = errorf("%w: %s", ErrInvalidTimestamp, errors.New("something else"))
+	if msg := Localize(err, "fr"); msg != "horodatage invalide: something else" {
+		t.Fatalf("unexpected French message %q", msg)
+	}
+	if !errors.Is(err, ErrInvalidTimestamp) {
+		t.Fatal("expected errorf to wrap ErrInvalidTimestamp")
+	}
+}
+
+func TestWithLanguage(t *testing.T) {
+	var report bytes.Buffer
+	logger := &recordingLogger{}
+	recoverEntity(t, strings.Replace(aliceInput, "Alice <alice", "Alice  <alice", 1),
+		WithLogger(logger),
+		WithLanguage("es"),
+		WithReport(&report, ReportJSON),
+	)
+	log := strings.Join(logger.lines, "\n")
+	if !strings.Contains(log, "WARN el ID de usuario contiene espacios repetidos") {
+		t.Fatalf("expected the user ID warning in Spanish, got:\n%s", log)
+	}
+	if !strings.Contains(report.String(), "the user ID contains repeated spaces") {
+		t.Fatalf("expected the report to stay in English, got:\n%s", report.String())
+	}
+}
+
+// formatVerb matches the verbs of a format string.
+var formatVerb = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+func TestTranslations(t *testing.T) {
+	for lang, catalog := range translations {
+		for format, translated := range catalog {
+			expected := strings.Join(formatVerb.FindAllString(format, -1), " ")
+			if actual := strings.Join(formatVerb.FindAllString(translated, -1), " "); actual != expected {
+				t.Errorf("%s translation of %q has verbs %q, expected %q", lang, format, actual, expected)
+			}
+		}
+	}
+	if langs := strings.Join(Languages(), ","); langs != "de,es,fr" {
+		t.Fatalf("unexpected languages %s", langs)
+	}
+}
@@ -0,0 +1,160 @@
+package recovery
+
+import (
+	"fmt"
+	"io"
+)
+
+// LogLevel is the least severe level of message which is logged.
+type LogLevel int
+
+const (
+	// LogDebug logs traces of each step of the derivation for debugging, as
+	// well as everything LogInfo does. Like every log message they never
+	// contain secrets, just the derivation paths, key parameters and the
+	// fingerprints of the keys derived.
+	LogDebug LogLevel = iota - 1
+
+	// LogInfo logs progress messages and warnings, and is the default.
+	LogInfo
+
+	// LogWarn only logs warnings and errors.
+	LogWarn
+)
+
+// ParseLogLevel returns the LogLevel called debug, info or warn.
+func ParseLogLevel(s string) (LogLevel, error) {
+	for _, level := range []LogLevel{LogDebug, LogInfo, LogWarn} {
+		if s == level.String() {
+			return level, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q: must be debug, info or warn", s)
+}
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogDebug:
+		return "debug"
+	case LogInfo:
+		return "info"
+	case LogWarn:
+		return "warn"
+	default:
+		return fmt.Sprintf("LogLevel(%d)", int(l))
+	}
+}
+
+// WithLogLevel configures the least severe level of message which is logged,
+// which defaults to LogInfo.
+func WithLogLevel(level LogLevel) Option {
+	return func(r *Recovery) {
+		r.logLevel = level
+	}
+}
+
+// LogField is a named value attached to a log message, for loggers which
+// record structured data.
+type LogField struct {
+	Key   string
+	Value interface{}
+}
+
+// Logger receives the recovery's progress, warning and error messages, so
+// that embedders can route them into their own logging.
+//
+// Secrets are kept out of it by construction: messages and fields are only
+// ever built from values which aren't secret (e.g. counts, fingerprints,
+// user IDs and timestamps), and anything the user needs to see which is
+// secret (such as the passphrase a search found) is only displayed on the
+// terminal.
+type Logger interface {
+	Info(msg string, fields ...LogField)
+	Warn(msg string, fields ...LogField)
+	Error(msg string, fields ...LogField)
+}
+
+// DebugLogger is a Logger which also receives debug messages, which are
+// otherwise passed to Info when enabled with WithLogLevel(LogDebug).
+type DebugLogger interface {
+	Logger
+	Debug(msg string, fields ...LogField)
+}
+
+// WithLogger configures the logger the recovery's messages are written to,
+// rather than writing them to stderr.
+func WithLogger(l Logger) Option {
+	return func(r *Recovery) {
+		r.logger = l
+	}
+}
+
+// textLogger is the default Logger, which writes each message on a line of
+// w. The fields are not written since the messages already include them.
+type textLogger struct {
+	w io.Writer
+}
+
+func (l textLogger) Debug(msg string, fields ...LogField) {
+	fmt.Fprintln(l.w, "DEBUG: "+msg)
+}
+
+func (l textLogger) Info(msg string, fields ...LogField) {
+	fmt.Fprintln(l.w, msg)
+}
+
+func (l textLogger) Warn(msg string, fields ...LogField) {
+	fmt.Fprintln(l.w, "WARNING: "+msg)
+}
+
+func (l textLogger) Error(msg string, fields ...LogField) {
+	fmt.Fprintln(l.w, msg)
+}
+
+// activeLogger returns the configured logger, or a textLogger writing to
+// stderr if there isn't one.
+func (r *Recovery) activeLogger() Logger {
+	if r.logger == nil {
+		return textLogger{w: r.stderr}
+	}
+	return r.logger
+}
+
+// debug logs a trace of the derivation if debug messages are enabled.
+func (r *Recovery) debug(msg string, fields ...LogField) {
+	if r.logLevel > LogDebug {
+		return
+	}
+	if l, ok := r.activeLogger().(DebugLogger); ok {
+		l.Debug(msg, fields...)
+		return
+	}
+	r.activeLogger().Info(msg, fields...)
+}
+
+// debugEnabled reports whether debug messages are logged, for callers which
+// would otherwise do extra work to build them.
+func (r *Recovery) debugEnabled() bool {
+	return r.logLevel <= LogDebug
+}
+
+// info logs a progress message unless only warnings are logged.
+func (r *Recovery) info(msg string, fields ...LogField) {
+	if r.logLevel > LogInfo {
+		return
+	}
+	r.activeLogger().Info(msg, fields...)
+}
+
+// display shows text on the terminal (i.e. stderr) which is part of the
+// interactive interface rather than a log message, such as banners, prompts
+// and the summary of the recovered identity.
+func (r *Recovery) display(format string, args ...interface{}) {
+	text := fmt.Sprintf(format, args...)
+	if r.screenReader {
+		if text = plainText(text); text == "" {
+			return
+		}
+	}
+	fmt.Fprintln(r.stderr, text)
+}
@@ -0,0 +1,130 @@
+package recovery
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// recordingLogger records the messages and fields it is given.
+type recordingLogger struct {
+	lines []string
+}
+
+func (l *recordingLogger) record(level, msg string, fields []LogField) {
+	line := level + " " + msg
+	for _, f := range fields {
+		line += fmt.Sprintf(" %s=%v", f.Key, f.Value)
+	}
+	l.lines = append(l.lines, line)
+}
+
+func (l *recordingLogger) Info(msg string, fields ...LogField)  { l.record("INFO", msg, fields) }
+func (l *recordingLogger) Warn(msg string, fields ...LogField)  { l.record("WARN", msg, fields) }
+func (l *recordingLogger) Error(msg string, fields ...LogField) { l.record("ERROR", msg, fields) }
+
+func TestWithLogger(t *testing.T) {
+	var stderr bytes.Buffer
+	logger := &recordingLogger{}
+	input := strings.TrimSuffix(aliceInput, "s3cr3t\n")
+	recoverEntity(t, input,
+		WithStderr(&stderr),
+		WithLogger(logger),
+		WithFingerprint("ab86c8c7b5136d19b0a6aec0406d7920dcad67c3"),
+		WithPassphraseCandidates([]string{"secret", "s3cr3t"}),
+	)
+	log := strings.Join(logger.lines, "\n")
+
+	// check the progress messages went to the logger
+	for _, expected := range []string{
+		"INFO Searching 2 candidate passphrases for fingerprint AB86C8C7B5136D19B0A6AEC0406D7920DCAD67C3... candidates=2",
+		"INFO Found matching passphrase (candidate 2 of 2) candidate=2 candidates=2",
+	} {
+		if !strings.Contains(log, expected) {
+			t.Fatalf("expected %q to be logged, got:\n%s", expected, log)
+		}
+	}
+	if strings.Contains(log, "s3cr3t") {
+		t.Fatalf("expected the passphrase not to be logged, got:\n%s", log)
+	}
+	if strings.Contains(stderr.String(), "Searching") {
+		t.Fatalf("expected log messages not to be written to stderr, got:\n%s", stderr.String())
+	}
+	if !strings.Contains(stderr.String(), `Matching passphrase: "s3cr3t"`) {
+		t.Fatalf("expected the matching passphrase to be displayed, got:\n%s", stderr.String())
+	}
+}
+
+func TestWithLoggerWarning(t *testing.T) {
+	var stderr bytes.Buffer
+	logger := &recordingLogger{}
+	recoverEntity(t, strings.Replace(aliceInput, "Alice <alice", "Alice  <alice", 1),
+		WithStderr(&stderr),
+		WithLogger(logger),
+	)
+	log := strings.Join(logger.lines, "\n")
+	if !strings.Contains(log, "WARN the user ID contains repeated spaces") {
+		t.Fatalf("expected the user ID warning to be logged, got:\n%s", log)
+	}
+	if strings.Contains(stderr.String(), "WARNING: the user ID") {
+		t.Fatalf("expected the warning not to be written to stderr, got:\n%s", stderr.String())
+	}
+}
+
+func TestLogLevel(t *testing.T) {
+	input := strings.TrimSuffix(aliceInput, "s3cr3t\n")
+	candidates := WithPassphraseCandidates([]string{"secret", "s3cr3t"})
+
+	// check debug messages are passed to Info by loggers without Debug
+	logger := &recordingLogger{}
+	recoverEntity(t, input, WithLogger(logger), WithLogLevel(LogDebug), WithFingerprint(aliceFingerprint), candidates)
+	log := strings.Join(logger.lines, "\n")
+	for _, expected := range []string{
+		"INFO Step: recovery seed entered step=recovery seed entered",
+		"INFO Deriving with key parameters: device Trezor, curve nist256p1, index 0,",
+		"INFO Searching candidates 1 to 2 on 1 workers",
+		"INFO Derived candidate 2: user ID \"Alice <alice@example.com>\", timestamp 1523060353, primary key AB86C8C7B5136D19B0A6AEC0406D7920DCAD67C3 at m/13'/",
+	} {
+		if !strings.Contains(log, expected) {
+			t.Fatalf("expected %q to be logged, got:\n%s", expected, log)
+		}
+	}
+	if strings.Contains(log, "s3cr3t") {
+		t.Fatalf("expected the passphrase not to be logged, got:\n%s", log)
+	}
+
+	// check only warnings are logged at LogWarn
+	logger = &recordingLogger{}
+	recoverEntity(t, strings.Replace(aliceInput, "Alice <alice", "Alice  <alice", 1), WithLogger(logger), WithLogLevel(LogWarn))
+	for _, line := range logger.lines {
+		if !strings.HasPrefix(line, "WARN ") {
+			t.Fatalf("expected only warnings to be logged, got %q", line)
+		}
+	}
+	if len(logger.lines) == 0 {
+		t.Fatal("expected the user ID warning to be logged")
+	}
+
+	// check the default text logger prefixes debug messages
+	var stderr bytes.Buffer
+	recoverEntity(t, aliceInput, WithStderr(&stderr), WithLogLevel(LogDebug))
+	if !strings.Contains(stderr.String(), "DEBUG: Step: identity derived") {
+		t.Fatalf("expected debug messages on stderr, got:\n%s", stderr.String())
+	}
+}
+
+func TestParseLogLevel(t *testing.T) {
+	for _, level := range []LogLevel{LogDebug, LogInfo, LogWarn} {
+		parsed, err := ParseLogLevel(level.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed != level {
+			t.Fatalf("expected %s, got %s", level, parsed)
+		}
+	}
+	if _, err := ParseLogLevel("trace"); err == nil {
+		t.Fatal("expected an error for an unknown level")
+	}
+}
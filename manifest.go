@@ -0,0 +1,226 @@
+package recovery
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// ManifestIdentity is an identity recorded in a recovery manifest, with
+// everything needed to recover it except the recovery seed and passphrase.
+// Unknown fields are left zero, and written blank to be filled in by hand.
+type ManifestIdentity struct {
+	UserID      string
+	Timestamp   time.Time
+	Device      Device
+	Curve       string
+	Index       uint32
+	Fingerprint string
+}
+
+// NewManifestIdentity returns the manifest entry of the Trezor identity with
+// the given public key.
+func NewManifestIdentity(pub *openpgp.Entity) ManifestIdentity {
+	return ManifestIdentity{
+		UserID:      entityUserID(pub),
+		Timestamp:   pub.PrimaryKey.CreationTime,
+		Device:      DeviceTrezor,
+		Curve:       keyCurve(pub.PrimaryKey),
+		Fingerprint: formatFingerprint(pub.PrimaryKey),
+	}
+}
+
+// manifestHeader explains a recovery manifest to whoever finds it with the
+// seed backup.
+const manifestHeader = `# TREZOR GPG RECOVERY MANIFEST
+#
+# This records everything needed to recover the GPG identities below with
+# trezor-gpg-recovery except the recovery seed and passphrase. It contains
+# no secrets, so print it and keep it with the seed backup. Fill in any blank
+# values by hand, but never write the seed or passphrase here.
+#
+#   user-id      the user ID given to 'trezor-gpg init', exactly as typed
+#                (in double quotes if it starts or ends with a space)
+#   timestamp    the Unix time the identity was created at
+#   device       trezor, or ledger for the Ledger OpenPGP app in seed mode
+#   curve        the curve of the keys (nist256p1)
+#   index        the SLIP-0013 index of a Trezor identity (0 unless another
+#                was used), or the key slot of a Ledger one
+#   fingerprint  the primary key fingerprint the recovered identity must have
+#
+# To recover the identities, run:
+#
+#   trezor-gpg-recovery --manifest THIS-FILE
+#
+# which checks each recovered identity against its fingerprint.
+`
+
+// WriteManifestTemplate writes a recovery manifest for identities to w, or
+// a blank one with the default parameters if none are given, for completing
+// by hand and storing with the seed backup.
+func WriteManifestTemplate(w io.Writer, identities ...ManifestIdentity) error {
+	if len(identities) == 0 {
+		identities = []ManifestIdentity{{Device: DeviceTrezor, Curve: CurveNIST256P1}}
+	}
+	b := bufio.NewWriter(w)
+	b.WriteString(manifestHeader)
+	for _, id := range identities {
+		field := func(name, value string) {
+			if value == "" {
+				fmt.Fprintf(b, "%s:\n", name)
+				return
+			}
+			fmt.Fprintf(b, "%-12s %s\n", name+":", value)
+		}
+		b.WriteString("\n[identity]\n")
+		field("user-id", quoteUserID(id.UserID))
+		if id.Timestamp.IsZero() {
+			field("timestamp", "")
+		} else {
+			fmt.Fprintf(b, "# created %s\n", id.Timestamp.UTC().Format(time.RFC1123))
+			field("timestamp", strconv.FormatInt(id.Timestamp.Unix(), 10))
+		}
+		field("device", string(id.Device))
+		field("curve", id.Curve)
+		index := id.Index
+		if id.Device == DeviceLedger && index == 0 {
+			index = 1
+		}
+		field("index", strconv.FormatUint(uint64(index), 10))
+		field("fingerprint", spacedFingerprint(id.Fingerprint))
+	}
+	return b.Flush()
+}
+
+// ParseManifest parses a recovery manifest written by WriteManifestTemplate
+// and completed by hand. Every identity must have a timestamp, fingerprint
+// and (unless created with a Ledger) user ID, since a recovery from the
+// manifest checks each derived identity against its fingerprint.
+func ParseManifest(r io.Reader) ([]ManifestIdentity, error) {
+	var identities []ManifestIdentity
+	var seen map[string]bool
+	s := newLineScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if line == "[identity]" {
+			identities = append(identities, ManifestIdentity{Device: DeviceTrezor, Curve: CurveNIST256P1})
+			seen = make(map[string]bool)
+			continue
+		}
+		i := strings.Index(line, ":")
+		if i == -1 {
+			return nil, fmt.Errorf("invalid line %d of the manifest: expected [identity] or a field such as \"user-id: Alice <alice@example.com>\"", n)
+		}
+		if len(identities) == 0 {
+			return nil, fmt.Errorf("invalid line %d of the manifest: fields must follow an [identity] line", n)
+		}
+		name, value := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		if seen[name] {
+			return nil, fmt.Errorf("invalid line %d of the manifest: %s is given twice for the identity", n, name)
+		}
+		seen[name] = true
+		if err := identities[len(identities)-1].setField(name, value); err != nil {
+			return nil, fmt.Errorf("invalid line %d of the manifest: %s", n, err)
+		}
+	}
+	if err := scanErr(s, "the manifest"); err != nil {
+		return nil, err
+	}
+	if len(identities) == 0 {
+		return nil, errors.New("the manifest has no identities")
+	}
+	for i, id := range identities {
+		switch {
+		case id.UserID == "" && id.Device != DeviceLedger:
+			return nil, fmt.Errorf("identity %d of the manifest has no user-id", i+1)
+		case id.Timestamp.IsZero():
+			return nil, fmt.Errorf("identity %d of the manifest has no timestamp", i+1)
+		case id.Fingerprint == "":
+			return nil, fmt.Errorf("identity %d of the manifest has no fingerprint to check the recovered identity against", i+1)
+		}
+	}
+	return identities, nil
+}
+
+// setField sets the manifest field name to value, leaving it unchanged if
+// value is blank.
+func (id *ManifestIdentity) setField(name, value string) error {
+	if value == "" {
+		switch name {
+		case "user-id", "timestamp", "device", "curve", "index", "fingerprint":
+			return nil
+		}
+	}
+	var err error
+	switch name {
+	case "user-id":
+		id.UserID, err = unquoteUserID(value)
+	case "timestamp":
+		id.Timestamp, err = ParseTimestamp(value)
+	case "device":
+		id.Device, err = ParseDevice(value)
+	case "curve":
+		if value != CurveNIST256P1 {
+			return fmt.Errorf("unsupported curve %q: only %s is supported", value, CurveNIST256P1)
+		}
+		id.Curve = value
+	case "index":
+		var index uint64
+		if index, err = strconv.ParseUint(value, 10, 32); err != nil {
+			return fmt.Errorf("invalid index %q", value)
+		}
+		id.Index = uint32(index)
+	case "fingerprint":
+		id.Fingerprint, err = parseFingerprint(value)
+	default:
+		return fmt.Errorf("unknown field %q", name)
+	}
+	return err
+}
+
+// quoteUserID quotes a user ID for a manifest if it would otherwise change
+// when parsed: if it starts or ends with whitespace (which the manifest
+// trims, so the values line up), starts with a quote or contains a control
+// character such as a newline. Any whitespace in a user ID changes its
+// fingerprint, so it must be kept exactly.
+func quoteUserID(userID string) string {
+	if userID != strings.TrimSpace(userID) || strings.HasPrefix(userID, `"`) || strings.IndexFunc(userID, unicode.IsControl) != -1 {
+		return strconv.Quote(userID)
+	}
+	return userID
+}
+
+// unquoteUserID returns the user ID written in a manifest, unquoting it if
+// it's in double quotes.
+func unquoteUserID(value string) (string, error) {
+	if !strings.HasPrefix(value, `"`) {
+		return value, nil
+	}
+	userID, err := strconv.Unquote(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid quoted user-id %s", value)
+	}
+	return userID, nil
+}
+
+// BatchIdentity returns the identity for recovering with Batch, which checks
+// it against the manifest's fingerprint.
+func (id ManifestIdentity) BatchIdentity() BatchIdentity {
+	return BatchIdentity{
+		UserID:      id.UserID,
+		Timestamp:   id.Timestamp,
+		Index:       id.Index,
+		Device:      id.Device,
+		Fingerprint: id.Fingerprint,
+	}
+}
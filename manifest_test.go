@@ -0,0 +1,145 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWriteManifestTemplate(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteManifestTemplate(&buf, NewManifestIdentity(recoverEntity(t, aliceInput))); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"\n[identity]\n",
+		"user-id:     Alice <alice@example.com>\n",
+		"timestamp:   1523060353\n",
+		"device:      trezor\n",
+		"curve:       nist256p1\n",
+		"index:       0\n",
+		"fingerprint: " + spacedFingerprint(aliceFingerprint) + "\n",
+	} {
+		if !strings.Contains(buf.String(), s) {
+			t.Fatalf("expected manifest to contain %q, got:\n%s", s, buf.String())
+		}
+	}
+
+	// a blank template leaves the fields to be filled in
+	buf.Reset()
+	if err := WriteManifestTemplate(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"\nuser-id:\n", "\ntimestamp:\n", "\nfingerprint:\n", "curve:       nist256p1\n"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Fatalf("expected blank manifest to contain %q, got:\n%s", s, buf.String())
+		}
+	}
+}
+
+func TestParseManifest(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteManifestTemplate(&buf, NewManifestIdentity(recoverEntity(t, aliceInput))); err != nil {
+		t.Fatal(err)
+	}
+	identities, err := ParseManifest(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(identities) != 1 || identities[0].UserID != "Alice <alice@example.com>" || identities[0].Timestamp.Unix() != 1523060353 || identities[0].Fingerprint != aliceFingerprint {
+		t.Fatalf("unexpected identities %+v", identities)
+	}
+
+	// a blank template can't be recovered from
+	buf.Reset()
+	if err := WriteManifestTemplate(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseManifest(&buf); err == nil || !strings.Contains(err.Error(), "has no user-id") {
+		t.Fatalf("expected an error parsing a blank template, got %v", err)
+	}
+	for _, manifest := range []string{
+		"user-id: Alice <alice@example.com>\n",
+		"[identity]\nname: Alice\n",
+		"[identity]\ncurve: ed25519\n",
+		"[identity]\nindex: 0\nindex: 1\n",
+	} {
+		if _, err := ParseManifest(strings.NewReader(manifest)); err == nil || !strings.Contains(err.Error(), "line ") {
+			t.Fatalf("expected an error parsing %q, got %v", manifest, err)
+		}
+	}
+}
+
+func TestManifestUserID(t *testing.T) {
+	// user IDs round trip exactly, since any change to them changes the
+	// fingerprint
+	for _, userID := range []string{
+		"Alice <alice@example.com>",
+		" Alice <alice@example.com>",
+		"Alice <alice@example.com> ",
+		"Alice  <alice@example.com>",
+		"Alice\t<alice@example.com>",
+		`"Alice" <alice@example.com>`,
+		`Alice "Al" <alice@example.com>`,
+	} {
+		id := NewManifestIdentity(recoverEntity(t, aliceInput))
+		id.UserID = userID
+		var buf bytes.Buffer
+		if err := WriteManifestTemplate(&buf, id); err != nil {
+			t.Fatal(err)
+		}
+		identities, err := ParseManifest(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if identities[0].UserID != userID {
+			t.Fatalf("expected user ID %q, got %q from:\n%s", userID, identities[0].UserID, buf.String())
+		}
+	}
+
+	// a quoted user ID can be written by hand, but must be valid
+	manifest := "[identity]\nuser-id: \"Alice <alice@example.com> \"\ntimestamp: 1523060353\nfingerprint: " + spacedFingerprint(aliceFingerprint) + "\n"
+	identities, err := ParseManifest(strings.NewReader(manifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identities[0].UserID != "Alice <alice@example.com> " {
+		t.Fatalf("unexpected user ID %q", identities[0].UserID)
+	}
+	if _, err := ParseManifest(strings.NewReader("[identity]\nuser-id: \"Alice\n")); err == nil || !strings.Contains(err.Error(), "invalid quoted user-id") {
+		t.Fatalf("expected an invalid quoted user ID error, got %v", err)
+	}
+}
+
+func TestManifestRecovery(t *testing.T) {
+	manifest := "[identity]\nuser-id: Alice <alice@example.com>\ntimestamp: 1523060353\nfingerprint: " + spacedFingerprint(aliceFingerprint) + "\n"
+	input := aliceInput[strings.Index(aliceInput, "12\n"):]
+	recoverManifest := func(manifest string) (string, error) {
+		identities, err := ParseManifest(strings.NewReader(manifest))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var stdout bytes.Buffer
+		batch := []BatchIdentity{identities[0].BatchIdentity()}
+		err = Batch(context.Background(), batch,
+			WithStdin(strings.NewReader(input)),
+			WithStdout(&stdout),
+			WithStderr(&bytes.Buffer{}),
+		)
+		return stdout.String(), err
+	}
+	if out, err := recoverManifest(manifest); err != nil || !strings.Contains(out, "PGP PRIVATE KEY BLOCK") {
+		t.Fatalf("expected the private key, got %v:\n%s", err, out)
+	}
+
+	// a mismatched identity is refused without writing anything
+	out, err := recoverManifest(strings.Replace(manifest, "1523060353", "1523060354", 1))
+	if !errors.Is(err, ErrFingerprintMismatch) || !strings.Contains(err.Error(), "no private keys were written") {
+		t.Fatalf("expected a fingerprint mismatch, got %v", err)
+	}
+	if out != "" {
+		t.Fatalf("expected nothing to be written, got:\n%s", out)
+	}
+}
@@ -0,0 +1,118 @@
+package recovery
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// missingWord is entered in place of a seed word which isn't known, to
+// search for it.
+const missingWord = "*"
+
+// maxMissingWords is the most missing words which can be searched for, since
+// each one multiplies the number of candidate seeds by 2048 (and even with
+// the checksum ruling most of them out, three missing words of a 24 word seed
+// would take years to search).
+const maxMissingWords = 2
+
+// partialSeed is a BIP39 mnemonic with missing words, whose candidates are
+// each way of filling them in from the English wordlist.
+type partialSeed struct {
+	known   [][]byte
+	missing []int
+}
+
+// newPartialSeed returns the partialSeed of words, the positions in missing
+// being unknown, copying the known words into secure memory.
+func (r *Recovery) newPartialSeed(words [][]byte, missing []int) (*partialSeed, error) {
+	if r.fingerprint == "" {
+		return nil, errors.New("searching for missing words requires an expected fingerprint")
+	}
+	if len(missing) > maxMissingWords {
+		return nil, errorf("%w: %d words are missing, but at most %d can be searched for", ErrInvalidMnemonic, len(missing), maxMissingWords)
+	}
+	s := &partialSeed{known: make([][]byte, len(words)), missing: missing}
+	for i, word := range words {
+		if string(word) == missingWord {
+			continue
+		}
+		if _, ok := englishWordlist.index[string(word)]; !ok {
+			s.Wipe()
+			if r.dualOperator {
+				return nil, errorf("%w: word %d is not in the BIP39 English wordlist", ErrInvalidMnemonic, i+1)
+			}
+			return nil, errorf("%w: word %d (%q) is not in the BIP39 English wordlist", ErrInvalidMnemonic, i+1, word)
+		}
+		s.known[i] = r.mem.copy(word)
+	}
+	return s, nil
+}
+
+// missingWords returns the positions of the words entered as missingWord.
+func missingWords(words [][]byte) []int {
+	var missing []int
+	for i, word := range words {
+		if string(word) == missingWord {
+			missing = append(missing, i)
+		}
+	}
+	return missing
+}
+
+// candidates returns the number of ways of filling in the missing words,
+// most of which have an invalid checksum.
+func (s *partialSeed) candidates() uint64 {
+	n := uint64(1)
+	for range s.missing {
+		n *= uint64(len(englishWordlist.words))
+	}
+	return n
+}
+
+// words returns the words of candidate n, which the caller should wipe.
+func (s *partialSeed) words(n uint64) [][]byte {
+	words := make([][]byte, len(s.known))
+	for i, word := range s.known {
+		words[i] = append([]byte(nil), word...)
+	}
+	size := uint64(len(englishWordlist.words))
+	for j := len(s.missing) - 1; j >= 0; j-- {
+		words[s.missing[j]] = []byte(englishWordlist.words[n%size])
+		n /= size
+	}
+	return words
+}
+
+// candidate returns the seed of candidate n, or false if its checksum is
+// invalid.
+func (s *partialSeed) candidate(n uint64) (Seed, bool) {
+	words := s.words(n)
+	defer wipeWords(words)
+	entropy, err := englishWordlist.entropy(words)
+	wipe(entropy)
+	if err != nil {
+		return nil, false
+	}
+	return &mnemonicSeed{mnemonic: bytes.Join(words, []byte(" "))}, true
+}
+
+// MasterSeed fails since a partialSeed is only searched through its
+// candidates.
+func (s *partialSeed) MasterSeed(passphrase string) ([]byte, error) {
+	return nil, errorf("%w: the recovery seed has missing words", ErrInvalidMnemonic)
+}
+
+func (s *partialSeed) Wipe() {
+	for _, word := range s.known {
+		wipe(word)
+	}
+}
+
+// pluralWords returns "word" or "n words".
+func pluralWords(n int) string {
+	if n == 1 {
+		return "word"
+	}
+	return fmt.Sprintf("%d words", n)
+}
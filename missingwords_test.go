@@ -0,0 +1,72 @@
+package recovery
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMissingWords(t *testing.T) {
+	var stderr bytes.Buffer
+	input := strings.Replace(aliceInput, "12\nall\nall\nall\n", "12\nall\nall\n*\n", 1)
+	entity := recoverEntity(t, input,
+		WithStderr(&stderr),
+		WithFingerprint(aliceFingerprint),
+		WithSearchWorkers(4),
+	)
+	if fpr := formatFingerprint(entity.PrimaryKey); fpr != aliceFingerprint {
+		t.Fatalf("unexpected fingerprint %s", fpr)
+	}
+	if !strings.Contains(stderr.String(), "Searching 2048 candidate seeds") || !strings.Contains(stderr.String(), "Missing word 3: all\n") {
+		t.Fatalf("expected the missing word to be found, got:\n%s", stderr.String())
+	}
+
+	for _, test := range []struct {
+		input string
+		opts  []Option
+		err   string
+	}{
+		{
+			input: input,
+			err:   "requires an expected fingerprint",
+		},
+		{
+			input: strings.Replace(aliceInput, "12\nall\nall\nall\n", "12\n*\n*\n*\n", 1),
+			opts:  []Option{WithFingerprint(aliceFingerprint)},
+			err:   "3 words are missing, but at most 2",
+		},
+		{
+			input: strings.Replace(aliceInput, "12\nall\nall\nall\n", "12\nall\nqwerty\n*\n", 1),
+			opts:  []Option{WithFingerprint(aliceFingerprint)},
+			err:   `word 2 ("qwerty") is not in the BIP39 English wordlist`,
+		},
+	} {
+		err := Run(append([]Option{
+			WithStdin(strings.NewReader(test.input)),
+			WithStdout(&bytes.Buffer{}),
+			WithStderr(&bytes.Buffer{}),
+		}, test.opts...)...)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Fatalf("expected an error containing %q, got %v", test.err, err)
+		}
+	}
+}
+
+func TestPartialSeedCandidates(t *testing.T) {
+	r := &Recovery{fingerprint: aliceFingerprint, mem: &secureMemory{}}
+	words := bytes.Fields([]byte("all all all all all all all all all all * *"))
+	s, err := r.newPartialSeed(words, missingWords(words))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := s.candidates(); n != 2048*2048 {
+		t.Fatalf("unexpected number of candidates %d", n)
+	}
+	if words := s.words(2048 + 2); string(bytes.Join(words, []byte(" "))) != "all all all all all all all all all all ability able" {
+		t.Fatalf("unexpected candidate %q", words)
+	}
+	if _, err := s.MasterSeed(""); !errors.Is(err, ErrInvalidMnemonic) {
+		t.Fatalf("expected ErrInvalidMnemonic, got %v", err)
+	}
+}
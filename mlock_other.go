@@ -0,0 +1,12 @@
+//go:build !darwin && !linux
+// +build !darwin,!linux
+
+package recovery
+
+import "errors"
+
+func lockedAlloc(size int) ([]byte, error) {
+	return nil, errors.New("locking memory is not supported on this platform")
+}
+
+func lockedFree(mem []byte) {}
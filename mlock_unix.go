@@ -0,0 +1,25 @@
+//go:build darwin || linux
+// +build darwin linux
+
+package recovery
+
+import "syscall"
+
+// lockedAlloc maps size bytes of anonymous memory and locks it into RAM.
+func lockedAlloc(size int) ([]byte, error) {
+	mem, err := syscall.Mmap(-1, 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Mlock(mem); err != nil {
+		syscall.Munmap(mem)
+		return nil, err
+	}
+	return mem, nil
+}
+
+// lockedFree unlocks and unmaps memory returned by lockedAlloc.
+func lockedFree(mem []byte) {
+	syscall.Munlock(mem)
+	syscall.Munmap(mem)
+}
@@ -0,0 +1,280 @@
+package recovery
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// wordlistFiles are the BIP39 wordlists, one word per line as published in
+// the BIP, compiled into the binary so the recovery doesn't depend on
+// anything on the filesystem.
+//
+//go:embed wordlists/*.txt
+var wordlistFiles embed.FS
+
+// wordlist is a BIP39 wordlist along with a reverse index of its words.
+type wordlist struct {
+	name  string
+	words []string
+	index map[string]int
+}
+
+// loadWordlist loads the embedded wordlist in file, panicking if it isn't a
+// valid BIP39 wordlist since that means the binary was built wrongly.
+func loadWordlist(name, file string) *wordlist {
+	data, err := wordlistFiles.ReadFile("wordlists/" + file)
+	if err != nil {
+		panic(err)
+	}
+	words := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	l := &wordlist{name: name, words: words, index: make(map[string]int, len(words))}
+	for i, word := range words {
+		l.index[word] = i
+	}
+	if len(words) != 2048 || len(l.index) != 2048 {
+		panic(fmt.Sprintf("invalid %s wordlist: %d words, %d unique", name, len(words), len(l.index)))
+	}
+	return l
+}
+
+var (
+	// englishWordlist is the only wordlist supported by Trezor devices.
+	englishWordlist = loadWordlist("English", "english.txt")
+
+	// otherWordlists are checked when a mnemonic isn't valid English so that
+	// we can tell the user which wordlist their words actually came from.
+	otherWordlists = []*wordlist{
+		loadWordlist("Spanish", "spanish.txt"),
+		loadWordlist("Italian", "italian.txt"),
+		loadWordlist("Japanese", "japanese.txt"),
+		loadWordlist("Korean", "korean.txt"),
+		loadWordlist("Chinese (Simplified)", "chinese_simplified.txt"),
+		loadWordlist("Chinese (Traditional)", "chinese_traditional.txt"),
+		loadWordlist("French", "french.txt"),
+	}
+)
+
+var errChecksum = errors.New("checksum incorrect")
+
+// unknown returns the position of the first word not in the wordlist, or -1
+// if all the words are in the wordlist.
+func (l *wordlist) unknown(words [][]byte) int {
+	for i, word := range words {
+		if _, ok := l.index[string(word)]; !ok {
+			return i
+		}
+	}
+	return -1
+}
+
+//go:generate go run nfkd_gen.go
+
+// normalizeWord trims surrounding whitespace from a hand-typed seed word,
+// decomposes it to NFKD as the wordlists are (so that "é" typed as a single
+// character is found) and lower cases ASCII letters, since the English
+// wordlist is all lower case ASCII. ASCII words are normalized in place,
+// while other words are decomposed into a new buffer and the input wiped.
+func normalizeWord(word []byte) []byte {
+	word = bytes.TrimSpace(word)
+	if !isASCII(word) {
+		word = decomposeWord(word)
+	}
+	for i, c := range word {
+		if 'A' <= c && c <= 'Z' {
+			word[i] = c + 'a' - 'A'
+		}
+	}
+	return word
+}
+
+// isASCII returns whether b only contains ASCII.
+func isASCII(b []byte) bool {
+	for _, c := range b {
+		if c >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+const (
+	hangulBase   = 0xac00
+	hangulCount  = 11172
+	hangulLBase  = 0x1100
+	hangulVBase  = 0x1161
+	hangulTBase  = 0x11a7
+	hangulVCount = 21
+	hangulTCount = 28
+)
+
+// decomposeWord returns the NFKD decomposition of word, wiping word. Only
+// the decompositions which can lead to a word in one of the wordlists are
+// applied, other runes (and invalid UTF-8) being kept as they are.
+func decomposeWord(word []byte) []byte {
+	// size the output up front so it isn't copied as it grows
+	n := 0
+	for i := 0; i < len(word); {
+		c, size := utf8.DecodeRune(word[i:])
+		n += decomposedLen(c, word[i:i+size])
+		i += size
+	}
+	out := make([]byte, 0, n)
+	for i := 0; i < len(word); {
+		c, size := utf8.DecodeRune(word[i:])
+		out = appendDecomposed(out, c, word[i:i+size])
+		i += size
+	}
+	wipe(word)
+	return out
+}
+
+// decomposedLen returns the length of the decomposition of the rune c
+// encoded as b.
+func decomposedLen(c rune, b []byte) int {
+	if s := c - hangulBase; 0 <= s && s < hangulCount {
+		// each jamo is 3 bytes
+		if s%hangulTCount != 0 {
+			return 9
+		}
+		return 6
+	}
+	if d, ok := nfkdTable[c]; ok {
+		return len(d)
+	}
+	return len(b)
+}
+
+// appendDecomposed appends the decomposition of the rune c encoded as b.
+func appendDecomposed(out []byte, c rune, b []byte) []byte {
+	if s := c - hangulBase; 0 <= s && s < hangulCount {
+		// Hangul syllables decompose to their leading consonant, vowel and
+		// optional trailing consonant as described in the Unicode standard
+		out = utf8.AppendRune(out, hangulLBase+s/(hangulVCount*hangulTCount))
+		out = utf8.AppendRune(out, hangulVBase+s%(hangulVCount*hangulTCount)/hangulTCount)
+		if t := s % hangulTCount; t != 0 {
+			out = utf8.AppendRune(out, hangulTBase+t)
+		}
+		return out
+	}
+	if d, ok := nfkdTable[c]; ok {
+		return append(out, d...)
+	}
+	return append(out, b...)
+}
+
+// entropy returns the entropy encoded by the given words, checking the
+// mnemonic checksum as described in BIP39.
+func (l *wordlist) entropy(words [][]byte) ([]byte, error) {
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return nil, errorf("invalid mnemonic length %d", len(words))
+	}
+	if i := l.unknown(words); i != -1 {
+		return nil, errorf("word %d is not in the %s wordlist", i+1, message(l.name))
+	}
+
+	// concatenate the 11 bit indexes of each word
+	numBits := len(words) * 11
+	buf := make([]byte, (numBits+7)/8)
+	for i, word := range words {
+		index := l.index[string(word)]
+		for b := 0; b < 11; b++ {
+			if index&(1<<uint(10-b)) != 0 {
+				pos := i*11 + b
+				buf[pos/8] |= 0x80 >> uint(pos%8)
+			}
+		}
+	}
+
+	// split into the entropy and checksum and check the checksum
+	checksumBits := uint(numBits / 33)
+	entropy := buf[:(numBits-int(checksumBits))/8]
+	hash := sha256.Sum256(entropy)
+	if buf[len(entropy)]>>(8-checksumBits) != hash[0]>>(8-checksumBits) {
+		wipe(buf)
+		return nil, errChecksum
+	}
+	return entropy, nil
+}
+
+// electrumSeedTypes are the version prefixes of Electrum's seed format, see
+// https://electrum.readthedocs.io/en/latest/seedphrase.html
+var electrumSeedTypes = []struct {
+	prefix string
+	name   string
+}{
+	{"01", "standard"},
+	{"100", "segwit"},
+	{"101", "2FA"},
+	{"102", "2FA segwit"},
+}
+
+// electrumSeedType returns the type of Electrum seed the given words form, or
+// an empty string if they don't pass Electrum's version check.
+func electrumSeedType(words [][]byte) string {
+	mnemonic := bytes.ToLower(bytes.Join(words, []byte(" ")))
+	defer wipe(mnemonic)
+	mac := hmac.New(sha512.New, []byte("Seed version"))
+	mac.Write(mnemonic)
+	version := hex.EncodeToString(mac.Sum(nil))
+	for _, t := range electrumSeedTypes {
+		if strings.HasPrefix(version, t.prefix) {
+			return t.name
+		}
+	}
+	return ""
+}
+
+// checkMnemonic checks the given words form a valid English BIP39 mnemonic,
+// returning an explanatory error if not (in particular if the words are from
+// a different language's wordlist or form an Electrum seed).
+func (r *Recovery) checkMnemonic(words [][]byte) error {
+	unknown := englishWordlist.unknown(words)
+	if unknown == -1 {
+		entropy, err := englishWordlist.entropy(words)
+		wipe(entropy)
+		electrumType := electrumSeedType(words)
+		if err != nil && electrumType != "" {
+			return errorf("%w: this looks like an Electrum (%s) seed rather than a BIP39 seed, and will not derive the keys of a Trezor", ErrInvalidMnemonic, electrumType)
+		} else if err != nil {
+			return errorf("%w: %s (check the words were entered correctly and in the right order)", ErrInvalidMnemonic, err)
+		}
+		// about 1 in 256 BIP39 seeds pass Electrum's check by chance, so this
+		// is only a notice which strict mode doesn't make fatal
+		if electrumType != "" {
+			r.info(r.translate("the recovery seed is a valid BIP39 seed but also passes Electrum's seed version check; if it was generated by Electrum rather than a Trezor the derived keys will be wrong"))
+		}
+		return nil
+	}
+
+	// check if the words come from another wordlist
+	var candidate *wordlist
+	for _, l := range otherWordlists {
+		if l.unknown(words) != -1 {
+			continue
+		}
+		if entropy, err := l.entropy(words); err == nil {
+			wipe(entropy)
+			candidate = l
+			break
+		}
+		if candidate == nil {
+			candidate = l
+		}
+	}
+	if candidate != nil {
+		return errorf("%w: these look like words from the %s BIP39 wordlist, but Trezor recovery seeds always use the English wordlist", ErrInvalidMnemonic, message(candidate.name))
+	}
+	if r.dualOperator {
+		// don't show either operator the other's word
+		return errorf("%w: word %d is not in the BIP39 English wordlist", ErrInvalidMnemonic, unknown+1)
+	}
+	return errorf("%w: word %d (%q) is not in the BIP39 English wordlist", ErrInvalidMnemonic, unknown+1, words[unknown])
+}
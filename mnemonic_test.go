@@ -0,0 +1,166 @@
+package recovery
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/tyler-smith/go-bip39/wordlists"
+)
+
+func TestCheckMnemonic(t *testing.T) {
+	repeat := func(word string, n int) []string {
+		words := make([]string, n)
+		for i := range words {
+			words[i] = word
+		}
+		return words
+	}
+	// the all-zero entropy mnemonic in a given wordlist
+	zero := func(list []string) []string {
+		return append(repeat(list[0], 11), list[3])
+	}
+
+	for _, test := range []struct {
+		name  string
+		words []string
+		err   string
+	}{
+		{
+			name:  "valid 12 words",
+			words: repeat("all", 12),
+		},
+		{
+			name:  "valid 24 words",
+			words: append(repeat("abandon", 23), "art"),
+		},
+		{
+			name:  "bad checksum",
+			words: repeat("zoo", 12),
+			err:   "checksum incorrect",
+		},
+		{
+			name:  "unknown word",
+			words: append(repeat("all", 11), "llama"),
+			err:   `word 12 ("llama") is not in the BIP39 English wordlist`,
+		},
+		{
+			name:  "electrum",
+			words: strings.Fields("wild father tree among universe such mobile favorite target dynamic credit identify"),
+			err:   "this looks like an Electrum (segwit) seed rather than a BIP39 seed",
+		},
+		{
+			name:  "spanish",
+			words: zero(wordlists.Spanish),
+			err:   "these look like words from the Spanish BIP39 wordlist",
+		},
+		{
+			name:  "french",
+			words: zero(otherWordlists[6].words),
+			err:   "these look like words from the French BIP39 wordlist",
+		},
+		{
+			name:  "japanese",
+			words: zero(wordlists.Japanese),
+			err:   "these look like words from the Japanese BIP39 wordlist",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			r := &Recovery{stderr: ioutil.Discard}
+			words := make([][]byte, len(test.words))
+			for i, word := range test.words {
+				words[i] = []byte(word)
+			}
+			err := r.checkMnemonic(words)
+			if test.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestCheckMnemonicElectrumNotice(t *testing.T) {
+	// a valid BIP39 seed which passes Electrum's version check by chance
+	// isn't refused, even in strict mode
+	var stderr bytes.Buffer
+	r := &Recovery{stderr: &stderr, strict: true}
+	words := bytes.Fields([]byte("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon mass"))
+	if err := r.checkMnemonic(words); err != nil {
+		t.Fatalf("expected the seed to be accepted, got %v", err)
+	}
+	if !strings.Contains(stderr.String(), "also passes Electrum's seed version check") {
+		t.Fatalf("expected the Electrum notice, got:\n%s", stderr.String())
+	}
+}
+
+func TestNormalizeWord(t *testing.T) {
+	// words typed with precomposed characters (NFC, as most keyboards and
+	// input methods produce) are found in the NFKD wordlists
+	for _, test := range []struct {
+		word string
+		list *wordlist
+	}{
+		{" Abandon\t", englishWordlist},
+		{"ａｂａｎｄｏｎ", englishWordlist},
+		{"ábaco", otherWordlists[0]},
+		{"ÁBACO", otherWordlists[0]},
+		{"a\u0301baco", otherWordlists[0]},
+		{"がっこう", otherWordlists[2]},
+		{"가격", otherWordlists[3]},
+		{"élève", otherWordlists[6]},
+	} {
+		input := []byte(test.word)
+		word := normalizeWord(input)
+		if test.list.unknown([][]byte{word}) != -1 {
+			t.Fatalf("%q normalized to %q, which isn't in the %s wordlist", test.word, word, test.list.name)
+		}
+		if !isASCII(input) && strings.Trim(string(input), "\x00") != "" {
+			t.Fatalf("expected %q to be wiped, got %q", test.word, input)
+		}
+	}
+}
+
+func TestEmbeddedWordlists(t *testing.T) {
+	// english.txt and french.txt are byte for byte the ones published with
+	// BIP39 (go-bip39 has no French wordlist to compare against)
+	for file, expected := range map[string]string{
+		"english.txt": "2f5eed53a4727b4bf8880d8f3f199efc90e58503646d9ff8eff3a2ed3b24dbda",
+		"french.txt":  "ebc3959ab7801a1df6bac4fa7d970652f1df76b683cd2f4003c941c63d517e59",
+	} {
+		data, err := wordlistFiles.ReadFile("wordlists/" + file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != expected {
+			t.Fatalf("unexpected %s SHA256 %x", file, sum)
+		}
+	}
+
+	// and the other wordlists match go-bip39's
+	for _, test := range []struct {
+		list  *wordlist
+		words []string
+	}{
+		{englishWordlist, wordlists.English},
+		{otherWordlists[0], wordlists.Spanish},
+		{otherWordlists[1], wordlists.Italian},
+		{otherWordlists[2], wordlists.Japanese},
+		{otherWordlists[3], wordlists.Korean},
+		{otherWordlists[4], wordlists.ChineseSimplified},
+		{otherWordlists[5], wordlists.ChineseTraditional},
+	} {
+		if strings.Join(test.list.words, " ") != strings.Join(test.words, " ") {
+			t.Fatalf("the embedded %s wordlist doesn't match go-bip39's", test.list.name)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// Package mobile is a binding of the recovery for gomobile, so that offline
+// Android and iOS apps can use the same derivation as the command line tool:
+//
+//	gomobile bind -target android github.com/lmars/trezor-gpg-recovery/mobile
+//
+// gomobile only supports simple types, so the API takes and returns strings,
+// byte slices and integers, and the app renders its own UI for the prompts.
+package mobile
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	recovery "github.com/lmars/trezor-gpg-recovery"
+)
+
+// Params are the inputs to a recovery, which are the answers to the prompts
+// of the command line tool.
+type Params struct {
+	// Mnemonic is the BIP39 recovery seed, with words separated by spaces.
+	Mnemonic string
+
+	// Passphrase is the optional BIP39 passphrase.
+	Passphrase string
+
+	// UserID is the user ID given to 'trezor-gpg init'.
+	UserID string
+
+	// Timestamp is the timestamp given to 'trezor-gpg init', in seconds
+	// since the Unix epoch.
+	Timestamp int64
+
+	// Index is the SLIP-0013 index of the identity, which is 0 for
+	// identities created by 'trezor-gpg init'.
+	Index int
+
+	// Fingerprint is the optional expected primary key fingerprint.
+	Fingerprint string
+}
+
+// NewParams returns empty parameters for the app to fill in.
+func NewParams() *Params {
+	return &Params{}
+}
+
+// Identity is a recovered GPG identity.
+type Identity struct {
+	UserID             string
+	PrimaryFingerprint string
+	PrimaryKeyID       string
+	SubkeyFingerprint  string
+	SubkeyID           string
+
+	// PublicKey is the ascii armored public key.
+	PublicKey string
+
+	// PrivateKey is the ascii armored private key. It is bytes rather than a
+	// string so that it can be wiped with Wipe once saved.
+	PrivateKey []byte
+}
+
+// Wipe wipes the private key.
+func (id *Identity) Wipe() {
+	for i := range id.PrivateKey {
+		id.PrivateKey[i] = 0
+	}
+}
+
+// Recover derives the GPG identity for the given parameters, checking it
+// matches the expected fingerprint if one is given.
+func Recover(params *Params) (*Identity, error) {
+	opts := []recovery.Option{recovery.WithIndex(uint32(params.Index))}
+	if params.Fingerprint != "" {
+		opts = append(opts, recovery.WithFingerprint(params.Fingerprint))
+	}
+	r, err := recovery.New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	result, err := r.Derive(recovery.Params{
+		Mnemonic:   params.Mnemonic,
+		Passphrase: params.Passphrase,
+		UserID:     params.UserID,
+		Timestamp:  time.Unix(params.Timestamp, 0),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer result.Wipe()
+	pubKey, err := result.ArmoredPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	privKey, err := result.ArmoredPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	return &Identity{
+		UserID:             result.UserID,
+		PrimaryFingerprint: result.PrimaryFingerprint,
+		PrimaryKeyID:       result.PrimaryKeyID,
+		SubkeyFingerprint:  result.SubkeyFingerprint,
+		SubkeyID:           result.SubkeyID,
+		PublicKey:          pubKey,
+		PrivateKey:         privKey,
+	}, nil
+}
+
+// CheckMnemonic checks the recovery seed is a valid English BIP39 mnemonic,
+// so the app can tell the user before asking for the passphrase.
+func CheckMnemonic(mnemonic string) error {
+	fields := strings.Fields(mnemonic)
+	words := make([][]byte, len(fields))
+	for i, field := range fields {
+		words[i] = []byte(field)
+	}
+	seed, err := recovery.NewMnemonicSeed(words)
+	if err != nil {
+		return err
+	}
+	seed.Wipe()
+	return nil
+}
+
+// SelfTest runs the known-answer tests, returning their output, and an error
+// if any failed.
+func SelfTest() (string, error) {
+	var out bytes.Buffer
+	err := recovery.SelfTest(&out)
+	return out.String(), err
+}
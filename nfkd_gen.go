@@ -0,0 +1,92 @@
+//go:build ignore
+// +build ignore
+
+// nfkd_gen.go generates nfkd_table.go, the NFKD decompositions used by
+// normalizeWord. Run it with go generate.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+func main() {
+	// the wordlists are already NFKD, so the runes they contain (along with
+	// upper case ASCII, which normalizeWord lowercases) are the only ones a
+	// decomposition can produce for a word to be found
+	wanted := make(map[rune]bool)
+	for c := 'A'; c <= 'Z'; c++ {
+		wanted[c] = true
+	}
+	files, err := filepath.Glob("wordlists/*.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !norm.NFKD.IsNormal(data) {
+			log.Fatalf("%s isn't NFKD", file)
+		}
+		for _, c := range string(bytes.TrimSpace(data)) {
+			if !unicode.IsSpace(c) {
+				wanted[c] = true
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by nfkd_gen.go; DO NOT EDIT.\n\n")
+	buf.WriteString("package recovery\n\n")
+	buf.WriteString("// nfkdTable holds the NFKD decomposition of each rune which decomposes\n")
+	buf.WriteString("// only to runes found in the wordlists or upper case ASCII, apart from\n")
+	buf.WriteString("// the Hangul syllables which are decomposed algorithmically.\n")
+	buf.WriteString("var nfkdTable = map[rune]string{\n")
+	n := 0
+	for c := rune(0); c <= unicode.MaxRune; c++ {
+		if !utf8.ValidRune(c) || hangulBase <= c && c < hangulBase+hangulCount {
+			continue
+		}
+		s := string(c)
+		d := norm.NFKD.String(s)
+		if d == s || !only(d, wanted) {
+			continue
+		}
+		fmt.Fprintf(&buf, "\t%+q: %+q,\n", c, d)
+		n++
+	}
+	buf.WriteString("}\n")
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile("nfkd_table.go", src, 0644); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("wrote %d decompositions", n)
+}
+
+const (
+	hangulBase  = 0xac00
+	hangulCount = 11172
+)
+
+// only returns whether s only contains the given runes.
+func only(s string, runes map[rune]bool) bool {
+	for _, c := range s {
+		if !runes[c] {
+			return false
+		}
+	}
+	return true
+}
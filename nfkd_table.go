@@ -0,0 +1,1888 @@
+// Code generated by nfkd_gen.go; DO NOT EDIT.
+
+package recovery
+
+// nfkdTable holds the NFKD decomposition of each rune which decomposes
+// only to runes found in the wordlists or upper case ASCII, apart from
+// the Hangul syllables which are decomposed algorithmically.
+var nfkdTable = map[rune]string{
+	'\u00aa':     "a",
+	'\u00ba':     "o",
+	'\u00c0':     "A\u0300",
+	'\u00c1':     "A\u0301",
+	'\u00c3':     "A\u0303",
+	'\u00c8':     "E\u0300",
+	'\u00c9':     "E\u0301",
+	'\u00cc':     "I\u0300",
+	'\u00cd':     "I\u0301",
+	'\u00d1':     "N\u0303",
+	'\u00d2':     "O\u0300",
+	'\u00d3':     "O\u0301",
+	'\u00d5':     "O\u0303",
+	'\u00d9':     "U\u0300",
+	'\u00da':     "U\u0301",
+	'\u00dd':     "Y\u0301",
+	'\u00e0':     "a\u0300",
+	'\u00e1':     "a\u0301",
+	'\u00e3':     "a\u0303",
+	'\u00e8':     "e\u0300",
+	'\u00e9':     "e\u0301",
+	'\u00ec':     "i\u0300",
+	'\u00ed':     "i\u0301",
+	'\u00f1':     "n\u0303",
+	'\u00f2':     "o\u0300",
+	'\u00f3':     "o\u0301",
+	'\u00f5':     "o\u0303",
+	'\u00f9':     "u\u0300",
+	'\u00fa':     "u\u0301",
+	'\u00fd':     "y\u0301",
+	'\u0106':     "C\u0301",
+	'\u0107':     "c\u0301",
+	'\u0128':     "I\u0303",
+	'\u0129':     "i\u0303",
+	'\u0132':     "IJ",
+	'\u0133':     "ij",
+	'\u0139':     "L\u0301",
+	'\u013a':     "l\u0301",
+	'\u0143':     "N\u0301",
+	'\u0144':     "n\u0301",
+	'\u0154':     "R\u0301",
+	'\u0155':     "r\u0301",
+	'\u015a':     "S\u0301",
+	'\u015b':     "s\u0301",
+	'\u0168':     "U\u0303",
+	'\u0169':     "u\u0303",
+	'\u0179':     "Z\u0301",
+	'\u017a':     "z\u0301",
+	'\u017f':     "s",
+	'\u01c7':     "LJ",
+	'\u01c8':     "Lj",
+	'\u01c9':     "lj",
+	'\u01ca':     "NJ",
+	'\u01cb':     "Nj",
+	'\u01cc':     "nj",
+	'\u01f1':     "DZ",
+	'\u01f2':     "Dz",
+	'\u01f3':     "dz",
+	'\u01f4':     "G\u0301",
+	'\u01f5':     "g\u0301",
+	'\u01f8':     "N\u0300",
+	'\u01f9':     "n\u0300",
+	'\u02b0':     "h",
+	'\u02b2':     "j",
+	'\u02b3':     "r",
+	'\u02b7':     "w",
+	'\u02b8':     "y",
+	'\u02e1':     "l",
+	'\u02e2':     "s",
+	'\u02e3':     "x",
+	'\u0340':     "\u0300",
+	'\u0341':     "\u0301",
+	'\u1d2c':     "A",
+	'\u1d2e':     "B",
+	'\u1d30':     "D",
+	'\u1d31':     "E",
+	'\u1d33':     "G",
+	'\u1d34':     "H",
+	'\u1d35':     "I",
+	'\u1d36':     "J",
+	'\u1d37':     "K",
+	'\u1d38':     "L",
+	'\u1d39':     "M",
+	'\u1d3a':     "N",
+	'\u1d3c':     "O",
+	'\u1d3e':     "P",
+	'\u1d3f':     "R",
+	'\u1d40':     "T",
+	'\u1d41':     "U",
+	'\u1d42':     "W",
+	'\u1d43':     "a",
+	'\u1d47':     "b",
+	'\u1d48':     "d",
+	'\u1d49':     "e",
+	'\u1d4d':     "g",
+	'\u1d4f':     "k",
+	'\u1d50':     "m",
+	'\u1d52':     "o",
+	'\u1d56':     "p",
+	'\u1d57':     "t",
+	'\u1d58':     "u",
+	'\u1d5b':     "v",
+	'\u1d62':     "i",
+	'\u1d63':     "r",
+	'\u1d64':     "u",
+	'\u1d65':     "v",
+	'\u1d9c':     "c",
+	'\u1da0':     "f",
+	'\u1dbb':     "z",
+	'\u1e30':     "K\u0301",
+	'\u1e31':     "k\u0301",
+	'\u1e3e':     "M\u0301",
+	'\u1e3f':     "m\u0301",
+	'\u1e4c':     "O\u0303\u0301",
+	'\u1e4d':     "o\u0303\u0301",
+	'\u1e54':     "P\u0301",
+	'\u1e55':     "p\u0301",
+	'\u1e78':     "U\u0303\u0301",
+	'\u1e79':     "u\u0303\u0301",
+	'\u1e7c':     "V\u0303",
+	'\u1e7d':     "v\u0303",
+	'\u1e80':     "W\u0300",
+	'\u1e81':     "w\u0300",
+	'\u1e82':     "W\u0301",
+	'\u1e83':     "w\u0301",
+	'\u1ebc':     "E\u0303",
+	'\u1ebd':     "e\u0303",
+	'\u1ef2':     "Y\u0300",
+	'\u1ef3':     "y\u0300",
+	'\u1ef8':     "Y\u0303",
+	'\u1ef9':     "y\u0303",
+	'\u2071':     "i",
+	'\u207f':     "n",
+	'\u2090':     "a",
+	'\u2091':     "e",
+	'\u2092':     "o",
+	'\u2093':     "x",
+	'\u2095':     "h",
+	'\u2096':     "k",
+	'\u2097':     "l",
+	'\u2098':     "m",
+	'\u2099':     "n",
+	'\u209a':     "p",
+	'\u209b':     "s",
+	'\u209c':     "t",
+	'\u20a8':     "Rs",
+	'\u2102':     "C",
+	'\u210a':     "g",
+	'\u210b':     "H",
+	'\u210c':     "H",
+	'\u210d':     "H",
+	'\u210e':     "h",
+	'\u2110':     "I",
+	'\u2111':     "I",
+	'\u2112':     "L",
+	'\u2113':     "l",
+	'\u2115':     "N",
+	'\u2116':     "No",
+	'\u2119':     "P",
+	'\u211a':     "Q",
+	'\u211b':     "R",
+	'\u211c':     "R",
+	'\u211d':     "R",
+	'\u2120':     "SM",
+	'\u2121':     "TEL",
+	'\u2122':     "TM",
+	'\u2124':     "Z",
+	'\u2128':     "Z",
+	'\u212a':     "K",
+	'\u212c':     "B",
+	'\u212d':     "C",
+	'\u212f':     "e",
+	'\u2130':     "E",
+	'\u2131':     "F",
+	'\u2133':     "M",
+	'\u2134':     "o",
+	'\u2139':     "i",
+	'\u213b':     "FAX",
+	'\u2145':     "D",
+	'\u2146':     "d",
+	'\u2147':     "e",
+	'\u2148':     "i",
+	'\u2149':     "j",
+	'\u2160':     "I",
+	'\u2161':     "II",
+	'\u2162':     "III",
+	'\u2163':     "IV",
+	'\u2164':     "V",
+	'\u2165':     "VI",
+	'\u2166':     "VII",
+	'\u2167':     "VIII",
+	'\u2168':     "IX",
+	'\u2169':     "X",
+	'\u216a':     "XI",
+	'\u216b':     "XII",
+	'\u216c':     "L",
+	'\u216d':     "C",
+	'\u216e':     "D",
+	'\u216f':     "M",
+	'\u2170':     "i",
+	'\u2171':     "ii",
+	'\u2172':     "iii",
+	'\u2173':     "iv",
+	'\u2174':     "v",
+	'\u2175':     "vi",
+	'\u2176':     "vii",
+	'\u2177':     "viii",
+	'\u2178':     "ix",
+	'\u2179':     "x",
+	'\u217a':     "xi",
+	'\u217b':     "xii",
+	'\u217c':     "l",
+	'\u217d':     "c",
+	'\u217e':     "d",
+	'\u217f':     "m",
+	'\u24b6':     "A",
+	'\u24b7':     "B",
+	'\u24b8':     "C",
+	'\u24b9':     "D",
+	'\u24ba':     "E",
+	'\u24bb':     "F",
+	'\u24bc':     "G",
+	'\u24bd':     "H",
+	'\u24be':     "I",
+	'\u24bf':     "J",
+	'\u24c0':     "K",
+	'\u24c1':     "L",
+	'\u24c2':     "M",
+	'\u24c3':     "N",
+	'\u24c4':     "O",
+	'\u24c5':     "P",
+	'\u24c6':     "Q",
+	'\u24c7':     "R",
+	'\u24c8':     "S",
+	'\u24c9':     "T",
+	'\u24ca':     "U",
+	'\u24cb':     "V",
+	'\u24cc':     "W",
+	'\u24cd':     "X",
+	'\u24ce':     "Y",
+	'\u24cf':     "Z",
+	'\u24d0':     "a",
+	'\u24d1':     "b",
+	'\u24d2':     "c",
+	'\u24d3':     "d",
+	'\u24d4':     "e",
+	'\u24d5':     "f",
+	'\u24d6':     "g",
+	'\u24d7':     "h",
+	'\u24d8':     "i",
+	'\u24d9':     "j",
+	'\u24da':     "k",
+	'\u24db':     "l",
+	'\u24dc':     "m",
+	'\u24dd':     "n",
+	'\u24de':     "o",
+	'\u24df':     "p",
+	'\u24e0':     "q",
+	'\u24e1':     "r",
+	'\u24e2':     "s",
+	'\u24e3':     "t",
+	'\u24e4':     "u",
+	'\u24e5':     "v",
+	'\u24e6':     "w",
+	'\u24e7':     "x",
+	'\u24e8':     "y",
+	'\u24e9':     "z",
+	'\u2c7c':     "j",
+	'\u2c7d':     "V",
+	'\u2e9f':     "\u6bcd",
+	'\u2f00':     "\u4e00",
+	'\u2f04':     "\u4e59",
+	'\u2f06':     "\u4e8c",
+	'\u2f08':     "\u4eba",
+	'\u2f09':     "\u513f",
+	'\u2f0a':     "\u5165",
+	'\u2f0b':     "\u516b",
+	'\u2f0f':     "\u51e0",
+	'\u2f11':     "\u5200",
+	'\u2f12':     "\u529b",
+	'\u2f17':     "\u5341",
+	'\u2f1a':     "\u5382",
+	'\u2f1c':     "\u53c8",
+	'\u2f1d':     "\u53e3",
+	'\u2f1f':     "\u571f",
+	'\u2f20':     "\u58eb",
+	'\u2f24':     "\u5927",
+	'\u2f25':     "\u5973",
+	'\u2f26':     "\u5b50",
+	'\u2f28':     "\u5bf8",
+	'\u2f29':     "\u5c0f",
+	'\u2f2b':     "\u5c38",
+	'\u2f2d':     "\u5c71",
+	'\u2f2f':     "\u5de5",
+	'\u2f32':     "\u5e72",
+	'\u2f34':     "\u5e7f",
+	'\u2f38':     "\u5f13",
+	'\u2f3c':     "\u5fc3",
+	'\u2f3d':     "\u6208",
+	'\u2f3e':     "\u6236",
+	'\u2f3f':     "\u624b",
+	'\u2f40':     "\u652f",
+	'\u2f42':     "\u6587",
+	'\u2f43':     "\u6597",
+	'\u2f44':     "\u65a4",
+	'\u2f45':     "\u65b9",
+	'\u2f46':     "\u65e0",
+	'\u2f47':     "\u65e5",
+	'\u2f48':     "\u66f0",
+	'\u2f49':     "\u6708",
+	'\u2f4a':     "\u6728",
+	'\u2f4c':     "\u6b62",
+	'\u2f50':     "\u6bd4",
+	'\u2f51':     "\u6bdb",
+	'\u2f52':     "\u6c0f",
+	'\u2f53':     "\u6c14",
+	'\u2f54':     "\u6c34",
+	'\u2f55':     "\u706b",
+	'\u2f57':     "\u7236",
+	'\u2f5a':     "\u7247",
+	'\u2f5b':     "\u7259",
+	'\u2f5c':     "\u725b",
+	'\u2f5e':     "\u7384",
+	'\u2f5f':     "\u7389",
+	'\u2f60':     "\u74dc",
+	'\u2f61':     "\u74e6",
+	'\u2f62':     "\u7518",
+	'\u2f63':     "\u751f",
+	'\u2f64':     "\u7528",
+	'\u2f65':     "\u7530",
+	'\u2f69':     "\u767d",
+	'\u2f6a':     "\u76ae",
+	'\u2f6c':     "\u76ee",
+	'\u2f6d':     "\u77db",
+	'\u2f6f':     "\u77f3",
+	'\u2f70':     "\u793a",
+	'\u2f74':     "\u7acb",
+	'\u2f75':     "\u7af9",
+	'\u2f76':     "\u7c73",
+	'\u2f79':     "\u7f51",
+	'\u2f7a':     "\u7f8a",
+	'\u2f7b':     "\u7fbd",
+	'\u2f7c':     "\u8001",
+	'\u2f7d':     "\u800c",
+	'\u2f7f':     "\u8033",
+	'\u2f81':     "\u8089",
+	'\u2f82':     "\u81e3",
+	'\u2f83':     "\u81ea",
+	'\u2f84':     "\u81f3",
+	'\u2f88':     "\u821f",
+	'\u2f8a':     "\u8272",
+	'\u2f8d':     "\u866b",
+	'\u2f8e':     "\u8840",
+	'\u2f8f':     "\u884c",
+	'\u2f90':     "\u8863",
+	'\u2f92':     "\u898b",
+	'\u2f93':     "\u89d2",
+	'\u2f94':     "\u8a00",
+	'\u2f95':     "\u8c37",
+	'\u2f96':     "\u8c46",
+	'\u2f99':     "\u8c9d",
+	'\u2f9a':     "\u8d64",
+	'\u2f9b':     "\u8d70",
+	'\u2f9c':     "\u8db3",
+	'\u2f9d':     "\u8eab",
+	'\u2f9e':     "\u8eca",
+	'\u2f9f':     "\u8f9b",
+	'\u2fa5':     "\u91cc",
+	'\u2fa6':     "\u91d1",
+	'\u2fa7':     "\u9577",
+	'\u2fa8':     "\u9580",
+	'\u2faa':     "\u96b6",
+	'\u2fac':     "\u96e8",
+	'\u2fae':     "\u975e",
+	'\u2faf':     "\u9762",
+	'\u2fb0':     "\u9769",
+	'\u2fb1':     "\u97cb",
+	'\u2fb3':     "\u97f3",
+	'\u2fb4':     "\u9801",
+	'\u2fb5':     "\u98a8",
+	'\u2fb6':     "\u98db",
+	'\u2fb7':     "\u98df",
+	'\u2fb8':     "\u9996",
+	'\u2fb9':     "\u9999",
+	'\u2fba':     "\u99ac",
+	'\u2fbb':     "\u9aa8",
+	'\u2fbc':     "\u9ad8",
+	'\u2fbe':     "\u9b25",
+	'\u2fc1':     "\u9b3c",
+	'\u2fc2':     "\u9b5a",
+	'\u2fc3':     "\u9ce5",
+	'\u2fc6':     "\u9ea5",
+	'\u2fc7':     "\u9ebb",
+	'\u2fc8':     "\u9ec3",
+	'\u2fca':     "\u9ed1",
+	'\u2fce':     "\u9f13",
+	'\u2fd0':     "\u9f3b",
+	'\u2fd1':     "\u9f4a",
+	'\u2fd2':     "\u9f52",
+	'\u2fd3':     "\u9f8d",
+	'\u3038':     "\u5341",
+	'\u304c':     "\u304b\u3099",
+	'\u304e':     "\u304d\u3099",
+	'\u3050':     "\u304f\u3099",
+	'\u3052':     "\u3051\u3099",
+	'\u3054':     "\u3053\u3099",
+	'\u3056':     "\u3055\u3099",
+	'\u3058':     "\u3057\u3099",
+	'\u305a':     "\u3059\u3099",
+	'\u305c':     "\u305b\u3099",
+	'\u305e':     "\u305d\u3099",
+	'\u3060':     "\u305f\u3099",
+	'\u3062':     "\u3061\u3099",
+	'\u3065':     "\u3064\u3099",
+	'\u3067':     "\u3066\u3099",
+	'\u3069':     "\u3068\u3099",
+	'\u3070':     "\u306f\u3099",
+	'\u3071':     "\u306f\u309a",
+	'\u3073':     "\u3072\u3099",
+	'\u3074':     "\u3072\u309a",
+	'\u3076':     "\u3075\u3099",
+	'\u3077':     "\u3075\u309a",
+	'\u3079':     "\u3078\u3099",
+	'\u307a':     "\u3078\u309a",
+	'\u307c':     "\u307b\u3099",
+	'\u307d':     "\u307b\u309a",
+	'\u3094':     "\u3046\u3099",
+	'\u309f':     "\u3088\u308a",
+	'\u3131':     "\u1100",
+	'\u3132':     "\u1101",
+	'\u3134':     "\u1102",
+	'\u3136':     "\u11ad",
+	'\u3137':     "\u1103",
+	'\u3138':     "\u1104",
+	'\u3139':     "\u1105",
+	'\u313a':     "\u11b0",
+	'\u313c':     "\u11b2",
+	'\u3141':     "\u1106",
+	'\u3142':     "\u1107",
+	'\u3143':     "\u1108",
+	'\u3145':     "\u1109",
+	'\u3146':     "\u110a",
+	'\u3147':     "\u110b",
+	'\u3148':     "\u110c",
+	'\u3149':     "\u110d",
+	'\u314a':     "\u110e",
+	'\u314b':     "\u110f",
+	'\u314c':     "\u1110",
+	'\u314d':     "\u1111",
+	'\u314e':     "\u1112",
+	'\u314f':     "\u1161",
+	'\u3150':     "\u1162",
+	'\u3151':     "\u1163",
+	'\u3153':     "\u1165",
+	'\u3154':     "\u1166",
+	'\u3155':     "\u1167",
+	'\u3156':     "\u1168",
+	'\u3157':     "\u1169",
+	'\u3158':     "\u116a",
+	'\u3159':     "\u116b",
+	'\u315a':     "\u116c",
+	'\u315b':     "\u116d",
+	'\u315c':     "\u116e",
+	'\u315d':     "\u116f",
+	'\u315e':     "\u1170",
+	'\u315f':     "\u1171",
+	'\u3160':     "\u1172",
+	'\u3161':     "\u1173",
+	'\u3162':     "\u1174",
+	'\u3163':     "\u1175",
+	'\u3192':     "\u4e00",
+	'\u3193':     "\u4e8c",
+	'\u3194':     "\u4e09",
+	'\u3195':     "\u56db",
+	'\u3196':     "\u4e0a",
+	'\u3197':     "\u4e2d",
+	'\u3198':     "\u4e0b",
+	'\u3199':     "\u7532",
+	'\u319a':     "\u4e59",
+	'\u319b':     "\u4e19",
+	'\u319c':     "\u4e01",
+	'\u319d':     "\u5929",
+	'\u319e':     "\u5730",
+	'\u319f':     "\u4eba",
+	'\u3244':     "\u554f",
+	'\u3245':     "\u5e7c",
+	'\u3246':     "\u6587",
+	'\u3250':     "PTE",
+	'\u3260':     "\u1100",
+	'\u3261':     "\u1102",
+	'\u3262':     "\u1103",
+	'\u3263':     "\u1105",
+	'\u3264':     "\u1106",
+	'\u3265':     "\u1107",
+	'\u3266':     "\u1109",
+	'\u3267':     "\u110b",
+	'\u3268':     "\u110c",
+	'\u3269':     "\u110e",
+	'\u326a':     "\u110f",
+	'\u326b':     "\u1110",
+	'\u326c':     "\u1111",
+	'\u326d':     "\u1112",
+	'\u326e':     "\u1100\u1161",
+	'\u326f':     "\u1102\u1161",
+	'\u3270':     "\u1103\u1161",
+	'\u3271':     "\u1105\u1161",
+	'\u3272':     "\u1106\u1161",
+	'\u3273':     "\u1107\u1161",
+	'\u3274':     "\u1109\u1161",
+	'\u3275':     "\u110b\u1161",
+	'\u3276':     "\u110c\u1161",
+	'\u3277':     "\u110e\u1161",
+	'\u3278':     "\u110f\u1161",
+	'\u3279':     "\u1110\u1161",
+	'\u327a':     "\u1111\u1161",
+	'\u327b':     "\u1112\u1161",
+	'\u327c':     "\u110e\u1161\u11b7\u1100\u1169",
+	'\u327d':     "\u110c\u116e\u110b\u1174",
+	'\u327e':     "\u110b\u116e",
+	'\u3280':     "\u4e00",
+	'\u3281':     "\u4e8c",
+	'\u3282':     "\u4e09",
+	'\u3283':     "\u56db",
+	'\u3284':     "\u4e94",
+	'\u3285':     "\u516d",
+	'\u3286':     "\u4e03",
+	'\u3287':     "\u516b",
+	'\u3288':     "\u4e5d",
+	'\u3289':     "\u5341",
+	'\u328a':     "\u6708",
+	'\u328b':     "\u706b",
+	'\u328c':     "\u6c34",
+	'\u328d':     "\u6728",
+	'\u328e':     "\u91d1",
+	'\u328f':     "\u571f",
+	'\u3290':     "\u65e5",
+	'\u3291':     "\u682a",
+	'\u3292':     "\u6709",
+	'\u3293':     "\u793e",
+	'\u3294':     "\u540d",
+	'\u3295':     "\u7279",
+	'\u3296':     "\u8ca1",
+	'\u3297':     "\u795d",
+	'\u3299':     "\u79d8",
+	'\u329a':     "\u7537",
+	'\u329b':     "\u5973",
+	'\u329c':     "\u9069",
+	'\u329d':     "\u512a",
+	'\u329e':     "\u5370",
+	'\u329f':     "\u6ce8",
+	'\u32a0':     "\u9805",
+	'\u32a1':     "\u4f11",
+	'\u32a2':     "\u5199",
+	'\u32a3':     "\u6b63",
+	'\u32a4':     "\u4e0a",
+	'\u32a5':     "\u4e2d",
+	'\u32a6':     "\u4e0b",
+	'\u32a7':     "\u5de6",
+	'\u32a8':     "\u53f3",
+	'\u32a9':     "\u533b",
+	'\u32aa':     "\u5b97",
+	'\u32ab':     "\u5b66",
+	'\u32ac':     "\u76e3",
+	'\u32ad':     "\u4f01",
+	'\u32ae':     "\u8cc7",
+	'\u32af':     "\u5354",
+	'\u32b0':     "\u591c",
+	'\u32cc':     "Hg",
+	'\u32cd':     "erg",
+	'\u32ce':     "eV",
+	'\u32cf':     "LTD",
+	'\u32ff':     "\u4ee4\u548c",
+	'\u3371':     "hPa",
+	'\u3372':     "da",
+	'\u3373':     "AU",
+	'\u3374':     "bar",
+	'\u3375':     "oV",
+	'\u3376':     "pc",
+	'\u3377':     "dm",
+	'\u337a':     "IU",
+	'\u337b':     "\u5e73\u6210",
+	'\u337d':     "\u5927\u6b63",
+	'\u337e':     "\u660e\u6cbb",
+	'\u337f':     "\u682a\u5f0f\u4f1a\u793e",
+	'\u3380':     "pA",
+	'\u3381':     "nA",
+	'\u3383':     "mA",
+	'\u3384':     "kA",
+	'\u3385':     "KB",
+	'\u3386':     "MB",
+	'\u3387':     "GB",
+	'\u3388':     "cal",
+	'\u3389':     "kcal",
+	'\u338a':     "pF",
+	'\u338b':     "nF",
+	'\u338e':     "mg",
+	'\u338f':     "kg",
+	'\u3390':     "Hz",
+	'\u3391':     "kHz",
+	'\u3392':     "MHz",
+	'\u3393':     "GHz",
+	'\u3394':     "THz",
+	'\u3396':     "ml",
+	'\u3397':     "dl",
+	'\u3398':     "kl",
+	'\u3399':     "fm",
+	'\u339a':     "nm",
+	'\u339c':     "mm",
+	'\u339d':     "cm",
+	'\u339e':     "km",
+	'\u33a9':     "Pa",
+	'\u33aa':     "kPa",
+	'\u33ab':     "MPa",
+	'\u33ac':     "GPa",
+	'\u33ad':     "rad",
+	'\u33b0':     "ps",
+	'\u33b1':     "ns",
+	'\u33b3':     "ms",
+	'\u33b4':     "pV",
+	'\u33b5':     "nV",
+	'\u33b7':     "mV",
+	'\u33b8':     "kV",
+	'\u33b9':     "MV",
+	'\u33ba':     "pW",
+	'\u33bb':     "nW",
+	'\u33bd':     "mW",
+	'\u33be':     "kW",
+	'\u33bf':     "MW",
+	'\u33c3':     "Bq",
+	'\u33c4':     "cc",
+	'\u33c5':     "cd",
+	'\u33c8':     "dB",
+	'\u33c9':     "Gy",
+	'\u33ca':     "ha",
+	'\u33cb':     "HP",
+	'\u33cc':     "in",
+	'\u33cd':     "KK",
+	'\u33ce':     "KM",
+	'\u33cf':     "kt",
+	'\u33d0':     "lm",
+	'\u33d1':     "ln",
+	'\u33d2':     "log",
+	'\u33d3':     "lx",
+	'\u33d4':     "mb",
+	'\u33d5':     "mil",
+	'\u33d6':     "mol",
+	'\u33d7':     "PH",
+	'\u33d9':     "PPM",
+	'\u33da':     "PR",
+	'\u33db':     "sr",
+	'\u33dc':     "Sv",
+	'\u33dd':     "Wb",
+	'\u33ff':     "gal",
+	'\ua7f1':     "S",
+	'\ua7f2':     "C",
+	'\ua7f3':     "F",
+	'\ua7f4':     "Q",
+	'\uf901':     "\u66f4",
+	'\uf902':     "\u8eca",
+	'\uf904':     "\u6ed1",
+	'\uf905':     "\u4e32",
+	'\uf906':     "\u53e5",
+	'\uf90a':     "\u91d1",
+	'\uf90f':     "\u7f85",
+	'\uf911':     "\u87ba",
+	'\uf913':     "\u908f",
+	'\uf914':     "\u6a02",
+	'\uf915':     "\u6d1b",
+	'\uf918':     "\u843d",
+	'\uf91b':     "\u4e82",
+	'\uf91c':     "\u5375",
+	'\uf91d':     "\u6b04",
+	'\uf91e':     "\u721b",
+	'\uf91f':     "\u862d",
+	'\uf923':     "\u85cd",
+	'\uf925':     "\u62c9",
+	'\uf926':     "\u81d8",
+	'\uf927':     "\u881f",
+	'\uf929':     "\u6717",
+	'\uf92a':     "\u6d6a",
+	'\uf92c':     "\u90ce",
+	'\uf92d':     "\u4f86",
+	'\uf92e':     "\u51b7",
+	'\uf92f':     "\u52de",
+	'\uf932':     "\u7210",
+	'\uf933':     "\u76e7",
+	'\uf934':     "\u8001",
+	'\uf937':     "\u8def",
+	'\uf938':     "\u9732",
+	'\uf939':     "\u9b6f",
+	'\uf93d':     "\u7da0",
+	'\uf93f':     "\u9304",
+	'\uf941':     "\u8ad6",
+	'\uf942':     "\u58df",
+	'\uf943':     "\u5f04",
+	'\uf944':     "\u7c60",
+	'\uf946':     "\u7262",
+	'\uf949':     "\u96f7",
+	'\uf94c':     "\u6a13",
+	'\uf94d':     "\u6dda",
+	'\uf94e':     "\u6f0f",
+	'\uf94f':     "\u7d2f",
+	'\uf952':     "\u52d2",
+	'\uf959':     "\u9675",
+	'\uf95a':     "\u8b80",
+	'\uf95c':     "\u6a02",
+	'\uf95d':     "\u8afe",
+	'\uf95e':     "\u4e39",
+	'\uf95f':     "\u5be7",
+	'\uf960':     "\u6012",
+	'\uf961':     "\u7387",
+	'\uf962':     "\u7570",
+	'\uf963':     "\u5317",
+	'\uf965':     "\u4fbf",
+	'\uf967':     "\u4e0d",
+	'\uf969':     "\u6578",
+	'\uf96a':     "\u7d22",
+	'\uf96b':     "\u53c3",
+	'\uf96c':     "\u585e",
+	'\uf96d':     "\u7701",
+	'\uf96e':     "\u8449",
+	'\uf96f':     "\u8aaa",
+	'\uf970':     "\u6bba",
+	'\uf972':     "\u6c88",
+	'\uf974':     "\u82e5",
+	'\uf976':     "\u7565",
+	'\uf977':     "\u4eae",
+	'\uf978':     "\u5169",
+	'\uf979':     "\u51c9",
+	'\uf97a':     "\u6881",
+	'\uf97b':     "\u7ce7",
+	'\uf97c':     "\u826f",
+	'\uf97e':     "\u91cf",
+	'\uf97f':     "\u52f5",
+	'\uf981':     "\u5973",
+	'\uf983':     "\u65c5",
+	'\uf984':     "\u6ffe",
+	'\uf988':     "\u9e97",
+	'\uf989':     "\u9ece",
+	'\uf98a':     "\u529b",
+	'\uf98c':     "\u6b77",
+	'\uf98e':     "\u5e74",
+	'\uf993':     "\u7149",
+	'\uf996':     "\u7df4",
+	'\uf997':     "\u806f",
+	'\uf999':     "\u84ee",
+	'\uf99a':     "\u9023",
+	'\uf99c':     "\u5217",
+	'\uf99d':     "\u52a3",
+	'\uf99f':     "\u70c8",
+	'\uf9a0':     "\u88c2",
+	'\uf9a1':     "\u8aaa",
+	'\uf9a3':     "\u5ff5",
+	'\uf9a8':     "\u4ee4",
+	'\uf9aa':     "\u5be7",
+	'\uf9ab':     "\u5dba",
+	'\uf9b1':     "\u9234",
+	'\uf9b2':     "\u96f6",
+	'\uf9b3':     "\u9748",
+	'\uf9b4':     "\u9818",
+	'\uf9b5':     "\u4f8b",
+	'\uf9b6':     "\u79ae",
+	'\uf9b8':     "\u96b8",
+	'\uf9b9':     "\u60e1",
+	'\uf9ba':     "\u4e86",
+	'\uf9bb':     "\u50da",
+	'\uf9be':     "\u6599",
+	'\uf9bf':     "\u6a02",
+	'\uf9c1':     "\u7642",
+	'\uf9c3':     "\u907c",
+	'\uf9c4':     "\u9f8d",
+	'\uf9c7':     "\u5289",
+	'\uf9c9':     "\u67f3",
+	'\uf9ca':     "\u6d41",
+	'\uf9cb':     "\u6e9c",
+	'\uf9cd':     "\u7559",
+	'\uf9ce':     "\u786b",
+	'\uf9d0':     "\u985e",
+	'\uf9d1':     "\u516d",
+	'\uf9d3':     "\u9678",
+	'\uf9d4':     "\u502b",
+	'\uf9d7':     "\u8f2a",
+	'\uf9d8':     "\u5f8b",
+	'\uf9db':     "\u7387",
+	'\uf9dc':     "\u9686",
+	'\uf9dd':     "\u5229",
+	'\uf9de':     "\u540f",
+	'\uf9e0':     "\u6613",
+	'\uf9e1':     "\u674e",
+	'\uf9e3':     "\u6ce5",
+	'\uf9e4':     "\u7406",
+	'\uf9e8':     "\u88e1",
+	'\uf9e9':     "\u91cc",
+	'\uf9ea':     "\u96e2",
+	'\uf9f4':     "\u6797",
+	'\uf9f6':     "\u81e8",
+	'\uf9f7':     "\u7acb",
+	'\uf9f9':     "\u7c92",
+	'\uf9fa':     "\u72c0",
+	'\uf9fc':     "\u8b58",
+	'\uf9fd':     "\u4ec0",
+	'\uf9fe':     "\u8336",
+	'\uf9ff':     "\u523a",
+	'\ufa00':     "\u5207",
+	'\ufa01':     "\u5ea6",
+	'\ufa03':     "\u7cd6",
+	'\ufa05':     "\u6d1e",
+	'\ufa06':     "\u66b4",
+	'\ufa08':     "\u884c",
+	'\ufa09':     "\u964d",
+	'\ufa0a':     "\u898b",
+	'\ufa16':     "\u732a",
+	'\ufa17':     "\u76ca",
+	'\ufa18':     "\u793c",
+	'\ufa19':     "\u795e",
+	'\ufa1a':     "\u7965",
+	'\ufa1b':     "\u798f",
+	'\ufa1d':     "\u7cbe",
+	'\ufa1e':     "\u7fbd",
+	'\ufa22':     "\u8af8",
+	'\ufa26':     "\u90fd",
+	'\ufa2a':     "\u98ef",
+	'\ufa2b':     "\u98fc",
+	'\ufa2c':     "\u9928",
+	'\ufa32':     "\u514d",
+	'\ufa34':     "\u52e4",
+	'\ufa36':     "\u559d",
+	'\ufa37':     "\u5606",
+	'\ufa38':     "\u5668",
+	'\ufa3a':     "\u58a8",
+	'\ufa3b':     "\u5c64",
+	'\ufa40':     "\u61f2",
+	'\ufa41':     "\u654f",
+	'\ufa42':     "\u65e2",
+	'\ufa44':     "\u6885",
+	'\ufa45':     "\u6d77",
+	'\ufa47':     "\u6f22",
+	'\ufa48':     "\u716e",
+	'\ufa4c':     "\u793e",
+	'\ufa50':     "\u7956",
+	'\ufa51':     "\u795d",
+	'\ufa52':     "\u798d",
+	'\ufa55':     "\u7a81",
+	'\ufa56':     "\u7bc0",
+	'\ufa57':     "\u7df4",
+	'\ufa59':     "\u7e41",
+	'\ufa5a':     "\u7f72",
+	'\ufa5b':     "\u8005",
+	'\ufa5f':     "\u8457",
+	'\ufa61':     "\u8996",
+	'\ufa64':     "\u8cd3",
+	'\ufa68':     "\u96e3",
+	'\ufa69':     "\u97ff",
+	'\ufa6a':     "\u983b",
+	'\ufa70':     "\u4e26",
+	'\ufa71':     "\u51b5",
+	'\ufa72':     "\u5168",
+	'\ufa74':     "\u5145",
+	'\ufa76':     "\u52c7",
+	'\ufa78':     "\u559d",
+	'\ufa7f':     "\u5954",
+	'\ufa84':     "\u5f69",
+	'\ufa88':     "\u6108",
+	'\ufa8b':     "\u61f2",
+	'\ufa8c':     "\u6234",
+	'\ufa8e':     "\u641c",
+	'\ufa92':     "\u6717",
+	'\ufa93':     "\u671b",
+	'\ufa96':     "\u6bba",
+	'\ufa97':     "\u6d41",
+	'\ufa9a':     "\u6f22",
+	'\ufa9c':     "\u716e",
+	'\ufa9d':     "\u77a7",
+	'\ufa9f':     "\u72af",
+	'\ufaa0':     "\u732a",
+	'\ufaa3':     "\u753b",
+	'\ufaa6':     "\u76ca",
+	'\ufaa7':     "\u76db",
+	'\ufaa8':     "\u76f4",
+	'\ufaaa':     "\u7740",
+	'\ufaad':     "\u7bc0",
+	'\ufaae':     "\u7c7b",
+	'\ufab0':     "\u7df4",
+	'\ufab2':     "\u8005",
+	'\ufab3':     "\u8352",
+	'\ufab4':     "\u83ef",
+	'\ufab7':     "\u8986",
+	'\ufab8':     "\u8996",
+	'\ufab9':     "\u8abf",
+	'\ufaba':     "\u8af8",
+	'\ufabb':     "\u8acb",
+	'\ufabd':     "\u8afe",
+	'\ufac0':     "\u8b8a",
+	'\ufac2':     "\u8f38",
+	'\ufac3':     "\u9072",
+	'\ufac7':     "\u96e3",
+	'\ufaca':     "\u97ff",
+	'\ufacc':     "\u983b",
+	'\ufb00':     "ff",
+	'\ufb01':     "fi",
+	'\ufb02':     "fl",
+	'\ufb03':     "ffi",
+	'\ufb04':     "ffl",
+	'\ufb05':     "st",
+	'\ufb06':     "st",
+	'\uff21':     "A",
+	'\uff22':     "B",
+	'\uff23':     "C",
+	'\uff24':     "D",
+	'\uff25':     "E",
+	'\uff26':     "F",
+	'\uff27':     "G",
+	'\uff28':     "H",
+	'\uff29':     "I",
+	'\uff2a':     "J",
+	'\uff2b':     "K",
+	'\uff2c':     "L",
+	'\uff2d':     "M",
+	'\uff2e':     "N",
+	'\uff2f':     "O",
+	'\uff30':     "P",
+	'\uff31':     "Q",
+	'\uff32':     "R",
+	'\uff33':     "S",
+	'\uff34':     "T",
+	'\uff35':     "U",
+	'\uff36':     "V",
+	'\uff37':     "W",
+	'\uff38':     "X",
+	'\uff39':     "Y",
+	'\uff3a':     "Z",
+	'\uff41':     "a",
+	'\uff42':     "b",
+	'\uff43':     "c",
+	'\uff44':     "d",
+	'\uff45':     "e",
+	'\uff46':     "f",
+	'\uff47':     "g",
+	'\uff48':     "h",
+	'\uff49':     "i",
+	'\uff4a':     "j",
+	'\uff4b':     "k",
+	'\uff4c':     "l",
+	'\uff4d':     "m",
+	'\uff4e':     "n",
+	'\uff4f':     "o",
+	'\uff50':     "p",
+	'\uff51':     "q",
+	'\uff52':     "r",
+	'\uff53':     "s",
+	'\uff54':     "t",
+	'\uff55':     "u",
+	'\uff56':     "v",
+	'\uff57':     "w",
+	'\uff58':     "x",
+	'\uff59':     "y",
+	'\uff5a':     "z",
+	'\uff9e':     "\u3099",
+	'\uff9f':     "\u309a",
+	'\uffa1':     "\u1100",
+	'\uffa2':     "\u1101",
+	'\uffa4':     "\u1102",
+	'\uffa6':     "\u11ad",
+	'\uffa7':     "\u1103",
+	'\uffa8':     "\u1104",
+	'\uffa9':     "\u1105",
+	'\uffaa':     "\u11b0",
+	'\uffac':     "\u11b2",
+	'\uffb1':     "\u1106",
+	'\uffb2':     "\u1107",
+	'\uffb3':     "\u1108",
+	'\uffb5':     "\u1109",
+	'\uffb6':     "\u110a",
+	'\uffb7':     "\u110b",
+	'\uffb8':     "\u110c",
+	'\uffb9':     "\u110d",
+	'\uffba':     "\u110e",
+	'\uffbb':     "\u110f",
+	'\uffbc':     "\u1110",
+	'\uffbd':     "\u1111",
+	'\uffbe':     "\u1112",
+	'\uffc2':     "\u1161",
+	'\uffc3':     "\u1162",
+	'\uffc4':     "\u1163",
+	'\uffc6':     "\u1165",
+	'\uffc7':     "\u1166",
+	'\uffca':     "\u1167",
+	'\uffcb':     "\u1168",
+	'\uffcc':     "\u1169",
+	'\uffcd':     "\u116a",
+	'\uffce':     "\u116b",
+	'\uffcf':     "\u116c",
+	'\uffd2':     "\u116d",
+	'\uffd3':     "\u116e",
+	'\uffd4':     "\u116f",
+	'\uffd5':     "\u1170",
+	'\uffd6':     "\u1171",
+	'\uffd7':     "\u1172",
+	'\uffda':     "\u1173",
+	'\uffdb':     "\u1174",
+	'\uffdc':     "\u1175",
+	'\U000107a5': "q",
+	'\U0001ccd6': "A",
+	'\U0001ccd7': "B",
+	'\U0001ccd8': "C",
+	'\U0001ccd9': "D",
+	'\U0001ccda': "E",
+	'\U0001ccdb': "F",
+	'\U0001ccdc': "G",
+	'\U0001ccdd': "H",
+	'\U0001ccde': "I",
+	'\U0001ccdf': "J",
+	'\U0001cce0': "K",
+	'\U0001cce1': "L",
+	'\U0001cce2': "M",
+	'\U0001cce3': "N",
+	'\U0001cce4': "O",
+	'\U0001cce5': "P",
+	'\U0001cce6': "Q",
+	'\U0001cce7': "R",
+	'\U0001cce8': "S",
+	'\U0001cce9': "T",
+	'\U0001ccea': "U",
+	'\U0001cceb': "V",
+	'\U0001ccec': "W",
+	'\U0001cced': "X",
+	'\U0001ccee': "Y",
+	'\U0001ccef': "Z",
+	'\U0001d400': "A",
+	'\U0001d401': "B",
+	'\U0001d402': "C",
+	'\U0001d403': "D",
+	'\U0001d404': "E",
+	'\U0001d405': "F",
+	'\U0001d406': "G",
+	'\U0001d407': "H",
+	'\U0001d408': "I",
+	'\U0001d409': "J",
+	'\U0001d40a': "K",
+	'\U0001d40b': "L",
+	'\U0001d40c': "M",
+	'\U0001d40d': "N",
+	'\U0001d40e': "O",
+	'\U0001d40f': "P",
+	'\U0001d410': "Q",
+	'\U0001d411': "R",
+	'\U0001d412': "S",
+	'\U0001d413': "T",
+	'\U0001d414': "U",
+	'\U0001d415': "V",
+	'\U0001d416': "W",
+	'\U0001d417': "X",
+	'\U0001d418': "Y",
+	'\U0001d419': "Z",
+	'\U0001d41a': "a",
+	'\U0001d41b': "b",
+	'\U0001d41c': "c",
+	'\U0001d41d': "d",
+	'\U0001d41e': "e",
+	'\U0001d41f': "f",
+	'\U0001d420': "g",
+	'\U0001d421': "h",
+	'\U0001d422': "i",
+	'\U0001d423': "j",
+	'\U0001d424': "k",
+	'\U0001d425': "l",
+	'\U0001d426': "m",
+	'\U0001d427': "n",
+	'\U0001d428': "o",
+	'\U0001d429': "p",
+	'\U0001d42a': "q",
+	'\U0001d42b': "r",
+	'\U0001d42c': "s",
+	'\U0001d42d': "t",
+	'\U0001d42e': "u",
+	'\U0001d42f': "v",
+	'\U0001d430': "w",
+	'\U0001d431': "x",
+	'\U0001d432': "y",
+	'\U0001d433': "z",
+	'\U0001d434': "A",
+	'\U0001d435': "B",
+	'\U0001d436': "C",
+	'\U0001d437': "D",
+	'\U0001d438': "E",
+	'\U0001d439': "F",
+	'\U0001d43a': "G",
+	'\U0001d43b': "H",
+	'\U0001d43c': "I",
+	'\U0001d43d': "J",
+	'\U0001d43e': "K",
+	'\U0001d43f': "L",
+	'\U0001d440': "M",
+	'\U0001d441': "N",
+	'\U0001d442': "O",
+	'\U0001d443': "P",
+	'\U0001d444': "Q",
+	'\U0001d445': "R",
+	'\U0001d446': "S",
+	'\U0001d447': "T",
+	'\U0001d448': "U",
+	'\U0001d449': "V",
+	'\U0001d44a': "W",
+	'\U0001d44b': "X",
+	'\U0001d44c': "Y",
+	'\U0001d44d': "Z",
+	'\U0001d44e': "a",
+	'\U0001d44f': "b",
+	'\U0001d450': "c",
+	'\U0001d451': "d",
+	'\U0001d452': "e",
+	'\U0001d453': "f",
+	'\U0001d454': "g",
+	'\U0001d456': "i",
+	'\U0001d457': "j",
+	'\U0001d458': "k",
+	'\U0001d459': "l",
+	'\U0001d45a': "m",
+	'\U0001d45b': "n",
+	'\U0001d45c': "o",
+	'\U0001d45d': "p",
+	'\U0001d45e': "q",
+	'\U0001d45f': "r",
+	'\U0001d460': "s",
+	'\U0001d461': "t",
+	'\U0001d462': "u",
+	'\U0001d463': "v",
+	'\U0001d464': "w",
+	'\U0001d465': "x",
+	'\U0001d466': "y",
+	'\U0001d467': "z",
+	'\U0001d468': "A",
+	'\U0001d469': "B",
+	'\U0001d46a': "C",
+	'\U0001d46b': "D",
+	'\U0001d46c': "E",
+	'\U0001d46d': "F",
+	'\U0001d46e': "G",
+	'\U0001d46f': "H",
+	'\U0001d470': "I",
+	'\U0001d471': "J",
+	'\U0001d472': "K",
+	'\U0001d473': "L",
+	'\U0001d474': "M",
+	'\U0001d475': "N",
+	'\U0001d476': "O",
+	'\U0001d477': "P",
+	'\U0001d478': "Q",
+	'\U0001d479': "R",
+	'\U0001d47a': "S",
+	'\U0001d47b': "T",
+	'\U0001d47c': "U",
+	'\U0001d47d': "V",
+	'\U0001d47e': "W",
+	'\U0001d47f': "X",
+	'\U0001d480': "Y",
+	'\U0001d481': "Z",
+	'\U0001d482': "a",
+	'\U0001d483': "b",
+	'\U0001d484': "c",
+	'\U0001d485': "d",
+	'\U0001d486': "e",
+	'\U0001d487': "f",
+	'\U0001d488': "g",
+	'\U0001d489': "h",
+	'\U0001d48a': "i",
+	'\U0001d48b': "j",
+	'\U0001d48c': "k",
+	'\U0001d48d': "l",
+	'\U0001d48e': "m",
+	'\U0001d48f': "n",
+	'\U0001d490': "o",
+	'\U0001d491': "p",
+	'\U0001d492': "q",
+	'\U0001d493': "r",
+	'\U0001d494': "s",
+	'\U0001d495': "t",
+	'\U0001d496': "u",
+	'\U0001d497': "v",
+	'\U0001d498': "w",
+	'\U0001d499': "x",
+	'\U0001d49a': "y",
+	'\U0001d49b': "z",
+	'\U0001d49c': "A",
+	'\U0001d49e': "C",
+	'\U0001d49f': "D",
+	'\U0001d4a2': "G",
+	'\U0001d4a5': "J",
+	'\U0001d4a6': "K",
+	'\U0001d4a9': "N",
+	'\U0001d4aa': "O",
+	'\U0001d4ab': "P",
+	'\U0001d4ac': "Q",
+	'\U0001d4ae': "S",
+	'\U0001d4af': "T",
+	'\U0001d4b0': "U",
+	'\U0001d4b1': "V",
+	'\U0001d4b2': "W",
+	'\U0001d4b3': "X",
+	'\U0001d4b4': "Y",
+	'\U0001d4b5': "Z",
+	'\U0001d4b6': "a",
+	'\U0001d4b7': "b",
+	'\U0001d4b8': "c",
+	'\U0001d4b9': "d",
+	'\U0001d4bb': "f",
+	'\U0001d4bd': "h",
+	'\U0001d4be': "i",
+	'\U0001d4bf': "j",
+	'\U0001d4c0': "k",
+	'\U0001d4c1': "l",
+	'\U0001d4c2': "m",
+	'\U0001d4c3': "n",
+	'\U0001d4c5': "p",
+	'\U0001d4c6': "q",
+	'\U0001d4c7': "r",
+	'\U0001d4c8': "s",
+	'\U0001d4c9': "t",
+	'\U0001d4ca': "u",
+	'\U0001d4cb': "v",
+	'\U0001d4cc': "w",
+	'\U0001d4cd': "x",
+	'\U0001d4ce': "y",
+	'\U0001d4cf': "z",
+	'\U0001d4d0': "A",
+	'\U0001d4d1': "B",
+	'\U0001d4d2': "C",
+	'\U0001d4d3': "D",
+	'\U0001d4d4': "E",
+	'\U0001d4d5': "F",
+	'\U0001d4d6': "G",
+	'\U0001d4d7': "H",
+	'\U0001d4d8': "I",
+	'\U0001d4d9': "J",
+	'\U0001d4da': "K",
+	'\U0001d4db': "L",
+	'\U0001d4dc': "M",
+	'\U0001d4dd': "N",
+	'\U0001d4de': "O",
+	'\U0001d4df': "P",
+	'\U0001d4e0': "Q",
+	'\U0001d4e1': "R",
+	'\U0001d4e2': "S",
+	'\U0001d4e3': "T",
+	'\U0001d4e4': "U",
+	'\U0001d4e5': "V",
+	'\U0001d4e6': "W",
+	'\U0001d4e7': "X",
+	'\U0001d4e8': "Y",
+	'\U0001d4e9': "Z",
+	'\U0001d4ea': "a",
+	'\U0001d4eb': "b",
+	'\U0001d4ec': "c",
+	'\U0001d4ed': "d",
+	'\U0001d4ee': "e",
+	'\U0001d4ef': "f",
+	'\U0001d4f0': "g",
+	'\U0001d4f1': "h",
+	'\U0001d4f2': "i",
+	'\U0001d4f3': "j",
+	'\U0001d4f4': "k",
+	'\U0001d4f5': "l",
+	'\U0001d4f6': "m",
+	'\U0001d4f7': "n",
+	'\U0001d4f8': "o",
+	'\U0001d4f9': "p",
+	'\U0001d4fa': "q",
+	'\U0001d4fb': "r",
+	'\U0001d4fc': "s",
+	'\U0001d4fd': "t",
+	'\U0001d4fe': "u",
+	'\U0001d4ff': "v",
+	'\U0001d500': "w",
+	'\U0001d501': "x",
+	'\U0001d502': "y",
+	'\U0001d503': "z",
+	'\U0001d504': "A",
+	'\U0001d505': "B",
+	'\U0001d507': "D",
+	'\U0001d508': "E",
+	'\U0001d509': "F",
+	'\U0001d50a': "G",
+	'\U0001d50d': "J",
+	'\U0001d50e': "K",
+	'\U0001d50f': "L",
+	'\U0001d510': "M",
+	'\U0001d511': "N",
+	'\U0001d512': "O",
+	'\U0001d513': "P",
+	'\U0001d514': "Q",
+	'\U0001d516': "S",
+	'\U0001d517': "T",
+	'\U0001d518': "U",
+	'\U0001d519': "V",
+	'\U0001d51a': "W",
+	'\U0001d51b': "X",
+	'\U0001d51c': "Y",
+	'\U0001d51e': "a",
+	'\U0001d51f': "b",
+	'\U0001d520': "c",
+	'\U0001d521': "d",
+	'\U0001d522': "e",
+	'\U0001d523': "f",
+	'\U0001d524': "g",
+	'\U0001d525': "h",
+	'\U0001d526': "i",
+	'\U0001d527': "j",
+	'\U0001d528': "k",
+	'\U0001d529': "l",
+	'\U0001d52a': "m",
+	'\U0001d52b': "n",
+	'\U0001d52c': "o",
+	'\U0001d52d': "p",
+	'\U0001d52e': "q",
+	'\U0001d52f': "r",
+	'\U0001d530': "s",
+	'\U0001d531': "t",
+	'\U0001d532': "u",
+	'\U0001d533': "v",
+	'\U0001d534': "w",
+	'\U0001d535': "x",
+	'\U0001d536': "y",
+	'\U0001d537': "z",
+	'\U0001d538': "A",
+	'\U0001d539': "B",
+	'\U0001d53b': "D",
+	'\U0001d53c': "E",
+	'\U0001d53d': "F",
+	'\U0001d53e': "G",
+	'\U0001d540': "I",
+	'\U0001d541': "J",
+	'\U0001d542': "K",
+	'\U0001d543': "L",
+	'\U0001d544': "M",
+	'\U0001d546': "O",
+	'\U0001d54a': "S",
+	'\U0001d54b': "T",
+	'\U0001d54c': "U",
+	'\U0001d54d': "V",
+	'\U0001d54e': "W",
+	'\U0001d54f': "X",
+	'\U0001d550': "Y",
+	'\U0001d552': "a",
+	'\U0001d553': "b",
+	'\U0001d554': "c",
+	'\U0001d555': "d",
+	'\U0001d556': "e",
+	'\U0001d557': "f",
+	'\U0001d558': "g",
+	'\U0001d559': "h",
+	'\U0001d55a': "i",
+	'\U0001d55b': "j",
+	'\U0001d55c': "k",
+	'\U0001d55d': "l",
+	'\U0001d55e': "m",
+	'\U0001d55f': "n",
+	'\U0001d560': "o",
+	'\U0001d561': "p",
+	'\U0001d562': "q",
+	'\U0001d563': "r",
+	'\U0001d564': "s",
+	'\U0001d565': "t",
+	'\U0001d566': "u",
+	'\U0001d567': "v",
+	'\U0001d568': "w",
+	'\U0001d569': "x",
+	'\U0001d56a': "y",
+	'\U0001d56b': "z",
+	'\U0001d56c': "A",
+	'\U0001d56d': "B",
+	'\U0001d56e': "C",
+	'\U0001d56f': "D",
+	'\U0001d570': "E",
+	'\U0001d571': "F",
+	'\U0001d572': "G",
+	'\U0001d573': "H",
+	'\U0001d574': "I",
+	'\U0001d575': "J",
+	'\U0001d576': "K",
+	'\U0001d577': "L",
+	'\U0001d578': "M",
+	'\U0001d579': "N",
+	'\U0001d57a': "O",
+	'\U0001d57b': "P",
+	'\U0001d57c': "Q",
+	'\U0001d57d': "R",
+	'\U0001d57e': "S",
+	'\U0001d57f': "T",
+	'\U0001d580': "U",
+	'\U0001d581': "V",
+	'\U0001d582': "W",
+	'\U0001d583': "X",
+	'\U0001d584': "Y",
+	'\U0001d585': "Z",
+	'\U0001d586': "a",
+	'\U0001d587': "b",
+	'\U0001d588': "c",
+	'\U0001d589': "d",
+	'\U0001d58a': "e",
+	'\U0001d58b': "f",
+	'\U0001d58c': "g",
+	'\U0001d58d': "h",
+	'\U0001d58e': "i",
+	'\U0001d58f': "j",
+	'\U0001d590': "k",
+	'\U0001d591': "l",
+	'\U0001d592': "m",
+	'\U0001d593': "n",
+	'\U0001d594': "o",
+	'\U0001d595': "p",
+	'\U0001d596': "q",
+	'\U0001d597': "r",
+	'\U0001d598': "s",
+	'\U0001d599': "t",
+	'\U0001d59a': "u",
+	'\U0001d59b': "v",
+	'\U0001d59c': "w",
+	'\U0001d59d': "x",
+	'\U0001d59e': "y",
+	'\U0001d59f': "z",
+	'\U0001d5a0': "A",
+	'\U0001d5a1': "B",
+	'\U0001d5a2': "C",
+	'\U0001d5a3': "D",
+	'\U0001d5a4': "E",
+	'\U0001d5a5': "F",
+	'\U0001d5a6': "G",
+	'\U0001d5a7': "H",
+	'\U0001d5a8': "I",
+	'\U0001d5a9': "J",
+	'\U0001d5aa': "K",
+	'\U0001d5ab': "L",
+	'\U0001d5ac': "M",
+	'\U0001d5ad': "N",
+	'\U0001d5ae': "O",
+	'\U0001d5af': "P",
+	'\U0001d5b0': "Q",
+	'\U0001d5b1': "R",
+	'\U0001d5b2': "S",
+	'\U0001d5b3': "T",
+	'\U0001d5b4': "U",
+	'\U0001d5b5': "V",
+	'\U0001d5b6': "W",
+	'\U0001d5b7': "X",
+	'\U0001d5b8': "Y",
+	'\U0001d5b9': "Z",
+	'\U0001d5ba': "a",
+	'\U0001d5bb': "b",
+	'\U0001d5bc': "c",
+	'\U0001d5bd': "d",
+	'\U0001d5be': "e",
+	'\U0001d5bf': "f",
+	'\U0001d5c0': "g",
+	'\U0001d5c1': "h",
+	'\U0001d5c2': "i",
+	'\U0001d5c3': "j",
+	'\U0001d5c4': "k",
+	'\U0001d5c5': "l",
+	'\U0001d5c6': "m",
+	'\U0001d5c7': "n",
+	'\U0001d5c8': "o",
+	'\U0001d5c9': "p",
+	'\U0001d5ca': "q",
+	'\U0001d5cb': "r",
+	'\U0001d5cc': "s",
+	'\U0001d5cd': "t",
+	'\U0001d5ce': "u",
+	'\U0001d5cf': "v",
+	'\U0001d5d0': "w",
+	'\U0001d5d1': "x",
+	'\U0001d5d2': "y",
+	'\U0001d5d3': "z",
+	'\U0001d5d4': "A",
+	'\U0001d5d5': "B",
+	'\U0001d5d6': "C",
+	'\U0001d5d7': "D",
+	'\U0001d5d8': "E",
+	'\U0001d5d9': "F",
+	'\U0001d5da': "G",
+	'\U0001d5db': "H",
+	'\U0001d5dc': "I",
+	'\U0001d5dd': "J",
+	'\U0001d5de': "K",
+	'\U0001d5df': "L",
+	'\U0001d5e0': "M",
+	'\U0001d5e1': "N",
+	'\U0001d5e2': "O",
+	'\U0001d5e3': "P",
+	'\U0001d5e4': "Q",
+	'\U0001d5e5': "R",
+	'\U0001d5e6': "S",
+	'\U0001d5e7': "T",
+	'\U0001d5e8': "U",
+	'\U0001d5e9': "V",
+	'\U0001d5ea': "W",
+	'\U0001d5eb': "X",
+	'\U0001d5ec': "Y",
+	'\U0001d5ed': "Z",
+	'\U0001d5ee': "a",
+	'\U0001d5ef': "b",
+	'\U0001d5f0': "c",
+	'\U0001d5f1': "d",
+	'\U0001d5f2': "e",
+	'\U0001d5f3': "f",
+	'\U0001d5f4': "g",
+	'\U0001d5f5': "h",
+	'\U0001d5f6': "i",
+	'\U0001d5f7': "j",
+	'\U0001d5f8': "k",
+	'\U0001d5f9': "l",
+	'\U0001d5fa': "m",
+	'\U0001d5fb': "n",
+	'\U0001d5fc': "o",
+	'\U0001d5fd': "p",
+	'\U0001d5fe': "q",
+	'\U0001d5ff': "r",
+	'\U0001d600': "s",
+	'\U0001d601': "t",
+	'\U0001d602': "u",
+	'\U0001d603': "v",
+	'\U0001d604': "w",
+	'\U0001d605': "x",
+	'\U0001d606': "y",
+	'\U0001d607': "z",
+	'\U0001d608': "A",
+	'\U0001d609': "B",
+	'\U0001d60a': "C",
+	'\U0001d60b': "D",
+	'\U0001d60c': "E",
+	'\U0001d60d': "F",
+	'\U0001d60e': "G",
+	'\U0001d60f': "H",
+	'\U0001d610': "I",
+	'\U0001d611': "J",
+	'\U0001d612': "K",
+	'\U0001d613': "L",
+	'\U0001d614': "M",
+	'\U0001d615': "N",
+	'\U0001d616': "O",
+	'\U0001d617': "P",
+	'\U0001d618': "Q",
+	'\U0001d619': "R",
+	'\U0001d61a': "S",
+	'\U0001d61b': "T",
+	'\U0001d61c': "U",
+	'\U0001d61d': "V",
+	'\U0001d61e': "W",
+	'\U0001d61f': "X",
+	'\U0001d620': "Y",
+	'\U0001d621': "Z",
+	'\U0001d622': "a",
+	'\U0001d623': "b",
+	'\U0001d624': "c",
+	'\U0001d625': "d",
+	'\U0001d626': "e",
+	'\U0001d627': "f",
+	'\U0001d628': "g",
+	'\U0001d629': "h",
+	'\U0001d62a': "i",
+	'\U0001d62b': "j",
+	'\U0001d62c': "k",
+	'\U0001d62d': "l",
+	'\U0001d62e': "m",
+	'\U0001d62f': "n",
+	'\U0001d630': "o",
+	'\U0001d631': "p",
+	'\U0001d632': "q",
+	'\U0001d633': "r",
+	'\U0001d634': "s",
+	'\U0001d635': "t",
+	'\U0001d636': "u",
+	'\U0001d637': "v",
+	'\U0001d638': "w",
+	'\U0001d639': "x",
+	'\U0001d63a': "y",
+	'\U0001d63b': "z",
+	'\U0001d63c': "A",
+	'\U0001d63d': "B",
+	'\U0001d63e': "C",
+	'\U0001d63f': "D",
+	'\U0001d640': "E",
+	'\U0001d641': "F",
+	'\U0001d642': "G",
+	'\U0001d643': "H",
+	'\U0001d644': "I",
+	'\U0001d645': "J",
+	'\U0001d646': "K",
+	'\U0001d647': "L",
+	'\U0001d648': "M",
+	'\U0001d649': "N",
+	'\U0001d64a': "O",
+	'\U0001d64b': "P",
+	'\U0001d64c': "Q",
+	'\U0001d64d': "R",
+	'\U0001d64e': "S",
+	'\U0001d64f': "T",
+	'\U0001d650': "U",
+	'\U0001d651': "V",
+	'\U0001d652': "W",
+	'\U0001d653': "X",
+	'\U0001d654': "Y",
+	'\U0001d655': "Z",
+	'\U0001d656': "a",
+	'\U0001d657': "b",
+	'\U0001d658': "c",
+	'\U0001d659': "d",
+	'\U0001d65a': "e",
+	'\U0001d65b': "f",
+	'\U0001d65c': "g",
+	'\U0001d65d': "h",
+	'\U0001d65e': "i",
+	'\U0001d65f': "j",
+	'\U0001d660': "k",
+	'\U0001d661': "l",
+	'\U0001d662': "m",
+	'\U0001d663': "n",
+	'\U0001d664': "o",
+	'\U0001d665': "p",
+	'\U0001d666': "q",
+	'\U0001d667': "r",
+	'\U0001d668': "s",
+	'\U0001d669': "t",
+	'\U0001d66a': "u",
+	'\U0001d66b': "v",
+	'\U0001d66c': "w",
+	'\U0001d66d': "x",
+	'\U0001d66e': "y",
+	'\U0001d66f': "z",
+	'\U0001d670': "A",
+	'\U0001d671': "B",
+	'\U0001d672': "C",
+	'\U0001d673': "D",
+	'\U0001d674': "E",
+	'\U0001d675': "F",
+	'\U0001d676': "G",
+	'\U0001d677': "H",
+	'\U0001d678': "I",
+	'\U0001d679': "J",
+	'\U0001d67a': "K",
+	'\U0001d67b': "L",
+	'\U0001d67c': "M",
+	'\U0001d67d': "N",
+	'\U0001d67e': "O",
+	'\U0001d67f': "P",
+	'\U0001d680': "Q",
+	'\U0001d681': "R",
+	'\U0001d682': "S",
+	'\U0001d683': "T",
+	'\U0001d684': "U",
+	'\U0001d685': "V",
+	'\U0001d686': "W",
+	'\U0001d687': "X",
+	'\U0001d688': "Y",
+	'\U0001d689': "Z",
+	'\U0001d68a': "a",
+	'\U0001d68b': "b",
+	'\U0001d68c': "c",
+	'\U0001d68d': "d",
+	'\U0001d68e': "e",
+	'\U0001d68f': "f",
+	'\U0001d690': "g",
+	'\U0001d691': "h",
+	'\U0001d692': "i",
+	'\U0001d693': "j",
+	'\U0001d694': "k",
+	'\U0001d695': "l",
+	'\U0001d696': "m",
+	'\U0001d697': "n",
+	'\U0001d698': "o",
+	'\U0001d699': "p",
+	'\U0001d69a': "q",
+	'\U0001d69b': "r",
+	'\U0001d69c': "s",
+	'\U0001d69d': "t",
+	'\U0001d69e': "u",
+	'\U0001d69f': "v",
+	'\U0001d6a0': "w",
+	'\U0001d6a1': "x",
+	'\U0001d6a2': "y",
+	'\U0001d6a3': "z",
+	'\U0001f12b': "C",
+	'\U0001f12c': "R",
+	'\U0001f12d': "CD",
+	'\U0001f12e': "WZ",
+	'\U0001f130': "A",
+	'\U0001f131': "B",
+	'\U0001f132': "C",
+	'\U0001f133': "D",
+	'\U0001f134': "E",
+	'\U0001f135': "F",
+	'\U0001f136': "G",
+	'\U0001f137': "H",
+	'\U0001f138': "I",
+	'\U0001f139': "J",
+	'\U0001f13a': "K",
+	'\U0001f13b': "L",
+	'\U0001f13c': "M",
+	'\U0001f13d': "N",
+	'\U0001f13e': "O",
+	'\U0001f13f': "P",
+	'\U0001f140': "Q",
+	'\U0001f141': "R",
+	'\U0001f142': "S",
+	'\U0001f143': "T",
+	'\U0001f144': "U",
+	'\U0001f145': "V",
+	'\U0001f146': "W",
+	'\U0001f147': "X",
+	'\U0001f148': "Y",
+	'\U0001f149': "Z",
+	'\U0001f14a': "HV",
+	'\U0001f14b': "MV",
+	'\U0001f14c': "SD",
+	'\U0001f14d': "SS",
+	'\U0001f14e': "PPV",
+	'\U0001f14f': "WC",
+	'\U0001f16a': "MC",
+	'\U0001f16b': "MD",
+	'\U0001f16c': "MR",
+	'\U0001f190': "DJ",
+	'\U0001f200': "\u307b\u304b",
+	'\U0001f210': "\u624b",
+	'\U0001f211': "\u5b57",
+	'\U0001f212': "\u53cc",
+	'\U0001f214': "\u4e8c",
+	'\U0001f215': "\u591a",
+	'\U0001f216': "\u89e3",
+	'\U0001f217': "\u5929",
+	'\U0001f218': "\u4ea4",
+	'\U0001f219': "\u6620",
+	'\U0001f21a': "\u7121",
+	'\U0001f21b': "\u6599",
+	'\U0001f21c': "\u524d",
+	'\U0001f21d': "\u5f8c",
+	'\U0001f21e': "\u518d",
+	'\U0001f21f': "\u65b0",
+	'\U0001f220': "\u521d",
+	'\U0001f221': "\u7d42",
+	'\U0001f222': "\u751f",
+	'\U0001f224': "\u58f0",
+	'\U0001f225': "\u5439",
+	'\U0001f226': "\u6f14",
+	'\U0001f227': "\u6295",
+	'\U0001f228': "\u6355",
+	'\U0001f229': "\u4e00",
+	'\U0001f22a': "\u4e09",
+	'\U0001f22b': "\u904a",
+	'\U0001f22c': "\u5de6",
+	'\U0001f22d': "\u4e2d",
+	'\U0001f22e': "\u53f3",
+	'\U0001f22f': "\u6307",
+	'\U0001f230': "\u8d70",
+	'\U0001f231': "\u6253",
+	'\U0001f232': "\u7981",
+	'\U0001f233': "\u7a7a",
+	'\U0001f234': "\u5408",
+	'\U0001f236': "\u6709",
+	'\U0001f237': "\u6708",
+	'\U0001f238': "\u7533",
+	'\U0001f239': "\u5272",
+	'\U0001f23b': "\u914d",
+	'\U0001f250': "\u5f97",
+	'\U0001f251': "\u53ef",
+	'\U0002f800': "\u4e3d",
+	'\U0002f804': "\u4f60",
+	'\U0002f809': "\u5099",
+	'\U0002f80b': "\u50cf",
+	'\U0002f80e': "\u514d",
+	'\U0002f811': "\u5177",
+	'\U0002f814': "\u5167",
+	'\U0002f815': "\u518d",
+	'\U0002f81a': "\u51ac",
+	'\U0002f81b': "\u51b5",
+	'\U0002f820': "\u523b",
+	'\U0002f822': "\u5272",
+	'\U0002f825': "\u52c7",
+	'\U0002f827': "\u52e4",
+	'\U0002f829': "\u5305",
+	'\U0002f82b': "\u5317",
+	'\U0002f82e': "\u535a",
+	'\U0002f82f': "\u5373",
+	'\U0002f831': "\u537f",
+	'\U0002f832': "\u537f",
+	'\U0002f833': "\u537f",
+	'\U0002f835': "\u7070",
+	'\U0002f836': "\u53ca",
+	'\U0002f839': "\u53eb",
+	'\U0002f83d': "\u5438",
+	'\U0002f83e': "\u5448",
+	'\U0002f83f': "\u5468",
+	'\U0002f842': "\u5510",
+	'\U0002f845': "\u5584",
+	'\U0002f846': "\u5584",
+	'\U0002f84b': "\u5716",
+	'\U0002f84c': "\u5606",
+	'\U0002f84f': "\u5674",
+	'\U0002f850': "\u5207",
+	'\U0002f851': "\u58ee",
+	'\U0002f852': "\u57ce",
+	'\U0002f855': "\u578b",
+	'\U0002f857': "\u5831",
+	'\U0002f85d': "\u591a",
+	'\U0002f85e': "\u5922",
+	'\U0002f866': "\u5a66",
+	'\U0002f86f': "\u5be7",
+	'\U0002f872': "\u5bff",
+	'\U0002f873': "\u5c06",
+	'\U0002f874': "\u5f53",
+	'\U0002f881': "\u5de1",
+	'\U0002f886': "\u5e3d",
+	'\U0002f899': "\u5f62",
+	'\U0002f89d': "\u5fcd",
+	'\U0002f89e': "\u5fd7",
+	'\U0002f8a7': "\u614c",
+	'\U0002f8a9': "\u614c",
+	'\U0002f8ac': "\u61b2",
+	'\U0002f8ad': "\u61a4",
+	'\U0002f8b0': "\u61f2",
+	'\U0002f8b2': "\u6210",
+	'\U0002f8b5': "\u62b1",
+	'\U0002f8b6': "\u62d4",
+	'\U0002f8b7': "\u6350",
+	'\U0002f8bc': "\u6383",
+	'\U0002f8c1': "\u63a9",
+	'\U0002f8c3': "\u6469",
+	'\U0002f8c8': "\u654f",
+	'\U0002f8c9': "\u656c",
+	'\U0002f8cc': "\u66f8",
+	'\U0002f8cd': "\u6649",
+	'\U0002f8d2': "\u5192",
+	'\U0002f8d4': "\u6700",
+	'\U0002f8d8': "\u6717",
+	'\U0002f8d9': "\u671b",
+	'\U0002f8e2': "\u6885",
+	'\U0002f8ef': "\u6b21",
+	'\U0002f8f3': "\u6b72",
+	'\U0002f8f5': "\u6bba",
+	'\U0002f8fc': "\u6cbf",
+	'\U0002f900': "\u6d3e",
+	'\U0002f901': "\u6d77",
+	'\U0002f902': "\u6d41",
+	'\U0002f903': "\u6d69",
+	'\U0002f904': "\u6d78",
+	'\U0002f908': "\u6e2f",
+	'\U0002f90f': "\u6f6e",
+	'\U0002f918': "\u707d",
+	'\U0002f91a': "\u70ad",
+	'\U0002f929': "\u738b",
+	'\U0002f938': "\u7570",
+	'\U0002f940': "\u76f4",
+	'\U0002f946': "\u771f",
+	'\U0002f947': "\u771f",
+	'\U0002f953': "\u7956",
+	'\U0002f956': "\u798f",
+	'\U0002f963': "\u7bc9",
+	'\U0002f96a': "\u7d00",
+	'\U0002f97a': "\u8005",
+	'\U0002f982': "\u80b2",
+	'\U0002f98d': "\u8f9e",
+	'\U0002f992': "\u52b3",
+	'\U0002f993': "\u82b1",
+	'\U0002f994': "\u82b3",
+	'\U0002f995': "\u82bd",
+	'\U0002f996': "\u82e6",
+	'\U0002f998': "\u82e5",
+	'\U0002f99a': "\u8363",
+	'\U0002f99f': "\u8457",
+	'\U0002f9a2': "\u83cc",
+	'\U0002f9a3': "\u83dc",
+	'\U0002f9b5': "\u8667",
+	'\U0002f9c4': "\u8863",
+	'\U0002f9cf': "\u8aa0",
+	'\U0002f9d1': "\u8b8a",
+	'\U0002f9d4': "\u8cab",
+	'\U0002f9d7': "\u8d77",
+	'\U0002f9df': "\u8f38",
+	'\U0002f9ee': "\u958b",
+	'\U0002fa15': "\u9ebb",
+	'\U0002fa1c': "\u9f3b",
+}
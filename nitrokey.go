@@ -0,0 +1,131 @@
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// WithNitrokey configures a guided flow like WithYubiKey for Nitrokey Pro 2,
+// Storage 2, Nitrokey 3 and Nitrokey Start devices, which checks the
+// Nitrokey can hold NIST P-256 keys and sets its key attributes before
+// moving the keys onto it (see MoveToNitrokey).
+func WithNitrokey(home string) Option {
+	return func(r *Recovery) {
+		r.gpgAgent = true
+		r.gpgAgentHome = home
+		r.nitrokey = true
+	}
+}
+
+// The manufacturer IDs in the serial numbers of Nitrokeys: the Nitrokey Pro
+// and Storage use ZeitControl's OpenPGP card, the Nitrokey 3 Nitrokey's own
+// and the Nitrokey Start runs FSIJ's Gnuk.
+const (
+	manufacturerZeitControl = 0x0005
+	manufacturerNitrokey    = 0x000f
+	manufacturerFSIJ        = 0xf517
+)
+
+// nitrokeyKeyAttributes are the key attributes for the signature (ECDSA,
+// algorithm 19) and encryption (ECDH, algorithm 18) slots, see
+// setCardKeyAttributes.
+var nitrokeyKeyAttributes = []string{"1 19 nistp256", "2 18 nistp256"}
+
+// moveToNitrokey guides the user through moving the keys of entity from
+// gpg-agent onto a Nitrokey.
+func (r *Recovery) moveToNitrokey(entity *openpgp.Entity) error {
+	r.display(`
+-----------------------------------------------------------------------------
+ Moving the keys onto a Nitrokey
+
+ The primary key is moved to the Nitrokey's signature slot and the subkey to
+ its encryption slot, which needs a Nitrokey Pro 2, Storage 2, Nitrokey 3 or
+ Nitrokey Start for NIST P-256 keys (the first Nitrokey Pro and Storage only
+ hold RSA keys). gpg-agent then only keeps stubs pointing at the Nitrokey,
+ so the recovery seed is the only other copy of the keys.
+
+ The Nitrokey Pro and Storage have to be factory reset, wiping them, once
+ the admin PIN has been entered wrongly three times.
+-----------------------------------------------------------------------------`)
+	// scdaemon's own CCID driver can't use the Nitrokey while pcscd has it
+	// open
+	hint := "if pcscd is running, add disable-ccid to scdaemon.conf and run 'gpgconf --kill scdaemon'"
+	card, err := r.selectCard("Nitrokey", hint, checkNitrokey)
+	if err != nil {
+		return err
+	}
+	switch card.Manufacturer {
+	case manufacturerZeitControl, manufacturerNitrokey, manufacturerFSIJ:
+	default:
+		if err := r.warn("card %s doesn't look like a Nitrokey (manufacturer %04X)", card.Serial, card.Manufacturer); err != nil {
+			return err
+		}
+	}
+	pin, err := r.readSecret("Please enter the Nitrokey's admin PIN (12345678 if it hasn't been changed):")
+	if err != nil {
+		return err
+	}
+	defer wipe(pin)
+	if err := MoveToNitrokey(r.ctx, entity, r.gpgAgentHome, card.Serial, pin); err != nil {
+		return fmt.Errorf("could not move the keys onto the Nitrokey: %s", err)
+	}
+	r.info(fmt.Sprintf("Moved the keys onto Nitrokey %s", card.Serial), LogField{"serial", card.Serial})
+	r.audit(auditEntry{Step: "keys moved to card"})
+	return nil
+}
+
+// checkNitrokey returns an error if card can't hold NIST P-256 keys.
+func checkNitrokey(card *Card) error {
+	if card.Version == "" {
+		return fmt.Errorf("unexpected serial number %s", card.Serial)
+	}
+
+	// Gnuk supports ECC as an extension of version 2.0 of the
+	// specification, other cards from version 3
+	if card.Version[0] < '3' && card.Manufacturer != manufacturerFSIJ {
+		return fmt.Errorf("the card implements version %s of the OpenPGP card specification, which only supports RSA keys (a Nitrokey Pro 2, Storage 2, Nitrokey 3 or Nitrokey Start is needed)", card.Version)
+	}
+	return nil
+}
+
+// MoveToNitrokey is like MoveToCard, but first sets the key attributes of
+// the card's signature and encryption slots to NIST P-256 as 'gpg
+// --card-edit' and key-attr would. Nitrokeys ship with RSA key attributes,
+// which not every version of scdaemon changes when writing an ECC key.
+func MoveToNitrokey(ctx context.Context, entity *openpgp.Entity, home, serial string, adminPIN []byte) error {
+	_, agent, err := connectGPGAgent(ctx, home)
+	if err != nil {
+		return err
+	}
+	defer agent.Close()
+	if err := agent.setCardKeyAttributes(nitrokeyKeyAttributes, adminPIN); err != nil {
+		return fmt.Errorf("could not set the key attributes: %s", err)
+	}
+	return agent.moveToCard(entity, serial, adminPIN)
+}
+
+// setCardKeyAttributes sets the key attributes of the inserted card's slots
+// with scdaemon's SETATTR KEY-ATTR, each of attrs being the slot number, the
+// OpenPGP algorithm and the curve.
+func (c *assuanConn) setCardKeyAttributes(attrs []string, adminPIN []byte) error {
+	if _, err := c.transact("OPTION pinentry-mode=loopback", nil); err != nil {
+		return err
+	}
+	for _, attr := range attrs {
+		// the value is plus escaped, as gpg sends it
+		command := "SCD SETATTR KEY-ATTR " + strings.ReplaceAll("--force "+attr, " ", "+")
+		_, err := c.transact(command, func(keyword string) ([]byte, error) {
+			if keyword != "PASSPHRASE" {
+				return nil, fmt.Errorf("unexpected inquiry %s", keyword)
+			}
+			return adminPIN, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package recovery
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckNitrokey(t *testing.T) {
+	for _, test := range []struct {
+		serial string
+		err    string
+	}{
+		// Nitrokey Pro 2
+		{"D2760001240103030005123456780000", ""},
+		// Nitrokey 3
+		{"D276000124010304000F123456780000", ""},
+		// Nitrokey Start
+		{"D276000124010200F517123456780000", ""},
+		// the first Nitrokey Pro
+		{"D2760001240102010005123456780000", "only supports RSA keys"},
+		{"D2760001240102000006123456780000", "only supports RSA keys"},
+	} {
+		conn, _ := dialFakeCardAgent(t, test.serial)
+		card, err := conn.readCard()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = checkNitrokey(card)
+		if test.err == "" && err != nil {
+			t.Fatalf("unexpected error for %s: %s", test.serial, err)
+		} else if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Fatalf("expected a %q error for %s, got %v", test.err, test.serial, err)
+		}
+	}
+}
+
+func TestSetCardKeyAttributes(t *testing.T) {
+	conn, agent := dialFakeCardAgent(t, testCardSerial)
+	if err := conn.setCardKeyAttributes(nitrokeyKeyAttributes, []byte("12345678")); err != nil {
+		t.Fatal(err)
+	}
+	// the attributes gpg --card-edit sends for a NIST P-256 key-attr
+	expected := []string{
+		"OPTION pinentry-mode=loopback",
+		"SCD SETATTR KEY-ATTR --force+1+19+nistp256",
+		"SCD SETATTR KEY-ATTR --force+2+18+nistp256",
+	}
+	if strings.Join(agent.commands, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("unexpected commands %q", agent.commands)
+	}
+}
@@ -0,0 +1,37 @@
+package recovery
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// WithRequireOffline configures the recovery to refuse to run while any
+// non-loopback network interface is up, since the recovery should be run on
+// an air-gapped machine.
+func WithRequireOffline() Option {
+	return func(r *Recovery) {
+		r.requireOffline = true
+	}
+}
+
+// networkInterfaces is a variable so tests can fake the interfaces.
+var networkInterfaces = net.Interfaces
+
+// checkOffline returns an error if any non-loopback network interface is up.
+func checkOffline() error {
+	ifaces, err := networkInterfaces()
+	if err != nil {
+		return fmt.Errorf("could not check network interfaces: %s", err)
+	}
+	var up []string
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp != 0 && iface.Flags&net.FlagLoopback == 0 {
+			up = append(up, iface.Name)
+		}
+	}
+	if len(up) > 0 {
+		return fmt.Errorf("refusing to recover keys while connected to a network (interfaces up: %s): disconnect the machine or disable the interfaces first", strings.Join(up, ", "))
+	}
+	return nil
+}
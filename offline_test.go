@@ -0,0 +1,41 @@
+package recovery
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestRequireOffline(t *testing.T) {
+	defer func(f func() ([]net.Interface, error)) { networkInterfaces = f }(networkInterfaces)
+	setInterfaces := func(ifaces ...net.Interface) {
+		networkInterfaces = func() ([]net.Interface, error) { return ifaces, nil }
+	}
+	lo := net.Interface{Name: "lo", Flags: net.FlagUp | net.FlagLoopback}
+	eth0 := net.Interface{Name: "eth0", Flags: net.FlagUp | net.FlagBroadcast}
+	wlan0 := net.Interface{Name: "wlan0", Flags: net.FlagBroadcast}
+
+	// check the recovery runs with only loopback and down interfaces
+	setInterfaces(lo, wlan0)
+	recoverEntity(t, aliceInput, WithRequireOffline())
+
+	// check the recovery refuses to run with an interface up
+	setInterfaces(lo, eth0, wlan0)
+	var stderr bytes.Buffer
+	err := Run(
+		WithStdin(strings.NewReader(aliceInput)),
+		WithStdout(&bytes.Buffer{}),
+		WithStderr(&stderr),
+		WithRequireOffline(),
+	)
+	if err == nil || !strings.Contains(err.Error(), "interfaces up: eth0)") {
+		t.Fatalf("expected the recovery to refuse to run, got %v", err)
+	}
+	if stderr.Len() > 0 {
+		t.Fatalf("expected nothing to be prompted, got:\n%s", stderr.String())
+	}
+
+	// check the interfaces aren't checked unless required
+	recoverEntity(t, aliceInput)
+}
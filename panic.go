@@ -0,0 +1,51 @@
+package recovery
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// safeRun runs the recovery, passing its result to output and turning a
+// panic (e.g. deep in a dependency) into an error. By the time the panic is
+// recovered the deferred wipes in run have already cleared the secrets, and
+// only a sanitized stack trace is printed since the panic value and the
+// default trace's function arguments could contain secrets.
+func (r *Recovery) safeRun(output func(*Result) error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = r.panicked(p)
+		}
+	}()
+	return r.run(output)
+}
+
+// panicked prints a sanitized stack trace for the panic value p, returning an
+// error describing it.
+func (r *Recovery) panicked(p interface{}) error {
+	// only include the message of runtime errors (e.g. a nil dereference)
+	// since other panic values may contain secrets
+	desc := fmt.Sprintf("%T (value hidden as it may contain secrets)", p)
+	if e, ok := p.(runtime.Error); ok {
+		desc = e.Error()
+	}
+	r.activeLogger().Error(fmt.Sprintf("panic: %s\n\n%s", desc, sanitizedStack(4)), LogField{"panic", desc})
+	return fmt.Errorf("internal error: panic: %s", desc)
+}
+
+// sanitizedStack returns the current stack without any function arguments,
+// skipping the given number of frames.
+func sanitizedStack(skip int) string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var b strings.Builder
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
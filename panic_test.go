@@ -0,0 +1,31 @@
+package recovery
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type panicWriter struct{}
+
+func (panicWriter) Write(p []byte) (int, error) {
+	panic("leaked: " + string(p))
+}
+
+func TestPanicIsSanitized(t *testing.T) {
+	var stderr bytes.Buffer
+	err := Run(
+		WithStdin(strings.NewReader(aliceInput)),
+		WithStdout(panicWriter{}),
+		WithStderr(&stderr),
+	)
+	if err == nil || !strings.Contains(err.Error(), "internal error: panic: string (value hidden") {
+		t.Fatalf("expected a sanitized panic error, got %v", err)
+	}
+	if strings.Contains(err.Error(), "leaked") || strings.Contains(stderr.String(), "leaked") {
+		t.Fatalf("expected the panic value to be hidden, got %s\n%s", err, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "recovery.panicWriter.Write") {
+		t.Fatalf("expected a stack trace, got:\n%s", stderr.String())
+	}
+}
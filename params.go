@@ -0,0 +1,103 @@
+package recovery
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// CurveNIST256P1 is the curve of the keys trezor-agent derives by default,
+// and currently the only one supported.
+const CurveNIST256P1 = "nist256p1"
+
+// Params are the inputs to a recovery.
+type Params struct {
+	// Mnemonic is the BIP39 recovery seed, with words separated by spaces.
+	Mnemonic string
+
+	// Passphrase is the optional BIP39 passphrase.
+	Passphrase string
+
+	// UserID is the user ID given to 'trezor-gpg init'.
+	UserID string
+
+	// Timestamp is the timestamp given to 'trezor-gpg init'.
+	Timestamp time.Time
+
+	// Curve is the curve of the keys, defaulting to CurveNIST256P1.
+	Curve string
+}
+
+// Recover derives the Trezor GPG identity for the given parameters without
+// prompting for anything, so that other tools can embed the derivation. The
+// returned identity contains the private keys.
+func Recover(params Params) (*openpgp.Entity, error) {
+	keys := defaultKeyParams
+	return deriveEntity(params, &keys)
+}
+
+// Derive is like Recover, but derives the identity with the key parameters r
+// was created with (e.g. WithIndex), checking it matches the expected
+// fingerprint if one was given (with WithFingerprint or WithPublicKey). It
+// doesn't modify r, so is safe to call concurrently.
+func (r *Recovery) Derive(params Params) (*Result, error) {
+	keys := defaultKeyParams
+	if r.keys != nil {
+		keys = *r.keys
+	}
+	entity, err := deriveEntity(params, &keys)
+	if err != nil {
+		return nil, err
+	}
+	result := newResult(entity)
+	if r.fingerprint != "" && result.PrimaryFingerprint != r.fingerprint {
+		result.Wipe()
+		return nil, &mismatchError{actual: result.PrimaryFingerprint, expected: r.fingerprint}
+	}
+	return result, nil
+}
+
+// deriveEntity derives the identity for the given parameters with keys.
+func deriveEntity(params Params, keys *keyParams) (*openpgp.Entity, error) {
+	if params.Curve != "" {
+		keys.curve = params.Curve
+	}
+	if err := keys.check(); err != nil {
+		return nil, err
+	}
+	if params.UserID == "" {
+		return nil, errors.New("missing user ID")
+	}
+	if params.Timestamp.IsZero() {
+		return nil, errors.New("missing timestamp")
+	}
+	if err := checkTimestampRange(params.Timestamp); err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(params.Mnemonic)
+	words := make([][]byte, len(fields))
+	for i, field := range fields {
+		words[i] = []byte(field)
+	}
+	defer wipeWords(words)
+	r := &Recovery{stderr: ioutil.Discard}
+	if err := r.checkMnemonic(words); err != nil {
+		return nil, err
+	}
+	mnemonic := bytes.Join(words, []byte(" "))
+	defer wipe(mnemonic)
+	masterKey, err := keys.masterKey(&mnemonicSeed{mnemonic: mnemonic}, params.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeSlip10Key(masterKey)
+	primaryKey, subKey, err := keys.deriveKeys(masterKey, params.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return buildEntity(primaryKey, subKey, params.UserID, params.Timestamp, keys), nil
+}
@@ -0,0 +1,51 @@
+package recovery
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecover(t *testing.T) {
+	for _, v := range testVectors {
+		entity, err := Recover(Params{
+			Mnemonic:   v.Mnemonic,
+			Passphrase: v.Passphrase,
+			UserID:     v.UserID,
+			Timestamp:  time.Unix(v.Timestamp, 0),
+		})
+		if err != nil {
+			t.Fatalf("%s: %s", v.Name, err)
+		}
+		if fp := formatFingerprint(entity.PrimaryKey); fp != v.PrimaryFingerprint {
+			t.Fatalf("%s: unexpected primary fingerprint %s", v.Name, fp)
+		}
+		if fp := formatFingerprint(entity.Subkeys[0].PublicKey); fp != v.SubkeyFingerprint {
+			t.Fatalf("%s: unexpected subkey fingerprint %s", v.Name, fp)
+		}
+		if entity.PrivateKey == nil {
+			t.Fatalf("%s: expected the private key", v.Name)
+		}
+	}
+
+	params := Params{
+		Mnemonic:  demoVector.Mnemonic,
+		UserID:    demoVector.UserID,
+		Timestamp: time.Unix(demoVector.Timestamp, 0),
+	}
+	for _, test := range []struct {
+		modify func(p *Params)
+		err    string
+	}{
+		{func(p *Params) { p.Curve = "ed25519" }, `unsupported curve "ed25519"`},
+		{func(p *Params) { p.UserID = "" }, "missing user ID"},
+		{func(p *Params) { p.Timestamp = time.Time{} }, "missing timestamp"},
+		{func(p *Params) { p.Mnemonic = strings.Repeat("zoo ", 12) }, "checksum incorrect"},
+	} {
+		p := params
+		test.modify(&p)
+		if _, err := Recover(p); err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Fatalf("expected error containing %q, got %v", test.err, err)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package recovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// PassphraseTable derives the primary key of the identity under each of
+// several candidate passphrases and writes a table of their fingerprints to
+// stdout, for users with a standard wallet and hidden wallets who don't know
+// which one their GPG identity was created under. It prompts for the user
+// ID, timestamp and recovery seed like Run, then for the candidate
+// passphrases until a blank one (unless given with WithPassphraseCandidates).
+// The empty passphrase of the standard wallet is always included. The
+// passphrases themselves aren't written, only their position, and if an
+// expected fingerprint is given (e.g. with WithPublicKey) the matching one is
+// marked. No private key is written.
+func PassphraseTable(ctx context.Context, opts ...Option) error {
+	return new(Recovery).PassphraseTable(ctx, opts...)
+}
+
+// PassphraseTable is like the package level PassphraseTable, using the
+// options r was created with followed by opts.
+func (r *Recovery) PassphraseTable(ctx context.Context, opts ...Option) error {
+	run := r.newRun(ctx, opts)
+	return run.finish(run.passphraseTable())
+}
+
+// passphraseTable does the work of PassphraseTable.
+func (r *Recovery) passphraseTable() error {
+	if r.stdin == nil && r.prompter == nil {
+		return errors.New("no input configured: use WithStdin or WithPrompter")
+	}
+	if r.requireOffline {
+		if err := checkOffline(); err != nil {
+			return err
+		}
+	}
+	if err := r.keyParams().check(); err != nil {
+		return err
+	}
+	if r.userIDs != nil || r.timestamps != nil || r.indexes != nil || r.passphraseTypos {
+		return errors.New("only candidate passphrases can be compared in a passphrase table")
+	}
+	// the candidate passphrases are compared by fingerprint rather than
+	// searched, so don't need an expected one
+	candidates := r.passphrases
+	r.passphrases = nil
+	err := r.checkSearch()
+	r.passphrases = candidates
+	if err != nil {
+		return err
+	}
+	if err := r.checkLedger(); err != nil {
+		return err
+	}
+	if err := r.applyTrezorConfig(); err != nil {
+		return err
+	}
+
+	// protect the seed as a recovery does
+	if err := disableCoreDumps(); err != nil {
+		if err := r.warn("could not disable core dumps: %s", err); err != nil {
+			return err
+		}
+	}
+	if err := disableTracing(); err != nil {
+		if err := r.warn("could not prevent other processes attaching: %s", err); err != nil {
+			return err
+		}
+	}
+	freeInput, err := r.setupInput()
+	if err != nil {
+		return err
+	}
+	defer freeInput()
+	if err := r.warnSwap(); err != nil {
+		return err
+	}
+
+	userID, err := r.readUserID()
+	if err != nil {
+		return err
+	}
+	timestamp, err := r.readTimestamp()
+	if err != nil {
+		return err
+	}
+	if err := r.readExpertParams(); err != nil {
+		return err
+	}
+	seed, err := r.readSeed()
+	if err != nil {
+		return err
+	}
+	defer seed.Wipe()
+	if _, ok := seed.(*partialSeed); ok {
+		return errors.New("missing seed words can't be searched in a passphrase table")
+	}
+	passphrases, err := r.readPassphraseCandidates()
+	if err != nil {
+		return err
+	}
+
+	// derive the primary key under each passphrase
+	var b strings.Builder
+	var matched bool
+	fmt.Fprintf(&b, "User ID:   %s\nTimestamp: %d (%s)\n\n", userID, timestamp.Unix(), timestamp.UTC().Format(time.RFC1123))
+	fmt.Fprintf(&b, "%-20s %s\n", "Passphrase", "Primary key fingerprint")
+	for i, passphrase := range passphrases {
+		if err := r.ctx.Err(); err != nil {
+			return err
+		}
+		fingerprint, err := r.passphraseFingerprint(seed, passphrase, userID, timestamp)
+		if err != nil {
+			return err
+		}
+		name := fmt.Sprintf("candidate %d", i)
+		if passphrase == "" {
+			name = "(empty)"
+		}
+		fmt.Fprintf(&b, "%-20s %s", name, fingerprint)
+		if fingerprint == r.fingerprint {
+			b.WriteString("  <- expected")
+			matched = true
+		}
+		b.WriteString("\n")
+	}
+	if r.stdout != nil {
+		if _, err := fmt.Fprint(r.stdout, b.String()); err != nil {
+			return err
+		}
+	} else {
+		r.display("%s", b.String())
+	}
+	r.audit(auditEntry{Step: "passphrase table written"})
+	if r.fingerprint == "" {
+		return nil
+	}
+	if !matched {
+		err := &mismatchError{expected: r.fingerprint, candidates: len(passphrases), noun: candidateNoun(1, len(passphrases), 1, 1, 1)}
+		r.check("fingerprint "+r.fingerprint, err)
+		return err
+	}
+	r.check("fingerprint "+r.fingerprint, nil)
+	return nil
+}
+
+// readPassphraseCandidates returns the empty passphrase followed by the
+// candidate passphrases, prompting for them until a blank one unless given
+// with WithPassphraseCandidates.
+func (r *Recovery) readPassphraseCandidates() ([]string, error) {
+	passphrases := []string{""}
+	add := func(passphrase string) {
+		for _, p := range passphrases {
+			if p == passphrase {
+				return
+			}
+		}
+		passphrases = append(passphrases, passphrase)
+	}
+	if r.passphrases != nil {
+		for _, passphrase := range r.passphrases {
+			add(passphrase)
+		}
+		return passphrases, nil
+	}
+	if r.demo {
+		add(demoVector.Passphrase)
+		return passphrases, nil
+	}
+	for {
+		secret, err := r.readSecret(fmt.Sprintf("Please enter candidate passphrase %d (leave blank once they have all been entered, the empty passphrase is always included):", len(passphrases)))
+		if err != nil {
+			return nil, err
+		}
+		passphrase := string(secret)
+		wipe(secret)
+		if passphrase == "" {
+			break
+		}
+		add(passphrase)
+	}
+	r.display(`-----------------------------------------------------------------------------`)
+	r.audit(auditEntry{Step: "passphrases entered"})
+	return passphrases, nil
+}
+
+// passphraseFingerprint returns the fingerprint of the primary key derived
+// from seed and passphrase.
+func (r *Recovery) passphraseFingerprint(seed Seed, passphrase, userID string, timestamp time.Time) (string, error) {
+	params := r.keyParams()
+	masterKey, err := params.masterKey(seed, passphrase)
+	if err != nil {
+		return "", err
+	}
+	defer wipeSlip10Key(masterKey)
+	primaryKey, subKey, err := params.deriveKeys(masterKey, userID)
+	if err != nil {
+		return "", err
+	}
+	defer wipeKey(primaryKey)
+	defer wipeKey(subKey)
+	return formatFingerprint(packet.NewECDSAPublicKey(timestamp, &primaryKey.PublicKey)), nil
+}
@@ -0,0 +1,52 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPassphraseTable(t *testing.T) {
+	// Alice's passphrase is entered second, after a hidden wallet's
+	input := strings.TrimPrefix(strings.TrimSuffix(aliceInput, "s3cr3t\n"), "yes\n") + "hidden\ns3cr3t\nhidden\n\n"
+	var stdout, stderr bytes.Buffer
+	err := PassphraseTable(context.Background(),
+		WithStdin(strings.NewReader(input)),
+		WithStdout(&stdout),
+		WithStderr(&stderr),
+		WithFingerprint(aliceFingerprint),
+	)
+	if err != nil {
+		t.Fatalf("%s\n%s", err, stderr.String())
+	}
+	table := stdout.String()
+	for _, row := range []string{"(empty)   ", "candidate 1   ", "candidate 2          " + aliceFingerprint + "  <- expected\n"} {
+		if !strings.Contains(table, row) {
+			t.Fatalf("expected the table to contain %q, got:\n%s", row, table)
+		}
+	}
+	if strings.Contains(table, "candidate 3") || strings.Count(table, "<- expected") != 1 {
+		t.Fatalf("expected three rows with one match, got:\n%s", table)
+	}
+	if strings.Contains(table+stderr.String(), "s3cr3t") || strings.Contains(table+stderr.String(), "hidden") {
+		t.Fatalf("expected the passphrases not to be written, got:\n%s\n%s", table, stderr.String())
+	}
+
+	// without the expected passphrase, the table is written but fails to
+	// match
+	stdout.Reset()
+	err = PassphraseTable(context.Background(),
+		WithStdin(strings.NewReader(strings.TrimPrefix(strings.TrimSuffix(aliceInput, "s3cr3t\n"), "yes\n"))),
+		WithStdout(&stdout),
+		WithStderr(&bytes.Buffer{}),
+		WithFingerprint(aliceFingerprint),
+		WithPassphraseCandidates([]string{"hidden"}),
+	)
+	if err == nil || !strings.Contains(err.Error(), "none of the 2 candidate passphrases match") {
+		t.Fatalf("expected a mismatch error, got %v", err)
+	}
+	if !strings.Contains(stdout.String(), "candidate 1") {
+		t.Fatalf("expected the table to be written, got:\n%s", stdout.String())
+	}
+}
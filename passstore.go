@@ -0,0 +1,300 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// WithPasswordStore configures the recovery to offer to re-encrypt the
+// pass(1) password store in dir once the identity has been recovered (see
+// ReencryptPasswordStore), to the given recipients or the recovered identity
+// if there are none.
+func WithPasswordStore(dir string, recipients ...*openpgp.Entity) Option {
+	return func(r *Recovery) {
+		r.passwordStore = dir
+		r.passRecipients = recipients
+	}
+}
+
+// PasswordStoreStats are the number of password files re-encrypted by
+// ReencryptPasswordStore and the paths of those it skipped.
+type PasswordStoreStats struct {
+	Reencrypted int
+	Skipped     []string
+}
+
+// offerPasswordStore asks whether to re-encrypt the configured password store
+// with the recovered identity, doing so if confirmed.
+func (r *Recovery) offerPasswordStore(entity *openpgp.Entity) error {
+	recipients := r.passRecipients
+	if len(recipients) == 0 {
+		recipients = []*openpgp.Entity{entity}
+	}
+	names := make([]string, len(recipients))
+	for i, recipient := range recipients {
+		names[i] = fmt.Sprintf("%s (%s)", entityUserID(recipient), formatKeyID(recipient.PrimaryKey))
+	}
+	if ok, err := r.confirm(fmt.Sprintf("Re-encrypt the password store in %s to %s?", r.passwordStore, strings.Join(names, ", ")), passwordStoreHelp); err != nil {
+		return err
+	} else if !ok {
+		return nil
+	}
+	stats, err := reencryptPasswordStore(r.ctx, r.passwordStore, entity, recipients, r.now())
+	if err != nil {
+		return fmt.Errorf("could not re-encrypt the password store: %s", err)
+	}
+	r.info(fmt.Sprintf("Re-encrypted %d passwords in %s", stats.Reencrypted, r.passwordStore), LogField{"reencrypted", stats.Reencrypted})
+	for _, path := range stats.Skipped {
+		if err := r.warn("skipped %s, which is shared with other keys or not encrypted to the recovered identity", path); err != nil {
+			return err
+		}
+	}
+	r.audit(auditEntry{Step: "password store re-encrypted"})
+	return nil
+}
+
+// ReencryptPasswordStore re-encrypts the passwords in the pass(1) password
+// store in dir, which are decrypted with the recovered identity entity, to
+// recipients (e.g. the recovered identity, a new key or the recovered
+// identity's public key with a new encryption subkey), updating the .gpg-id
+// files to match. Each file is replaced atomically, so the store is usable
+// with either key if re-encrypting is interrupted.
+//
+// Only the parts of the store whose .gpg-id lists just the recovered
+// identity are re-encrypted, since passwords shared with other people would
+// otherwise no longer be readable by them. Files in other parts are returned
+// as skipped.
+func ReencryptPasswordStore(ctx context.Context, dir string, entity *openpgp.Entity, recipients []*openpgp.Entity) (*PasswordStoreStats, error) {
+	return reencryptPasswordStore(ctx, dir, entity, recipients, time.Now())
+}
+
+// reencryptPasswordStore is ReencryptPasswordStore, skipping subkeys of the
+// recipients which have expired by now.
+func reencryptPasswordStore(ctx context.Context, dir string, entity *openpgp.Entity, recipients []*openpgp.Entity, now time.Time) (*PasswordStoreStats, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipients to re-encrypt to")
+	}
+	keys := make([]*packet.PublicKey, len(recipients))
+	ids := make([]string, len(recipients))
+	for i, recipient := range recipients {
+		key, err := encryptionKey(recipient, now)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+		ids[i] = formatFingerprint(recipient.PrimaryKey)
+	}
+
+	// find the .gpg-id files which only list the recovered identity
+	owned := make(map[string]bool)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && (info.Name() == ".git" || info.Name() == ".extensions") {
+			return filepath.SkipDir
+		}
+		if info.Name() != ".gpg-id" {
+			return nil
+		}
+		ok, err := gpgIDOnlyLists(path, entity)
+		if err != nil {
+			return err
+		}
+		owned[filepath.Dir(path)] = ok
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := owned[filepath.Clean(dir)]; !ok {
+		return nil, fmt.Errorf("%s is not a password store: it has no .gpg-id", dir)
+	}
+
+	stats := &PasswordStoreStats{}
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if info.IsDir() && (info.Name() == ".git" || info.Name() == ".extensions") {
+			return filepath.SkipDir
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".gpg") {
+			return nil
+		}
+		if !ownedBy(owned, dir, filepath.Dir(path)) {
+			stats.Skipped = append(stats.Skipped, path)
+			return nil
+		}
+		if err := reencryptFile(path, entity, keys); errors.Is(err, errNotRecipient) {
+			stats.Skipped = append(stats.Skipped, path)
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		}
+		stats.Reencrypted++
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	// point the re-encrypted parts of the store at the recipients
+	gpgID := []byte(strings.Join(ids, "\n") + "\n")
+	for gpgIDDir, ok := range owned {
+		if !ok {
+			continue
+		}
+		if err := writeFileAtomic(filepath.Join(gpgIDDir, ".gpg-id"), gpgID, 0600); err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// ownedBy returns whether the nearest .gpg-id of dir (within the store root)
+// only lists the recovered identity, as pass uses the nearest one.
+func ownedBy(owned map[string]bool, root, dir string) bool {
+	root = filepath.Clean(root)
+	for {
+		if ok, exists := owned[dir]; exists {
+			return ok
+		}
+		if dir == root || dir == filepath.Dir(dir) {
+			return false
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// gpgIDOnlyLists returns whether each key listed in the .gpg-id file at
+// path refers to entity, by fingerprint, key ID, user ID or email address.
+func gpgIDOnlyLists(path string, entity *openpgp.Entity) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	found := false
+	scanner := newLineScanner(f)
+	for scanner.Scan() {
+		id := strings.TrimSpace(scanner.Text())
+		if id == "" || strings.HasPrefix(id, "#") {
+			continue
+		}
+		if !refersTo(id, entity) {
+			return false, nil
+		}
+		found = true
+	}
+	return found, scanErr(scanner, path)
+}
+
+// refersTo returns whether a gpg key specifier refers to entity or one of
+// its subkeys.
+func refersTo(id string, entity *openpgp.Entity) bool {
+	hexID := strings.ToUpper(strings.TrimPrefix(strings.TrimPrefix(strings.TrimSuffix(id, "!"), "0x"), "0X"))
+	keys := []*packet.PublicKey{entity.PrimaryKey}
+	for _, subkey := range entity.Subkeys {
+		keys = append(keys, subkey.PublicKey)
+	}
+	for _, key := range keys {
+		fingerprint := formatFingerprint(key)
+		if hexID == fingerprint || len(hexID) >= 8 && strings.HasSuffix(fingerprint, hexID) {
+			return true
+		}
+	}
+	email := strings.Trim(id, "<>")
+	for name := range entity.Identities {
+		if id == name || strings.EqualFold(email, userIDEmail(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// encryptionKey returns the newest encryption subkey of entity which is valid
+// at now.
+func encryptionKey(entity *openpgp.Entity, now time.Time) (*packet.PublicKey, error) {
+	var key *packet.PublicKey
+	for _, subkey := range entity.Subkeys {
+		sig := subkey.Sig
+		if !sig.FlagsValid || !(sig.FlagEncryptCommunications || sig.FlagEncryptStorage) || sig.KeyExpired(now) {
+			continue
+		}
+		if key == nil || subkey.PublicKey.CreationTime.After(key.CreationTime) {
+			key = subkey.PublicKey
+		}
+	}
+	if key == nil {
+		return nil, fmt.Errorf("%s has no encryption subkey", formatFingerprint(entity.PrimaryKey))
+	}
+	return key, nil
+}
+
+// reencryptFile decrypts the password file at path with entity's subkey and
+// replaces it with the password encrypted to keys.
+func reencryptFile(path string, entity *openpgp.Entity, keys []*packet.PublicKey) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	msg, err := dearmor(bytes.NewReader(data), "PGP MESSAGE")
+	if err != nil {
+		return err
+	}
+	password, err := decryptMessage(msg, entity.Subkeys[0].PrivateKey)
+	if err != nil {
+		return err
+	}
+	defer wipe(password)
+	var encrypted bytes.Buffer
+	if err := encryptMessageTo(&encrypted, rand.Reader, keys, password); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, encrypted.Bytes(), 0600)
+}
+
+// writeFileAtomic replaces the file at path with data and permissions perm
+// by renaming a temporary file over it, so that it is never left partially
+// written.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".")
+	if err != nil {
+		return err
+	}
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
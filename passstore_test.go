@@ -0,0 +1,177 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// writePasswordStore writes a password store of passwords encrypted to
+// entity, with a shared directory which is also encrypted to another key.
+func writePasswordStore(t *testing.T, entity *openpgp.Entity) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		".gpg-id":        formatKeyID(entity.PrimaryKey) + "\n",
+		"shared/.gpg-id": "alice@example.com\nbob@example.com\n",
+	}
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"email/example.com.gpg", "bank.gpg", "shared/wifi.gpg"} {
+		var encrypted bytes.Buffer
+		if err := encryptMessage(&encrypted, rand.Reader, entity.Subkeys[0].PublicKey, []byte(name+" password\n")); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, encrypted.Bytes(), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestReencryptPasswordStore(t *testing.T) {
+	v := demoVector
+	entity, err := newEntity(v.Mnemonic, v.Passphrase, v.UserID, time.Unix(v.Timestamp, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wipeEntity(entity)
+	dir := writePasswordStore(t, entity)
+
+	// re-encrypt to a new RSA key
+	newKey, err := openpgp.NewEntity("Alice", "", "alice@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := ReencryptPasswordStore(context.Background(), dir, entity, []*openpgp.Entity{newKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Reencrypted != 2 || len(stats.Skipped) != 1 || !strings.HasSuffix(stats.Skipped[0], "shared/wifi.gpg") {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	for _, name := range []string{"email/example.com.gpg", "bank.gpg"} {
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		md, err := openpgp.ReadMessage(f, openpgp.EntityList{newKey}, nil, nil)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		password, err := ioutil.ReadAll(md.UnverifiedBody)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(password) != name+" password\n" {
+			t.Fatalf("unexpected password %q", password)
+		}
+	}
+	gpgID, err := ioutil.ReadFile(filepath.Join(dir, ".gpg-id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(gpgID) != formatFingerprint(newKey.PrimaryKey)+"\n" {
+		t.Fatalf("unexpected .gpg-id %q", gpgID)
+	}
+
+	// the shared password is left as it was
+	shared, err := os.Open(filepath.Join(dir, "shared", "wifi.gpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shared.Close()
+	if _, err := decryptMessage(shared, entity.Subkeys[0].PrivateKey); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWithPasswordStore(t *testing.T) {
+	entity := recoverEntity(t, aliceInput)
+	pub := NewIdentity(entity)
+	dir := writePasswordStore(t, entity)
+
+	// declining leaves the store as it is
+	before, err := ioutil.ReadFile(filepath.Join(dir, "bank.gpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recoverEntity(t, aliceInput+"no\n", WithPasswordStore(dir))
+	after, err := ioutil.ReadFile(filepath.Join(dir, "bank.gpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Fatal("expected the store not to be re-encrypted")
+	}
+
+	// re-encrypting to the recovered identity updates the .gpg-id
+	recoverEntity(t, aliceInput+"yes\n", WithPasswordStore(dir))
+	gpgID, err := ioutil.ReadFile(filepath.Join(dir, ".gpg-id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(gpgID) != pub.Fingerprint()+"\n" {
+		t.Fatalf("unexpected .gpg-id %q", gpgID)
+	}
+}
+
+func TestRefersTo(t *testing.T) {
+	v := demoVector
+	entity, err := newEntity(v.Mnemonic, v.Passphrase, v.UserID, time.Unix(v.Timestamp, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wipeEntity(entity)
+	for id, expected := range map[string]bool{
+		v.PrimaryFingerprint:                  true,
+		"0x" + formatKeyID(entity.PrimaryKey): true,
+		v.UserID:                              true,
+		userIDEmail(v.UserID):                 true,
+		"<" + userIDEmail(v.UserID) + ">":     true,
+		"bob@example.com":                     false,
+	} {
+		if refersTo(id, entity) != expected {
+			t.Fatalf("expected refersTo(%q) to be %t", id, expected)
+		}
+	}
+}
+
+func TestEncryptionKeyExpiry(t *testing.T) {
+	v := demoVector
+	entity, err := newEntity(v.Mnemonic, v.Passphrase, v.UserID, time.Unix(v.Timestamp, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wipeEntity(entity)
+	lifetime := uint32(3600)
+	entity.Subkeys[0].Sig.KeyLifetimeSecs = &lifetime
+
+	created := entity.Subkeys[0].PublicKey.CreationTime
+	if _, err := encryptionKey(entity, created.Add(time.Minute)); err != nil {
+		t.Fatalf("expected the subkey to be valid before it expires, got %s", err)
+	}
+	if _, err := encryptionKey(entity, created.Add(2*time.Hour)); err == nil || !strings.Contains(err.Error(), "has no encryption subkey") {
+		t.Fatalf("expected the expired subkey to be skipped, got %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package recovery
+
+import (
+	"crypto/aes"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// p256ECParams is the DER encoded OID of NIST P-256 (prime256v1), the value
+// of CKA_EC_PARAMS.
+var p256ECParams = []byte{0x06, 0x08, 0x2a, 0x86, 0x48, 0xce, 0x3d, 0x03, 0x01, 0x07}
+
+// keyWrapPadIV is the alternative initial value prefix from RFC 5649,
+// section 3.
+var keyWrapPadIV = []byte{0xa6, 0x59, 0x59, 0xa6}
+
+// PKCS11Encoder returns an Encoder which writes the primary key and subkey
+// for importing into a PKCS #11 token such as SoftHSM or an HSM, as a JSON
+// object holding each key's PKCS #8 DER encoding along with the PKCS #11
+// attributes to create its private and public key objects with.
+//
+// If kek is nil the PKCS #8 keys are written as they are, for
+// 'softhsm2-util --import'. Otherwise they are wrapped with the AES key kek
+// (e.g. the HSM's wrapping key) using AES key wrap with padding (RFC 5649,
+// CKM_AES_KEY_WRAP_KWP), for C_UnwrapKey.
+func PKCS11Encoder(kek []byte) Encoder {
+	return pkcs11Encoder{kek: kek}
+}
+
+type pkcs11Encoder struct {
+	kek []byte
+}
+
+type pkcs11Key struct {
+	Fingerprint      string                 `json:"fingerprint"`
+	PKCS8            string                 `json:"pkcs8,omitempty"`
+	WrappedPKCS8     string                 `json:"wrapped_pkcs8,omitempty"`
+	Attributes       map[string]interface{} `json:"private_key_attributes"`
+	PublicAttributes map[string]interface{} `json:"public_key_attributes"`
+}
+
+func (e pkcs11Encoder) Encode(w io.Writer, result *Result) error {
+	if e.kek != nil {
+		if _, err := aes.NewCipher(e.kek); err != nil {
+			return fmt.Errorf("invalid wrapping key: %s", err)
+		}
+	}
+	out := struct {
+		UserID    string       `json:"user_id"`
+		Comment   string       `json:"comment,omitempty"`
+		Mechanism string       `json:"unwrap_mechanism,omitempty"`
+		Keys      []*pkcs11Key `json:"keys"`
+	}{
+		UserID: result.UserID,
+	}
+	if result.Demo {
+		out.Comment = demoComment
+	}
+	if e.kek != nil {
+		out.Mechanism = "CKM_AES_KEY_WRAP_KWP"
+	}
+	for _, k := range []struct {
+		key         *packet.PrivateKey
+		fingerprint string
+		usage       string
+		label       string
+	}{
+		{result.Entity.PrivateKey, result.PrimaryFingerprint, "CKA_SIGN", "signing key"},
+		{result.Entity.Subkeys[0].PrivateKey, result.SubkeyFingerprint, "CKA_DERIVE", "encryption key"},
+	} {
+		key, err := privateECDSAKey(k.key)
+		if err != nil {
+			return err
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return err
+		}
+		pk := &pkcs11Key{Fingerprint: k.fingerprint}
+		if e.kek != nil {
+			wrapped, err := aesKeyWrapPad(e.kek, der)
+			wipe(der)
+			if err != nil {
+				return err
+			}
+			pk.WrappedPKCS8 = base64.StdEncoding.EncodeToString(wrapped)
+		} else {
+			pk.PKCS8 = base64.StdEncoding.EncodeToString(der)
+			wipe(der)
+		}
+
+		// the private and public key objects are paired by their CKA_ID,
+		// which is the OpenPGP key ID
+		id := hex.EncodeToString(k.key.PublicKey.Fingerprint[12:])
+		label := fmt.Sprintf("%s %s", result.UserID, k.label)
+		point := elliptic.Marshal(key.Curve, key.X, key.Y)
+		pk.Attributes = map[string]interface{}{
+			"CKA_CLASS":       "CKO_PRIVATE_KEY",
+			"CKA_KEY_TYPE":    "CKK_EC",
+			"CKA_ID":          id,
+			"CKA_LABEL":       label,
+			"CKA_EC_PARAMS":   hex.EncodeToString(p256ECParams),
+			"CKA_TOKEN":       true,
+			"CKA_PRIVATE":     true,
+			"CKA_SENSITIVE":   true,
+			"CKA_EXTRACTABLE": false,
+			k.usage:           true,
+		}
+		pk.PublicAttributes = map[string]interface{}{
+			"CKA_CLASS":     "CKO_PUBLIC_KEY",
+			"CKA_KEY_TYPE":  "CKK_EC",
+			"CKA_ID":        id,
+			"CKA_LABEL":     label,
+			"CKA_EC_PARAMS": hex.EncodeToString(p256ECParams),
+			"CKA_EC_POINT":  hex.EncodeToString(append([]byte{0x04, byte(len(point))}, point...)),
+			"CKA_TOKEN":     true,
+		}
+		if k.usage == "CKA_SIGN" {
+			pk.PublicAttributes["CKA_VERIFY"] = true
+		}
+		out.Keys = append(out.Keys, pk)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// aesKeyWrapPad implements the AES key wrap with padding algorithm from RFC
+// 5649, for plaintexts which aren't a multiple of 8 bytes.
+func aesKeyWrapPad(kek, plaintext []byte) ([]byte, error) {
+	iv := make([]byte, 8)
+	copy(iv, keyWrapPadIV)
+	binary.BigEndian.PutUint32(iv[4:], uint32(len(plaintext)))
+	padded := make([]byte, (len(plaintext)+7)/8*8)
+	copy(padded, plaintext)
+	defer wipe(padded)
+	if len(padded) > 8 {
+		return aesKeyWrapIV(kek, iv, padded)
+	}
+
+	// a single block is encrypted directly
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 16)
+	copy(out, iv)
+	copy(out[8:], padded)
+	block.Encrypt(out, out)
+	return out, nil
+}
@@ -0,0 +1,116 @@
+package recovery
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAESKeyWrapPad(t *testing.T) {
+	// the test vectors from RFC 5649, section 6
+	kek, _ := hex.DecodeString("5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8")
+	for key, expected := range map[string]string{
+		"c37b7e6492584340bed12207808941155068f738": "138bdeaa9b8fa7fc61f97742e72248ee5ae6ae5360d1ae6a5f54f373fa543b6a",
+		"466f7250617369": "afbeb0f07dfbf5419200f2ccb50bb24f",
+	} {
+		plaintext, _ := hex.DecodeString(key)
+		wrapped, err := aesKeyWrapPad(kek, plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(wrapped) != expected {
+			t.Fatalf("unexpected wrapping of %s: %x", key, wrapped)
+		}
+	}
+}
+
+func TestPKCS11Encoder(t *testing.T) {
+	v := demoVector
+	entity, err := newEntity(v.Mnemonic, v.Passphrase, v.UserID, time.Unix(v.Timestamp, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := newResult(entity)
+	defer result.Wipe()
+
+	type key struct {
+		Fingerprint      string                 `json:"fingerprint"`
+		PKCS8            string                 `json:"pkcs8"`
+		WrappedPKCS8     string                 `json:"wrapped_pkcs8"`
+		Attributes       map[string]interface{} `json:"private_key_attributes"`
+		PublicAttributes map[string]interface{} `json:"public_key_attributes"`
+	}
+	encode := func(kek []byte) (out struct {
+		Mechanism string `json:"unwrap_mechanism"`
+		Keys      []key  `json:"keys"`
+	}) {
+		t.Helper()
+		var buf bytes.Buffer
+		if err := PKCS11Encoder(kek).Encode(&buf, result); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+			t.Fatal(err)
+		}
+		if len(out.Keys) != 2 {
+			t.Fatalf("expected 2 keys, got %d", len(out.Keys))
+		}
+		return out
+	}
+
+	// the PKCS #8 keys are the derived keys, with matching attributes
+	out := encode(nil)
+	for i, fingerprint := range []string{v.PrimaryFingerprint, v.SubkeyFingerprint} {
+		k := out.Keys[i]
+		if k.Fingerprint != fingerprint {
+			t.Fatalf("unexpected fingerprint %s", k.Fingerprint)
+		}
+		der, err := base64.StdEncoding.DecodeString(k.PKCS8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		priv, err := x509.ParsePKCS8PrivateKey(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub := []*ecdsa.PublicKey{
+			entity.PrimaryKey.PublicKey.(*ecdsa.PublicKey),
+			entity.Subkeys[0].PublicKey.PublicKey.(*ecdsa.PublicKey),
+		}[i]
+		if !priv.(*ecdsa.PrivateKey).PublicKey.Equal(pub) {
+			t.Fatalf("unexpected private key for %s", fingerprint)
+		}
+		if k.Attributes["CKA_ID"] != strings.ToLower(fingerprint[24:]) || k.PublicAttributes["CKA_ID"] != k.Attributes["CKA_ID"] {
+			t.Fatalf("unexpected CKA_IDs %v and %v", k.Attributes["CKA_ID"], k.PublicAttributes["CKA_ID"])
+		}
+		if k.Attributes["CKA_EC_PARAMS"] != "06082a8648ce3d030107" || k.Attributes["CKA_EXTRACTABLE"] != false {
+			t.Fatalf("unexpected attributes %v", k.Attributes)
+		}
+	}
+	if out.Keys[0].Attributes["CKA_SIGN"] != true || out.Keys[1].Attributes["CKA_DERIVE"] != true {
+		t.Fatal("expected the primary key to sign and the subkey to derive")
+	}
+
+	// wrapping them with a key encryption key
+	kek := bytes.Repeat([]byte{1}, 32)
+	wrapped := encode(kek)
+	if wrapped.Mechanism != "CKM_AES_KEY_WRAP_KWP" {
+		t.Fatalf("unexpected mechanism %q", wrapped.Mechanism)
+	}
+	for i, k := range wrapped.Keys {
+		der, _ := base64.StdEncoding.DecodeString(out.Keys[i].PKCS8)
+		expected, err := aesKeyWrapPad(kek, der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if k.PKCS8 != "" || k.WrappedPKCS8 != base64.StdEncoding.EncodeToString(expected) {
+			t.Fatal("expected the PKCS #8 keys to be wrapped")
+		}
+	}
+}
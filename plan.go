@@ -0,0 +1,283 @@
+package recovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/lmars/trezor-gpg-recovery/derive"
+)
+
+// Plan reads the non-secret inputs of a recovery (the user ID and timestamp,
+// unless candidates are given) and writes to w exactly what a recovery with
+// the same options would derive and where it would write it, without asking
+// for the recovery seed or passphrase. Nothing is written to the outputs the
+// options configure (including the report and audit log), so the procedure
+// can be reviewed before the ceremony.
+func Plan(ctx context.Context, w io.Writer, opts ...Option) error {
+	return new(Recovery).Plan(ctx, w, opts...)
+}
+
+// Plan is like the package level Plan, using the options r was created with
+// followed by opts.
+func (r *Recovery) Plan(ctx context.Context, w io.Writer, opts ...Option) error {
+	// keep the report and audit log destinations to describe them, without
+	// writing to them
+	var reportOut, auditOut io.Writer
+	opts = append(opts[:len(opts):len(opts)], func(r *Recovery) {
+		reportOut, auditOut = r.reportOut, r.auditOut
+		r.reportOut, r.auditOut = nil, nil
+	})
+	run := r.newRun(ctx, opts)
+	return run.finish(run.plan(w, reportOut, auditOut))
+}
+
+// plan does the work of Plan.
+func (r *Recovery) plan(w io.Writer, reportOut, auditOut io.Writer) error {
+	if r.stdin == nil && r.prompter == nil {
+		return errors.New("no input configured: use WithStdin or WithPrompter")
+	}
+	if err := r.keyParams().check(); err != nil {
+		return err
+	}
+	if err := r.checkSearch(); err != nil {
+		return err
+	}
+	if err := r.checkLedger(); err != nil {
+		return err
+	}
+	if err := r.applyTrezorConfig(); err != nil {
+		return err
+	}
+	if err := r.checkShares(); err != nil {
+		return err
+	}
+	if err := r.checkEncoder(); err != nil {
+		return err
+	}
+	if reportOut != nil && r.reportFormat != ReportJSON && r.reportFormat != ReportText && r.reportFormat != ReportHTML {
+		return fmt.Errorf("unknown report format %q", r.reportFormat)
+	}
+	freeInput, err := r.setupInput()
+	if err != nil {
+		return err
+	}
+	defer freeInput()
+
+	userIDs := r.userIDs
+	if userIDs == nil {
+		userID, err := r.readUserID()
+		if err != nil {
+			return err
+		}
+		userIDs = []string{userID}
+	}
+	timestamps := r.timestamps
+	if timestamps == nil {
+		timestamp, err := r.readTimestamp()
+		if err != nil {
+			return err
+		}
+		timestamps = []time.Time{timestamp}
+	}
+	if err := r.readExpertParams(); err != nil {
+		return err
+	}
+	indexes := r.indexes
+	if indexes == nil {
+		indexes = []uint32{r.keyParams().index}
+	}
+
+	var b strings.Builder
+	params := r.keyParams()
+	fmt.Fprintf(&b, "Recovery plan (%s)\n", params)
+	if r.demo {
+		b.WriteString("This is a practice run with the public demo seed, and the output is watermarked.\n")
+	}
+
+	// the inputs
+	b.WriteString("\nInputs:\n")
+	switch {
+	case r.demo:
+		b.WriteString("  Recovery seed:    the demo seed\n")
+	case r.seedProvider != nil:
+		fmt.Fprintf(&b, "  Recovery seed:    read from the seed provider (%T)\n", r.seedProvider)
+	case r.dualOperator:
+		b.WriteString("  Recovery seed:    prompted for, half the words from each of two operators\n")
+	default:
+		b.WriteString("  Recovery seed:    prompted for one word at a time\n")
+	}
+	switch {
+	case r.passphrases != nil:
+		fmt.Fprintf(&b, "  Passphrase:       searched among %d candidates\n", len(r.passphrases))
+	case r.passphraseTypos:
+		b.WriteString("  Passphrase:       prompted for, searching common typos of it\n")
+	default:
+		b.WriteString("  Passphrase:       prompted for\n")
+	}
+	if r.fingerprint != "" {
+		fmt.Fprintf(&b, "  Fingerprint:      %s (expected)\n", r.fingerprint)
+	}
+
+	// the identities derived
+	b.WriteString("\nDerivation:\n")
+	for _, userID := range userIDs {
+		fmt.Fprintf(&b, "  User ID:          %s\n", userID)
+		if params.device != DeviceLedger {
+			fmt.Fprintf(&b, "  Identity URI:     %s\n", derive.URI(userID))
+		}
+		for _, index := range indexes {
+			p := *params
+			p.index = index
+			primary, subkey := p.paths(userID)
+			fmt.Fprintf(&b, "  Primary key path: %s\n", primary)
+			fmt.Fprintf(&b, "  Subkey path:      %s\n", subkey)
+		}
+	}
+	for _, timestamp := range timestamps {
+		fmt.Fprintf(&b, "  Timestamp:        %d (%s)\n", timestamp.Unix(), timestamp.UTC().Format(time.RFC1123))
+	}
+	if candidates := len(userIDs) * len(timestamps) * len(indexes); candidates > 1 || r.passphrases != nil || r.passphraseTypos {
+		b.WriteString("  The candidates are searched for the expected fingerprint, along with any missing seed words.\n")
+	}
+
+	// the packets of the private key
+	encrypted := "unencrypted"
+	if r.passphraseTTY != nil {
+		encrypted = "encrypted with a one-time passphrase"
+	} else if r.shareOutputs != nil {
+		encrypted = "unencrypted, before being split into shares"
+	}
+	b.WriteString("\nPackets:\n")
+	fmt.Fprintf(&b, "  Secret-Key:       ECDSA %s, created at the timestamp (%s)\n", params.curve, encrypted)
+	if len(userIDs) == 1 {
+		fmt.Fprintf(&b, "  User ID:          %q\n", userIDs[0])
+	} else {
+		b.WriteString("  User ID:          the matching candidate\n")
+	}
+	sigTime := "created at the timestamp"
+	if !params.sigTime.IsZero() {
+		sigTime = fmt.Sprintf("created at %d", params.sigTime.Unix())
+	}
+	primaryUserID := ""
+	if params.primaryUserID {
+		primaryUserID = ", primary user ID"
+	}
+	fmt.Fprintf(&b, "  Signature:        positive certification, %s, %s, key flags %s%s\n", params.sigHash, sigTime, params.primaryFlags, primaryUserID)
+	fmt.Fprintf(&b, "  Secret-Subkey:    ECDH %s, KDF %s with %s, created at the timestamp (%s)\n", params.curve, params.kdfHash, cipherName(params.kdfCipher), encrypted)
+	fmt.Fprintf(&b, "  Signature:        subkey binding, %s, %s, key flags %s\n", params.sigHash, sigTime, params.subkeyFlags)
+
+	// the checks and outputs
+	b.WriteString("\nChecks:\n")
+	b.WriteString("  Encryption self-test\n")
+	if r.fingerprint != "" {
+		b.WriteString("  Fingerprint\n")
+	}
+	if r.testMessage != nil {
+		b.WriteString("  Test decryption\n")
+	}
+	if r.gnupgInterop {
+		b.WriteString("  GnuPG interop\n")
+	}
+	if r.sequoiaCheck {
+		b.WriteString("  Sequoia-PGP certificate checks\n")
+	}
+	b.WriteString("\nOutputs:\n")
+	for _, output := range r.plannedOutputs(reportOut, auditOut) {
+		fmt.Fprintf(&b, "  %s\n", output)
+	}
+	b.WriteString("\nNothing was derived or written: the recovery seed is only entered in the recovery itself.\n")
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return err
+	}
+	r.audit(auditEntry{Step: "planned"})
+	return nil
+}
+
+// plannedOutputs describes where a recovery would write the private key and
+// records of it.
+func (r *Recovery) plannedOutputs(reportOut, auditOut io.Writer) []string {
+	var outputs []string
+	format := "ascii armored"
+	if r.encoder != nil {
+		// name the encoder as NewEncoder does if it's a built-in one
+		format = fmt.Sprintf("%T", r.encoder)
+		for name, newEncoder := range encoders {
+			if fmt.Sprintf("%T", newEncoder()) == fmt.Sprintf("%T", r.encoder) {
+				format = name
+			}
+		}
+	}
+	switch {
+	case r.shareOutputs != nil:
+		for i, w := range r.shareOutputs {
+			outputs = append(outputs, fmt.Sprintf("Share %d of %d (%d needed): %s", i+1, len(r.shareOutputs), r.shareThreshold, writerName(w)))
+		}
+	case r.passphraseTTY != nil:
+		outputs = append(outputs, fmt.Sprintf("Encrypted private key: %s (passphrase displayed on %s)", writerName(r.stdout), writerName(r.passphraseTTY)))
+	case r.stdout != nil:
+		outputs = append(outputs, fmt.Sprintf("Private key (%s): %s", format, writerName(r.stdout)))
+	}
+	if r.gpgAgent || r.yubiKey || r.nitrokey {
+		home := r.gpgAgentHome
+		if home == "" {
+			home = "GNUPGHOME (or ~/.gnupg)"
+		}
+		outputs = append(outputs, "gpg-agent of "+home)
+	}
+	if r.yubiKey {
+		outputs = append(outputs, "YubiKey (after confirming its serial number)")
+	}
+	if r.nitrokey {
+		outputs = append(outputs, "Nitrokey (after confirming its serial number)")
+	}
+	if r.tailsHome != "" {
+		outputs = append(outputs, "Tails persistent GnuPG keyring in "+r.tailsHome+" (if accepted)")
+	}
+	if r.gitSigning {
+		outputs = append(outputs, "git signing configuration (if accepted)")
+	}
+	if r.passwordStore != "" {
+		outputs = append(outputs, "password store in "+r.passwordStore+", re-encrypted (if accepted)")
+	}
+	if r.thunderbirdDir != "" {
+		outputs = append(outputs, "Thunderbird bundle: "+r.thunderbirdDir)
+	}
+	if r.vaultAddr != "" {
+		outputs = append(outputs, fmt.Sprintf("Vault at %s: %s", r.vaultAddr, r.vaultTarget))
+	}
+	if r.sequoiaStore {
+		outputs = append(outputs, "Sequoia-PGP certificate store and keystore")
+	}
+	if r.attestationOut != nil {
+		outputs = append(outputs, "Signed attestation: "+writerName(r.attestationOut))
+	}
+	if reportOut != nil {
+		outputs = append(outputs, fmt.Sprintf("Report (%s): %s", r.reportFormat, writerName(reportOut)))
+	}
+	if auditOut != nil {
+		outputs = append(outputs, "Audit log: "+writerName(auditOut))
+	}
+	if r.checkpointPath != "" {
+		outputs = append(outputs, "Search checkpoint: "+r.checkpointPath)
+	}
+	if r.sessionPath != "" {
+		outputs = append(outputs, "Session file: "+r.sessionPath+" (removed once the recovery succeeds)")
+	}
+	if len(outputs) == 0 {
+		outputs = append(outputs, "none")
+	}
+	return outputs
+}
+
+// writerName describes an output by its name if it has one (e.g. an
+// *os.File), otherwise by its type.
+func writerName(w io.Writer) string {
+	if named, ok := w.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+	return fmt.Sprintf("%T", w)
+}
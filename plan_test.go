@@ -0,0 +1,46 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPlan(t *testing.T) {
+	var plan, stdout, stderr, report, audit bytes.Buffer
+	err := Plan(context.Background(), &plan,
+		WithStdin(strings.NewReader("Alice <alice@example.com>\n1523060353\n")),
+		WithStdout(&stdout),
+		WithStderr(&stderr),
+		WithReport(&report, ReportJSON),
+		WithAuditLog(&audit),
+		WithFingerprint(aliceFingerprint),
+	)
+	if err != nil {
+		t.Fatalf("%s\n%s", err, stderr.String())
+	}
+	for _, expected := range []string{
+		"Identity URI:     gpg://Alice <alice@example.com>",
+		"Primary key path: m/13'/",
+		"Timestamp:        1523060353 (Sat, 07 Apr 2018 00:19:13 UTC)",
+		"Fingerprint:      " + aliceFingerprint,
+		"Signature:        positive certification, SHA-256, created at the timestamp, key flags certify,sign, primary user ID",
+		"Secret-Subkey:    ECDH nist256p1, KDF SHA-256 with AES128",
+		"Private key (ascii armored): *bytes.Buffer",
+		"Report (json): *bytes.Buffer",
+		"Audit log: *bytes.Buffer",
+	} {
+		if !strings.Contains(plan.String(), expected) {
+			t.Fatalf("expected the plan to contain %q, got:\n%s", expected, plan.String())
+		}
+	}
+
+	// nothing is written to the outputs, and the seed isn't asked for
+	if stdout.Len() != 0 || report.Len() != 0 || audit.Len() != 0 {
+		t.Fatalf("expected nothing to be written to the outputs, got %q, %q and %q", stdout.String(), report.String(), audit.String())
+	}
+	if strings.Contains(stderr.String(), "Recovery Seed") {
+		t.Fatalf("expected the seed not to be asked for, got:\n%s", stderr.String())
+	}
+}
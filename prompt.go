@@ -0,0 +1,120 @@
+package recovery
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Prompter reads the answers to the recovery's prompts, so that frontends
+// (e.g. a GUI) and tests can drive the recovery without emulating a
+// terminal. Each method returns ctx.Err() if ctx is cancelled while waiting
+// for an answer.
+type Prompter interface {
+	// ReadLine prompts for a line of input which isn't secret, such as the
+	// user ID.
+	ReadLine(ctx context.Context, prompt string) (string, error)
+
+	// ReadSecret prompts for a secret, such as a seed word or the
+	// passphrase. The recovery copies the secret into locked memory and
+	// then wipes the returned slice, so it need not stay valid after the
+	// next call.
+	ReadSecret(ctx context.Context, prompt string) ([]byte, error)
+
+	// Confirm asks a yes/no question, returning whether the answer was yes.
+	Confirm(ctx context.Context, prompt string) (bool, error)
+}
+
+// WithPrompter configures the recovery to read answers from p rather than
+// prompting on stderr and reading stdin.
+func WithPrompter(p Prompter) Option {
+	return func(r *Recovery) {
+		r.prompter = p
+	}
+}
+
+// errAnswerTooLong is returned when a line of stdin doesn't fit in its buffer.
+var errAnswerTooLong = fmt.Errorf("the answer is longer than %d bytes (check a whole key or file wasn't pasted)", stdinBufferSize)
+
+// terminalPrompter is the default Prompter, which writes prompts to w and
+// reads answers from a scanner of stdin.
+type terminalPrompter struct {
+	scanner *bufio.Scanner
+	w       io.Writer
+
+	// pending is set if a read was cancelled, in which case the scanner's
+	// buffer may still be written to.
+	pending bool
+
+	// plain leaves out the padding and separator lines for screen readers.
+	plain bool
+
+	// large lays out the prompts in large print mode if set.
+	large *largePrompter
+}
+
+func (t *terminalPrompter) ReadLine(ctx context.Context, prompt string) (string, error) {
+	if t.large != nil {
+		line, err := t.large.readLine(ctx, t, prompt)
+		return string(line), err
+	}
+	if t.plain {
+		fmt.Fprintf(t.w, "%s\n> ", prompt)
+		line, err := t.scanLine(ctx, prompt)
+		return string(line), err
+	}
+	fmt.Fprintf(t.w, "%-77s\n> ", prompt)
+	defer fmt.Fprintln(t.w, "-----------------------------------------------------------------------------")
+	line, err := t.scanLine(ctx, prompt)
+	return string(line), err
+}
+
+func (t *terminalPrompter) ReadSecret(ctx context.Context, prompt string) ([]byte, error) {
+	if t.large != nil {
+		return t.large.readLine(ctx, t, prompt)
+	}
+	fmt.Fprintf(t.w, "%s ", prompt)
+	return t.scanLine(ctx, prompt)
+}
+
+func (t *terminalPrompter) Confirm(ctx context.Context, prompt string) (bool, error) {
+	response, err := t.ReadLine(ctx, prompt+" (yes/no):")
+	if err == nil && isHelp(response) {
+		return false, errHelp
+	}
+	return response == "yes", err
+}
+
+// scanLine scans the next line of stdin, returning early if the context is
+// cancelled, and failing at the end of stdin rather than returning an empty
+// answer (e.g. when piped input is too short) or if the line is longer than
+// the buffer. The returned bytes are only valid until the next call.
+func (t *terminalPrompter) scanLine(ctx context.Context, prompt string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	done := make(chan error, 1)
+	go func() {
+		if t.scanner.Scan() {
+			done <- nil
+			return
+		}
+		switch err := t.scanner.Err(); err {
+		case nil:
+			done <- errorf("%w at prompt %q", ErrEndOfInput, strings.TrimSpace(prompt))
+		case bufio.ErrTooLong:
+			done <- errorf("%w at prompt %q", errAnswerTooLong, strings.TrimSpace(prompt))
+		default:
+			done <- err
+		}
+	}()
+	select {
+	case err := <-done:
+		return t.scanner.Bytes(), err
+	case <-ctx.Done():
+		t.pending = true
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,157 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// fakePrompter answers prompts from a list, recording how each was asked.
+// Once the answers run out it returns ErrEndOfInput if end is set.
+type fakePrompter struct {
+	answers []string
+	asked   []string
+	secrets [][]byte
+	end     bool
+}
+
+func (f *fakePrompter) next(kind, prompt string) (string, error) {
+	f.asked = append(f.asked, kind+" "+prompt)
+	if len(f.answers) == 0 && f.end {
+		return "", ErrEndOfInput
+	} else if len(f.answers) == 0 {
+		return "", errors.New("unexpected prompt: " + prompt)
+	}
+	answer := f.answers[0]
+	f.answers = f.answers[1:]
+	return answer, nil
+}
+
+func (f *fakePrompter) ReadLine(ctx context.Context, prompt string) (string, error) {
+	return f.next("line", prompt)
+}
+
+func (f *fakePrompter) ReadSecret(ctx context.Context, prompt string) ([]byte, error) {
+	answer, err := f.next("secret", prompt)
+	secret := []byte(answer)
+	f.secrets = append(f.secrets, secret)
+	return secret, err
+}
+
+func (f *fakePrompter) Confirm(ctx context.Context, prompt string) (bool, error) {
+	answer, err := f.next("confirm", prompt)
+	return answer == "y", err
+}
+
+func TestPrompter(t *testing.T) {
+	p := &fakePrompter{answers: append(
+		[]string{"y", "Alice <alice@example.com>", "1523060353", "12"},
+		append(strings.Fields(strings.Repeat("all ", 12)), "s3cr3t")...,
+	)}
+	var stdout bytes.Buffer
+	err := Run(
+		WithStdin(strings.NewReader("")),
+		WithStdout(&stdout),
+		WithStderr(&bytes.Buffer{}),
+		WithPrompter(p),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entities, err := openpgp.ReadArmoredKeyRing(&stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fp := formatFingerprint(entities[0].PrimaryKey); fp != demoVector.PrimaryFingerprint {
+		t.Fatalf("unexpected fingerprint %s", fp)
+	}
+
+	// check the seed words and passphrase were read as secrets and wiped
+	expected := []string{
+		"confirm Are you sure you want to continue with the recovery?",
+		`line Please enter your GPG User ID (ex: "Alice <alice@example.com>"):`,
+		"line Please enter the timestamp from the original 'trezor-gpg init' command:",
+		"line How many words are in your Recovery Seed? (12, 18 or 24):",
+		"secret  1:",
+	}
+	for i, prompt := range expected {
+		if p.asked[i] != prompt {
+			t.Fatalf("expected prompt %d to be %q, got %q", i, prompt, p.asked[i])
+		}
+	}
+	if last := p.asked[len(p.asked)-1]; !strings.HasPrefix(last, "secret Please enter your passphrase") {
+		t.Fatalf("expected the passphrase to be read as a secret, got %q", last)
+	}
+	for _, secret := range p.secrets {
+		if !bytes.Equal(secret, make([]byte, len(secret))) {
+			t.Fatalf("expected secret to be wiped, got %q", secret)
+		}
+	}
+
+	// check declining to continue aborts
+	p = &fakePrompter{answers: []string{"n"}}
+	err = Run(WithStdout(&bytes.Buffer{}), WithStderr(&bytes.Buffer{}), WithPrompter(p))
+	if err == nil || err.Error() != "aborting at user's request" {
+		t.Fatalf("expected the recovery to abort, got %v", err)
+	}
+}
+
+func TestEndOfInput(t *testing.T) {
+	// stop after the seed length, before any words
+	input := strings.SplitAfter(aliceInput, "\n")
+	err := Run(
+		WithStdin(strings.NewReader(strings.Join(input[:4], ""))),
+		WithStdout(&bytes.Buffer{}),
+		WithStderr(&bytes.Buffer{}),
+	)
+	if !errors.Is(err, ErrEndOfInput) {
+		t.Fatalf("expected ErrEndOfInput, got %v", err)
+	}
+	if err.Error() != `unexpected end of input at prompt "1:" at seed word 1` {
+		t.Fatalf("unexpected error %q", err)
+	}
+
+	// a prompter may return ErrEndOfInput itself
+	p := &fakePrompter{answers: []string{"y", "Alice <alice@example.com>", "1523060353", "12", "all"}, end: true}
+	err = Run(WithStdout(&bytes.Buffer{}), WithStderr(&bytes.Buffer{}), WithPrompter(p))
+	if !errors.Is(err, ErrEndOfInput) || err.Error() != "unexpected end of input at seed word 2" {
+		t.Fatalf("expected the input to end at seed word 2, got %v", err)
+	}
+
+	// check a last answer without a newline is still read
+	err = Run(
+		WithStdin(strings.NewReader("yes\nAlice <alice@example.com>")),
+		WithStdout(&bytes.Buffer{}),
+		WithStderr(&bytes.Buffer{}),
+	)
+	if !errors.Is(err, ErrEndOfInput) || !strings.Contains(err.Error(), "timestamp") {
+		t.Fatalf("expected the input to end at the timestamp prompt, got %v", err)
+	}
+}
+
+func TestLongAnswer(t *testing.T) {
+	// a whole armored key pasted at the user ID prompt
+	err := Run(
+		WithStdin(strings.NewReader("yes\n"+strings.Repeat("A", 2*stdinBufferSize)+"\n")),
+		WithStdout(&bytes.Buffer{}),
+		WithStderr(&bytes.Buffer{}),
+	)
+	if !errors.Is(err, errAnswerTooLong) || !strings.Contains(err.Error(), "User ID") {
+		t.Fatalf("expected a too long answer at the user ID prompt, got %v", err)
+	}
+
+	// a seed word
+	input := strings.SplitAfter(aliceInput, "\n")
+	err = Run(
+		WithStdin(strings.NewReader(strings.Join(input[:4], "")+strings.Repeat("all ", stdinBufferSize)+"\n")),
+		WithStdout(&bytes.Buffer{}),
+		WithStderr(&bytes.Buffer{}),
+	)
+	if !errors.Is(err, errAnswerTooLong) || !strings.HasSuffix(err.Error(), "at seed word 1") {
+		t.Fatalf("expected a too long answer at seed word 1, got %v", err)
+	}
+}
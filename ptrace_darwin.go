@@ -0,0 +1,14 @@
+package recovery
+
+import "syscall"
+
+const ptDenyAttach = 31
+
+// disableTracing denies debuggers (and other processes using ptrace) from
+// attaching to the process.
+func disableTracing() error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_PTRACE, ptDenyAttach, 0, 0); errno != 0 {
+		return errno
+	}
+	return nil
+}
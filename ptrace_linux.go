@@ -0,0 +1,15 @@
+package recovery
+
+import "syscall"
+
+const prSetDumpable = 4
+
+// disableTracing marks the process as non-dumpable, which stops other
+// processes of the same user attaching with ptrace or reading its memory
+// through /proc.
+func disableTracing() error {
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetDumpable, 0, 0); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package recovery
+
+import (
+	"syscall"
+	"testing"
+)
+
+const prGetDumpable = 3
+
+func TestDisableTracing(t *testing.T) {
+	if err := disableTracing(); err != nil {
+		t.Fatal(err)
+	}
+	dumpable, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prGetDumpable, 0, 0)
+	if errno != 0 {
+		t.Fatal(errno)
+	}
+	if dumpable != 0 {
+		t.Fatalf("expected the process to be non-dumpable, got %d", dumpable)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !darwin && !linux
+// +build !darwin,!linux
+
+package recovery
+
+import "errors"
+
+func disableTracing() error {
+	return errors.New("preventing other processes attaching is not supported on this platform")
+}
@@ -0,0 +1,133 @@
+package recovery
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// WithPublicKey configures the public key (armored or binary) of the identity
+// being recovered. The recovered identity must match its fingerprint, and if
+// it doesn't the entered user ID and timestamp are compared with the public
+// key's to help find the cause.
+func WithPublicKey(pub io.Reader) Option {
+	return func(r *Recovery) {
+		r.publicKey = pub
+	}
+}
+
+// readPublicKey reads the public key given with WithPublicKey and sets the
+// expected fingerprint from it.
+func (r *Recovery) readPublicKey() error {
+	body, err := dearmor(r.publicKey, openpgp.PublicKeyType)
+	if err != nil {
+		return fmt.Errorf("could not read public key: %s", err)
+	}
+	entity, err := openpgp.ReadEntity(packet.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("could not read public key: %s", err)
+	}
+	fingerprint := formatFingerprint(entity.PrimaryKey)
+	if r.fingerprint != "" {
+		expected, err := parseFingerprint(r.fingerprint)
+		if err != nil {
+			return err
+		}
+		if expected != fingerprint {
+			return fmt.Errorf("%w: expected fingerprint %s does not match the public key's fingerprint %s", ErrFingerprintMismatch, expected, fingerprint)
+		}
+	}
+	if len(entity.Identities) == 0 {
+		return errors.New("could not read public key: no user IDs found")
+	}
+	r.pubEntity = entity
+	r.fingerprint = fingerprint
+	return nil
+}
+
+// diagnoseMismatch compares the entered user ID and timestamp with those of
+// the public key, since both feed the derivation and are the most common
+// cause of fingerprint mismatches.
+func (r *Recovery) diagnoseMismatch(userID string, timestamp time.Time) {
+	r.display("The recovered identity does not match the public key:")
+
+	// compare the user IDs, which don't feed a Ledger's derivation
+	ledger := r.keyParams().device == DeviceLedger
+	uidMatches := ledger
+	for name := range r.pubEntity.Identities {
+		if name == userID {
+			uidMatches = true
+			break
+		}
+	}
+	if !uidMatches {
+		for name := range r.pubEntity.Identities {
+			r.display(`
+  The entered user ID does not match the public key's user ID:
+
+    entered:    %s
+    public key: %s
+
+  %s
+`, strconv.Quote(userID), strconv.Quote(name), describeDifference(userID, name))
+		}
+	}
+
+	// compare the timestamps
+	created := r.pubEntity.PrimaryKey.CreationTime
+	if !created.Equal(timestamp) {
+		r.display("  The public key was created at timestamp %d (%s) but the entered timestamp is %d.\n",
+			created.Unix(), created.UTC().Format(time.RFC3339), timestamp.Unix())
+	}
+
+	if ledger && created.Equal(timestamp) {
+		r.display("  The timestamp matches the public key, so check the recovery seed, passphrase and Ledger key slot.\n")
+	} else if uidMatches && created.Equal(timestamp) {
+		r.display("  The user ID and timestamp match the public key, so check the recovery seed and passphrase.\n")
+	}
+}
+
+// describeDifference explains how the entered string differs from the
+// expected string, pointing out differences which are hard to see such as
+// case and invisible whitespace.
+func describeDifference(entered, expected string) string {
+	var summary string
+	switch {
+	case strings.EqualFold(entered, expected):
+		summary = "The user IDs differ only in upper/lower case. "
+	case strings.TrimSpace(entered) == strings.TrimSpace(expected):
+		summary = "The user IDs differ only in leading or trailing whitespace. "
+	case strings.Join(strings.Fields(entered), " ") == strings.Join(strings.Fields(expected), " "):
+		summary = "The user IDs differ only in whitespace. "
+	}
+
+	// find the first differing character
+	a, b := []rune(entered), []rune(expected)
+	for i := 0; ; i++ {
+		switch {
+		case i >= len(a):
+			return summary + fmt.Sprintf("The entered user ID is missing %s from the end.", strconv.Quote(string(b[i:])))
+		case i >= len(b):
+			return summary + fmt.Sprintf("The entered user ID has extra %s at the end.", strconv.Quote(string(a[i:])))
+		case a[i] != b[i]:
+			return summary + fmt.Sprintf("They first differ at character %d: entered %s, public key has %s.",
+				i+1, describeRune(a[i]), describeRune(b[i]))
+		}
+	}
+}
+
+func describeRune(c rune) string {
+	s := strconv.QuoteRune(c)
+	if unicode.IsSpace(c) || !unicode.IsPrint(c) || c >= utf8.RuneSelf {
+		s += fmt.Sprintf(" (U+%04X)", c)
+	}
+	return s
+}
@@ -0,0 +1,56 @@
+package recovery
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPublicKeyMismatch(t *testing.T) {
+	// export the test identity's public key
+	var pub bytes.Buffer
+	if err := recoverEntity(t, aliceInput).Serialize(&pub); err != nil {
+		t.Fatal(err)
+	}
+
+	// check a matching recovery succeeds
+	recoverEntity(t, aliceInput, WithPublicKey(bytes.NewReader(pub.Bytes())))
+
+	// check a mismatched user ID is diagnosed
+	var stderr bytes.Buffer
+	input := strings.Replace(aliceInput, "Alice <alice", "alice <alice", 1)
+	err := Run(
+		WithStdin(strings.NewReader(input)),
+		WithStdout(&bytes.Buffer{}),
+		WithStderr(&stderr),
+		WithPublicKey(bytes.NewReader(pub.Bytes())),
+	)
+	if err == nil || !strings.Contains(err.Error(), "does not match expected fingerprint") {
+		t.Fatalf("expected fingerprint mismatch, got %v", err)
+	}
+	for _, s := range []string{
+		`entered:    "alice <alice@example.com>"`,
+		`public key: "Alice <alice@example.com>"`,
+		"The user IDs differ only in upper/lower case.",
+	} {
+		if !strings.Contains(stderr.String(), s) {
+			t.Fatalf("expected output to contain %q, got:\n%s", s, stderr.String())
+		}
+	}
+}
+
+func TestDescribeDifference(t *testing.T) {
+	for _, test := range []struct {
+		entered, expected, description string
+	}{
+		{"alice <alice@example.com>", "Alice <alice@example.com>", "differ only in upper/lower case"},
+		{"Alice <alice@example.com> ", "Alice <alice@example.com>", "differ only in leading or trailing whitespace"},
+		{"Alice\u00a0<alice@example.com>", "Alice <alice@example.com>", `differ only in whitespace. They first differ at character 6: entered '\u00a0' (U+00A0), public key has ' ' (U+0020)`},
+		{"Alice", "Alice <alice@example.com>", `missing " <alice@example.com>" from the end`},
+		{"Alice <alice@example.com> (work)", "Alice <alice@example.com>", `extra " (work)" at the end`},
+	} {
+		if d := describeDifference(test.entered, test.expected); !strings.Contains(d, test.description) {
+			t.Errorf("expected %q to be described with %q, got %q", test.entered, test.description, d)
+		}
+	}
+}
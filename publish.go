@@ -0,0 +1,136 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Forge is a code hosting service the public keys of an identity can be
+// published to with PublishGPGKey and PublishSSHKey, so that commits signed
+// with the recovered key are shown as verified again.
+type Forge string
+
+// The supported forges.
+const (
+	GitHub Forge = "github"
+	GitLab Forge = "gitlab"
+)
+
+// forges are the names and default API URLs of the supported forges.
+var forges = map[Forge]struct {
+	name string
+	api  string
+}{
+	GitHub: {"GitHub", "https://api.github.com"},
+	GitLab: {"GitLab", "https://gitlab.com/api/v4"},
+}
+
+// httpClient is the HTTP client keys are published to forges and written to
+// Vault with.
+var httpClient = &http.Client{Timeout: time.Minute}
+
+// Name returns the forge's name, e.g. "GitHub".
+func (f Forge) Name() string {
+	if forge, ok := forges[f]; ok {
+		return forge.name
+	}
+	return string(f)
+}
+
+// PublishGPGKey uploads the public key of id to the account of token on
+// forge, using the API at apiURL (the forge's public instance if empty, or
+// e.g. https://gitlab.example.com/api/v4). It returns whether the account
+// already had the key, which isn't an error.
+//
+// GitHub tokens need the write:gpg_key scope and GitLab tokens the api
+// scope.
+func PublishGPGKey(ctx context.Context, forge Forge, apiURL, token string, id *Identity) (bool, error) {
+	armored, err := id.ArmorPublic()
+	if err != nil {
+		return false, err
+	}
+	var body interface{}
+	switch forge {
+	case GitHub:
+		body = map[string]string{"name": id.UserID(), "armored_public_key": armored}
+	case GitLab:
+		body = map[string]string{"key": armored}
+	}
+	return forge.post(ctx, apiURL, token, "/user/gpg_keys", body)
+}
+
+// PublishSSHKey is like PublishGPGKey but uploads the primary key as an SSH
+// public key (see Identity.SSHPublicKey), for the SSH access which went
+// through trezor-agent. GitHub tokens need the write:public_key scope.
+func PublishSSHKey(ctx context.Context, forge Forge, apiURL, token string, id *Identity) (bool, error) {
+	key, err := id.SSHPublicKey()
+	if err != nil {
+		return false, err
+	}
+	return forge.post(ctx, apiURL, token, "/user/keys", map[string]string{"title": id.UserID(), "key": key})
+}
+
+// post posts body as JSON to path of the forge's API, returning whether the
+// key being added already exists.
+func (f Forge) post(ctx context.Context, apiURL, token, path string, body interface{}) (bool, error) {
+	forge, ok := forges[f]
+	if !ok {
+		return false, fmt.Errorf("unknown forge %q: must be github or gitlab", f)
+	}
+	if apiURL == "" {
+		apiURL = forge.api
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return false, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", strings.TrimSuffix(apiURL, "/")+path, bytes.NewReader(data))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	switch f {
+	case GitHub:
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	case GitLab:
+		req.Header.Set("PRIVATE-TOKEN", token)
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return false, err
+	}
+	if res.StatusCode == http.StatusCreated {
+		return false, nil
+	}
+
+	// GitHub responds 422 with e.g. "key_id already exists" or "key is
+	// already in use", and GitLab 400 with "has already been taken"
+	if (res.StatusCode == http.StatusUnprocessableEntity || res.StatusCode == http.StatusBadRequest) && bytes.Contains(resBody, []byte("already")) {
+		return true, nil
+	}
+	// the message is a string, except for GitLab's validation errors which
+	// are shown as they are
+	var errRes struct {
+		Message interface{} `json:"message"`
+	}
+	msg := strings.TrimSpace(string(resBody))
+	if err := json.Unmarshal(resBody, &errRes); err == nil {
+		if s, ok := errRes.Message.(string); ok {
+			msg = s
+		}
+	}
+	return false, fmt.Errorf("%s responded %s: %s", forge.name, res.Status, msg)
+}
@@ -0,0 +1,147 @@
+package recovery
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// fakeForge serves the key endpoints of a forge's API, accepting each key
+// once.
+func fakeForge(t *testing.T, forge Forge, token string) *httptest.Server {
+	t.Helper()
+	keys := make(map[string]bool)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var auth string
+		switch forge {
+		case GitHub:
+			auth = strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+		case GitLab:
+			auth = req.Header.Get("PRIVATE-TOKEN")
+		}
+		if auth != token {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message":"Bad credentials"}`))
+			return
+		}
+		var body map[string]string
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var key string
+		switch {
+		case req.URL.Path == "/user/gpg_keys" && forge == GitHub:
+			key = body["armored_public_key"]
+		case req.URL.Path == "/user/gpg_keys" && forge == GitLab:
+			key = body["key"]
+		case req.URL.Path == "/user/keys" && body["title"] != "":
+			key = body["key"]
+		}
+		if key == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		// GPG keys are identified by their fingerprint, since the
+		// signatures differ each time they are made
+		if strings.HasPrefix(key, "-----BEGIN PGP PUBLIC KEY BLOCK-----") {
+			entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(key))
+			if err != nil {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				return
+			}
+			key = formatFingerprint(entities[0].PrimaryKey)
+		}
+		if keys[key] {
+			if forge == GitHub {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				w.Write([]byte(`{"message":"Validation Failed","errors":[{"message":"key_id already exists"}]}`))
+			} else {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"message":{"fingerprint":["has already been taken"]}}`))
+			}
+			return
+		}
+		keys[key] = true
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestPublishKeys(t *testing.T) {
+	v := demoVector
+	entity, err := newEntity(v.Mnemonic, v.Passphrase, v.UserID, time.Unix(v.Timestamp, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wipeEntity(entity)
+	id := NewIdentity(entity)
+	ctx := context.Background()
+
+	for _, forge := range []Forge{GitHub, GitLab} {
+		srv := fakeForge(t, forge, "token")
+		for name, publish := range map[string]func(context.Context, Forge, string, string, *Identity) (bool, error){
+			"gpg": PublishGPGKey,
+			"ssh": PublishSSHKey,
+		} {
+			if _, err := publish(ctx, forge, srv.URL, "wrong", id); err == nil || !strings.Contains(err.Error(), "Bad credentials") {
+				t.Fatalf("%s %s: expected a bad credentials error, got %v", forge, name, err)
+			}
+			existed, err := publish(ctx, forge, srv.URL, "token", id)
+			if err != nil {
+				t.Fatalf("%s %s: %s", forge, name, err)
+			}
+			if existed {
+				t.Fatalf("%s %s: expected the key to be new", forge, name)
+			}
+			existed, err = publish(ctx, forge, srv.URL+"/", "token", id)
+			if err != nil {
+				t.Fatalf("%s %s: %s", forge, name, err)
+			}
+			if !existed {
+				t.Fatalf("%s %s: expected the key to already exist", forge, name)
+			}
+		}
+	}
+}
+
+func TestPublishGPGKeyPublicOnly(t *testing.T) {
+	// the public key is what forges parse, so publishing a public only
+	// identity (as read from a file) must upload the same key
+	v := demoVector
+	entity, err := newEntity(v.Mnemonic, v.Passphrase, v.UserID, time.Unix(v.Timestamp, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wipeEntity(entity)
+	armored, err := NewIdentity(entity).ArmorPublic()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armored))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var uploaded string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body map[string]string
+		json.NewDecoder(req.Body).Decode(&body)
+		uploaded = body["armored_public_key"]
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+	if _, err := PublishGPGKey(context.Background(), GitHub, srv.URL, "token", NewIdentity(entities[0])); err != nil {
+		t.Fatal(err)
+	}
+	if uploaded != armored {
+		t.Fatalf("unexpected key uploaded:\n%s", uploaded)
+	}
+}
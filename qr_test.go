@@ -0,0 +1,118 @@
+package recovery
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestQRReedSolomon(t *testing.T) {
+	// the "HELLO WORLD" 1-M example from the standard's annex
+	data := []byte{0x20, 0x5b, 0x0b, 0x78, 0xd1, 0x72, 0xdc, 0x4d, 0x43, 0x40, 0xec, 0x11, 0xec, 0x11, 0xec, 0x11}
+	expected := []byte{0xc4, 0x23, 0x27, 0x77, 0xeb, 0xd7, 0xe7, 0xe2, 0x5d, 0x17}
+	if ecc := qrRSRemainder(data, qrRSDivisor(10)); !bytes.Equal(ecc, expected) {
+		t.Fatalf("unexpected error correction codewords %x", ecc)
+	}
+}
+
+func TestQRFormat(t *testing.T) {
+	for mask, expected := range map[int]int{0: 0x5412, 1: 0x5125, 7: 0x4aa0} {
+		if bits := qrFormatBits(mask); bits != expected {
+			t.Fatalf("unexpected format bits for mask %d: %015b", mask, bits)
+		}
+	}
+
+	// the byte mode capacities of versions 1 and 40 at level M
+	for version, capacity := range map[int]int{1: 14, 6: 106, 7: 122, 10: 213, 40: 2331} {
+		if n := (qrDataCodewords(version)*8 - 4 - qrCountBits(version)) / 8; n != capacity {
+			t.Fatalf("expected version %d to hold %d bytes, got %d", version, capacity, n)
+		}
+	}
+	if _, err := newQRCode(make([]byte, 2332)); err == nil {
+		t.Fatal("expected an error encoding too much data")
+	}
+}
+
+func TestQRCode(t *testing.T) {
+	// 120 bytes needs a version 7 symbol, the first with version
+	// information, which is 0x07c94 in the standard's table
+	data := []byte(strings.Repeat("OPENPGP4FPR:"+aliceFingerprint+"\n", 3)[:120])
+	q, err := newQRCode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.version != 7 || q.size != 45 {
+		t.Fatalf("expected a version 7 symbol, got version %d", q.version)
+	}
+	version := 0
+	for i := 0; i < 18; i++ {
+		version |= qrBit(q.modules[q.size-11+i%3][i/3]) << uint(i)
+	}
+	if version != 0x07c94 {
+		t.Fatalf("unexpected version information %018b", version)
+	}
+
+	// read the mask from the format information and the codewords back out
+	format := 0
+	for i := 0; i <= 5; i++ {
+		format |= qrBit(q.modules[i][8]) << uint(i)
+	}
+	format |= qrBit(q.modules[7][8])<<6 | qrBit(q.modules[8][8])<<7 | qrBit(q.modules[8][7])<<8
+	for i := 9; i < 15; i++ {
+		format |= qrBit(q.modules[8][14-i]) << uint(i)
+	}
+	mask := -1
+	for m := 0; m < 8; m++ {
+		if qrFormatBits(m) == format {
+			mask = m
+		}
+	}
+	if mask < 0 {
+		t.Fatalf("invalid format information %015b", format)
+	}
+	q.applyMask(mask)
+	var codewords []byte
+	var b byte
+	n := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < q.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = q.size - 1 - vert
+				}
+				if q.function[y][x] {
+					continue
+				}
+				b = b<<1 | byte(qrBit(q.modules[y][x]))
+				if n++; n%8 == 0 {
+					codewords = append(codewords, b)
+				}
+			}
+		}
+	}
+
+	// the first data codewords are in the first bytes of each block, and
+	// start with the byte mode indicator and length
+	blocks := qrNumBlocks[q.version]
+	first := []byte{codewords[0], codewords[blocks]}
+	length := int(first[0]&0xf)<<4 | int(first[1]>>4)
+	if first[0]>>4 != 0x4 || length != len(data) {
+		t.Fatalf("unexpected mode and length %x", first)
+	}
+
+	svg := q.svg()
+	if !strings.HasPrefix(svg, "<svg ") || !strings.Contains(svg, `viewBox="0 0 `) {
+		t.Fatalf("unexpected SVG %s", svg)
+	}
+}
+
+func qrBit(dark bool) int {
+	if dark {
+		return 1
+	}
+	return 0
+}
@@ -3,22 +3,20 @@ package recovery
 import (
 	"bufio"
 	"bytes"
-	"crypto"
+	"context"
 	"crypto/ecdsa"
-	"crypto/elliptic"
+	"crypto/rand"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
-	"math/big"
+	"io/ioutil"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
 	slip10 "github.com/lmars/go-slip10"
-	slip13 "github.com/lmars/go-slip13"
-	bip39 "github.com/tyler-smith/go-bip39"
 	"golang.org/x/crypto/openpgp"
 	"golang.org/x/crypto/openpgp/armor"
 	"golang.org/x/crypto/openpgp/packet"
@@ -26,25 +24,198 @@ import (
 )
 
 // Run recovers a Trezor GPG identity by reading a recovery seed from stdin and
-// writing the resulting identity to stdout.
+// writing the resulting identity to stdout. There are no default streams, so
+// the input must be configured with WithStdin (or WithPrompter) and the
+// output with WithStdout (or WithShamirShares), while prompts and messages
+// are discarded unless WithStderr is given.
 func Run(opts ...Option) error {
-	r := &Recovery{
-		stdin:  os.Stdin,
-		stdout: os.Stdout,
-		stderr: os.Stderr,
-	}
+	return RunContext(context.Background(), opts...)
+}
+
+// RunContext is like Run but stops prompting and returns ctx.Err() once ctx
+// is cancelled (e.g. on SIGINT), wiping any secrets entered so far.
+func RunContext(ctx context.Context, opts ...Option) error {
+	return new(Recovery).Run(ctx, opts...)
+}
+
+// New returns a Recovery configured with opts, which can be run any number of
+// times, including concurrently (e.g. by a server validating many recovery
+// requests). Each run has its own state, and is given the options which
+// differ between runs, such as its streams. Inputs consumed by a run (e.g.
+// WithStdin, WithPrompter and WithTestDecrypt) must be given per run, while
+// writers and loggers given to New are shared between runs, so must be safe
+// for concurrent use. A public key given to New is read once here.
+func New(opts ...Option) (*Recovery, error) {
+	r := &Recovery{}
 	for _, opt := range opts {
 		opt(r)
 	}
-	r.stdinScan = bufio.NewScanner(r.stdin)
-	return r.run()
+	if err := r.keyParams().check(); err != nil {
+		return nil, err
+	}
+	if r.publicKey != nil {
+		if err := r.readPublicKey(); err != nil {
+			return nil, err
+		}
+		r.publicKey = nil
+	}
+	if r.fingerprint != "" {
+		fingerprint, err := parseFingerprint(r.fingerprint)
+		if err != nil {
+			return nil, err
+		}
+		r.fingerprint = fingerprint
+	}
+	return r, nil
+}
+
+// Run runs a recovery configured with the options r was created with followed
+// by opts, like RunContext. It doesn't modify r, so is safe to call
+// concurrently.
+func (r *Recovery) Run(ctx context.Context, opts ...Option) error {
+	run := r.newRun(ctx, opts)
+	if run.stdout == nil && run.shareOutputs == nil && !run.gpgAgent && run.thunderbirdDir == "" && run.vaultAddr == "" && !run.sequoiaStore {
+		return run.finish(errors.New("no output configured: use WithStdout, WithGPGAgent, WithYubiKey, WithThunderbirdBundle, WithVault or WithSequoiaStore"))
+	}
+	return run.finish(run.safeRun(run.output))
+}
+
+// newRun returns a copy of r for a single run configured with opts, with its
+// own state so that r can be reused.
+func (r *Recovery) newRun(ctx context.Context, opts []Option) *Recovery {
+	run := *r
+	run.ctx = ctx
+	if run.stderr == nil {
+		run.stderr = ioutil.Discard
+	}
+	keys := defaultKeyParams
+	if r.keys != nil {
+		keys = *r.keys
+	}
+	run.keys = &keys
+	for _, opt := range opts {
+		opt(&run)
+	}
+	run.report = &report{
+		Started:      run.now(),
+		Verification: []reportCheck{},
+		Warnings:     []string{},
+	}
+	run.audit(auditEntry{Step: "start"})
+	return &run
+}
+
+// finish logs the result of the recovery and writes the report if requested,
+// returning err or the first error writing them.
+func (r *Recovery) finish(err error) error {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	r.audit(auditEntry{Step: "finish", Result: result})
+	if r.auditErr != nil && err == nil {
+		err = fmt.Errorf("could not write audit log: %s", r.auditErr)
+	}
+	if r.reportOut != nil {
+		r.report.finish(err, r.now())
+		if reportErr := r.report.write(r.reportOut, r.reportFormat); reportErr != nil && err == nil {
+			err = fmt.Errorf("could not write report: %s", reportErr)
+		}
+	}
+	return err
 }
 
 type Recovery struct {
-	stdin     io.Reader
-	stdinScan *bufio.Scanner
-	stdout    io.Writer
-	stderr    io.Writer
+	ctx         context.Context
+	stdin       io.Reader
+	prompter    Prompter
+	mem         *secureMemory
+	stdout      io.Writer
+	stderr      io.Writer
+	testMessage io.Reader
+	strict      bool
+	sandbox     bool
+
+	requireOffline bool
+	preventSleep   bool
+	rootCheck      bool
+	clearScreen    bool
+	screenReader   bool
+	largePrint     bool
+	dualOperator   bool
+	demo           bool
+	expert         bool
+	generate       bool
+	fromPublicKey  bool
+
+	shareThreshold int
+	shareOutputs   []io.Writer
+	passphraseTTY  io.Writer
+	encoder        Encoder
+
+	gnupgInterop bool
+	sequoiaCheck bool
+
+	trezorConfig *TrezorConfig
+
+	gpgAgent           bool
+	gpgAgentHome       string
+	gpgAgentPassphrase bool
+	yubiKey            bool
+	nitrokey           bool
+
+	tailsHome  string
+	gitSigning bool
+
+	thunderbirdDir string
+
+	attestationOut io.Writer
+	operator       string
+
+	sequoiaStore    bool
+	sequoiaCertD    string
+	sequoiaKeystore string
+
+	vaultAddr   string
+	vaultToken  string
+	vaultTarget string
+
+	passwordStore  string
+	passRecipients []*openpgp.Entity
+
+	publicKey       io.Reader
+	pubEntity       *openpgp.Entity
+	fingerprint     string
+	passphrases     []string
+	userIDs         []string
+	timestamps      []time.Time
+	indexes         []uint32
+	passphraseTypos bool
+	searchWorkers   int
+	checkpointPath  string
+	checkpoint      *os.File
+	seedProvider    SeedProvider
+
+	sessionPath    string
+	sessionOptions map[string]string
+	sessionFile    *os.File
+	session        *SessionFile
+
+	report       *report
+	reportOut    io.Writer
+	reportFormat ReportFormat
+
+	keys         *keyParams
+	logger       Logger
+	logLevel     LogLevel
+	language     string
+	clock        Clock
+	onStep       func(step string)
+	onKeyDerived func(candidate, candidates int, fingerprint string)
+	onVerified   func(check string, err error)
+
+	auditOut io.Writer
+	auditErr error
 }
 
 type Option func(*Recovery)
@@ -67,9 +238,133 @@ func WithStderr(stderr io.Writer) Option {
 	}
 }
 
-func (r *Recovery) run() error {
+// WithTestDecrypt configures an OpenPGP message (armored or binary) which is
+// decrypted with the recovered subkey to prove the recovery worked.
+func WithTestDecrypt(msg io.Reader) Option {
+	return func(r *Recovery) {
+		r.testMessage = msg
+	}
+}
+
+// run prompts for the answers, derives the identity and runs the checks
+// before passing the result to output, which is then responsible for wiping
+// it.
+func (r *Recovery) run(output func(*Result) error) error {
+	if r.stdin == nil && r.prompter == nil {
+		return errors.New("no input configured: use WithStdin or WithPrompter")
+	}
+	if r.requireOffline {
+		if err := checkOffline(); err != nil {
+			return err
+		}
+	}
+	if err := r.keyParams().check(); err != nil {
+		return err
+	}
+	if err := r.checkGenerate(); err != nil {
+		return err
+	}
+	if err := r.checkFromPublicKey(); err != nil {
+		return err
+	}
+	if err := r.checkSearch(); err != nil {
+		return err
+	}
+	if err := r.checkLedger(); err != nil {
+		return err
+	}
+	if err := r.applyTrezorConfig(); err != nil {
+		return err
+	}
+	if err := r.checkShares(); err != nil {
+		return err
+	}
+	if err := r.checkEphemeral(); err != nil {
+		return err
+	}
+	if err := r.checkEncoder(); err != nil {
+		return err
+	}
+	if err := r.checkGPGAgent(); err != nil {
+		return err
+	}
+	if err := r.checkTails(); err != nil {
+		return err
+	}
+	if err := r.checkGitSigning(); err != nil {
+		return err
+	}
+	if err := r.checkThunderbird(); err != nil {
+		return err
+	}
+	if err := r.checkVault(); err != nil {
+		return err
+	}
+	if err := r.checkSequoiaStore(); err != nil {
+		return err
+	}
+	closeCheckpoint, err := r.openCheckpoint()
+	if err != nil {
+		return err
+	}
+	defer closeCheckpoint()
+	closeSession, err := r.openSessionFile()
+	if err != nil {
+		return err
+	}
+	defer closeSession()
+	if r.passwordStore != "" && r.demo {
+		return errors.New("the demo identity can't be used to re-encrypt a password store")
+	}
+	if r.seedProvider != nil && r.demo {
+		return errors.New("a seed provider cannot be used in a practice run, which always uses the demo seed")
+	}
+	if r.reportOut != nil && r.reportFormat != ReportJSON && r.reportFormat != ReportText && r.reportFormat != ReportHTML {
+		return fmt.Errorf("unknown report format %q", r.reportFormat)
+	}
+
+	// make sure a crash can't write the seed to disk
+	if err := disableCoreDumps(); err != nil {
+		if err := r.warn("could not disable core dumps: %s", err); err != nil {
+			return err
+		}
+	}
+
+	// stop other processes attaching to read the seed out of memory
+	if err := disableTracing(); err != nil {
+		if err := r.warn("could not prevent other processes attaching: %s", err); err != nil {
+			return err
+		}
+	}
+
+	// stop the machine hibernating (writing memory to disk) while secrets
+	// are in memory
+	if r.preventSleep {
+		release, err := r.holdSleepInhibitor()
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+
+	// scan stdin into locked memory which is wiped on return since it will
+	// contain the seed
+	freeInput, err := r.setupInput()
+	if err != nil {
+		return err
+	}
+	defer freeInput()
+
 	// print a warning
-	r.log(`
+	if r.largePrint {
+		r.display(largePrintBanner)
+	} else if r.screenReader {
+		r.display(`Trezor GPG Recovery.
+Warning: this program recovers private keys and prints them on the command
+line. You should only run this in a secure, controlled environment (e.g.
+Tails running from a USB stick).`)
+	} else {
+		r.display(`
 -----------------------------------------------------------------------------
                              Trezor GPG Recovery
 -----------------------------------------------------------------------------
@@ -81,87 +376,536 @@ func (r *Recovery) run() error {
 
    WARNING WARNING WARNING WARNING WARNING WARNING WARNING WARNING WARNING
 -----------------------------------------------------------------------------`)
+	}
+	if r.demo {
+		r.logDemo()
+	}
 
-	// make sure the user wants to continue
-	response, err := r.readLine(`Are you sure you want to continue with the recovery? (yes/no):`)
-	if err != nil {
+	// make sure the user wants to continue, including as root
+	if r.rootCheck {
+		if err := r.confirmRoot(); err != nil {
+			return err
+		}
+	}
+	if ok, err := r.confirm("Are you sure you want to continue with the recovery?", continueHelp); err != nil {
 		return err
-	} else if response != "yes" {
-		return errors.New("aborting at user's request")
+	} else if !ok {
+		return ErrAborted
 	}
+	r.audit(auditEntry{Step: "confirmed"})
 
-	// prompt for the user's ID
-	userID, err := r.readLine(`Please enter your GPG User ID (ex: "Alice <alice@example.com>"):`)
-	if err != nil {
+	// warn before any secrets are entered if they could be swapped to disk
+	if err := r.warnSwap(); err != nil {
 		return err
 	}
 
-	// prompt for the timestamp
-	timestampStr, err := r.readLine("Please enter the timestamp from the original 'trezor-gpg init' command:")
-	if err != nil {
+	// prompt for the user's ID unless given candidates to search
+	userIDs := r.userIDs
+	if userIDs == nil {
+		userID, err := r.readUserID()
+		if err != nil {
+			return err
+		}
+		userIDs = []string{userID}
+	}
+
+	// prompt for the timestamp unless given candidates to search
+	timestamps := r.timestamps
+	if timestamps == nil {
+		timestamp, err := r.readTimestamp()
+		if err != nil {
+			return err
+		}
+		timestamps = []time.Time{timestamp}
+	}
+
+	// prompt for every derivation parameter in expert mode
+	if err := r.readExpertParams(); err != nil {
 		return err
 	}
-	timestampInt, err := strconv.ParseInt(timestampStr, 10, 64)
-	if err != nil {
-		return fmt.Errorf("could not parse timestamp: %s", err)
+
+	// prompt for the operator to name in the attestation if needed
+	if err := r.readOperator(); err != nil {
+		return err
 	}
-	timestamp := time.Unix(timestampInt, 0)
 
-	// prompt for the recovery seed
-	seedLengthStr, err := r.readLine(`How many words are in your Recovery Seed? (12, 18 or 24):`)
+	// read the recovery seed
+	seed, err := r.readSeed()
 	if err != nil {
 		return err
 	}
-	seedLength, err := strconv.Atoi(seedLengthStr)
+	defer seed.Wipe()
+
+	// prompt for a passphrase unless given candidates to search
+	passphrases := r.passphrases
+	if passphrases == nil {
+		secret, err := r.readSecretInput("Please enter your passphrase (leave blank if you don't use one):", demoVector.Passphrase)
+		if err != nil {
+			return err
+		}
+		r.display(`-----------------------------------------------------------------------------`)
+		passphrase := string(secret)
+		wipe(secret)
+		passphrases = []string{passphrase}
+		if r.passphraseTypos {
+			passphrases = passphraseTypos(passphrase)
+		}
+		r.audit(auditEntry{Step: "passphrase entered"})
+	}
+
+	// all input has been read so sandbox the process, unless steps which
+	// need to run other programs or write files are enabled (in which case
+	// it happens after them)
+	if r.sandbox && !r.unsandboxedSteps() {
+		if err := r.enterSandbox(); err != nil {
+			return err
+		}
+	}
+
+	// derive the GPG identity
+	r.report.Curve = r.keyParams().curve
+	entity, err := r.search(seed, passphrases, userIDs, timestamps)
+	if mismatch, ok := err.(*mismatchError); ok && mismatch.entity != nil && r.fromPublicKey {
+		r.diagnoseMismatch(userIDs[0], timestamps[0])
+		entity, err = r.resolveMismatch(seed, mismatch, passphrases[0], userIDs[0], timestamps[0])
+		if err != nil && err != mismatch {
+			wipeEntity(mismatch.entity)
+		}
+	}
+	if r.fingerprint != "" {
+		r.check("fingerprint "+r.fingerprint, err)
+	}
+	if mismatch, ok := err.(*mismatchError); ok && mismatch.entity != nil {
+		// a mismatch is always fatal, since the identity is the wrong one
+		if r.pubEntity != nil && !r.fromPublicKey {
+			r.diagnoseMismatch(userIDs[0], timestamps[0])
+		}
+		wipeEntity(mismatch.entity)
+		return mismatch
+	} else if err != nil {
+		return err
+	}
+	handedOff := false
+	defer func() {
+		if !handedOff {
+			wipeEntity(entity)
+		}
+	}()
+	userID := entityUserID(entity)
+	r.report.UserID = userID
+	r.report.Timestamp = entity.PrimaryKey.CreationTime.Unix()
+	r.report.setEntity(entity, r.keyParams(), userID)
+	r.auditDerived(entity, userID)
+
+	// check the subkey can decrypt a message encrypted to it
+	err = checkEncryption(entity)
+	r.check("encryption self-test", err)
 	if err != nil {
+		return fmt.Errorf("encryption self-test failed: %s", err)
+	}
+
+	// decrypt the test message if given
+	if r.testMessage != nil {
+		err := r.testDecrypt(entity)
+		r.check("test decryption", err)
+		if err != nil {
+			return fmt.Errorf("test decryption failed: %s", err)
+		}
+	}
+
+	// check the identity works with GnuPG if requested
+	if r.gnupgInterop {
+		r.info("Checking the recovered identity with GnuPG:")
+		err := checkGnuPG(r.ctx, entity, r.stderr)
+		r.check("GnuPG interop", err)
+		if err != nil {
+			if err := r.warn("GnuPG interop check failed: %s", err); err != nil {
+				return err
+			}
+		}
+	}
+
+	// check the certificate against Sequoia's rules if requested
+	if r.sequoiaCheck {
+		r.info("Checking the recovered identity against Sequoia-PGP's rules:")
+		err := checkSequoia(r.ctx, entity, r.stderr)
+		r.check("Sequoia-PGP certificate checks", err)
+		if err != nil {
+			if err := r.warn("Sequoia-PGP certificate check failed: %s", err); err != nil {
+				return err
+			}
+		}
+	}
+
+	// don't print anything if cancelled while deriving or checking the key
+	if err := r.ctx.Err(); err != nil {
 		return err
 	}
-	if seedLength != 12 && seedLength != 18 && seedLength != 24 {
-		return fmt.Errorf("invalid seed length %d: must be 12, 18 or 24", seedLength)
+
+	// provision gpg-agent and move the keys onto a YubiKey or Nitrokey if
+	// requested
+	if r.gpgAgent {
+		if err := r.provisionGPGAgent(entity); err != nil {
+			return err
+		}
+	}
+	if r.yubiKey {
+		if err := r.moveToYubiKey(entity); err != nil {
+			return err
+		}
+	}
+	if r.nitrokey {
+		if err := r.moveToNitrokey(entity); err != nil {
+			return err
+		}
 	}
-	r.log("Please enter your %d word recovery seed (hit ctrl-c to exit):                ", seedLength)
-	seedWords := make([]string, seedLength)
-	for i := 0; i < seedLength; i++ {
-		word, err := r.readWord(i + 1)
+
+	// offer to import the identity into Tails' persistent storage
+	imported := r.gpgAgent
+	if r.tailsHome != "" {
+		ok, err := r.offerTailsPersistence(entity)
 		if err != nil {
 			return err
 		}
-		seedWords[i] = word
+		imported = ok
 	}
-	r.log(`-----------------------------------------------------------------------------`)
 
-	// prompt for a passphrase
-	passphrase, err := r.readLine("Please enter your passphrase (leave blank if you don't use one):")
-	if err != nil {
+	// offer to sign git commits with the imported identity
+	if r.gitSigning && imported {
+		if err := r.offerGitSigning(entity); err != nil {
+			return err
+		}
+	}
+
+	// offer to re-encrypt the password store if configured
+	if r.passwordStore != "" {
+		if err := r.offerPasswordStore(entity); err != nil {
+			return err
+		}
+	}
+
+	// write the Thunderbird bundle if configured
+	if r.thunderbirdDir != "" {
+		if err := r.writeThunderbirdBundle(newResult(entity)); err != nil {
+			return err
+		}
+	}
+
+	// write the key to Vault if configured
+	if r.vaultAddr != "" {
+		if err := r.writeVault(newResult(entity)); err != nil {
+			return err
+		}
+	}
+
+	// write the key into Sequoia's stores if configured
+	if r.sequoiaStore {
+		if err := r.writeSequoiaStore(newResult(entity)); err != nil {
+			return err
+		}
+	}
+
+	// sign the attestation once all the checks have run
+	if r.attestationOut != nil {
+		if err := r.writeAttestation(entity); err != nil {
+			return err
+		}
+	}
+
+	if r.sandbox && r.unsandboxedSteps() {
+		if err := r.enterSandbox(); err != nil {
+			return err
+		}
+	}
+
+	handedOff = true
+	result := newResult(entity)
+	result.Demo = r.demo
+	if err := output(result); err != nil {
 		return err
 	}
+	r.removeSessionFile()
+	return nil
+}
+
+// setupInput scans stdin into locked memory unless a prompter is configured,
+// returning a function which wipes it.
+func (r *Recovery) setupInput() (func(), error) {
+	mem, memErr := newSecureMemory(secureMemorySize)
+	var terminal *terminalPrompter
+	free := func() {
+		// if a read was cancelled it may still write into the memory, so just
+		// wipe it rather than unmapping it
+		if terminal != nil && terminal.pending {
+			wipe(mem.mem)
+			return
+		}
+		mem.free()
+	}
+	if memErr != nil {
+		if err := r.warn("could not lock memory, secrets may be swapped to disk: %s", memErr); err != nil {
+			free()
+			return nil, err
+		}
+	}
+	r.mem = mem
+	if r.prompter == nil {
+		scanner := bufio.NewScanner(r.stdin)
+		scanner.Buffer(mem.alloc(stdinBufferSize), stdinBufferSize)
+		terminal = &terminalPrompter{scanner: scanner, w: r.stderr, plain: r.screenReader}
+		if r.largePrint {
+			terminal.large = &largePrompter{}
+		}
+		r.prompter = terminal
+	}
+	return free, nil
+}
 
-	// generate seed
-	mnemonic := strings.Join(seedWords, " ")
-	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+// readUserID prompts for the user ID and checks it.
+func (r *Recovery) readUserID() (string, error) {
+	userID, err := r.readResumed(`Please enter your GPG User ID (ex: "Alice <alice@example.com>"):`, userIDHelp, demoVector.UserID, r.configuredUserID(), r.sessionUserID())
 	if err != nil {
-		return err
+		return "", err
+	}
+	r.report.UserID = userID
+	r.audit(auditEntry{Step: "user ID entered", UserIDHash: userIDHash(userID)})
+	if err := r.checkUserID(userID); err != nil {
+		return "", err
 	}
+	r.saveAnswer(func(s *SessionFile) { s.UserID = userID })
+	return userID, nil
+}
 
-	// generate SLIP10 master key
-	masterKey, err := slip10.NewMasterKeyWithCurve(seed, slip10.CurveP256)
+// readTimestamp prompts for the timestamp and checks it, or generates it for
+// a new identity.
+func (r *Recovery) readTimestamp() (time.Time, error) {
+	if r.generate {
+		return r.generateTimestamp()
+	}
+	prompt, help := "Please enter the timestamp from the original 'trezor-gpg init' command:", timestampHelp
+	if r.keyParams().device == DeviceLedger {
+		prompt, help = "Please enter the creation timestamp of the keys (as shown by 'gpg --list-keys --with-colons'):", ledgerTimestampHelp
+	}
+	timestampStr, err := r.readResumed(prompt, help, strconv.FormatInt(demoVector.Timestamp, 10), r.configuredTimestamp(), r.sessionTimestamp())
 	if err != nil {
-		return err
+		return time.Time{}, err
+	}
+	timestamp, err := ParseTimestamp(timestampStr)
+	if err != nil {
+		return time.Time{}, err
 	}
+	r.report.Timestamp = timestamp.Unix()
+	r.audit(auditEntry{Step: "timestamp entered", Timestamp: timestamp.Unix()})
+	if err := r.checkTimestamp(timestamp); err != nil {
+		return time.Time{}, err
+	}
+	r.saveAnswer(func(s *SessionFile) { s.Timestamp = timestamp.Unix() })
+	return timestamp, nil
+}
+
+// unsandboxedSteps returns whether steps which can't run in the sandbox are
+// enabled, in which case it is entered after them.
+func (r *Recovery) unsandboxedSteps() bool {
+	return r.gnupgInterop || r.sequoiaCheck || r.gpgAgent || r.tailsHome != "" || r.gitSigning || r.passwordStore != "" || r.thunderbirdDir != "" || r.vaultAddr != "" || r.sequoiaStore
+}
+
+// output prints information about the recovered identity followed by the
+// ascii armored private key (or its shares or encrypted form), wiping the
+// result once done.
+func (r *Recovery) output(result *Result) error {
+	defer result.Wipe()
+	entity := result.Entity
 
-	// derive GPG primary and sub keys
-	uri := "gpg://" + userID
-	primaryKey, err := r.ecdsaKey(masterKey, uri, false)
+	// print information about the GPG identity
+	r.display(`
+GPG User ID:             %s
+
+Primary Key Fingerprint: %s
+Primary Key ID:          %s (short: %s)
+
+Subkey Fingerprint:      %s
+Subkey ID:               %s (short: %s)
+`,
+		result.UserID,
+		result.PrimaryFingerprint,
+		result.PrimaryKeyID,
+		formatShortKeyID(entity.PrimaryKey),
+		result.SubkeyFingerprint,
+		result.SubkeyID,
+		formatShortKeyID(entity.Subkeys[0].PublicKey),
+	)
+
+	if r.demo {
+		r.logDemo()
+	}
+	if r.generate {
+		r.displayTimestamp(entity.PrimaryKey.CreationTime)
+	}
+
+	// print the ascii armored private key, or split or encrypt it
+	if r.shareOutputs != nil {
+		if err := r.writeShares(entity); err != nil {
+			return err
+		}
+		r.audit(auditEntry{Step: "private key written", Output: "shares"})
+	} else if r.passphraseTTY != nil {
+		if err := r.writeEncrypted(entity); err != nil {
+			return err
+		}
+		r.audit(auditEntry{Step: "private key written", Output: "encrypted"})
+	} else if r.stdout != nil {
+		encoder, output := r.encoder, "encoded"
+		if encoder == nil {
+			encoder, output = ArmoredEncoder(), "armored"
+		}
+		if err := encoder.Encode(r.stdout, result); err != nil {
+			return err
+		}
+		r.audit(auditEntry{Step: "private key written", Output: output})
+	}
+
+	// clear the screen once the user has saved the private key
+	if r.clearScreen {
+		return r.waitAndClearScreen()
+	}
+	return nil
+}
+
+// readSeed reads the recovery seed from the configured provider, or prompts
+// for the BIP39 mnemonic one word at a time.
+func (r *Recovery) readSeed() (Seed, error) {
+	if r.seedProvider != nil {
+		seed, err := r.seedProvider.ReadSeed(r.ctx, providerPrompter{r})
+		if err != nil {
+			return nil, err
+		}
+		r.audit(auditEntry{Step: "recovery seed entered"})
+		return seed, nil
+	}
+
+	// prompt for the recovery seed
+	seedLengthStr, err := r.readInput(`How many words are in your Recovery Seed? (12, 18 or 24):`, seedLengthHelp, strconv.Itoa(len(strings.Fields(demoVector.Mnemonic))))
 	if err != nil {
-		return err
+		return nil, err
 	}
-	subKey, err := r.ecdsaKey(masterKey, uri, true)
+	seedLength, err := parseSeedLength(seedLengthStr)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	r.report.SeedLength = seedLength
+	seedWords := make([][]byte, seedLength)
+	defer wipeWords(seedWords)
+	if r.dualOperator {
+		if err := r.readDualOperatorWords(seedWords); err != nil {
+			return nil, err
+		}
+	} else {
+		if !r.largePrint {
+			r.display("Please enter your %d word recovery seed (hit ctrl-c to exit):                ", seedLength)
+		}
+		for i := 0; i < seedLength; i++ {
+			word, err := r.readWord(i+1, seedLength)
+			if err != nil {
+				return nil, err
+			}
+			seedWords[i] = word
+		}
+	}
+	r.display(`-----------------------------------------------------------------------------`)
+	r.audit(auditEntry{Step: "recovery seed entered", SeedLength: seedLength})
+
+	// missing words are searched for, checking the checksum of each
+	// candidate
+	if missing := missingWords(seedWords); len(missing) > 0 {
+		seed, err := r.newPartialSeed(seedWords, missing)
+		if err != nil {
+			return nil, err
+		}
+		return seed, nil
+	}
+	err = r.checkMnemonic(seedWords)
+	r.check("recovery seed checksum", err)
+	if err != nil {
+		return nil, err
+	}
+	return &mnemonicSeed{mnemonic: r.mem.join(seedWords, ' ')}, nil
+}
+
+// warn reports a validation issue, returning it as an error in strict mode.
+func (r *Recovery) warn(format string, args ...interface{}) error {
+	if r.report != nil {
+		r.report.Warnings = append(r.report.Warnings, fmt.Sprintf(format, args...))
+	}
+	r.audit(auditEntry{Step: "warning", Warning: format})
+	if r.strict {
+		return errorf(format, args...)
+	}
+	r.activeLogger().Warn(r.translate(format, args...))
+	return nil
+}
+
+// readSecret reads a secret, returning a copy in the locked memory which the
+// caller should wipe.
+func (r *Recovery) readSecret(prompt string) ([]byte, error) {
+	secret, err := r.prompter.ReadSecret(r.ctx, prompt)
+	defer wipe(secret)
+	return r.mem.copy(secret), err
+}
+
+// readWord reads a seed word, returning a copy which the caller should wipe.
+func (r *Recovery) readWord(num, total int) ([]byte, error) {
+	if r.demo {
+		word := strings.Fields(demoVector.Mnemonic)[num-1]
+		fmt.Fprintf(r.stderr, "%s %s\n", r.wordPrompt(num, total), word)
+		return r.mem.copy([]byte(word)), nil
+	}
+	for {
+		word, err := r.readSecret(r.wordPrompt(num, total))
+		if errors.Is(err, ErrEndOfInput) || errors.Is(err, errAnswerTooLong) {
+			return nil, errorf("%w at seed word %d", err, num)
+		}
+		word = normalizeWord(word)
+		if err != nil || string(word) != helpAnswer {
+			return word, err
+		}
+		r.showHelp(seedWordHelp)
+	}
+}
+
+// newEntity derives the Trezor GPG identity for the given user ID and
+// timestamp from a BIP39 mnemonic and passphrase.
+func newEntity(mnemonic, passphrase, userID string, timestamp time.Time) (*openpgp.Entity, error) {
+	masterKey, err := newMasterKey([]byte(mnemonic), passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeSlip10Key(masterKey)
+	primaryKey, subKey, err := defaultKeyParams.deriveKeys(masterKey, userID)
+	if err != nil {
+		return nil, err
 	}
+	return buildEntity(primaryKey, subKey, userID, timestamp, &defaultKeyParams), nil
+}
+
+// newMasterKey generates the SLIP-0010 master key for a BIP39 mnemonic and
+// passphrase, which the caller should wipe. The mnemonic must already have
+// been checked with checkMnemonic.
+func newMasterKey(mnemonic []byte, passphrase string) (*slip10.Key, error) {
+	return defaultKeyParams.masterKey(&mnemonicSeed{mnemonic: mnemonic}, passphrase)
+}
 
-	// construct GPG identity
-	isPrimaryId := true
+// buildEntity constructs the GPG identity trezor-gpg creates from the derived
+// keys, user ID and timestamp, serialized with the given parameters.
+func buildEntity(primaryKey, subKey *ecdsa.PrivateKey, userID string, timestamp time.Time, params *keyParams) *openpgp.Entity {
+	var isPrimaryId *bool
+	if params.primaryUserID {
+		primary := true
+		isPrimaryId = &primary
+	}
+	sigTime := timestamp
+	if !params.sigTime.IsZero() {
+		sigTime = params.sigTime
+	}
 	entity := &openpgp.Entity{
 		PrimaryKey: packet.NewECDSAPublicKey(timestamp, &primaryKey.PublicKey),
 		PrivateKey: packet.NewECDSAPrivateKey(timestamp, primaryKey),
@@ -171,113 +915,132 @@ func (r *Recovery) run() error {
 			Name:   userID,
 			UserId: &packet.UserId{Id: userID},
 			SelfSignature: &packet.Signature{
-				CreationTime: timestamp,
+				CreationTime: sigTime,
 				SigType:      packet.SigTypePositiveCert,
 				PubKeyAlgo:   packet.PubKeyAlgoECDSA,
-				Hash:         crypto.SHA256,
-				IsPrimaryId:  &isPrimaryId,
+				Hash:         params.sigHash,
+				IsPrimaryId:  isPrimaryId,
 				FlagsValid:   true,
-				FlagSign:     true,
-				FlagCertify:  true,
+				FlagSign:     params.primaryFlags&KeyFlagSign != 0,
+				FlagCertify:  params.primaryFlags&KeyFlagCertify != 0,
 				IssuerKeyId:  &entity.PrimaryKey.KeyId,
 			},
 		},
 	}
-	kdfHash, _ := s2k.HashToHashId(crypto.SHA256)
-	kdfAlgo := packet.CipherAES128
+	kdfHash, _ := s2k.HashToHashId(params.kdfHash)
+	kdfAlgo := params.kdfCipher
 	entity.Subkeys = []openpgp.Subkey{{
 		PublicKey:  packet.NewECDHPublicKey(timestamp, &subKey.PublicKey, kdfHash, kdfAlgo),
 		PrivateKey: packet.NewECDHPrivateKey(timestamp, subKey, kdfHash, kdfAlgo),
 		Sig: &packet.Signature{
-			CreationTime:              timestamp,
+			CreationTime:              sigTime,
 			SigType:                   packet.SigTypeSubkeyBinding,
 			PubKeyAlgo:                packet.PubKeyAlgoECDSA,
-			Hash:                      crypto.SHA256,
+			Hash:                      params.sigHash,
 			FlagsValid:                true,
-			FlagEncryptStorage:        true,
-			FlagEncryptCommunications: true,
+			FlagEncryptStorage:        params.subkeyFlags&KeyFlagEncryptStorage != 0,
+			FlagEncryptCommunications: params.subkeyFlags&KeyFlagEncryptCommunications != 0,
 			IssuerKeyId:               &entity.PrimaryKey.KeyId,
 		},
 	}}
 	entity.Subkeys[0].PublicKey.IsSubkey = true
 	entity.Subkeys[0].PrivateKey.IsSubkey = true
+	return entity
+}
 
-	// print information about the GPG identity
-	r.log(`
-GPG User ID:             %s
-
-Primary Key Fingerprint: %s
-
-Subkey Fingerprint:      %s
-`,
-		userID,
-		r.formatFingerprint(entity.PrimaryKey),
-		r.formatFingerprint(entity.Subkeys[0].PublicKey),
-	)
+// serializePrivate returns the ASCII armored private key of the given
+// identity, which the caller should wipe. The buffer is allocated up front so
+// that it isn't reallocated as it grows, leaving copies of the key which can't
+// be wiped.
+func serializePrivate(entity *openpgp.Entity, headers map[string]string) ([]byte, error) {
+	out := bytes.NewBuffer(make([]byte, 0, armoredPrivateKeySize))
+	if err := writePrivate(out, entity, headers); err != nil {
+		wipe(out.Bytes())
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
 
-	// print the ascii armored private key
-	privKey, err := r.serializePrivate(entity)
+// writePrivate writes the ASCII armored private key of the given identity to
+// w as it's serialized, rather than buffering it.
+func writePrivate(w io.Writer, entity *openpgp.Entity, headers map[string]string) error {
+	enc, err := armor.Encode(w, openpgp.PrivateKeyType, headers)
 	if err != nil {
 		return err
 	}
-	fmt.Fprintln(r.stdout, privKey)
-
-	return nil
-}
-
-func (r *Recovery) log(format string, args ...interface{}) {
-	fmt.Fprintln(r.stderr, fmt.Sprintf(format, args...))
-}
-
-func (r *Recovery) readLine(prompt string) (string, error) {
-	fmt.Fprintf(r.stderr, "%-77s\n> ", prompt)
-	defer fmt.Fprintln(r.stderr, "-----------------------------------------------------------------------------")
-	r.stdinScan.Scan()
-	return r.stdinScan.Text(), r.stdinScan.Err()
+	if err := entity.SerializePrivate(enc, nil); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	_, err = w.Write([]byte{'\n'})
+	return err
 }
 
-func (r *Recovery) readWord(num int) (string, error) {
-	fmt.Fprintf(r.stderr, "%2d: ", num)
-	r.stdinScan.Scan()
-	return r.stdinScan.Text(), r.stdinScan.Err()
+// checkEncryption encrypts a message to the entity's subkey and checks that
+// it decrypts back to the original, so that a mistake in the ECDH parameters
+// is caught now rather than when old messages fail to decrypt.
+func checkEncryption(entity *openpgp.Entity) error {
+	subkey := entity.Subkeys[0]
+	msg := make([]byte, 32)
+	if _, err := rand.Read(msg); err != nil {
+		return err
+	}
+	var encrypted bytes.Buffer
+	if err := encryptMessage(&encrypted, rand.Reader, subkey.PublicKey, msg); err != nil {
+		return err
+	}
+	decrypted, err := decryptMessage(&encrypted, subkey.PrivateKey)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(decrypted, msg) {
+		return errors.New("decrypted message does not match")
+	}
+	return nil
 }
 
-func (r *Recovery) ecdsaKey(masterKey *slip10.Key, uri string, ecdh bool) (*ecdsa.PrivateKey, error) {
-	// determine what purpose field to use
-	var purpose uint32 = slip13.Purpose
-	if ecdh {
-		purpose = 17
+func (r *Recovery) testDecrypt(entity *openpgp.Entity) error {
+	msg, err := dearmor(r.testMessage, "PGP MESSAGE")
+	if err != nil {
+		return err
 	}
-
-	// derive the SLIP13 authentication key
-	key, err := slip13.DeriveWithPurpose(masterKey, purpose, uri, 0)
+	plaintext, err := decryptMessage(msg, entity.Subkeys[0].PrivateKey)
 	if err != nil {
-		return nil, err
+		return err
 	}
-
-	// convert to an ecdsa.PrivateKey
-	curve := elliptic.P256()
-	priv := new(ecdsa.PrivateKey)
-	priv.PublicKey.Curve = curve
-	priv.D = new(big.Int).SetBytes(key.Key)
-	priv.PublicKey.X, priv.PublicKey.Y = curve.ScalarBaseMult(key.Key)
-	return priv, nil
+	r.info(fmt.Sprintf("Successfully decrypted the test message (%d bytes)", len(plaintext)), LogField{"bytes", len(plaintext)})
+	return nil
 }
 
-func (r *Recovery) serializePrivate(entity *openpgp.Entity) (string, error) {
-	var out bytes.Buffer
-	enc, err := armor.Encode(&out, openpgp.PrivateKeyType, nil)
+// dearmor returns the contents of the given OpenPGP data, decoding it first
+// if it is ASCII armored.
+func dearmor(r io.Reader, blockType string) (io.Reader, error) {
+	buf := bufio.NewReader(r)
+	if prefix, _ := buf.Peek(len("-----BEGIN")); string(prefix) != "-----BEGIN" {
+		return buf, nil
+	}
+	block, err := armor.Decode(buf)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	if err := entity.SerializePrivate(enc, nil); err != nil {
-		return "", err
+	if block.Type != blockType {
+		return nil, fmt.Errorf("expected %s, got %s", blockType, block.Type)
 	}
-	enc.Close()
-	out.Write([]byte{'\n'})
-	return out.String(), nil
+	return block.Body, nil
 }
 
-func (r *Recovery) formatFingerprint(key *packet.PublicKey) string {
+func formatFingerprint(key *packet.PublicKey) string {
 	return strings.ToUpper(hex.EncodeToString(key.Fingerprint[:]))
 }
+
+// formatKeyID returns the 16 character (long) key ID of the given key.
+func formatKeyID(key *packet.PublicKey) string {
+	return fmt.Sprintf("%016X", key.KeyId)
+}
+
+// formatShortKeyID returns the 8 character (short) key ID of the given key.
+func formatShortKeyID(key *packet.PublicKey) string {
+	return fmt.Sprintf("%08X", uint32(key.KeyId))
+}
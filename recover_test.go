@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"golang.org/x/crypto/openpgp"
 	"golang.org/x/crypto/openpgp/armor"
@@ -82,4 +83,72 @@ func TestRecovery(t *testing.T) {
 	if actualFingerprint != expectedFingerprint {
 		t.Fatalf("wrong fingerprint\nexpected: %s\nactual:   %s", expectedFingerprint, actualFingerprint)
 	}
+
+	// check the key IDs are printed
+	for _, line := range []string{
+		"Primary Key ID:          406D7920DCAD67C3 (short: DCAD67C3)",
+		"Subkey ID:               CDF28C7D36F3F4F5 (short: 36F3F4F5)",
+	} {
+		if !strings.Contains(stderr.String(), line) {
+			t.Fatalf("expected output to contain %q, got:\n%s", line, stderr.String())
+		}
+	}
+}
+
+func TestRecoveryNoStreams(t *testing.T) {
+	// there are no default streams, so both must be configured
+	if err := Run(WithStdin(strings.NewReader(aliceInput))); err == nil || !strings.Contains(err.Error(), "no output configured") {
+		t.Fatalf("expected a missing output error, got %v", err)
+	}
+	if err := Run(WithStdout(&bytes.Buffer{})); err == nil || !strings.Contains(err.Error(), "no input configured") {
+		t.Fatalf("expected a missing input error, got %v", err)
+	}
+
+	// check prompts are discarded without stderr
+	var stdout bytes.Buffer
+	if err := Run(WithStdin(strings.NewReader(aliceInput)), WithStdout(&stdout)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout.String(), "BEGIN PGP PRIVATE KEY BLOCK") {
+		t.Fatalf("expected the private key on stdout, got:\n%s", stdout.String())
+	}
+}
+
+// aliceInput is the stdin which recovers the test identity used above.
+const aliceInput = "yes\nAlice <alice@example.com>\n1523060353\n12\nall\nall\nall\nall\nall\nall\nall\nall\nall\nall\nall\nall\ns3cr3t\n"
+
+// recoverEntity runs a recovery with the given stdin and returns the entity
+// decoded from stdout.
+func recoverEntity(t *testing.T, stdin string, opts ...Option) *openpgp.Entity {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	opts = append([]Option{
+		WithStdin(strings.NewReader(stdin)),
+		WithStdout(&stdout),
+		WithStderr(&stderr),
+	}, opts...)
+	if err := Run(opts...); err != nil {
+		t.Fatal(err)
+	}
+	entities, err := openpgp.ReadArmoredKeyRing(&stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entities[0]
+}
+
+func BenchmarkSerializePrivate(b *testing.B) {
+	v := demoVector
+	entity, err := newEntity(v.Mnemonic, v.Passphrase, v.UserID, time.Unix(v.Timestamp, 0))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		armored, err := serializePrivate(entity, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		wipe(armored)
+	}
 }
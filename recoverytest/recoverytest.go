@@ -0,0 +1,180 @@
+// Package recoverytest provides a scripted Prompter and SeedProvider, so that
+// integrators can write deterministic tests of their recovery flows rather
+// than building newline separated stdin buffers:
+//
+//	p := recoverytest.NewPrompter(recoverytest.Alice())
+//	err := recovery.Run(recovery.WithPrompter(p), recovery.WithStdout(&out))
+//
+// The prompter answers each prompt by what it asks for rather than by its
+// position, so tests don't break when options add or reorder prompts.
+package recoverytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	recovery "github.com/lmars/trezor-gpg-recovery"
+)
+
+// Answers are the answers to the recovery's prompts.
+type Answers struct {
+	UserID     string
+	Timestamp  int64
+	Mnemonic   string
+	Passphrase string
+
+	// Entropy is the hex entropy given to the recovery.HexEntropy
+	// provider.
+	Entropy string
+
+	// Decline declines the "Are you sure" confirmations, which are
+	// otherwise answered yes.
+	Decline bool
+}
+
+// AliceFingerprint is the primary key fingerprint of the identity Alice
+// answers recover.
+const AliceFingerprint = "AB86C8C7B5136D19B0A6AEC0406D7920DCAD67C3"
+
+// Alice returns the answers for the public "all all all ..." test identity of
+// Alice <alice@example.com>, whose fingerprint is AliceFingerprint. Never
+// use this seed for real keys.
+func Alice() Answers {
+	return Answers{
+		UserID:     "Alice <alice@example.com>",
+		Timestamp:  1523060353,
+		Mnemonic:   strings.TrimSpace(strings.Repeat("all ", 12)),
+		Passphrase: "s3cr3t",
+		Entropy:    "0660cc198330660cc198330660cc1983",
+	}
+}
+
+// Prompter is a recovery.Prompter which answers prompts from Answers,
+// recording each prompt. It is safe for concurrent use.
+type Prompter struct {
+	answers Answers
+
+	mu      sync.Mutex
+	prompts []string
+	secrets [][]byte
+}
+
+// NewPrompter returns a Prompter which answers prompts from a.
+func NewPrompter(a Answers) *Prompter {
+	return &Prompter{answers: a}
+}
+
+// ReadLine answers the user ID, timestamp and seed length prompts, and
+// presses enter at prompts which ask to.
+func (p *Prompter) ReadLine(ctx context.Context, prompt string) (string, error) {
+	if err := p.ask(ctx, "line", prompt); err != nil {
+		return "", err
+	}
+	switch {
+	case strings.Contains(prompt, "User ID"):
+		return p.answers.UserID, nil
+	case strings.Contains(prompt, "timestamp"):
+		return strconv.FormatInt(p.answers.Timestamp, 10), nil
+	case strings.Contains(prompt, "How many words"):
+		return strconv.Itoa(len(strings.Fields(p.answers.Mnemonic))), nil
+	case strings.Contains(prompt, "press enter"), strings.Contains(prompt, "Press enter"):
+		return "", nil
+	default:
+		return "", unexpected(prompt)
+	}
+}
+
+// ReadSecret answers the seed word, passphrase and entropy prompts.
+func (p *Prompter) ReadSecret(ctx context.Context, prompt string) ([]byte, error) {
+	if err := p.ask(ctx, "secret", prompt); err != nil {
+		return nil, err
+	}
+	var answer string
+	if num, ok := recovery.ParseWordPrompt(prompt); ok {
+		words := strings.Fields(p.answers.Mnemonic)
+		if num < 1 || num > len(words) {
+			return nil, fmt.Errorf("recoverytest: no seed word %d", num)
+		}
+		answer = words[num-1]
+	} else if strings.Contains(prompt, "passphrase") {
+		answer = p.answers.Passphrase
+	} else if strings.Contains(prompt, "entropy") {
+		answer = p.answers.Entropy
+	} else {
+		return nil, unexpected(prompt)
+	}
+	secret := []byte(answer)
+	p.mu.Lock()
+	p.secrets = append(p.secrets, secret)
+	p.mu.Unlock()
+	return secret, nil
+}
+
+// Confirm answers yes unless the answers decline.
+func (p *Prompter) Confirm(ctx context.Context, prompt string) (bool, error) {
+	if err := p.ask(ctx, "confirm", prompt); err != nil {
+		return false, err
+	}
+	return !p.answers.Decline, nil
+}
+
+// Prompts returns the prompts asked so far, each prefixed with how it was
+// asked ("line", "secret" or "confirm").
+func (p *Prompter) Prompts() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.prompts...)
+}
+
+// Wiped returns whether the recovery wiped each secret it was given, as it
+// should once it has copied them into locked memory.
+func (p *Prompter) Wiped() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, secret := range p.secrets {
+		for _, b := range secret {
+			if b != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (p *Prompter) ask(ctx context.Context, kind, prompt string) error {
+	p.mu.Lock()
+	p.prompts = append(p.prompts, kind+" "+strings.TrimSpace(prompt))
+	p.mu.Unlock()
+	return ctx.Err()
+}
+
+func unexpected(prompt string) error {
+	return fmt.Errorf("recoverytest: unexpected prompt %q", prompt)
+}
+
+// SeedProvider returns a recovery.SeedProvider which provides the given BIP39
+// mnemonic without prompting, for tests which use recovery.WithSeedProvider.
+func SeedProvider(mnemonic string) recovery.SeedProvider {
+	return seedProvider(mnemonic)
+}
+
+type seedProvider string
+
+func (s seedProvider) ReadSeed(ctx context.Context, p recovery.Prompter) (recovery.Seed, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(string(s))
+	if len(fields) == 0 {
+		return nil, errors.New("recoverytest: empty mnemonic")
+	}
+	words := make([][]byte, len(fields))
+	for i, field := range fields {
+		words[i] = []byte(field)
+	}
+	return recovery.NewMnemonicSeed(words)
+}
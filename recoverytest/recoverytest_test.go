@@ -0,0 +1,97 @@
+package recoverytest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	recovery "github.com/lmars/trezor-gpg-recovery"
+)
+
+func recoverFingerprint(t *testing.T, opts ...recovery.Option) string {
+	t.Helper()
+	result, err := recovery.RecoverInteractive(context.Background(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer result.Wipe()
+	return result.PrimaryFingerprint
+}
+
+func TestPrompter(t *testing.T) {
+	p := NewPrompter(Alice())
+	if fp := recoverFingerprint(t, recovery.WithPrompter(p)); fp != AliceFingerprint {
+		t.Fatalf("unexpected fingerprint %s", fp)
+	}
+	if !p.Wiped() {
+		t.Fatal("expected the secrets to be wiped")
+	}
+	prompts := p.Prompts()
+	if len(prompts) != 17 || !strings.HasPrefix(prompts[0], "confirm ") || !strings.HasPrefix(prompts[16], "secret Please enter your passphrase") {
+		t.Fatalf("unexpected prompts: %q", prompts)
+	}
+}
+
+func TestPrompterOptions(t *testing.T) {
+	// the extra dual-operator prompts are answered too
+	p := NewPrompter(Alice())
+	if fp := recoverFingerprint(t, recovery.WithPrompter(p), recovery.WithDualOperator()); fp != AliceFingerprint {
+		t.Fatalf("unexpected fingerprint %s", fp)
+	}
+
+	p = NewPrompter(Alice())
+	if fp := recoverFingerprint(t, recovery.WithPrompter(p), recovery.WithSeedProvider(recovery.HexEntropy())); fp != AliceFingerprint {
+		t.Fatalf("unexpected fingerprint %s", fp)
+	}
+}
+
+func TestPrompterWordPrompts(t *testing.T) {
+	// seed words asked for by spelt out number or in large print are
+	// answered too
+	for _, opt := range []recovery.Option{recovery.WithScreenReader(), recovery.WithLargePrint()} {
+		p := NewPrompter(Alice())
+		if fp := recoverFingerprint(t, recovery.WithPrompter(p), opt); fp != AliceFingerprint {
+			t.Fatalf("unexpected fingerprint %s", fp)
+		}
+	}
+	p := NewPrompter(Alice())
+	recoverFingerprint(t, recovery.WithPrompter(p), recovery.WithScreenReader())
+	if prompts := p.Prompts(); !contains(prompts, "secret Word five of twelve:") {
+		t.Fatalf("expected the seed words to be spelt out, got %q", prompts)
+	}
+}
+
+func contains(prompts []string, prompt string) bool {
+	for _, p := range prompts {
+		if p == prompt {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPrompterDecline(t *testing.T) {
+	answers := Alice()
+	answers.Decline = true
+	err := recovery.Run(recovery.WithPrompter(NewPrompter(answers)), recovery.WithStdout(&bytes.Buffer{}))
+	if !errors.Is(err, recovery.ErrAborted) {
+		t.Fatalf("expected the recovery to abort, got %v", err)
+	}
+}
+
+func TestSeedProvider(t *testing.T) {
+	answers := Alice()
+	answers.Mnemonic = ""
+	p := NewPrompter(answers)
+	fp := recoverFingerprint(t, recovery.WithPrompter(p), recovery.WithSeedProvider(SeedProvider(Alice().Mnemonic)))
+	if fp != AliceFingerprint {
+		t.Fatalf("unexpected fingerprint %s", fp)
+	}
+	for _, prompt := range p.Prompts() {
+		if strings.HasPrefix(prompt, "secret ") && !strings.Contains(prompt, "passphrase") {
+			t.Fatalf("unexpected seed prompt %q", prompt)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package recovery
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// ReportFormat is the format of a recovery report.
+type ReportFormat string
+
+const (
+	ReportJSON ReportFormat = "json"
+	ReportText ReportFormat = "text"
+
+	// ReportHTML is a single self-contained HTML page, including QR codes
+	// of the public key and its fingerprint, for archiving with an
+	// organization's recovery records.
+	ReportHTML ReportFormat = "html"
+)
+
+// WithReport configures a report of the recovery session to be written to w
+// once the recovery finishes (whether or not it succeeds). The report never
+// contains the recovery seed, passphrase or private keys.
+func WithReport(w io.Writer, format ReportFormat) Option {
+	return func(r *Recovery) {
+		r.reportOut = w
+		r.reportFormat = format
+	}
+}
+
+// report records the non-secret details of a recovery session.
+type report struct {
+	Started      time.Time     `json:"started"`
+	Finished     time.Time     `json:"finished"`
+	Result       string        `json:"result"`
+	Error        string        `json:"error,omitempty"`
+	UserID       string        `json:"user_id,omitempty"`
+	Timestamp    int64         `json:"timestamp,omitempty"`
+	Curve        string        `json:"curve,omitempty"`
+	SeedLength   int           `json:"seed_length,omitempty"`
+	PrimaryKey   *reportKey    `json:"primary_key,omitempty"`
+	Subkey       *reportKey    `json:"subkey,omitempty"`
+	Verification []reportCheck `json:"verification"`
+	Warnings     []string      `json:"warnings"`
+
+	// publicKey is the armored public key, which is only in the HTML
+	// report
+	publicKey string
+}
+
+type reportKey struct {
+	Path        string `json:"path"`
+	Algorithm   string `json:"algorithm"`
+	Fingerprint string `json:"fingerprint"`
+	KeyID       string `json:"key_id"`
+	ECDHKDF     string `json:"ecdh_kdf,omitempty"`
+}
+
+type reportCheck struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// check records the result of a verification step.
+func (rep *report) check(name string, err error) {
+	c := reportCheck{Name: name, Passed: err == nil}
+	if err != nil {
+		c.Detail = err.Error()
+	}
+	rep.Verification = append(rep.Verification, c)
+}
+
+// setEntity records the keys of the recovered identity, derived with params
+// for the given user ID.
+func (rep *report) setEntity(entity *openpgp.Entity, params *keyParams, userID string) {
+	primaryPath, subkeyPath := params.paths(userID)
+	rep.PrimaryKey = &reportKey{
+		Path:        primaryPath,
+		Algorithm:   "ECDSA",
+		Fingerprint: formatFingerprint(entity.PrimaryKey),
+		KeyID:       formatKeyID(entity.PrimaryKey),
+	}
+	subkey := entity.Subkeys[0].PublicKey
+	rep.Subkey = &reportKey{
+		Path:        subkeyPath,
+		Algorithm:   "ECDH",
+		Fingerprint: formatFingerprint(subkey),
+		KeyID:       formatKeyID(subkey),
+	}
+	if params, err := readECDHParams(subkey); err == nil {
+		rep.Subkey.ECDHKDF = fmt.Sprintf("%s, %s", params.kdfHash, cipherName(params.kdfAlgo))
+	}
+	if publicKey, err := newResult(entity).ArmoredPublicKey(); err == nil {
+		rep.publicKey = publicKey
+	}
+}
+
+func (rep *report) finish(err error, now time.Time) {
+	rep.Finished = now
+	rep.Result = "success"
+	if err != nil {
+		rep.Result = "failure"
+		rep.Error = err.Error()
+	}
+}
+
+func (rep *report) write(w io.Writer, format ReportFormat) error {
+	switch format {
+	case ReportJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rep)
+	case ReportText:
+		return rep.writeText(w)
+	case ReportHTML:
+		return rep.writeHTML(w)
+	default:
+		return fmt.Errorf("unknown report format %q", format)
+	}
+}
+
+func (rep *report) writeText(w io.Writer) error {
+	var buf bytes.Buffer
+	field := func(name, format string, args ...interface{}) {
+		fmt.Fprintf(&buf, "%-16s %s\n", name+":", fmt.Sprintf(format, args...))
+	}
+	buf.WriteString("Trezor GPG Recovery Report\n\n")
+	field("Started", rep.Started.UTC().Format(time.RFC3339))
+	field("Finished", rep.Finished.UTC().Format(time.RFC3339))
+	field("Result", rep.Result)
+	if rep.Error != "" {
+		field("Error", rep.Error)
+	}
+	buf.WriteString("\n")
+	field("User ID", rep.UserID)
+	if rep.Timestamp != 0 {
+		field("Timestamp", "%d (%s)", rep.Timestamp, time.Unix(rep.Timestamp, 0).UTC().Format(time.RFC3339))
+	}
+	field("Curve", rep.Curve)
+	if rep.SeedLength != 0 {
+		field("Seed length", "%d words", rep.SeedLength)
+	}
+	for _, key := range []struct {
+		name string
+		key  *reportKey
+	}{{"Primary key", rep.PrimaryKey}, {"Subkey", rep.Subkey}} {
+		if key.key == nil {
+			continue
+		}
+		buf.WriteString("\n")
+		field(key.name, key.key.Algorithm)
+		field("  Path", key.key.Path)
+		field("  Fingerprint", key.key.Fingerprint)
+		field("  Key ID", key.key.KeyID)
+		if key.key.ECDHKDF != "" {
+			field("  ECDH KDF", key.key.ECDHKDF)
+		}
+	}
+	if len(rep.Verification) > 0 {
+		buf.WriteString("\nVerification:\n")
+		for _, c := range rep.Verification {
+			result := "PASS"
+			if !c.Passed {
+				result = "FAIL"
+			}
+			if c.Detail != "" {
+				fmt.Fprintf(&buf, "  %s  %s (%s)\n", result, c.Name, c.Detail)
+			} else {
+				fmt.Fprintf(&buf, "  %s  %s\n", result, c.Name)
+			}
+		}
+	}
+	if len(rep.Warnings) > 0 {
+		buf.WriteString("\nWarnings:\n")
+		for _, warning := range rep.Warnings {
+			fmt.Fprintf(&buf, "  %s\n", warning)
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeHTML writes the report as a single HTML page without any external
+// resources (the QR codes are inline SVG), so it can be archived and opened
+// offline.
+func (rep *report) writeHTML(w io.Writer) error {
+	type htmlKey struct {
+		Name string
+		*reportKey
+		Fingerprint string
+	}
+	data := struct {
+		*report
+		Started, Finished string
+		Timestamp         string
+		Keys              []htmlKey
+		PublicKey         string
+		FingerprintQR     template.HTML
+		PublicKeyQR       template.HTML
+	}{
+		report:    rep,
+		Started:   rep.Started.UTC().Format(time.RFC3339),
+		Finished:  rep.Finished.UTC().Format(time.RFC3339),
+		PublicKey: rep.publicKey,
+	}
+	if rep.Timestamp != 0 {
+		data.Timestamp = fmt.Sprintf("%d (%s)", rep.Timestamp, time.Unix(rep.Timestamp, 0).UTC().Format(time.RFC3339))
+	}
+	for _, key := range []struct {
+		name string
+		key  *reportKey
+	}{{"Primary key", rep.PrimaryKey}, {"Subkey", rep.Subkey}} {
+		if key.key != nil {
+			data.Keys = append(data.Keys, htmlKey{key.name, key.key, spacedFingerprint(key.key.Fingerprint)})
+		}
+	}
+
+	// the fingerprint QR code is the URI OpenKeychain and other apps scan to
+	// fetch and verify a key
+	if rep.PrimaryKey != nil {
+		if q, err := newQRCode([]byte("OPENPGP4FPR:" + rep.PrimaryKey.Fingerprint)); err == nil {
+			data.FingerprintQR = template.HTML(q.svg())
+		}
+	}
+	if rep.publicKey != "" {
+		if q, err := newQRCode([]byte(rep.publicKey)); err == nil {
+			data.PublicKeyQR = template.HTML(q.svg())
+		}
+	}
+	return reportTemplate.Execute(w, data)
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Trezor GPG Recovery Report{{with .UserID}}: {{.}}{{end}}</title>
+<style>
+body { font-family: sans-serif; max-width: 50em; margin: 2em auto; padding: 0 1em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { text-align: left; vertical-align: top; padding: 0.25em 1em 0.25em 0; }
+th { font-weight: normal; color: #555; }
+code, pre { font-family: monospace; }
+.pass { color: #070; }
+.fail { color: #b00; }
+.qr { display: inline-block; margin: 0 2em 1em 0; text-align: center; }
+.qr svg { width: 14em; height: 14em; display: block; }
+@media print { .qr { page-break-inside: avoid; } }
+</style>
+</head>
+<body>
+<h1>Trezor GPG Recovery Report</h1>
+<table>
+<tr><th>Started</th><td>{{.Started}}</td></tr>
+<tr><th>Finished</th><td>{{.Finished}}</td></tr>
+<tr><th>Result</th><td class="{{if eq .Result "success"}}pass{{else}}fail{{end}}">{{.Result}}</td></tr>
+{{- with .Error}}
+<tr><th>Error</th><td>{{.}}</td></tr>
+{{- end}}
+</table>
+
+<h2>Derivation parameters</h2>
+<table>
+<tr><th>User ID</th><td>{{.UserID}}</td></tr>
+{{- with .Timestamp}}
+<tr><th>Timestamp</th><td>{{.}}</td></tr>
+{{- end}}
+<tr><th>Curve</th><td>{{.Curve}}</td></tr>
+{{- with .SeedLength}}
+<tr><th>Seed length</th><td>{{.}} words</td></tr>
+{{- end}}
+</table>
+{{range .Keys}}
+<h3>{{.Name}}</h3>
+<table>
+<tr><th>Algorithm</th><td>{{.Algorithm}}</td></tr>
+<tr><th>Path</th><td><code>{{.Path}}</code></td></tr>
+<tr><th>Fingerprint</th><td><code>{{.Fingerprint}}</code></td></tr>
+<tr><th>Key ID</th><td><code>{{.KeyID}}</code></td></tr>
+{{- with .ECDHKDF}}
+<tr><th>ECDH KDF</th><td>{{.}}</td></tr>
+{{- end}}
+</table>
+{{end}}
+{{- with .Verification}}
+<h2>Verification</h2>
+<table>
+{{- range .}}
+<tr><td class="{{if .Passed}}pass">PASS{{else}}fail">FAIL{{end}}</td><td>{{.Name}}{{with .Detail}} ({{.}}){{end}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- with .Warnings}}
+<h2>Warnings</h2>
+<ul>
+{{- range .}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if or .FingerprintQR .PublicKeyQR}}
+<h2>Public key</h2>
+{{- with .FingerprintQR}}
+<div class="qr">{{.}}Fingerprint</div>
+{{- end}}
+{{- with .PublicKeyQR}}
+<div class="qr">{{.}}Public key</div>
+{{- end}}
+{{- with .PublicKey}}
+<pre>{{.}}</pre>
+{{- end}}
+{{- end}}
+<p>This report never contains the recovery seed, passphrase or private keys.</p>
+</body>
+</html>
+`))
+
+func cipherName(c packet.CipherFunction) string {
+	switch c {
+	case packet.CipherAES128:
+		return "AES128"
+	case packet.CipherAES192:
+		return "AES192"
+	case packet.CipherAES256:
+		return "AES256"
+	default:
+		return fmt.Sprintf("cipher %d", c)
+	}
+}
@@ -0,0 +1,102 @@
+package recovery
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestReportJSON(t *testing.T) {
+	var out bytes.Buffer
+	recoverEntity(t, aliceInput, WithReport(&out, ReportJSON))
+	checkNoSecrets(t, out.String())
+
+	var rep report
+	if err := json.Unmarshal(out.Bytes(), &rep); err != nil {
+		t.Fatal(err)
+	}
+	if rep.Result != "success" {
+		t.Fatalf("expected success, got %q (%s)", rep.Result, rep.Error)
+	}
+	if rep.UserID != "Alice <alice@example.com>" || rep.Timestamp != 1523060353 || rep.SeedLength != 12 {
+		t.Fatalf("unexpected parameters in report: %+v", rep)
+	}
+	if rep.PrimaryKey == nil || rep.PrimaryKey.Fingerprint != aliceFingerprint {
+		t.Fatalf("unexpected primary key in report: %+v", rep.PrimaryKey)
+	}
+	if !strings.HasPrefix(rep.PrimaryKey.Path, "m/13'/") || !strings.HasPrefix(rep.Subkey.Path, "m/17'/") {
+		t.Fatalf("unexpected derivation paths %q and %q", rep.PrimaryKey.Path, rep.Subkey.Path)
+	}
+	if rep.Subkey.ECDHKDF != "SHA-256, AES128" {
+		t.Fatalf("unexpected ECDH KDF %q", rep.Subkey.ECDHKDF)
+	}
+	for _, c := range rep.Verification {
+		if !c.Passed {
+			t.Fatalf("unexpected failed check: %+v", c)
+		}
+	}
+}
+
+func TestReportFailure(t *testing.T) {
+	var out bytes.Buffer
+	input := strings.Replace(aliceInput, "s3cr3t", "wrong", 1)
+	err := Run(
+		WithStdin(strings.NewReader(input)),
+		WithStdout(&bytes.Buffer{}),
+		WithStderr(&bytes.Buffer{}),
+		WithFingerprint(aliceFingerprint),
+		WithReport(&out, ReportText),
+		WithStrict(),
+	)
+	if err == nil {
+		t.Fatal("expected recovery to fail")
+	}
+	checkNoSecrets(t, out.String())
+	for _, s := range []string{"Result:          failure", "FAIL  fingerprint " + aliceFingerprint} {
+		if !strings.Contains(out.String(), s) {
+			t.Fatalf("expected report to contain %q, got:\n%s", s, out.String())
+		}
+	}
+}
+
+// checkNoSecrets checks that the test identity's recovery seed, passphrase
+// and private key do not appear in the given output.
+func checkNoSecrets(t *testing.T, out string) {
+	t.Helper()
+	for _, secret := range []string{"all all", "s3cr3t", "PRIVATE KEY"} {
+		if strings.Contains(out, secret) {
+			t.Fatalf("output contains secret %q:\n%s", secret, out)
+		}
+	}
+}
+
+func TestReportHTML(t *testing.T) {
+	var out bytes.Buffer
+	recoverEntity(t, aliceInput, WithReport(&out, ReportHTML))
+	html := out.String()
+	checkNoSecrets(t, html)
+	for _, s := range []string{
+		"<!DOCTYPE html>",
+		"Alice &lt;alice@example.com&gt;",
+		spacedFingerprint(aliceFingerprint),
+		"m/13&#39;/",
+		"-----BEGIN PGP PUBLIC KEY BLOCK-----",
+		`class="pass">PASS</td><td>encryption self-test`,
+	} {
+		if !strings.Contains(html, s) {
+			t.Fatalf("expected the report to contain %q, got:\n%s", s, html)
+		}
+	}
+
+	// the fingerprint and public key QR codes are inline, and nothing is
+	// loaded from elsewhere
+	if n := strings.Count(html, "<svg "); n != 2 {
+		t.Fatalf("expected 2 QR codes, got %d", n)
+	}
+	for _, s := range []string{"src=", "href=", "<script", "<link", "url("} {
+		if strings.Contains(html, s) {
+			t.Fatalf("expected a self-contained report, but it contains %q", s)
+		}
+	}
+}
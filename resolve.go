@@ -0,0 +1,190 @@
+package recovery
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// WithFromPublicKey configures the public key of the identity being
+// recovered like WithPublicKey, and also pre-fills the user ID and timestamp
+// prompts from it (an empty answer accepts them). If the recovered identity
+// still doesn't match, the likely causes are walked through one at a time
+// (the passphrase, the spelling of the user ID, the curve and the index),
+// deriving the identity again after each change without asking for the seed
+// again. It can't be combined with WithTrezorConfig.
+func WithFromPublicKey(pub io.Reader) Option {
+	return func(r *Recovery) {
+		r.publicKey = pub
+		r.fromPublicKey = true
+	}
+}
+
+// checkFromPublicKey checks the options make sense for pre-filling from the
+// public key before prompting for anything.
+func (r *Recovery) checkFromPublicKey() error {
+	if r.fromPublicKey && r.trezorConfig != nil {
+		return errors.New("the user ID and timestamp can be pre-filled from either the public key or a trezor-agent configuration, not both")
+	}
+	return nil
+}
+
+// publicKeyUserID returns the user ID of the public key given with
+// WithFromPublicKey, if any.
+func (r *Recovery) publicKeyUserID() string {
+	if !r.fromPublicKey || r.pubEntity == nil {
+		return ""
+	}
+	return entityUserID(r.pubEntity)
+}
+
+// publicKeyTimestamp returns the creation timestamp of the public key given
+// with WithFromPublicKey, if any.
+func (r *Recovery) publicKeyTimestamp() string {
+	if !r.fromPublicKey || r.pubEntity == nil {
+		return ""
+	}
+	return strconv.FormatInt(r.pubEntity.PrimaryKey.CreationTime.Unix(), 10)
+}
+
+// resolveMismatch walks through the likely causes of the identity derived
+// with passphrase, userID and timestamp not matching the public key, asking
+// whether to change each one and deriving the identity again from seed if it
+// is. It returns the matching identity, wiping the mismatched one, or
+// mismatch if none of the changes help.
+func (r *Recovery) resolveMismatch(seed Seed, mismatch *mismatchError, passphrase, userID string, timestamp time.Time) (*openpgp.Entity, error) {
+	r.display(`
+-----------------------------------------------------------------------------
+ The likely causes of the mismatch will now be checked one at a time. The
+ recovery seed doesn't need to be entered again.
+-----------------------------------------------------------------------------`)
+	r.audit(auditEntry{Step: "mismatch resolution started"})
+	params := r.keyParams()
+
+	// giveUp restores the parameters of the mismatched identity
+	saved, indexes := *params, r.indexes
+	giveUp := func() (*openpgp.Entity, error) {
+		*params, r.indexes = saved, indexes
+		return nil, mismatch
+	}
+
+	// derive derives the identity again after a change, returning it if it
+	// matches
+	derive := func(change string) (*openpgp.Entity, error) {
+		r.audit(auditEntry{Step: "mismatch resolution: " + change + " changed"})
+		entity, err := r.search(seed, []string{passphrase}, []string{userID}, []time.Time{timestamp})
+		if m, ok := err.(*mismatchError); ok && m.entity != nil {
+			wipeEntity(m.entity)
+			r.display("  The identity derived with the new %s has fingerprint %s, which still doesn't match.", change, m.actual)
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		r.display("  The identity derived with the new %s matches the public key.", change)
+		wipeEntity(mismatch.entity)
+		return entity, nil
+	}
+
+	// the passphrase, since every passphrase opens a different wallet
+	r.display(`
+  1. The passphrase: every passphrase (including none) opens a different
+     wallet, so a typo or the passphrase of another wallet recovers a
+     different identity.`)
+	if ok, err := r.confirm("Do you want to try a different passphrase?", resolveHelp); err != nil {
+		return nil, err
+	} else if ok {
+		secret, err := r.readSecret("Please enter the passphrase (leave blank if you don't use one):")
+		if err != nil {
+			return nil, err
+		}
+		passphrase = string(secret)
+		wipe(secret)
+		if entity, err := derive("passphrase"); entity != nil || err != nil {
+			return entity, err
+		}
+	}
+
+	// the spelling of the user ID, which doesn't feed a Ledger's derivation
+	if params.device != DeviceLedger {
+		r.display(`
+  2. The user ID: it must be spelt exactly as it was given to 'trezor-gpg
+     init', including case, spacing and punctuation. The user ID of the
+     public key may have been edited since.`)
+		answer, err := r.readLine(fmt.Sprintf("Please enter the user ID to try (leave blank to keep the current one) [%s]:", userID), resolveHelp)
+		if err != nil {
+			return nil, err
+		}
+		if answer != "" && answer != userID {
+			if err := r.checkUserID(answer); err != nil {
+				return nil, err
+			}
+			userID = answer
+			if entity, err := derive("user ID"); entity != nil || err != nil {
+				return entity, err
+			}
+		}
+	}
+
+	// the curve, which can't be changed since only NIST P-256 is supported
+	if curve := keyCurve(r.pubEntity.PrimaryKey); curve != params.curve {
+		r.display(`
+  3. The curve: the public key uses %s, but only %s identities can be
+     recovered, so this identity can't be recovered with this program.`, curve, params.curve)
+		return giveUp()
+	}
+	r.display("\n  3. The curve: the public key uses %s, as the recovered identity does.", params.curve)
+
+	// the index of a Trezor identity or key slot of a Ledger one
+	if params.device == DeviceLedger {
+		r.display(`
+  4. The key slot: the Ledger OpenPGP app has three key slots, each with
+     different keys.`)
+		answer, err := r.readLine(fmt.Sprintf("Please enter the key slot to try (leave blank to keep the current one) [%d]:", params.slot()), resolveHelp)
+		if err != nil {
+			return nil, err
+		}
+		if answer != "" && answer != strconv.FormatUint(uint64(params.slot()), 10) {
+			slot, err := strconv.ParseUint(answer, 10, 32)
+			if err != nil || slot < 1 || slot > 3 {
+				return nil, fmt.Errorf("invalid key slot %q: must be 1, 2 or 3", answer)
+			}
+			params.ledgerSlot = uint32(slot)
+			if entity, err := derive("key slot"); entity != nil || err != nil {
+				return entity, err
+			}
+		}
+	} else {
+		r.display(`
+  4. The index: identities created with a SLIP-0013 index other than 0
+     (e.g. by a patched agent) have different keys.`)
+		index := params.index
+		if len(r.indexes) == 1 {
+			index = r.indexes[0]
+		}
+		answer, err := r.readLine(fmt.Sprintf("Please enter the index to try (leave blank to keep the current one) [%d]:", index), resolveHelp)
+		if err != nil {
+			return nil, err
+		}
+		if answer != "" && answer != strconv.FormatUint(uint64(index), 10) {
+			index, err := strconv.ParseUint(answer, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid index %q: must be a number", answer)
+			}
+			params.index = uint32(index)
+			r.indexes = nil
+			if entity, err := derive("index"); entity != nil || err != nil {
+				return entity, err
+			}
+		}
+	}
+
+	r.display(`
+  None of the changes recovered the identity of the public key, so check the
+  recovery seed (entering * for a word which may be wrong searches for it)
+  and timestamp.`)
+	return giveUp()
+}
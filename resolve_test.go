@@ -0,0 +1,55 @@
+package recovery
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFromPublicKey(t *testing.T) {
+	var pub bytes.Buffer
+	if err := recoverEntity(t, aliceInput).Serialize(&pub); err != nil {
+		t.Fatal(err)
+	}
+
+	// check the user ID and timestamp are pre-filled from the public key
+	input := strings.Replace(aliceInput, "Alice <alice@example.com>\n1523060353\n", "\n\n", 1)
+	entity := recoverEntity(t, input, WithFromPublicKey(bytes.NewReader(pub.Bytes())))
+	if fingerprint := formatFingerprint(entity.PrimaryKey); fingerprint != aliceFingerprint {
+		t.Fatalf("expected fingerprint %s, got %s", aliceFingerprint, fingerprint)
+	}
+
+	// check a wrong passphrase can be corrected without entering the seed
+	// again
+	var stderr bytes.Buffer
+	input = strings.Replace(input, "s3cr3t\n", "wrong\nyes\ns3cr3t\n", 1)
+	entity = recoverEntity(t, input, WithFromPublicKey(bytes.NewReader(pub.Bytes())), WithStderr(&stderr), WithStrict())
+	if fingerprint := formatFingerprint(entity.PrimaryKey); fingerprint != aliceFingerprint {
+		t.Fatalf("expected fingerprint %s, got %s", aliceFingerprint, fingerprint)
+	}
+	if !strings.Contains(stderr.String(), "The identity derived with the new passphrase matches the public key.") {
+		t.Fatalf("expected the new passphrase to match, got:\n%s", stderr.String())
+	}
+	if strings.Count(stderr.String(), "Please enter your 12 word recovery seed") != 1 {
+		t.Fatalf("expected the seed to be entered once, got:\n%s", stderr.String())
+	}
+
+	// check each cause is offered before giving up
+	stderr.Reset()
+	input = strings.Replace(aliceInput, "s3cr3t\n", "wrong\nno\n\n\n", 1)
+	err := Run(
+		WithStdin(strings.NewReader(input)),
+		WithStdout(&bytes.Buffer{}),
+		WithStderr(&stderr),
+		WithFromPublicKey(bytes.NewReader(pub.Bytes())),
+		WithStrict(),
+	)
+	if err == nil || !strings.Contains(err.Error(), "does not match expected fingerprint") {
+		t.Fatalf("expected fingerprint mismatch, got %v", err)
+	}
+	for _, s := range []string{"1. The passphrase", "2. The user ID", "3. The curve: the public key uses nist256p1", "4. The index", "None of the changes recovered"} {
+		if !strings.Contains(stderr.String(), s) {
+			t.Fatalf("expected output to contain %q, got:\n%s", s, stderr.String())
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package recovery
+
+import (
+	"context"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// Result is a recovered GPG identity, leaving it to the caller to decide what
+// to print or where to store the private key.
+type Result struct {
+	// Entity is the recovered identity, including its private keys.
+	Entity *openpgp.Entity
+
+	UserID             string
+	PrimaryFingerprint string
+	PrimaryKeyID       string
+	SubkeyFingerprint  string
+	SubkeyID           string
+
+	// Demo is set if the identity was recovered in a practice run from the
+	// public demo seed, so its output should be watermarked.
+	Demo bool
+}
+
+func newResult(entity *openpgp.Entity) *Result {
+	return &Result{
+		Entity:             entity,
+		UserID:             entityUserID(entity),
+		PrimaryFingerprint: formatFingerprint(entity.PrimaryKey),
+		PrimaryKeyID:       formatKeyID(entity.PrimaryKey),
+		SubkeyFingerprint:  formatFingerprint(entity.Subkeys[0].PublicKey),
+		SubkeyID:           formatKeyID(entity.Subkeys[0].PublicKey),
+	}
+}
+
+// Identity returns the recovered identity wrapped with helpers, e.g. for its
+// SSH public key and keygrips.
+func (res *Result) Identity() *Identity {
+	return NewIdentity(res.Entity)
+}
+
+// ArmoredPublicKey returns the ascii armored public key.
+func (res *Result) ArmoredPublicKey() (string, error) {
+	return res.Identity().ArmorPublic()
+}
+
+// ArmoredPrivateKey returns the ascii armored private key. It is returned as
+// bytes rather than a string so that the caller can wipe it once written.
+func (res *Result) ArmoredPrivateKey() ([]byte, error) {
+	return res.Identity().ArmorPrivate()
+}
+
+// Wipe wipes the private keys of the recovered identity.
+func (res *Result) Wipe() {
+	wipeEntity(res.Entity)
+}
+
+// RecoverInteractive runs the recovery like RunContext, prompting for the
+// answers and running the configured checks, but returns the recovered
+// identity rather than printing it. Options which only affect the output
+// (e.g. WithShamirShares and WithClearScreen) are ignored, and the caller
+// should wipe the result once done with it.
+func RecoverInteractive(ctx context.Context, opts ...Option) (*Result, error) {
+	return new(Recovery).RecoverInteractive(ctx, opts...)
+}
+
+// RecoverInteractive is like the RecoverInteractive function, but runs the
+// recovery configured with the options r was created with followed by opts.
+// It is safe to call concurrently.
+func (r *Recovery) RecoverInteractive(ctx context.Context, opts ...Option) (*Result, error) {
+	run := r.newRun(ctx, opts)
+	var result *Result
+	err := run.finish(run.safeRun(func(res *Result) error {
+		result = res
+		return nil
+	}))
+	if err != nil {
+		if result != nil {
+			result.Wipe()
+		}
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,90 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+func TestRecoverInteractive(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	result, err := RecoverInteractive(
+		context.Background(),
+		WithStdin(strings.NewReader(aliceInput)),
+		WithStdout(&stdout),
+		WithStderr(&stderr),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer result.Wipe()
+
+	// check nothing was printed apart from the prompts
+	if stdout.Len() != 0 {
+		t.Fatalf("expected nothing on stdout, got:\n%s", stdout.String())
+	}
+	if strings.Contains(stderr.String(), "Primary Key Fingerprint") {
+		t.Fatalf("expected the identity not to be printed:\n%s", stderr.String())
+	}
+
+	if result.UserID != "Alice <alice@example.com>" {
+		t.Fatalf("unexpected user ID %q", result.UserID)
+	}
+	if result.PrimaryFingerprint != "AB86C8C7B5136D19B0A6AEC0406D7920DCAD67C3" {
+		t.Fatalf("unexpected primary fingerprint %q", result.PrimaryFingerprint)
+	}
+	if result.PrimaryKeyID != "406D7920DCAD67C3" {
+		t.Fatalf("unexpected primary key ID %q", result.PrimaryKeyID)
+	}
+	if result.SubkeyFingerprint != formatFingerprint(result.Entity.Subkeys[0].PublicKey) {
+		t.Fatalf("unexpected subkey fingerprint %q", result.SubkeyFingerprint)
+	}
+
+	// check the armored keys read back as the same identity
+	pub, err := result.ArmoredPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(pub))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if formatFingerprint(entities[0].PrimaryKey) != result.PrimaryFingerprint || entities[0].PrivateKey != nil {
+		t.Fatalf("unexpected public key:\n%s", pub)
+	}
+	priv, err := result.ArmoredPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entities, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(priv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entities[0].PrivateKey == nil || formatFingerprint(entities[0].PrimaryKey) != result.PrimaryFingerprint {
+		t.Fatal("expected the private key to read back")
+	}
+
+	// check Wipe clears the private keys
+	key := result.Entity.PrivateKey.PrivateKey.(*ecdsa.PrivateKey)
+	result.Wipe()
+	if key.D.Sign() != 0 {
+		t.Fatal("expected the private key to be wiped")
+	}
+}
+
+func TestRecoverInteractiveAborted(t *testing.T) {
+	result, err := RecoverInteractive(
+		context.Background(),
+		WithStdin(strings.NewReader("no\n")),
+		WithStdout(&bytes.Buffer{}),
+		WithStderr(&bytes.Buffer{}),
+	)
+	if !errors.Is(err, ErrAborted) || result != nil {
+		t.Fatalf("expected ErrAborted and no result, got %v, %v", result, err)
+	}
+}
@@ -0,0 +1,31 @@
+package recovery
+
+import "os"
+
+// WithRootCheck configures the recovery to warn and ask for confirmation
+// before continuing when run as root.
+func WithRootCheck() Option {
+	return func(r *Recovery) {
+		r.rootCheck = true
+	}
+}
+
+// geteuid is a variable so tests can fake running as root.
+var geteuid = os.Geteuid
+
+// confirmRoot warns about running as root and asks the user to confirm they
+// want to continue.
+func (r *Recovery) confirmRoot() error {
+	if geteuid() != 0 {
+		return nil
+	}
+	if err := r.warn("running as root: root's shell history, auditd and core dump settings make it more likely secrets are accidentally written to disk, so run the recovery as an unprivileged user if you can"); err != nil {
+		return err
+	}
+	if ok, err := r.confirm("Are you sure you want to continue the recovery as root?", rootHelp); err != nil {
+		return err
+	} else if !ok {
+		return ErrAborted
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package recovery
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRootCheck(t *testing.T) {
+	defer func(f func() int) { geteuid = f }(geteuid)
+
+	// check running as root requires confirmation
+	geteuid = func() int { return 0 }
+	var stderr bytes.Buffer
+	recoverEntity(t, "yes\n"+aliceInput, WithStderr(&stderr), WithRootCheck())
+	if !strings.Contains(stderr.String(), "WARNING: running as root") {
+		t.Fatalf("expected a warning about running as root, got:\n%s", stderr.String())
+	}
+	err := Run(
+		WithStdin(strings.NewReader("no\n"+aliceInput)),
+		WithStdout(&bytes.Buffer{}),
+		WithStderr(&bytes.Buffer{}),
+		WithRootCheck(),
+	)
+	if err == nil || err.Error() != "aborting at user's request" {
+		t.Fatalf("expected the recovery to abort, got %v", err)
+	}
+
+	// check running as root is fatal in strict mode
+	err = Run(
+		WithStdin(strings.NewReader("yes\n"+aliceInput)),
+		WithStdout(&bytes.Buffer{}),
+		WithStderr(&bytes.Buffer{}),
+		WithRootCheck(),
+		WithStrict(),
+	)
+	if err == nil || !strings.Contains(err.Error(), "running as root") {
+		t.Fatalf("expected running as root to fail in strict mode, got %v", err)
+	}
+
+	// check other users aren't prompted
+	geteuid = func() int { return 1000 }
+	recoverEntity(t, aliceInput, WithRootCheck())
+}
@@ -0,0 +1,23 @@
+package recovery
+
+// WithSandbox configures the recovery to sandbox the process once all input
+// has been read, so that even a compromised dependency can't open files or
+// network connections, or run other programs, while the derived secrets are
+// in memory. It is only supported on Linux (amd64 and arm64) using seccomp.
+//
+// Since the GnuPG and Sequoia-PGP checks run other programs, the sandbox is
+// entered after they complete if they are enabled.
+func WithSandbox() Option {
+	return func(r *Recovery) {
+		r.sandbox = true
+	}
+}
+
+// enterSandbox sandboxes the process, returning an error if that fails in
+// strict mode.
+func (r *Recovery) enterSandbox() error {
+	if err := installSandbox(); err != nil {
+		return r.warn("could not sandbox the process: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+//go:build linux && (amd64 || arm64)
+// +build linux
+// +build amd64 arm64
+
+package recovery
+
+import (
+	"runtime"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const (
+	prSetNoNewPrivs = 38
+
+	seccompSetModeFilter   = 1
+	seccompFilterFlagTsync = 1
+
+	seccompRetAllow = 0x7fff0000
+	seccompRetErrno = 0x00050000
+
+	// offsets into struct seccomp_data, where the low 32 bits of each
+	// argument come first on little-endian architectures
+	seccompDataNr   = 0
+	seccompDataArch = 4
+	seccompDataArg0 = 16
+	seccompDataArg1 = 24
+	seccompDataArg2 = 32
+
+	bpfLdWAbs = 0x20 // BPF_LD | BPF_W | BPF_ABS
+	bpfJeqK   = 0x15 // BPF_JMP | BPF_JEQ | BPF_K
+	bpfJgeK   = 0x35 // BPF_JMP | BPF_JGE | BPF_K
+	bpfJsetK  = 0x45 // BPF_JMP | BPF_JSET | BPF_K
+	bpfRetK   = 0x06 // BPF_RET | BPF_K
+
+	// x32 syscalls have this bit set on amd64
+	x32SyscallBit = 0x40000000
+
+	protExec    = 0x4     // PROT_EXEC
+	cloneThread = 0x10000 // CLONE_THREAD
+	atRemoveDir = 0x200   // AT_REMOVEDIR
+	tcgets      = 0x5401  // TCGETS
+	tiocgwinsz  = 0x5413  // TIOCGWINSZ
+)
+
+// installSandbox installs a seccomp filter on every thread of the process
+// which only allows the syscalls in sandboxAllowed, making every other one
+// fail with EPERM. A few more are allowed with restricted arguments:
+//
+//   - mmap and mprotect, only without PROT_EXEC so no code can be loaded
+//   - clone, only for threads (so not fork)
+//   - ioctl, only to query whether a file is a terminal and its size
+//   - unlinkat, only to remove files (so the output, session and checkpoint
+//     files can be removed), not directories
+//
+// and clone3 fails with ENOSYS since its arguments can't be checked. In
+// particular files can't be opened, created or renamed, no sockets can be
+// created or sent to (including through io_uring), and no programs can run.
+func installSandbox() error {
+	// load the local time zone now since it can't be read from disk later
+	_ = time.Local.String()
+
+	allow := syscall.SockFilter{Code: bpfRetK, K: seccompRetAllow}
+	deny := syscall.SockFilter{Code: bpfRetK, K: seccompRetErrno | uint32(syscall.EPERM)}
+	filter := []syscall.SockFilter{
+		// deny syscalls from other architectures (e.g. 32-bit syscalls)
+		{Code: bpfLdWAbs, K: seccompDataArch},
+		{Code: bpfJeqK, Jt: 1, K: sandboxArch},
+		deny,
+
+		// deny x32 syscalls
+		{Code: bpfLdWAbs, K: seccompDataNr},
+		{Code: bpfJgeK, Jf: 1, K: x32SyscallBit},
+		deny,
+	}
+	for _, nr := range sandboxAllowed {
+		filter = append(filter,
+			syscall.SockFilter{Code: bpfJeqK, Jf: 1, K: uint32(nr)},
+			allow,
+		)
+	}
+	for _, nr := range []uint32{syscall.SYS_MMAP, syscall.SYS_MPROTECT} {
+		filter = append(filter,
+			// mmap and mprotect only without PROT_EXEC
+			syscall.SockFilter{Code: bpfJeqK, Jf: 4, K: nr},
+			syscall.SockFilter{Code: bpfLdWAbs, K: seccompDataArg2},
+			syscall.SockFilter{Code: bpfJsetK, Jt: 1, K: protExec},
+			allow,
+			deny,
+		)
+	}
+	filter = append(filter,
+		// clone only with CLONE_THREAD
+		syscall.SockFilter{Code: bpfJeqK, Jf: 4, K: syscall.SYS_CLONE},
+		syscall.SockFilter{Code: bpfLdWAbs, K: seccompDataArg0},
+		syscall.SockFilter{Code: bpfJsetK, Jf: 1, K: cloneThread},
+		allow,
+		deny,
+
+		// clone3 as unavailable, so the runtime falls back to clone
+		syscall.SockFilter{Code: bpfJeqK, Jf: 1, K: sysClone3},
+		syscall.SockFilter{Code: bpfRetK, K: seccompRetErrno | uint32(syscall.ENOSYS)},
+
+		// ioctl only with TCGETS or TIOCGWINSZ
+		syscall.SockFilter{Code: bpfJeqK, Jf: 5, K: syscall.SYS_IOCTL},
+		syscall.SockFilter{Code: bpfLdWAbs, K: seccompDataArg1},
+		syscall.SockFilter{Code: bpfJeqK, Jt: 2, K: tcgets},
+		syscall.SockFilter{Code: bpfJeqK, Jt: 1, K: tiocgwinsz},
+		deny,
+		allow,
+
+		// unlinkat only without AT_REMOVEDIR
+		syscall.SockFilter{Code: bpfJeqK, Jf: 4, K: syscall.SYS_UNLINKAT},
+		syscall.SockFilter{Code: bpfLdWAbs, K: seccompDataArg2},
+		syscall.SockFilter{Code: bpfJsetK, Jt: 1, K: atRemoveDir},
+		allow,
+		deny,
+
+		deny,
+	)
+	prog := syscall.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+
+	// no_new_privs and the filter are set on the current thread and then
+	// synced to the others
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0); errno != 0 {
+		return errno
+	}
+	if _, _, errno := syscall.RawSyscall(sysSeccomp, seccompSetModeFilter, seccompFilterFlagTsync, uintptr(unsafe.Pointer(&prog))); errno != 0 {
+		return errno
+	}
+	return nil
+}
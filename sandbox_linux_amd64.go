@@ -0,0 +1,62 @@
+package recovery
+
+import "syscall"
+
+const (
+	auditArchX86_64 = 0xc000003e
+	sandboxArch     = auditArchX86_64
+
+	sysSeccomp   = 317
+	sysGetrandom = 318
+	sysRseq      = 334
+	sysClone3    = 435
+)
+
+// sandboxAllowed are the syscalls which are still allowed once the process
+// is sandboxed, which the Go runtime needs along with reading and writing
+// the files which are already open.
+var sandboxAllowed = []uintptr{
+	// files which are already open
+	syscall.SYS_READ,
+	syscall.SYS_WRITE,
+	syscall.SYS_PREAD64,
+	syscall.SYS_PWRITE64,
+	syscall.SYS_LSEEK,
+	syscall.SYS_FSTAT,
+	syscall.SYS_FSYNC,
+	syscall.SYS_FDATASYNC,
+	syscall.SYS_FTRUNCATE,
+	syscall.SYS_FCNTL,
+	syscall.SYS_CLOSE,
+	syscall.SYS_EPOLL_WAIT,
+	syscall.SYS_EPOLL_PWAIT,
+	syscall.SYS_EPOLL_CTL,
+
+	// memory (mmap and mprotect are allowed without PROT_EXEC)
+	syscall.SYS_MUNMAP,
+	syscall.SYS_MADVISE,
+	syscall.SYS_MLOCK,
+	syscall.SYS_MUNLOCK,
+
+	// threads, scheduling and signals
+	syscall.SYS_FUTEX,
+	syscall.SYS_GETTID,
+	syscall.SYS_GETPID,
+	syscall.SYS_TGKILL,
+	syscall.SYS_SCHED_YIELD,
+	syscall.SYS_SCHED_GETAFFINITY,
+	syscall.SYS_NANOSLEEP,
+	syscall.SYS_CLOCK_GETTIME,
+	syscall.SYS_RT_SIGACTION,
+	syscall.SYS_RT_SIGPROCMASK,
+	syscall.SYS_RT_SIGRETURN,
+	syscall.SYS_SIGALTSTACK,
+	syscall.SYS_RESTART_SYSCALL,
+	syscall.SYS_SET_ROBUST_LIST,
+	sysRseq,
+	syscall.SYS_EXIT,
+	syscall.SYS_EXIT_GROUP,
+
+	// random numbers
+	sysGetrandom,
+}
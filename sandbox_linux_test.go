@@ -0,0 +1,119 @@
+//go:build amd64 || arm64
+// +build amd64 arm64
+
+package recovery
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSandbox(t *testing.T) {
+	// the sandbox can't be removed so install it in a subprocess
+	if os.Getenv("TREZOR_GPG_RECOVERY_SANDBOX_TEST") == "1" {
+		testSandbox()
+		return
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestSandbox$", "-test.v")
+	cmd.Env = append(os.Environ(), "TREZOR_GPG_RECOVERY_SANDBOX_TEST=1")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("sandbox test failed: %s\n%s", err, out)
+	}
+	if !strings.Contains(string(out), "sandbox ok") {
+		t.Fatalf("expected sandbox to be tested, got:\n%s", out)
+	}
+}
+
+func testSandbox() {
+	fail := func(format string, args ...interface{}) {
+		os.Stderr.WriteString("FAIL: " + strings.TrimSpace(fmt.Sprintf(format, args...)) + "\n")
+		os.Exit(1)
+	}
+	dir, err := ioutil.TempDir("", "trezor-gpg-recovery-sandbox-")
+	if err != nil {
+		fail("%s", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "output")
+	if err := ioutil.WriteFile(file, nil, 0600); err != nil {
+		fail("%s", err)
+	}
+	if err := installSandbox(); err != nil {
+		fail("could not install sandbox: %s", err)
+	}
+
+	// check files, sockets and programs are denied
+	if _, err := os.Open(os.Args[0]); !isPermission(err) {
+		fail("expected opening a file to fail with EPERM, got %v", err)
+	}
+	if _, err := net.Dial("tcp", "127.0.0.1:1"); !isPermission(err) {
+		fail("expected dialing to fail with EPERM, got %v", err)
+	}
+	if err := exec.Command(os.Args[0]).Run(); !isPermission(err) {
+		fail("expected running a program to fail with EPERM, got %v", err)
+	}
+
+	// check directories can't be created, files renamed or directories
+	// removed, but files can be removed
+	if err := os.Mkdir(filepath.Join(dir, "new"), 0700); !isPermission(err) {
+		fail("expected creating a directory to fail with EPERM, got %v", err)
+	}
+	if err := os.Rename(file, file+".new"); !isPermission(err) {
+		fail("expected renaming a file to fail with EPERM, got %v", err)
+	}
+	if err := syscall.Rmdir(dir); !isPermission(err) {
+		fail("expected removing a directory to fail with EPERM, got %v", err)
+	}
+	if err := os.Remove(file); err != nil {
+		fail("expected removing a file to succeed, got %v", err)
+	}
+
+	// check data can't be sent on sockets or through io_uring
+	if _, _, errno := syscall.RawSyscall6(syscall.SYS_SENDTO, 1, 0, 0, 0, 0, 0); errno != syscall.EPERM {
+		fail("expected sendto to fail with EPERM, got %v", errno)
+	}
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_SENDMSG, 1, 0, 0); errno != syscall.EPERM {
+		fail("expected sendmsg to fail with EPERM, got %v", errno)
+	}
+	if _, _, errno := syscall.RawSyscall(sysIOURingSetup, 1, 0, 0); errno != syscall.EPERM {
+		fail("expected io_uring_setup to fail with EPERM, got %v", errno)
+	}
+
+	// check the runtime can still start threads
+	var wg sync.WaitGroup
+	for i := 0; i < 4*runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runtime.LockOSThread()
+			time.Sleep(10 * time.Millisecond)
+		}()
+	}
+	wg.Wait()
+	runtime.GC()
+
+	// check a recovery still works
+	v := testVectors[0]
+	if err := v.check(); err != nil {
+		fail("test vector failed: %s", err)
+	}
+	os.Stdout.WriteString("sandbox ok\n")
+}
+
+// sysIOURingSetup is io_uring_setup on both amd64 and arm64.
+const sysIOURingSetup = 425
+
+func isPermission(err error) bool {
+	return err != nil && strings.Contains(err.Error(), syscall.EPERM.Error())
+}
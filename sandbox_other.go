@@ -0,0 +1,10 @@
+//go:build !linux || (!amd64 && !arm64)
+// +build !linux !amd64,!arm64
+
+package recovery
+
+import "errors"
+
+func installSandbox() error {
+	return errors.New("sandboxing is not supported on this platform")
+}
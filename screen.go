@@ -0,0 +1,38 @@
+package recovery
+
+import "fmt"
+
+// clearScreen moves the cursor to the top left, clears the screen, and then
+// clears the scrollback on terminals which support it (e.g. xterm, VTE and
+// the Linux console).
+const clearScreen = "\x1b[H\x1b[2J\x1b[3J"
+
+// WithClearScreen configures the recovery to clear the terminal (including
+// the scrollback where supported) once the user confirms they have saved the
+// private key, so that neither it nor the recovery seed remain visible.
+func WithClearScreen() Option {
+	return func(r *Recovery) {
+		r.clearScreen = true
+	}
+}
+
+// waitAndClearScreen waits for the user to confirm they have saved the output
+// and then clears the screen.
+func (r *Recovery) waitAndClearScreen() error {
+	if _, err := r.readLine("Press enter once you have saved the private key to clear the screen:", clearScreenHelp); err != nil {
+		return err
+	}
+	return r.clearTerminal()
+}
+
+// clearTerminal clears the terminal, failing if it couldn't be cleared since
+// a secret would otherwise stay on the screen.
+func (r *Recovery) clearTerminal() error {
+	if err := clearTerminal(r.stderr); err != nil {
+		return fmt.Errorf("could not clear the screen (clear it yourself, e.g. with cls): %s", err)
+	}
+	if r.screenReader {
+		r.display("The screen has been cleared.")
+	}
+	return nil
+}
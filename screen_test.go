@@ -0,0 +1,20 @@
+package recovery
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestClearScreen(t *testing.T) {
+	var stderr bytes.Buffer
+	recoverEntity(t, aliceInput+"\n", WithStderr(&stderr), WithClearScreen())
+	out := stderr.String()
+	prompt := strings.Index(out, "Press enter once you have saved the private key")
+	if prompt == -1 {
+		t.Fatalf("expected a prompt before clearing the screen, got:\n%s", out)
+	}
+	if !strings.HasSuffix(out, clearScreen) {
+		t.Fatalf("expected the screen to be cleared, got:\n%q", out[prompt:])
+	}
+}
@@ -0,0 +1,95 @@
+package recovery
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// WithScreenReader configures prompts and messages for use with a screen
+// reader: the banner and separator lines are left out, prompts aren't
+// padded, and seed words are asked for by spelt out number (e.g. "Word five
+// of twenty-four:"). The screen is still cleared where WithClearScreen or
+// WithDualOperator ask for it, since that hides secrets, but it is announced.
+func WithScreenReader() Option {
+	return func(r *Recovery) {
+		r.screenReader = true
+	}
+}
+
+// plainText removes the separator lines and trailing padding of text
+// displayed in screen reader mode, which would otherwise be read out.
+func plainText(text string) string {
+	lines := strings.Split(text, "\n")
+	plain := lines[:0]
+	for _, line := range lines {
+		line = strings.TrimRight(line, " ")
+		if line != "" && strings.Trim(line, "-") == "" {
+			continue
+		}
+		plain = append(plain, strings.TrimLeft(line, " "))
+	}
+	return strings.Trim(strings.Join(plain, "\n"), "\n")
+}
+
+// wordPrompt returns the prompt for seed word num of total.
+func (r *Recovery) wordPrompt(num, total int) string {
+	if r.screenReader {
+		return "Word " + spellNumber(num) + " of " + spellNumber(total) + ":"
+	}
+	if r.largePrint {
+		return fmt.Sprintf("Type word %d of %d from the recovery card", num, total)
+	}
+	return fmt.Sprintf("%2d:", num)
+}
+
+// ParseWordPrompt returns the number of the seed word a prompt passed to
+// Prompter.ReadSecret asks for, in any of the forms the recovery uses (e.g.
+// " 5:", "Word five of twenty-four:" with WithScreenReader or "Type word 5
+// of 24 from the recovery card" with WithLargePrint), so that a Prompter
+// needn't match them itself. ok is false if the prompt isn't for a seed word.
+func ParseWordPrompt(prompt string) (num int, ok bool) {
+	prompt = strings.TrimSuffix(strings.TrimSpace(prompt), ":")
+	if num, err := strconv.Atoi(prompt); err == nil {
+		return num, true
+	}
+	fields := strings.Fields(prompt)
+	switch {
+	case len(fields) == 4 && fields[0] == "Word" && fields[2] == "of":
+		return parseSpelledNumber(fields[1])
+	case len(fields) == 9 && strings.HasPrefix(prompt, "Type word ") && strings.HasSuffix(prompt, " from the recovery card"):
+		num, err := strconv.Atoi(fields[2])
+		return num, err == nil
+	}
+	return 0, false
+}
+
+// parseSpelledNumber parses a number spelt out by spellNumber.
+func parseSpelledNumber(s string) (int, bool) {
+	for n := 0; n < 100; n++ {
+		if spellNumber(n) == s {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+var (
+	smallNumbers = []string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen"}
+	tens         = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+)
+
+// spellNumber spells out n in English words (e.g. "twenty-four") if it's
+// less than 100, which all seed word numbers are.
+func spellNumber(n int) string {
+	switch {
+	case n < 0 || n >= 100:
+		return strconv.Itoa(n)
+	case n < 20:
+		return smallNumbers[n]
+	case n%10 == 0:
+		return tens[n/10]
+	default:
+		return tens[n/10] + "-" + smallNumbers[n%10]
+	}
+}
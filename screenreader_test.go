@@ -0,0 +1,58 @@
+package recovery
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+func TestScreenReader(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := Run(
+		WithStdin(strings.NewReader(aliceInput)),
+		WithStdout(&stdout),
+		WithStderr(&stderr),
+		WithScreenReader(),
+	)
+	if err != nil {
+		t.Fatalf("%s\n%s", err, stderr.String())
+	}
+	if _, err := openpgp.ReadArmoredKeyRing(&stdout); err != nil {
+		t.Fatal(err)
+	}
+	out := stderr.String()
+	for _, expected := range []string{"Word one of twelve:", "Word five of twelve:", "Word twelve of twelve:", "Warning: this program recovers private keys"} {
+		if !strings.Contains(out, expected) {
+			t.Fatalf("expected %q, got:\n%s", expected, out)
+		}
+	}
+	if strings.Contains(out, "-----") || strings.Contains(out, "WARNING WARNING") || strings.Contains(out, " \n") {
+		t.Fatalf("expected no banner, separators or padding, got:\n%s", out)
+	}
+
+	for n, expected := range map[int]string{1: "one", 13: "thirteen", 20: "twenty", 24: "twenty-four"} {
+		if spelt := spellNumber(n); spelt != expected {
+			t.Fatalf("expected %d to be spelt %q, got %q", n, expected, spelt)
+		}
+	}
+}
+
+func TestParseWordPrompt(t *testing.T) {
+	for _, r := range []*Recovery{{}, {screenReader: true}, {largePrint: true}} {
+		for _, total := range []int{12, 18, 24} {
+			for num := 1; num <= total; num++ {
+				prompt := r.wordPrompt(num, total)
+				if n, ok := ParseWordPrompt(prompt); !ok || n != num {
+					t.Fatalf("expected %q to ask for word %d, got %d (%v)", prompt, num, n, ok)
+				}
+			}
+		}
+	}
+	for _, prompt := range []string{"Please enter your passphrase (leave blank if you don't use one):", "Word five of:", "Word fivety of twelve:", "Type word five of 24 from the recovery card"} {
+		if n, ok := ParseWordPrompt(prompt); ok {
+			t.Fatalf("expected %q not to be a seed word prompt, got %d", prompt, n)
+		}
+	}
+}
@@ -0,0 +1,587 @@
+package recovery
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	slip10 "github.com/lmars/go-slip10"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// WithFingerprint configures the expected primary key fingerprint of the
+// identity being recovered. The recovery fails if the derived key does not
+// match it, and searches use it to recognise the right candidate.
+func WithFingerprint(fingerprint string) Option {
+	return func(r *Recovery) {
+		r.fingerprint = fingerprint
+	}
+}
+
+// WithPassphraseCandidates configures a list of candidate passphrases to try
+// rather than prompting for a single passphrase. It requires WithFingerprint.
+func WithPassphraseCandidates(passphrases []string) Option {
+	return func(r *Recovery) {
+		r.passphrases = passphrases
+	}
+}
+
+// WithUserIDCandidates configures a list of candidate user IDs to try rather
+// than prompting for one, to find the exact user ID given to 'trezor-gpg
+// init'. It requires WithFingerprint.
+func WithUserIDCandidates(userIDs []string) Option {
+	return func(r *Recovery) {
+		r.userIDs = userIDs
+	}
+}
+
+// WithTimestampCandidates configures a list of candidate timestamps to try
+// rather than prompting for one, to find the timestamp given to 'trezor-gpg
+// init'. It requires WithFingerprint.
+func WithTimestampCandidates(timestamps []time.Time) Option {
+	return func(r *Recovery) {
+		r.timestamps = timestamps
+	}
+}
+
+// WithIndexCandidates configures a list of candidate SLIP-0013 indexes to
+// try rather than the one given with WithIndex, to find the index of an
+// identity which wasn't the first one created for its user ID. It requires
+// WithFingerprint.
+func WithIndexCandidates(indexes []uint32) Option {
+	return func(r *Recovery) {
+		r.indexes = indexes
+	}
+}
+
+// WithPassphraseTypos configures the recovery to also try common typing
+// mistakes of the entered passphrase. It requires WithFingerprint.
+func WithPassphraseTypos() Option {
+	return func(r *Recovery) {
+		r.passphraseTypos = true
+	}
+}
+
+// parseFingerprint normalises a user supplied fingerprint, removing any spaces
+// and "0x" prefix.
+func parseFingerprint(s string) (string, error) {
+	s = strings.ToUpper(strings.Replace(s, " ", "", -1))
+	s = strings.TrimPrefix(s, "0X")
+	if b, err := hex.DecodeString(s); err != nil || len(b) != 20 {
+		return "", fmt.Errorf("invalid fingerprint %q: must be 40 hex characters", s)
+	}
+	return s, nil
+}
+
+// checkSearch checks the search options are consistent before prompting for
+// anything.
+func (r *Recovery) checkSearch() error {
+	if r.publicKey != nil {
+		if err := r.readPublicKey(); err != nil {
+			return err
+		}
+	}
+	if r.fingerprint != "" {
+		fingerprint, err := parseFingerprint(r.fingerprint)
+		if err != nil {
+			return err
+		}
+		r.fingerprint = fingerprint
+		if r.passphrases != nil && len(r.passphrases) == 0 {
+			return errors.New("no candidate passphrases to search")
+		}
+		if r.userIDs != nil && len(r.userIDs) == 0 {
+			return errors.New("no candidate user IDs to search")
+		}
+		if r.timestamps != nil && len(r.timestamps) == 0 {
+			return errors.New("no candidate timestamps to search")
+		}
+		if r.indexes != nil && len(r.indexes) == 0 {
+			return errors.New("no candidate indexes to search")
+		}
+		for _, timestamp := range r.timestamps {
+			if err := checkTimestampRange(timestamp); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if r.passphrases != nil || r.passphraseTypos {
+		return errors.New("searching for a passphrase requires an expected fingerprint")
+	}
+	if r.userIDs != nil {
+		return errors.New("searching for a user ID requires an expected fingerprint")
+	}
+	if r.timestamps != nil {
+		return errors.New("searching for a timestamp requires an expected fingerprint")
+	}
+	if r.indexes != nil {
+		return errors.New("searching for an index requires an expected fingerprint")
+	}
+	return nil
+}
+
+// search derives the identity for each combination of candidate seed (when
+// words are missing), passphrase, user ID, index and timestamp, returning the
+// first one whose primary key matches the expected fingerprint.
+func (r *Recovery) search(seed Seed, passphrases, userIDs []string, timestamps []time.Time) (*openpgp.Entity, error) {
+	space := &searchSpace{
+		r:           r,
+		seed:        seed,
+		passphrases: passphrases,
+		userIDs:     userIDs,
+		indexes:     r.indexes,
+		timestamps:  timestamps,
+	}
+	if partial, ok := seed.(*partialSeed); ok {
+		space.partial = partial
+	}
+	if space.indexes == nil {
+		space.indexes = []uint32{r.keyParams().index}
+	}
+	candidates, err := space.size()
+	if err != nil {
+		return nil, err
+	}
+	noun := space.noun()
+	r.debug(fmt.Sprintf("Deriving with key parameters: %s", r.keyParams()))
+
+	// a single candidate is derived whether or not it matches
+	if candidates == 1 {
+		entity, err := space.entity(0)
+		if err != nil {
+			return nil, err
+		}
+		fingerprint := formatFingerprint(entity.PrimaryKey)
+		if r.onKeyDerived != nil {
+			r.onKeyDerived(1, 1, fingerprint)
+		}
+		if r.fingerprint != "" && fingerprint != r.fingerprint {
+			return nil, &mismatchError{actual: fingerprint, expected: r.fingerprint, entity: entity}
+		}
+		return entity, nil
+	}
+
+	r.info(fmt.Sprintf("Searching %d candidate %s for fingerprint %s...", candidates, noun, r.fingerprint), LogField{"candidates", candidates}, LogField{"fingerprint", r.fingerprint})
+	expected, err := hex.DecodeString(r.fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	start, err := r.resumeSearch(space, candidates)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.confirmSearch(space, start, candidates); err != nil {
+		return nil, err
+	}
+	var mtx sync.Mutex
+	n, found, err := r.bruteForce(start, candidates, func() candidateWorker {
+		return &searchWorker{space: space, total: candidates, expected: expected, mtx: &mtx}
+	}, r.saveCheckpoint(space, candidates))
+	if err != nil {
+		return nil, err
+	}
+	r.removeCheckpoint()
+	if !found {
+		return nil, &mismatchError{expected: r.fingerprint, candidates: int(candidates), noun: noun}
+	}
+	entity, err := space.entity(n)
+	if err != nil {
+		return nil, err
+	}
+	if fingerprint := formatFingerprint(entity.PrimaryKey); fingerprint != r.fingerprint {
+		wipeEntity(entity)
+		return nil, fmt.Errorf("candidate %d has fingerprint %s, expected %s", n+1, fingerprint, r.fingerprint)
+	}
+	space.reportMatch(n)
+	return entity, nil
+}
+
+// searchSpace is the candidates of a search, numbered with the timestamp
+// varying fastest then the index, user ID, passphrase and seed, so that
+// consecutive candidates mostly share a master key and derived keys.
+type searchSpace struct {
+	r           *Recovery
+	seed        Seed
+	partial     *partialSeed
+	passphrases []string
+	userIDs     []string
+	indexes     []uint32
+	timestamps  []time.Time
+}
+
+// searchCandidate is the position of a candidate in each list of a
+// searchSpace.
+type searchCandidate struct {
+	seed       uint64
+	passphrase int
+	userID     int
+	index      int
+	timestamp  int
+}
+
+// seeds returns the number of candidate seeds.
+func (s *searchSpace) seeds() uint64 {
+	if s.partial == nil {
+		return 1
+	}
+	return s.partial.candidates()
+}
+
+// size returns the number of candidates.
+func (s *searchSpace) size() (uint64, error) {
+	size := s.seeds()
+	for _, n := range []int{len(s.passphrases), len(s.userIDs), len(s.indexes), len(s.timestamps)} {
+		hi, lo := bits.Mul64(size, uint64(n))
+		if hi != 0 {
+			return 0, errors.New("too many candidates to search")
+		}
+		size = lo
+	}
+	return size, nil
+}
+
+// keysPerMasterKey returns the number of consecutive candidates which share
+// a master key, and keysPerKey the number which share derived keys.
+func (s *searchSpace) keysPerMasterKey() uint64 {
+	return uint64(len(s.userIDs)) * uint64(len(s.indexes)) * uint64(len(s.timestamps))
+}
+
+func (s *searchSpace) keysPerKey() uint64 {
+	return uint64(len(s.timestamps))
+}
+
+// candidate returns the position of candidate n in each list.
+func (s *searchSpace) candidate(n uint64) searchCandidate {
+	var c searchCandidate
+	c.timestamp = int(n % uint64(len(s.timestamps)))
+	n /= uint64(len(s.timestamps))
+	c.index = int(n % uint64(len(s.indexes)))
+	n /= uint64(len(s.indexes))
+	c.userID = int(n % uint64(len(s.userIDs)))
+	n /= uint64(len(s.userIDs))
+	c.passphrase = int(n % uint64(len(s.passphrases)))
+	c.seed = n / uint64(len(s.passphrases))
+	return c
+}
+
+// masterKey derives the master key of candidate c, returning nil if its seed
+// has an invalid checksum.
+func (s *searchSpace) masterKey(c searchCandidate) (*slip10.Key, error) {
+	seed := s.seed
+	if s.partial != nil {
+		candidate, ok := s.partial.candidate(c.seed)
+		if !ok {
+			return nil, nil
+		}
+		defer candidate.Wipe()
+		seed = candidate
+	}
+	return s.r.keyParams().masterKey(seed, s.passphrases[c.passphrase])
+}
+
+// params returns the key parameters of candidate c.
+func (s *searchSpace) params(c searchCandidate) *keyParams {
+	params := *s.r.keyParams()
+	params.index = s.indexes[c.index]
+	return &params
+}
+
+// entity derives the identity of candidate n.
+func (s *searchSpace) entity(n uint64) (*openpgp.Entity, error) {
+	c := s.candidate(n)
+	masterKey, err := s.masterKey(c)
+	if err != nil {
+		return nil, err
+	} else if masterKey == nil {
+		return nil, errorf("%w: %s", ErrInvalidMnemonic, errChecksum)
+	}
+	defer wipeSlip10Key(masterKey)
+	params := s.params(c)
+	userID := s.userIDs[c.userID]
+	primaryKey, subKey, err := params.deriveKeys(masterKey, userID)
+	if err != nil {
+		return nil, err
+	}
+	entity := buildEntity(primaryKey, subKey, userID, s.timestamps[c.timestamp], params)
+	if s.r.debugEnabled() {
+		primaryPath, subkeyPath := params.paths(userID)
+		primary, subkey := formatFingerprint(entity.PrimaryKey), formatFingerprint(entity.Subkeys[0].PublicKey)
+		s.r.debug(
+			fmt.Sprintf("Derived candidate %d: user ID %q, timestamp %d, primary key %s at %s, subkey %s at %s", n+1, userID, s.timestamps[c.timestamp].Unix(), primary, primaryPath, subkey, subkeyPath),
+			LogField{"candidate", n + 1}, LogField{"user_id", userID}, LogField{"timestamp", s.timestamps[c.timestamp].Unix()},
+			LogField{"primary_fingerprint", primary}, LogField{"primary_path", primaryPath},
+			LogField{"subkey_fingerprint", subkey}, LogField{"subkey_path", subkeyPath},
+		)
+	}
+	return entity, nil
+}
+
+// reportMatch reports which candidates matched, and sets the index of the
+// recovery to the matching one.
+func (s *searchSpace) reportMatch(n uint64) {
+	r := s.r
+	c := s.candidate(n)
+	if s.partial != nil {
+		// the missing words are secret so are only displayed
+		r.info(fmt.Sprintf("Found the missing %s (candidate %d of %d)", pluralWords(len(s.partial.missing)), c.seed+1, s.seeds()), LogField{"candidate", c.seed + 1}, LogField{"candidates", s.seeds()})
+		words := s.partial.words(c.seed)
+		for _, i := range s.partial.missing {
+			r.display("Missing word %d: %s", i+1, words[i])
+		}
+		wipeWords(words)
+		r.check("recovery seed checksum", nil)
+	}
+	if len(s.passphrases) > 1 {
+		// the passphrase is secret so is only displayed
+		r.info(fmt.Sprintf("Found matching passphrase (candidate %d of %d)", c.passphrase+1, len(s.passphrases)), LogField{"candidate", c.passphrase + 1}, LogField{"candidates", len(s.passphrases)})
+		r.display("Matching passphrase: %q", s.passphrases[c.passphrase])
+	}
+	if len(s.userIDs) > 1 {
+		userID := s.userIDs[c.userID]
+		r.info(fmt.Sprintf("Found matching user ID (candidate %d of %d): %q", c.userID+1, len(s.userIDs), userID), LogField{"candidate", c.userID + 1}, LogField{"candidates", len(s.userIDs)}, LogField{"user_id", userID})
+	}
+	if len(s.indexes) > 1 {
+		index := s.indexes[c.index]
+		r.info(fmt.Sprintf("Found matching index (candidate %d of %d): %d", c.index+1, len(s.indexes), index), LogField{"candidate", c.index + 1}, LogField{"candidates", len(s.indexes)}, LogField{"index", index})
+	}
+	r.keyParams().index = s.indexes[c.index]
+	if len(s.timestamps) > 1 {
+		timestamp := s.timestamps[c.timestamp]
+		r.info(fmt.Sprintf("Found matching timestamp (candidate %d of %d): %d (%s)", c.timestamp+1, len(s.timestamps), timestamp.Unix(), timestamp.UTC().Format(time.RFC1123)), LogField{"candidate", c.timestamp + 1}, LogField{"candidates", len(s.timestamps)}, LogField{"timestamp", timestamp.Unix()})
+	}
+}
+
+// noun describes what is being searched, for log and error messages.
+func (s *searchSpace) noun() string {
+	return candidateNoun(int(s.seeds()), len(s.passphrases), len(s.userIDs), len(s.indexes), len(s.timestamps))
+}
+
+// searchWorker is a candidateWorker for a searchSpace, which only derives
+// the master key when the seed or passphrase changes and the keys when the
+// user ID or index changes, and then just computes the fingerprint for each
+// timestamp.
+type searchWorker struct {
+	space    *searchSpace
+	total    uint64
+	expected []byte
+	mtx      *sync.Mutex
+
+	masterN      uint64
+	masterKey    *slip10.Key
+	keysN        uint64
+	primaryKey   *ecdsa.PrivateKey
+	subKey       *ecdsa.PrivateKey
+	fingerprints *fingerprinter
+	derived      bool
+}
+
+func (w *searchWorker) try(n uint64) (bool, error) {
+	s := w.space
+	c := s.candidate(n)
+	masterN, keysN := n/s.keysPerMasterKey(), n/s.keysPerKey()
+	if !w.derived || masterN != w.masterN {
+		w.wipe()
+		masterKey, err := s.masterKey(c)
+		if err != nil {
+			return false, err
+		}
+		w.masterN, w.masterKey, w.derived = masterN, masterKey, true
+		w.keysN = keysN + 1
+	}
+	if w.masterKey == nil {
+		// the seed has an invalid checksum
+		return false, nil
+	}
+	if keysN != w.keysN {
+		wipeKey(w.primaryKey)
+		wipeKey(w.subKey)
+		primaryKey, subKey, err := s.params(c).deriveKeys(w.masterKey, s.userIDs[c.userID])
+		if err != nil {
+			return false, err
+		}
+		w.keysN, w.primaryKey, w.subKey = keysN, primaryKey, subKey
+		w.fingerprints = newFingerprinter(primaryKey)
+	}
+	sum := w.fingerprints.sum(s.timestamps[c.timestamp])
+	if fn := s.r.onKeyDerived; fn != nil {
+		w.mtx.Lock()
+		fn(int(n+1), int(w.total), strings.ToUpper(hex.EncodeToString(sum[:])))
+		w.mtx.Unlock()
+	}
+	return bytes.Equal(sum[:], w.expected), nil
+}
+
+func (w *searchWorker) wipe() {
+	wipeSlip10Key(w.masterKey)
+	wipeKey(w.primaryKey)
+	wipeKey(w.subKey)
+	w.masterKey, w.primaryKey, w.subKey, w.derived = nil, nil, nil, false
+}
+
+// fingerprinter computes the fingerprint of a primary key with different
+// creation times, serializing the key once rather than building an entity
+// (which marshals both keys and computes both fingerprints) per timestamp.
+type fingerprinter struct {
+	prefix bytes.Buffer
+	body   []byte
+}
+
+func newFingerprinter(key *ecdsa.PrivateKey) *fingerprinter {
+	pub := packet.NewECDSAPublicKey(time.Unix(0, 0), &key.PublicKey)
+	f := &fingerprinter{}
+	pub.SerializeSignaturePrefix(&f.prefix)
+	var buf bytes.Buffer
+	pub.Serialize(&buf)
+	length := int(binary.BigEndian.Uint16(f.prefix.Bytes()[1:]))
+	f.body = buf.Bytes()[buf.Len()-length:]
+	return f
+}
+
+// sum returns the V4 fingerprint (RFC 4880, section 12.2) of the key
+// created at timestamp, whose 32 bit creation time follows the version
+// octet of the key packet.
+func (f *fingerprinter) sum(timestamp time.Time) [sha1.Size]byte {
+	binary.BigEndian.PutUint32(f.body[1:5], uint32(timestamp.Unix()))
+	h := sha1.New()
+	h.Write(f.prefix.Bytes())
+	h.Write(f.body)
+	var sum [sha1.Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+// candidateNoun describes what is being searched given the number of each
+// type of candidate, for log and error messages.
+func candidateNoun(seeds, passphrases, userIDs, indexes, timestamps int) string {
+	var names []string
+	if seeds > 1 {
+		names = append(names, "seed")
+	}
+	if passphrases > 1 {
+		names = append(names, "passphrase")
+	}
+	if userIDs > 1 {
+		names = append(names, "user ID")
+	}
+	if indexes > 1 {
+		names = append(names, "index")
+	}
+	if timestamps > 1 {
+		names = append(names, "timestamp")
+	}
+	switch {
+	case len(names) == 0:
+		return "passphrases"
+	case len(names) == 1 && names[0] == "index":
+		return "indexes"
+	case len(names) == 1:
+		return names[0] + "s"
+	default:
+		return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1] + " combinations"
+	}
+}
+
+// entityUserID returns the user ID of a derived identity.
+func entityUserID(entity *openpgp.Entity) string {
+	for name := range entity.Identities {
+		return name
+	}
+	return ""
+}
+
+// userIDEmail returns the email address of a "Name <email>" user ID, or ""
+// if it has none. Derived identities only have the user ID string, so
+// packet.UserId's Email isn't set.
+func userIDEmail(userID string) string {
+	start, end := strings.LastIndex(userID, "<"), strings.LastIndex(userID, ">")
+	if start == -1 || end < start {
+		return ""
+	}
+	return userID[start+1 : end]
+}
+
+// mismatchError is returned when no derived key matches the expected
+// fingerprint.
+type mismatchError struct {
+	actual     string
+	expected   string
+	candidates int
+	noun       string
+
+	// entity is the mismatched identity when only one was derived
+	entity *openpgp.Entity
+}
+
+// Is makes errors.Is(err, ErrFingerprintMismatch) true for mismatch errors.
+func (e *mismatchError) Is(target error) bool {
+	return target == ErrFingerprintMismatch
+}
+
+func (e *mismatchError) Error() string {
+	format, args := e.message()
+	return fmt.Sprintf(format, args...)
+}
+
+func (e *mismatchError) message() (string, []interface{}) {
+	if e.candidates > 0 {
+		return "none of the %d candidate %s match fingerprint %s", []interface{}{e.candidates, e.noun, e.expected}
+	}
+	return "primary key fingerprint %s does not match expected fingerprint %s", []interface{}{e.actual, e.expected}
+}
+
+// passphraseTypos returns the given passphrase followed by variations of it
+// containing common typing mistakes: caps lock, a wrongly capitalised first
+// letter, stray whitespace, and single dropped, doubled or swapped characters.
+func passphraseTypos(passphrase string) []string {
+	seen := make(map[string]bool)
+	var variants []string
+	add := func(s string) {
+		if !seen[s] {
+			seen[s] = true
+			variants = append(variants, s)
+		}
+	}
+	add(passphrase)
+
+	// caps lock and first letter capitalisation
+	add(strings.Map(func(c rune) rune {
+		if unicode.IsUpper(c) {
+			return unicode.ToLower(c)
+		}
+		return unicode.ToUpper(c)
+	}, passphrase))
+	if c, size := utf8.DecodeRuneInString(passphrase); c != utf8.RuneError {
+		add(string(unicode.ToUpper(c)) + passphrase[size:])
+		add(string(unicode.ToLower(c)) + passphrase[size:])
+	}
+
+	// stray whitespace
+	add(strings.TrimSpace(passphrase))
+	add(passphrase + " ")
+	add(" " + passphrase)
+
+	// dropped, doubled and swapped characters
+	runes := []rune(passphrase)
+	for i := range runes {
+		add(string(runes[:i]) + string(runes[i+1:]))
+		add(string(runes[:i+1]) + string(runes[i:]))
+		if i+1 < len(runes) {
+			swapped := append([]rune(nil), runes...)
+			swapped[i], swapped[i+1] = swapped[i+1], swapped[i]
+			add(string(swapped))
+		}
+	}
+	return variants
+}
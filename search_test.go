@@ -0,0 +1,255 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+const aliceFingerprint = "AB86C8C7B5136D19B0A6AEC0406D7920DCAD67C3"
+
+func TestPassphraseCandidates(t *testing.T) {
+	var stderr bytes.Buffer
+	input := strings.TrimSuffix(aliceInput, "s3cr3t\n")
+	entity := recoverEntity(t, input,
+		WithStderr(&stderr),
+		WithFingerprint("ab86 c8c7 b513 6d19 b0a6  aec0 406d 7920 dcad 67c3"),
+		WithPassphraseCandidates([]string{"", "secret", "s3cr3t", "S3cr3t"}),
+	)
+	if fpr := formatFingerprint(entity.PrimaryKey); fpr != aliceFingerprint {
+		t.Fatalf("unexpected fingerprint %s", fpr)
+	}
+	if !strings.Contains(stderr.String(), "Found matching passphrase (candidate 3 of 4)\nMatching passphrase: \"s3cr3t\"") {
+		t.Fatalf("expected matching passphrase to be reported, got:\n%s", stderr.String())
+	}
+
+	// check the search fails if no candidates match
+	err := Run(
+		WithStdin(strings.NewReader(input)),
+		WithStdout(&bytes.Buffer{}),
+		WithStderr(&bytes.Buffer{}),
+		WithFingerprint(aliceFingerprint),
+		WithPassphraseCandidates([]string{"", "secret"}),
+	)
+	if err == nil || !strings.Contains(err.Error(), "none of the 2 candidate passphrases match") {
+		t.Fatalf("expected search to fail, got %v", err)
+	}
+}
+
+func TestPassphraseTypos(t *testing.T) {
+	input := strings.Replace(aliceInput, "s3cr3t", "s3c3rt", 1)
+	entity := recoverEntity(t, input,
+		WithFingerprint(aliceFingerprint),
+		WithPassphraseTypos(),
+	)
+	if fpr := formatFingerprint(entity.PrimaryKey); fpr != aliceFingerprint {
+		t.Fatalf("unexpected fingerprint %s", fpr)
+	}
+}
+
+func TestUserIDCandidates(t *testing.T) {
+	var stderr bytes.Buffer
+	input := strings.Replace(aliceInput, "Alice <alice@example.com>\n", "", 1)
+	entity := recoverEntity(t, input,
+		WithStderr(&stderr),
+		WithFingerprint(aliceFingerprint),
+		WithUserIDCandidates([]string{"Alice", "alice@example.com", "Alice <alice@example.com>"}),
+	)
+	if fpr := formatFingerprint(entity.PrimaryKey); fpr != aliceFingerprint {
+		t.Fatalf("unexpected fingerprint %s", fpr)
+	}
+	if !strings.Contains(stderr.String(), `Found matching user ID (candidate 3 of 3): "Alice <alice@example.com>"`) {
+		t.Fatalf("expected matching user ID to be reported, got:\n%s", stderr.String())
+	}
+
+	// check user IDs are searched alongside passphrase candidates
+	input = strings.TrimSuffix(input, "s3cr3t\n")
+	entity = recoverEntity(t, input,
+		WithFingerprint(aliceFingerprint),
+		WithPassphraseCandidates([]string{"", "s3cr3t"}),
+		WithUserIDCandidates([]string{"Alice <alice@example.com>", "Alice"}),
+	)
+	if fpr := formatFingerprint(entity.PrimaryKey); fpr != aliceFingerprint {
+		t.Fatalf("unexpected fingerprint %s", fpr)
+	}
+
+	// check the search fails if no candidates match
+	err := Run(
+		WithStdin(strings.NewReader(input+"s3cr3t\n")),
+		WithStdout(&bytes.Buffer{}),
+		WithStderr(&bytes.Buffer{}),
+		WithFingerprint(aliceFingerprint),
+		WithUserIDCandidates([]string{"Alice", "Bob <bob@example.com>"}),
+	)
+	if err == nil || !strings.Contains(err.Error(), "none of the 2 candidate user IDs match") {
+		t.Fatalf("expected search to fail, got %v", err)
+	}
+}
+
+func TestTimestampCandidates(t *testing.T) {
+	var stderr bytes.Buffer
+	input := strings.Replace(aliceInput, "Alice <alice@example.com>\n1523060353\n", "", 1)
+	entity := recoverEntity(t, input,
+		WithStderr(&stderr),
+		WithFingerprint(aliceFingerprint),
+		WithUserIDCandidates([]string{"Alice", "Alice <alice@example.com>"}),
+		WithTimestampCandidates([]time.Time{time.Unix(1523060352, 0), time.Unix(1523060353, 0), time.Unix(1523060354, 0)}),
+	)
+	if fpr := formatFingerprint(entity.PrimaryKey); fpr != aliceFingerprint {
+		t.Fatalf("unexpected fingerprint %s", fpr)
+	}
+	if !strings.Contains(stderr.String(), "Searching 6 candidate user ID and timestamp combinations") {
+		t.Fatalf("expected search to be logged, got:\n%s", stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "Found matching timestamp (candidate 2 of 3): 1523060353") {
+		t.Fatalf("expected matching timestamp to be reported, got:\n%s", stderr.String())
+	}
+}
+
+func TestFingerprintMismatch(t *testing.T) {
+	input := strings.Replace(aliceInput, "s3cr3t", "secret", 1)
+
+	// check a mismatch is fatal with or without strict mode, and the
+	// mismatched private key isn't output
+	for _, strict := range []bool{false, true} {
+		var stdout bytes.Buffer
+		opts := []Option{
+			WithStdin(strings.NewReader(input)),
+			WithStdout(&stdout),
+			WithStderr(&bytes.Buffer{}),
+			WithFingerprint(aliceFingerprint),
+		}
+		if strict {
+			opts = append(opts, WithStrict())
+		}
+		err := Run(opts...)
+		if !errors.Is(err, ErrFingerprintMismatch) || !strings.Contains(err.Error(), "does not match expected fingerprint") {
+			t.Fatalf("expected fingerprint mismatch (strict %t), got %v", strict, err)
+		}
+		if strings.Contains(stdout.String(), "PRIVATE KEY") {
+			t.Fatalf("expected no private key to be output (strict %t), got:\n%s", strict, stdout.String())
+		}
+	}
+}
+
+func TestSearchRequiresFingerprint(t *testing.T) {
+	err := Run(
+		WithStdin(strings.NewReader(aliceInput)),
+		WithStdout(&bytes.Buffer{}),
+		WithStderr(&bytes.Buffer{}),
+		WithPassphraseTypos(),
+	)
+	if err == nil || !strings.Contains(err.Error(), "requires an expected fingerprint") {
+		t.Fatalf("expected error, got %v", err)
+	}
+}
+
+func TestPassphraseTyposVariants(t *testing.T) {
+	variants := passphraseTypos("Ab1")
+	for _, expected := range []string{"Ab1", "aB1", "ab1", "Ab1 ", "b1", "A1", "Abb1", "bA1", "A1b"} {
+		found := false
+		for _, v := range variants {
+			if v == expected {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("missing variant %q in %q", expected, variants)
+		}
+	}
+	if variants[0] != "Ab1" {
+		t.Fatalf("expected the original passphrase first, got %q", variants[0])
+	}
+}
+
+func BenchmarkTimestampSearch(b *testing.B) {
+	seed, err := NewMnemonicSeed(bytes.Fields([]byte(demoVector.Mnemonic)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	timestamps := make([]time.Time, 1000)
+	for i := range timestamps {
+		timestamps[i] = time.Unix(demoVector.Timestamp-int64(i)-1, 0)
+	}
+	r := &Recovery{ctx: context.Background(), stderr: ioutil.Discard, fingerprint: aliceFingerprint}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.search(seed, []string{demoVector.Passphrase}, []string{demoVector.UserID}, timestamps); err == nil {
+			b.Fatal("expected no candidate to match")
+		}
+	}
+	b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*len(timestamps)), "ns/candidate")
+}
+
+func TestFingerprinter(t *testing.T) {
+	v := demoVector
+	entity, err := newEntity(v.Mnemonic, v.Passphrase, v.UserID, time.Unix(v.Timestamp, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := privateECDSAKey(entity.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := newFingerprinter(key)
+	for _, timestamp := range []int64{v.Timestamp, 0, 1, v.Timestamp + 1, math.MaxUint32} {
+		pub := packet.NewECDSAPublicKey(time.Unix(timestamp, 0), &key.PublicKey)
+		if sum := f.sum(time.Unix(timestamp, 0)); !bytes.Equal(sum[:], pub.Fingerprint[:]) {
+			t.Fatalf("unexpected fingerprint %X for timestamp %d, expected %X", sum, timestamp, pub.Fingerprint)
+		}
+	}
+}
+
+func TestIndexCandidates(t *testing.T) {
+	fingerprint := formatFingerprint(recoverEntity(t, aliceInput, WithIndex(2)).PrimaryKey)
+
+	var stderr bytes.Buffer
+	entity := recoverEntity(t, aliceInput,
+		WithStderr(&stderr),
+		WithFingerprint(fingerprint),
+		WithIndexCandidates([]uint32{0, 1, 2, 3}),
+	)
+	if fpr := formatFingerprint(entity.PrimaryKey); fpr != fingerprint {
+		t.Fatalf("unexpected fingerprint %s", fpr)
+	}
+	if !strings.Contains(stderr.String(), "Searching 4 candidate indexes") || !strings.Contains(stderr.String(), "Found matching index (candidate 3 of 4): 2") {
+		t.Fatalf("expected the matching index to be reported, got:\n%s", stderr.String())
+	}
+
+	err := Run(WithStdin(strings.NewReader(aliceInput)), WithStdout(&bytes.Buffer{}), WithIndexCandidates([]uint32{0, 1}))
+	if err == nil || !strings.Contains(err.Error(), "requires an expected fingerprint") {
+		t.Fatalf("expected a missing fingerprint error, got %v", err)
+	}
+}
+
+func TestParallelSearch(t *testing.T) {
+	timestamps := make([]time.Time, 5000)
+	for i := range timestamps {
+		timestamps[i] = time.Unix(demoVector.Timestamp-int64(len(timestamps))/2+int64(i), 0)
+	}
+	input := strings.Replace(aliceInput, "1523060353\n", "", 1)
+	for _, workers := range []int{1, 4} {
+		var stderr bytes.Buffer
+		entity := recoverEntity(t, input,
+			WithStderr(&stderr),
+			WithFingerprint(aliceFingerprint),
+			WithPassphraseCandidates([]string{"", "s3cr3t"}),
+			WithTimestampCandidates(timestamps),
+			WithSearchWorkers(workers),
+		)
+		if fpr := formatFingerprint(entity.PrimaryKey); fpr != aliceFingerprint {
+			t.Fatalf("unexpected fingerprint %s with %d workers", fpr, workers)
+		}
+		if !strings.Contains(stderr.String(), "Found matching timestamp (candidate 2501 of 5000): 1523060353") {
+			t.Fatalf("expected matching timestamp to be reported with %d workers, got:\n%s", workers, stderr.String())
+		}
+	}
+}
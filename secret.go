@@ -0,0 +1,182 @@
+package recovery
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"io"
+	"math/big"
+
+	slip10 "github.com/lmars/go-slip10"
+	"golang.org/x/crypto/openpgp"
+)
+
+// The functions in this file overwrite secrets (seed words, the BIP39 seed
+// and derived private keys) once they are no longer needed, so that they
+// don't stay in memory for the lifetime of the process. This is best effort:
+// the Go runtime may have copied them, and libraries keep intermediate
+// values we have no access to.
+
+const (
+	// secureMemorySize is the amount of locked memory used to read secrets,
+	// kept small to fit within the default RLIMIT_MEMLOCK.
+	secureMemorySize = 8192
+
+	// stdinBufferSize is the size of the buffer used to scan stdin, which
+	// limits the length of each line entered.
+	stdinBufferSize = 4096
+
+	// maxSecretFileSize is the most read from a file containing a secret,
+	// such as a mnemonic, which is far more than any secret needs.
+	maxSecretFileSize = 4096
+
+	// armoredPrivateKeySize is the capacity of buffers holding an armored
+	// private key, which is around 1KB.
+	armoredPrivateKeySize = 4096
+)
+
+// secureMemory is a region of memory for secrets which is locked into RAM
+// where supported, so that it can't be swapped to disk on machines without
+// encrypted swap. Only the secrets which are read (the seed words, the
+// passphrase and the lines scanned from stdin) are kept in it; the BIP39
+// seed and the keys derived from it are allocated on the heap by the
+// libraries which compute them, and are only wiped.
+type secureMemory struct {
+	mem    []byte
+	next   int
+	locked bool
+}
+
+// newSecureMemory allocates size bytes of locked memory, returning unlocked
+// memory along with an error if it couldn't be locked.
+func newSecureMemory(size int) (*secureMemory, error) {
+	mem, err := lockedAlloc(size)
+	if err != nil {
+		return &secureMemory{mem: make([]byte, size)}, err
+	}
+	return &secureMemory{mem: mem, locked: true}, nil
+}
+
+// alloc returns n bytes of the memory, falling back to the heap if it is
+// exhausted.
+func (m *secureMemory) alloc(n int) []byte {
+	if m.next+n > len(m.mem) {
+		return make([]byte, n)
+	}
+	b := m.mem[m.next : m.next+n : m.next+n]
+	m.next += n
+	return b
+}
+
+// copy returns a copy of b in the memory.
+func (m *secureMemory) copy(b []byte) []byte {
+	c := m.alloc(len(b))
+	copy(c, b)
+	return c
+}
+
+// join concatenates words into the memory, separated by sep.
+func (m *secureMemory) join(words [][]byte, sep byte) []byte {
+	n := 0
+	for _, word := range words {
+		n += len(word) + 1
+	}
+	if n > 0 {
+		n--
+	}
+	b := m.alloc(n)[:0]
+	for i, word := range words {
+		if i > 0 {
+			b = append(b, sep)
+		}
+		b = append(b, word...)
+	}
+	return b
+}
+
+// free wipes the memory and releases it.
+func (m *secureMemory) free() {
+	wipe(m.mem)
+	if m.locked {
+		lockedFree(m.mem)
+		m.locked = false
+	}
+	m.mem = nil
+	m.next = 0
+}
+
+// wipe overwrites the given secret with zeros.
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// readLimited reads all of r into a buffer allocated up front, failing if it
+// holds more than limit bytes, so that reading a secret doesn't leave copies
+// behind as the buffer grows. The caller should wipe the returned data.
+func readLimited(r io.Reader, limit int) ([]byte, error) {
+	buf := make([]byte, limit+1)
+	n, err := io.ReadFull(r, buf)
+	switch err {
+	case nil:
+		wipe(buf)
+		return nil, fmt.Errorf("more than %d bytes", limit)
+	case io.EOF, io.ErrUnexpectedEOF:
+		return buf[:n], nil
+	default:
+		wipe(buf)
+		return nil, err
+	}
+}
+
+// wipeWords wipes each of the given seed words.
+func wipeWords(words [][]byte) {
+	for _, word := range words {
+		wipe(word)
+	}
+}
+
+// wipeInt overwrites the given secret integer with zeros. Unlike SetInt64(0)
+// this clears the underlying memory rather than just truncating it.
+func wipeInt(n *big.Int) {
+	if n == nil {
+		return
+	}
+	bits := n.Bits()
+	for i := range bits {
+		bits[i] = 0
+	}
+	n.SetInt64(0)
+}
+
+// wipeKey wipes the private scalar of the given key.
+func wipeKey(key *ecdsa.PrivateKey) {
+	if key != nil {
+		wipeInt(key.D)
+	}
+}
+
+// wipeSlip10Key wipes the private key and chain code of a SLIP-0010 key.
+func wipeSlip10Key(key *slip10.Key) {
+	if key != nil {
+		wipe(key.Key)
+		wipe(key.ChainCode)
+	}
+}
+
+// wipeEntity wipes the private keys of the given identity.
+func wipeEntity(entity *openpgp.Entity) {
+	if entity.PrivateKey != nil {
+		if key, ok := entity.PrivateKey.PrivateKey.(*ecdsa.PrivateKey); ok {
+			wipeKey(key)
+		}
+	}
+	for _, subkey := range entity.Subkeys {
+		if subkey.PrivateKey == nil {
+			continue
+		}
+		if key, ok := subkey.PrivateKey.PrivateKey.(*ecdsa.PrivateKey); ok {
+			wipeKey(key)
+		}
+	}
+}
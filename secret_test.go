@@ -0,0 +1,89 @@
+package recovery
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWipeEntity(t *testing.T) {
+	v := testVectors[0]
+	entity, err := newEntity(v.Mnemonic, v.Passphrase, v.UserID, time.Unix(v.Timestamp, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	primary := entity.PrivateKey.PrivateKey.(*ecdsa.PrivateKey)
+	subkey := entity.Subkeys[0].PrivateKey.PrivateKey.(*ecdsa.PrivateKey)
+	primaryBits, subkeyBits := primary.D.Bits(), subkey.D.Bits()
+
+	wipeEntity(entity)
+	for _, bits := range [][]big.Word{primaryBits, subkeyBits} {
+		for _, word := range bits {
+			if word != 0 {
+				t.Fatalf("expected private key to be wiped, got %x", bits)
+			}
+		}
+	}
+	if primary.D.Sign() != 0 || subkey.D.Sign() != 0 {
+		t.Fatal("expected private keys to be zero")
+	}
+}
+
+func TestSecureMemory(t *testing.T) {
+	mem, err := newSecureMemory(64)
+	if err != nil {
+		t.Logf("could not lock memory: %s", err)
+	}
+	words := [][]byte{mem.copy([]byte("all")), mem.copy([]byte("zoo"))}
+	mnemonic := mem.join(words, ' ')
+	if string(mnemonic) != "all zoo" {
+		t.Fatalf("unexpected mnemonic %q", mnemonic)
+	}
+
+	// check allocations outside the memory still work
+	if b := mem.alloc(100); len(b) != 100 {
+		t.Fatalf("expected 100 bytes, got %d", len(b))
+	}
+
+	mem.free()
+
+	// check unlocked memory is wiped when freed
+	mem = &secureMemory{mem: make([]byte, 8)}
+	word := mem.copy([]byte("all"))
+	mem.free()
+	if string(word) != "\x00\x00\x00" {
+		t.Fatalf("expected memory to be wiped, got %q", word)
+	}
+}
+
+func TestReadLimited(t *testing.T) {
+	data, err := readLimited(strings.NewReader("all all all"), 11)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "all all all" {
+		t.Fatalf("unexpected data %q", data)
+	}
+	if _, err := readLimited(strings.NewReader("all all all"), 10); err == nil || err.Error() != "more than 10 bytes" {
+		t.Fatalf("expected an error for data over the limit, got %v", err)
+	}
+}
+
+func TestSerializePrivate(t *testing.T) {
+	v := testVectors[0]
+	entity, err := newEntity(v.Mnemonic, v.Passphrase, v.UserID, time.Unix(v.Timestamp, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	armored, err := serializePrivate(entity, map[string]string{"Comment": demoComment})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// check the buffer never grew, leaving copies of the key behind
+	if cap(armored) != armoredPrivateKeySize {
+		t.Fatalf("expected the armored key to fit in %d bytes, got %d", armoredPrivateKeySize, len(armored))
+	}
+}
@@ -0,0 +1,184 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// SeedProvider reads the recovery seed the identity is derived from, so that
+// where the seed comes from (e.g. a file, SLIP-39 shares or a vault) is
+// decoupled from deriving the identity. By default the recovery prompts for
+// the BIP39 mnemonic one word at a time.
+type SeedProvider interface {
+	// ReadSeed reads the seed, prompting with p if needed. Secrets read with
+	// p are copied into locked memory, which is wiped once the recovery is
+	// done.
+	ReadSeed(ctx context.Context, p Prompter) (Seed, error)
+}
+
+// Seed is a recovery seed read by a SeedProvider.
+type Seed interface {
+	// MasterSeed returns the seed which the SLIP-0010 master key is
+	// generated from for the given passphrase (the 64 byte BIP39 seed for
+	// a mnemonic), which the caller should wipe.
+	MasterSeed(passphrase string) ([]byte, error)
+
+	// Wipe wipes the seed.
+	Wipe()
+}
+
+// WithSeedProvider configures the recovery to read the seed from p rather
+// than prompting for the BIP39 mnemonic.
+func WithSeedProvider(p SeedProvider) Option {
+	return func(r *Recovery) {
+		r.seedProvider = p
+	}
+}
+
+// mnemonicSeed is a BIP39 mnemonic, with words separated by spaces.
+type mnemonicSeed struct {
+	mnemonic []byte
+}
+
+// NewMnemonicSeed returns the Seed for a BIP39 mnemonic, checking its
+// checksum. The words are copied so the caller may wipe them once this
+// returns.
+func NewMnemonicSeed(words [][]byte) (Seed, error) {
+	r := &Recovery{stderr: ioutil.Discard}
+	if err := r.checkMnemonic(words); err != nil {
+		return nil, err
+	}
+	return &mnemonicSeed{mnemonic: bytes.Join(words, []byte(" "))}, nil
+}
+
+// MasterSeed generates the seed as described in BIP39 (rather than using
+// go-bip39 which needs the mnemonic as a string we can't wipe).
+func (s *mnemonicSeed) MasterSeed(passphrase string) ([]byte, error) {
+	salt := append([]byte("mnemonic"), passphrase...)
+	return pbkdf2.Key(s.mnemonic, salt, 2048, 64, sha512.New), nil
+}
+
+func (s *mnemonicSeed) Wipe() {
+	wipe(s.mnemonic)
+}
+
+// MnemonicFile returns a SeedProvider which reads the BIP39 mnemonic from the
+// file at path, with words separated by whitespace.
+func MnemonicFile(path string) SeedProvider {
+	return mnemonicFile(path)
+}
+
+type mnemonicFile string
+
+func (path mnemonicFile) ReadSeed(ctx context.Context, p Prompter) (Seed, error) {
+	f, err := os.Open(string(path))
+	if err != nil {
+		return nil, fmt.Errorf("could not read the recovery seed: %s", err)
+	}
+	defer f.Close()
+	data, err := readLimited(f, maxSecretFileSize)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the recovery seed: %s", err)
+	}
+	defer wipe(data)
+	words := bytes.Fields(data)
+	for i, word := range words {
+		words[i] = normalizeWord(word)
+	}
+	return NewMnemonicSeed(words)
+}
+
+// HexEntropy returns a SeedProvider which prompts for the entropy of the
+// BIP39 mnemonic in hex (16, 24 or 32 bytes), as shown by some wallets and
+// backup tools.
+func HexEntropy() SeedProvider {
+	return hexEntropy{}
+}
+
+type hexEntropy struct{}
+
+func (hexEntropy) ReadSeed(ctx context.Context, p Prompter) (Seed, error) {
+	secret, err := p.ReadSecret(ctx, "Please enter the recovery seed entropy in hex:")
+	if err != nil {
+		return nil, err
+	}
+	defer wipe(secret)
+	secret = bytes.TrimSpace(secret)
+	entropy := make([]byte, hex.DecodedLen(len(secret)))
+	defer wipe(entropy)
+	if _, err := hex.Decode(entropy, secret); err != nil {
+		return nil, fmt.Errorf("%w: the entropy is not valid hex", ErrInvalidMnemonic)
+	}
+	if n := len(entropy); n != 16 && n != 24 && n != 32 {
+		return nil, fmt.Errorf("%w: invalid entropy length %d bytes, must be 16, 24 or 32", ErrInvalidMnemonic, n)
+	}
+	return &mnemonicSeed{mnemonic: englishWordlist.mnemonic(entropy)}, nil
+}
+
+// mnemonic returns the space separated words encoding the given entropy along
+// with its checksum as described in BIP39.
+func (l *wordlist) mnemonic(entropy []byte) []byte {
+	// append the checksum bits to the entropy
+	checksumBits := len(entropy) / 4
+	hash := sha256.Sum256(entropy)
+	buf := make([]byte, len(entropy)+1)
+	defer wipe(buf)
+	copy(buf, entropy)
+	buf[len(entropy)] = hash[0] & ^byte(0xff>>uint(checksumBits))
+
+	// split into 11 bit word indexes
+	numWords := (len(entropy)*8 + checksumBits) / 11
+	size := numWords - 1
+	for i := 0; i < numWords; i++ {
+		size += len(l.words[wordIndex(buf, i)])
+	}
+	mnemonic := make([]byte, 0, size)
+	for i := 0; i < numWords; i++ {
+		if i > 0 {
+			mnemonic = append(mnemonic, ' ')
+		}
+		mnemonic = append(mnemonic, l.words[wordIndex(buf, i)]...)
+	}
+	return mnemonic
+}
+
+// wordIndex returns the i'th 11 bit word index in buf.
+func wordIndex(buf []byte, i int) int {
+	index := 0
+	for b := 0; b < 11; b++ {
+		pos := i*11 + b
+		index <<= 1
+		if buf[pos/8]&(0x80>>uint(pos%8)) != 0 {
+			index |= 1
+		}
+	}
+	return index
+}
+
+// providerPrompter is passed to seed providers, copying the secrets they read
+// into locked memory.
+type providerPrompter struct {
+	r *Recovery
+}
+
+func (p providerPrompter) ReadLine(ctx context.Context, prompt string) (string, error) {
+	return p.r.prompter.ReadLine(ctx, prompt)
+}
+
+func (p providerPrompter) ReadSecret(ctx context.Context, prompt string) ([]byte, error) {
+	secret, err := p.r.prompter.ReadSecret(ctx, prompt)
+	defer wipe(secret)
+	return p.r.mem.copy(secret), err
+}
+
+func (p providerPrompter) Confirm(ctx context.Context, prompt string) (bool, error) {
+	return confirmWithHelp(ctx, p.r.prompter, prompt, func() { p.r.showHelp(defaultConfirmHelp) })
+}
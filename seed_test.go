@@ -0,0 +1,117 @@
+package recovery
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// aliceAnswers is the stdin which recovers the test identity when the seed
+// comes from a SeedProvider.
+const aliceAnswers = "yes\nAlice <alice@example.com>\n1523060353\n"
+
+func TestWordlistMnemonic(t *testing.T) {
+	// test vectors from BIP39
+	for _, v := range []struct {
+		entropy  string
+		mnemonic string
+	}{
+		{"00000000000000000000000000000000", "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"},
+		{"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f", "legal winner thank year wave sausage worth useful legal winner thank yellow"},
+		{"808080808080808080808080808080808080808080808080", "letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic avoid letter always"},
+		{"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo vote"},
+	} {
+		entropy, _ := hex.DecodeString(v.entropy)
+		if mnemonic := string(englishWordlist.mnemonic(entropy)); mnemonic != v.mnemonic {
+			t.Fatalf("unexpected mnemonic for %s:\nexpected: %s\ngot:      %s", v.entropy, v.mnemonic, mnemonic)
+		}
+	}
+}
+
+func TestHexEntropy(t *testing.T) {
+	entropy, err := englishWordlist.entropy(bytes.Fields([]byte(demoVector.Mnemonic)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := aliceAnswers + hex.EncodeToString(entropy) + "\ns3cr3t\n"
+	entity := recoverEntity(t, input, WithSeedProvider(HexEntropy()))
+	if fingerprint := formatFingerprint(entity.PrimaryKey); fingerprint != "AB86C8C7B5136D19B0A6AEC0406D7920DCAD67C3" {
+		t.Fatalf("unexpected fingerprint %s", fingerprint)
+	}
+
+	// check invalid entropy is rejected
+	for _, invalid := range []string{"zz", "0000"} {
+		err := Run(
+			WithStdin(strings.NewReader(aliceAnswers+invalid+"\n")),
+			WithStdout(&bytes.Buffer{}),
+			WithStderr(&bytes.Buffer{}),
+			WithSeedProvider(HexEntropy()),
+		)
+		if !errors.Is(err, ErrInvalidMnemonic) {
+			t.Fatalf("expected ErrInvalidMnemonic for %q, got %v", invalid, err)
+		}
+	}
+}
+
+func TestMnemonicFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trezor-gpg-recovery-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "seed.txt")
+	if err := ioutil.WriteFile(path, []byte(strings.Replace(demoVector.Mnemonic, " ", "\n", -1)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	entity := recoverEntity(t, aliceAnswers+"s3cr3t\n", WithSeedProvider(MnemonicFile(path)))
+	if fingerprint := formatFingerprint(entity.PrimaryKey); fingerprint != "AB86C8C7B5136D19B0A6AEC0406D7920DCAD67C3" {
+		t.Fatalf("unexpected fingerprint %s", fingerprint)
+	}
+
+	// check a file with an invalid seed is rejected
+	if err := ioutil.WriteFile(path, []byte("all all all"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	err = Run(
+		WithStdin(strings.NewReader(aliceAnswers)),
+		WithStdout(&bytes.Buffer{}),
+		WithStderr(&bytes.Buffer{}),
+		WithSeedProvider(MnemonicFile(path)),
+	)
+	if !errors.Is(err, ErrInvalidMnemonic) {
+		t.Fatalf("expected ErrInvalidMnemonic, got %v", err)
+	}
+
+	// check an oversized file is rejected without reading it all
+	if err := ioutil.WriteFile(path, bytes.Repeat([]byte("all "), maxSecretFileSize), 0600); err != nil {
+		t.Fatal(err)
+	}
+	err = Run(
+		WithStdin(strings.NewReader(aliceAnswers)),
+		WithStdout(&bytes.Buffer{}),
+		WithStderr(&bytes.Buffer{}),
+		WithSeedProvider(MnemonicFile(path)),
+	)
+	if err == nil || !strings.Contains(err.Error(), "could not read the recovery seed: more than 4096 bytes") {
+		t.Fatalf("expected an error for the oversized file, got %v", err)
+	}
+}
+
+func BenchmarkMasterSeed(b *testing.B) {
+	seed, err := NewMnemonicSeed(bytes.Fields([]byte(demoVector.Mnemonic)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < b.N; i++ {
+		masterSeed, err := seed.MasterSeed(demoVector.Passphrase)
+		if err != nil {
+			b.Fatal(err)
+		}
+		wipe(masterSeed)
+	}
+}
@@ -0,0 +1,132 @@
+package recovery
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/lmars/trezor-gpg-recovery/derive"
+)
+
+// SelfTest derives the identity for each of the built-in test vectors and
+// checks the resulting fingerprints, writing a pass/fail line for each vector
+// to w. It returns an error if any vector fails.
+func SelfTest(w io.Writer) error {
+	failed := 0
+	for _, v := range testVectors {
+		if err := v.check(); err != nil {
+			fmt.Fprintf(w, "FAIL  %s: %s\n", v.Name, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(w, "PASS  %s\n", v.Name)
+	}
+	if failed > 0 {
+		return fmt.Errorf("self-test failed: %d of %d test vectors failed", failed, len(testVectors))
+	}
+	fmt.Fprintf(w, "self-test passed: %d test vectors\n", len(testVectors))
+	return nil
+}
+
+func (v *testVector) check() error {
+	entity, err := newEntity(v.Mnemonic, v.Passphrase, v.UserID, time.Unix(v.Timestamp, 0))
+	if err != nil {
+		return err
+	}
+	if fpr := formatFingerprint(entity.PrimaryKey); fpr != v.PrimaryFingerprint {
+		return fmt.Errorf("wrong primary key fingerprint %s, expected %s", fpr, v.PrimaryFingerprint)
+	}
+	if fpr := formatFingerprint(entity.Subkeys[0].PublicKey); fpr != v.SubkeyFingerprint {
+		return fmt.Errorf("wrong subkey fingerprint %s, expected %s", fpr, v.SubkeyFingerprint)
+	}
+	return checkEncryption(entity)
+}
+
+// CheckVectors recomputes each layer of the derivation from the embedded
+// published test vectors (the BIP39 seeds, SLIP-0010 NIST P-256 keys and
+// SLIP-0013 paths it is built on, then the GPG identities), writing
+// a pass/fail line for each vector to w. It returns an error if any vector
+// fails.
+func CheckVectors(w io.Writer) error {
+	total, failed := 0, 0
+	report := func(name string, err error) {
+		total++
+		if err != nil {
+			fmt.Fprintf(w, "FAIL  %s: %s\n", name, err)
+			failed++
+			return
+		}
+		fmt.Fprintf(w, "PASS  %s\n", name)
+	}
+	fmt.Fprintln(w, "BIP39 seeds:")
+	for _, v := range bip39Vectors {
+		report(fmt.Sprintf("%d words, passphrase %q", len(strings.Fields(v.Mnemonic)), v.Passphrase), v.check())
+	}
+	fmt.Fprintln(w, "SLIP-0010 nist256p1 keys:")
+	for _, v := range slip10Vectors {
+		report(v.Name, v.check())
+	}
+	fmt.Fprintln(w, "SLIP-0013 paths:")
+	for _, v := range slip13Vectors {
+		report(fmt.Sprintf("%s, index %d", v.URI, v.Index), v.check())
+	}
+	fmt.Fprintln(w, "GPG identities:")
+	for _, v := range testVectors {
+		report(v.Name, v.check())
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d test vectors failed", failed, total)
+	}
+	fmt.Fprintf(w, "all %d test vectors passed\n", total)
+	return nil
+}
+
+func (v *bip39Vector) check() error {
+	seed, err := NewMnemonicSeed(bytes.Fields([]byte(v.Mnemonic)))
+	if err != nil {
+		return err
+	}
+	defer seed.Wipe()
+	masterSeed, err := seed.MasterSeed(v.Passphrase)
+	if err != nil {
+		return err
+	}
+	defer wipe(masterSeed)
+	if s := hex.EncodeToString(masterSeed); s != v.Seed {
+		return fmt.Errorf("wrong seed %s, expected %s", s, v.Seed)
+	}
+	return nil
+}
+
+func (v *slip10Vector) check() error {
+	seed, err := hex.DecodeString(v.Seed)
+	if err != nil {
+		return err
+	}
+	key, err := derive.MasterKey(seed)
+	if err != nil {
+		return err
+	}
+	for _, child := range v.Path {
+		if key, err = key.NewChildKey(child); err != nil {
+			return err
+		}
+	}
+	if k := hex.EncodeToString(key.Key); k != v.Key {
+		return fmt.Errorf("wrong private key %s, expected %s", k, v.Key)
+	}
+	if c := hex.EncodeToString(key.ChainCode); c != v.ChainCode {
+		return fmt.Errorf("wrong chain code %s, expected %s", c, v.ChainCode)
+	}
+	return nil
+}
+
+func (v *slip13Vector) check() error {
+	if path := derive.Path(derive.PrimaryPurpose, v.URI, v.Index); path != v.Path {
+		return fmt.Errorf("wrong path %s, expected %s", path, v.Path)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package recovery
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha512"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lmars/trezor-gpg-recovery/derive"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+func TestSelfTest(t *testing.T) {
+	var out bytes.Buffer
+	if err := SelfTest(&out); err != nil {
+		t.Fatalf("%s\n%s", err, out.String())
+	}
+	if n := strings.Count(out.String(), "PASS"); n != len(testVectors) {
+		t.Fatalf("expected %d passing vectors, got %d:\n%s", len(testVectors), n, out.String())
+	}
+}
+
+func TestSelfTestFailure(t *testing.T) {
+	defer func(vectors []testVector) { testVectors = vectors }(testVectors)
+	v := testVectors[0]
+	v.UserID = "alice <alice@example.com>"
+	testVectors = []testVector{v}
+
+	var out bytes.Buffer
+	if err := SelfTest(&out); err == nil {
+		t.Fatal("expected self-test to fail")
+	}
+	if !strings.HasPrefix(out.String(), "FAIL") {
+		t.Fatalf("expected FAIL output, got:\n%s", out.String())
+	}
+}
+
+func TestCheckVectors(t *testing.T) {
+	var out bytes.Buffer
+	if err := CheckVectors(&out); err != nil {
+		t.Fatalf("%s\n%s", err, out.String())
+	}
+	total := len(bip39Vectors) + len(slip10Vectors) + len(slip13Vectors) + len(testVectors)
+	if n := strings.Count(out.String(), "PASS"); n != total {
+		t.Fatalf("expected %d passing vectors, got %d:\n%s", total, n, out.String())
+	}
+
+	defer func(vectors []slip13Vector) { slip13Vectors = vectors }(slip13Vectors)
+	slip13Vectors = []slip13Vector{{URI: "https://satoshi@bitcoin.org/login", Index: 1, Path: slip13Vectors[0].Path}}
+	out.Reset()
+	if err := CheckVectors(&out); err == nil || !strings.Contains(out.String(), "FAIL  https://satoshi@bitcoin.org/login, index 1") {
+		t.Fatalf("expected the changed vector to fail, got %v:\n%s", err, out.String())
+	}
+}
+
+// TestVectorsRecover recovers an identity from each of the BIP39 vectors'
+// mnemonics with and without a passphrase, checking the keys are those
+// derived from the published seeds, and that only nist256p1 is accepted.
+func TestVectorsRecover(t *testing.T) {
+	const uri = "gpg://Alice <alice@example.com>"
+	for _, v := range bip39Vectors {
+		for _, passphrase := range []string{v.Passphrase, ""} {
+			var seed []byte
+			if passphrase == v.Passphrase {
+				seed, _ = hex.DecodeString(v.Seed)
+			} else {
+				seed = pbkdf2.Key([]byte(v.Mnemonic), []byte("mnemonic"), 2048, 64, sha512.New)
+			}
+			primaryKey, subkey, err := derive.PublicKeys(seed, uri, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, curve := range []string{CurveNIST256P1, "ed25519", "secp256k1"} {
+				entity, err := Recover(Params{
+					Mnemonic:   v.Mnemonic,
+					Passphrase: passphrase,
+					UserID:     "Alice <alice@example.com>",
+					Timestamp:  time.Unix(1523060353, 0),
+					Curve:      curve,
+				})
+				if curve != CurveNIST256P1 {
+					if err == nil || !strings.Contains(err.Error(), "unsupported curve") {
+						t.Fatalf("expected an unsupported curve error for %s, got %v", curve, err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				words := len(strings.Fields(v.Mnemonic))
+				if key := entity.PrimaryKey.PublicKey.(*ecdsa.PublicKey); !key.Equal(primaryKey) {
+					t.Fatalf("wrong primary key for %d words with passphrase %q", words, passphrase)
+				}
+				if key := entity.Subkeys[0].PublicKey.PublicKey.(*ecdsa.PublicKey); !key.Equal(subkey) {
+					t.Fatalf("wrong subkey for %d words with passphrase %q", words, passphrase)
+				}
+			}
+		}
+	}
+}
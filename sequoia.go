@@ -0,0 +1,198 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// WithSequoiaCheck configures the emitted certificate to be checked against
+// the stricter rules Sequoia-PGP applies when canonicalizing certificates,
+// also running it through 'sq inspect' if sq is installed.
+func WithSequoiaCheck() Option {
+	return func(r *Recovery) {
+		r.sequoiaCheck = true
+	}
+}
+
+// checkSequoia checks the certificate emitted for entity against the rules
+// Sequoia-PGP applies when canonicalizing certificates (which are stricter
+// than GnuPG's, so a certificate gpg accepts may have components silently
+// dropped by sq), writing the result of each check to w. Running sq is
+// stopped if ctx is cancelled.
+func checkSequoia(ctx context.Context, entity *openpgp.Entity, w io.Writer) error {
+	c := &checklist{w: w}
+
+	// check the certificate as it is emitted rather than as it was built
+	var cert *openpgp.Entity
+	defer func() {
+		if cert != nil {
+			wipeEntity(cert)
+		}
+	}()
+	c.step("certificate parses", func() error {
+		var buf bytes.Buffer
+		defer func() { wipe(buf.Bytes()) }()
+		if err := entity.SerializePrivate(&buf, nil); err != nil {
+			return err
+		}
+		var err error
+		cert, err = openpgp.ReadEntity(packet.NewReader(bytes.NewReader(buf.Bytes())))
+		return err
+	})
+
+	c.step("user ID self-signatures verify", func() error {
+		if len(cert.Identities) == 0 {
+			return errors.New("no user IDs")
+		}
+		for name, id := range cert.Identities {
+			if err := cert.PrimaryKey.VerifyUserIdSignature(name, cert.PrimaryKey, id.SelfSignature); err != nil {
+				return fmt.Errorf("%q: %s", name, err)
+			}
+		}
+		return nil
+	})
+	c.step("subkey binding signatures verify", func() error {
+		if len(cert.Subkeys) == 0 {
+			return errors.New("no subkeys")
+		}
+		for _, subkey := range cert.Subkeys {
+			if err := cert.PrimaryKey.VerifyKeySignature(subkey.PublicKey, subkey.Sig); err != nil {
+				return fmt.Errorf("subkey %s: %s", formatKeyID(subkey.PublicKey), err)
+			}
+		}
+		return nil
+	})
+	c.step("signatures use hash algorithms accepted by Sequoia's standard policy", func() error {
+		for _, sig := range certSignatures(cert) {
+			switch sig.Hash {
+			case crypto.MD5, crypto.SHA1, crypto.RIPEMD160:
+				return fmt.Errorf("signature uses weak hash algorithm %s", sig.Hash)
+			}
+		}
+		return nil
+	})
+	c.step("signatures are not older than the keys they bind", func() error {
+		for _, sig := range certSignatures(cert) {
+			if sig.CreationTime.Before(cert.PrimaryKey.CreationTime) {
+				return fmt.Errorf("signature created at %d before primary key created at %d", sig.CreationTime.Unix(), cert.PrimaryKey.CreationTime.Unix())
+			}
+		}
+		for _, subkey := range cert.Subkeys {
+			if subkey.Sig.CreationTime.Before(subkey.PublicKey.CreationTime) {
+				return fmt.Errorf("binding signature created at %d before subkey created at %d", subkey.Sig.CreationTime.Unix(), subkey.PublicKey.CreationTime.Unix())
+			}
+		}
+		return nil
+	})
+	c.step("key flags are valid for each key's algorithm", func() error {
+		for name, id := range cert.Identities {
+			sig := id.SelfSignature
+			if !sig.FlagsValid || !sig.FlagCertify {
+				return fmt.Errorf("self-signature for %q does not mark the primary key as certification capable", name)
+			}
+		}
+		for _, subkey := range cert.Subkeys {
+			sig := subkey.Sig
+			if !sig.FlagsValid {
+				return fmt.Errorf("subkey %s has no key flags", formatKeyID(subkey.PublicKey))
+			}
+			if (sig.FlagEncryptCommunications || sig.FlagEncryptStorage) && !canEncrypt(subkey.PublicKey.PubKeyAlgo) {
+				return fmt.Errorf("subkey %s is marked for encryption but cannot encrypt", formatKeyID(subkey.PublicKey))
+			}
+			if (sig.FlagSign || sig.FlagCertify) && !canSign(subkey.PublicKey.PubKeyAlgo) {
+				return fmt.Errorf("subkey %s is marked for signing but cannot sign", formatKeyID(subkey.PublicKey))
+			}
+		}
+		return nil
+	})
+	c.step("ECDH KDF parameters are acceptable", func() error {
+		for _, subkey := range cert.Subkeys {
+			if subkey.PublicKey.PubKeyAlgo != packet.PubKeyAlgoECDH {
+				continue
+			}
+			params, err := readECDHParams(subkey.PublicKey)
+			if err != nil {
+				return err
+			}
+			if params.kdfHash.Size() < crypto.SHA256.Size() {
+				return fmt.Errorf("KDF hash %s is too weak", params.kdfHash)
+			}
+		}
+		return nil
+	})
+
+	// also run the certificate through sq if it is installed
+	if c.err == nil {
+		if path, err := exec.LookPath("sq"); err == nil {
+			c.step("sq inspect accepts the certificate", func() error {
+				return sqInspect(ctx, path, cert)
+			})
+		} else {
+			fmt.Fprintln(w, "  SKIP  sq inspect (sq not found in PATH)")
+		}
+	}
+	return c.err
+}
+
+// canEncrypt and canSign report the capabilities of public key algorithms,
+// including the elliptic curve ones which packet.PublicKeyAlgorithm's methods
+// don't know about.
+func canEncrypt(algo packet.PublicKeyAlgorithm) bool {
+	return algo == packet.PubKeyAlgoECDH || algo.CanEncrypt()
+}
+
+func canSign(algo packet.PublicKeyAlgorithm) bool {
+	return algo == packet.PubKeyAlgoECDSA || algo.CanSign()
+}
+
+// certSignatures returns the self-signatures of cert.
+func certSignatures(cert *openpgp.Entity) []*packet.Signature {
+	var sigs []*packet.Signature
+	for _, id := range cert.Identities {
+		sigs = append(sigs, id.SelfSignature)
+	}
+	for _, subkey := range cert.Subkeys {
+		sigs = append(sigs, subkey.Sig)
+	}
+	return sigs
+}
+
+// sqInspect runs 'sq inspect' on the public certificate, checking that sq
+// considers every one of its keys valid.
+func sqInspect(ctx context.Context, sq string, cert *openpgp.Entity) error {
+	f, err := ioutil.TempFile("", "trezor-gpg-recovery-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if err := cert.Serialize(f); err != nil {
+		return err
+	}
+	out, err := exec.CommandContext(ctx, sq, "inspect", f.Name()).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %s", err, strings.TrimSpace(string(out)))
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.Contains(line, "Invalid:") {
+			return fmt.Errorf("sq reports %q", strings.TrimSpace(line))
+		}
+	}
+	for _, key := range append([]*packet.PublicKey{cert.PrimaryKey}, cert.Subkeys[0].PublicKey) {
+		if !strings.Contains(string(out), formatFingerprint(key)) {
+			return fmt.Errorf("sq does not list key %s", formatFingerprint(key))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSequoiaCheck(t *testing.T) {
+	v := testVectors[0]
+	entity, err := newEntity(v.Mnemonic, v.Passphrase, v.UserID, time.Unix(v.Timestamp, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := checkSequoia(context.Background(), entity, &out); err != nil {
+		t.Fatalf("%s\n%s", err, out.String())
+	}
+
+	// check a SHA-1 binding signature is rejected
+	entity.Subkeys[0].Sig.Hash = crypto.SHA1
+	out.Reset()
+	err = checkSequoia(context.Background(), entity, &out)
+	if err == nil || !strings.Contains(err.Error(), "weak hash algorithm SHA-1") {
+		t.Fatalf("expected weak hash error, got %v\n%s", err, out.String())
+	}
+}
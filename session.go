@@ -0,0 +1,205 @@
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// Step is a step of a recovery Session.
+type Step int
+
+const (
+	NeedsConfirmation Step = iota
+	NeedsUserID
+	NeedsTimestamp
+	NeedsWords
+	NeedsPassphrase
+	Done
+)
+
+func (s Step) String() string {
+	switch s {
+	case NeedsConfirmation:
+		return "NeedsConfirmation"
+	case NeedsUserID:
+		return "NeedsUserID"
+	case NeedsTimestamp:
+		return "NeedsTimestamp"
+	case NeedsWords:
+		return "NeedsWords"
+	case NeedsPassphrase:
+		return "NeedsPassphrase"
+	case Done:
+		return "Done"
+	default:
+		return fmt.Sprintf("Step(%d)", int(s))
+	}
+}
+
+// Session is a recovery driven one step at a time, so that interactive
+// frontends can render their own UI for each step and feed the answers back:
+//
+//	NeedsConfirmation → NeedsUserID → NeedsTimestamp → NeedsWords →
+//	NeedsPassphrase → Done
+//
+// Each answer is validated as it is given; an invalid answer returns an
+// error and leaves the session at the same step so it can be retried. The
+// session should be closed once done with to wipe the secrets it holds.
+type Session struct {
+	r         *Recovery
+	step      Step
+	userID    string
+	timestamp time.Time
+	words     [][]byte
+	entity    *openpgp.Entity
+}
+
+// NewSession starts a recovery session. Options which affect validation and
+// the derived identity (e.g. WithStrict and WithFingerprint) are applied,
+// while those which affect prompting and output are ignored.
+func NewSession(opts ...Option) (*Session, error) {
+	r := &Recovery{
+		ctx:    context.Background(),
+		stderr: ioutil.Discard,
+		report: &report{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.report.Started = r.now()
+	if err := r.keyParams().check(); err != nil {
+		return nil, err
+	}
+	mem, memErr := newSecureMemory(secureMemorySize)
+	r.mem = mem
+	if memErr != nil {
+		if err := r.warn("could not lock memory, secrets may be swapped to disk: %s", memErr); err != nil {
+			mem.free()
+			return nil, err
+		}
+	}
+	return &Session{r: r}, nil
+}
+
+// Step returns the current step of the session.
+func (s *Session) Step() Step {
+	return s.step
+}
+
+// Warnings returns the validation warnings given so far.
+func (s *Session) Warnings() []string {
+	return s.r.report.Warnings
+}
+
+func (s *Session) expect(step Step) error {
+	if s.step != step {
+		return fmt.Errorf("recovery session is at step %s, not %s", s.step, step)
+	}
+	return nil
+}
+
+// Confirm answers whether the user wants to continue with the recovery.
+func (s *Session) Confirm(yes bool) error {
+	if err := s.expect(NeedsConfirmation); err != nil {
+		return err
+	}
+	if !yes {
+		return ErrAborted
+	}
+	s.step = NeedsUserID
+	return nil
+}
+
+// SetUserID sets the GPG user ID.
+func (s *Session) SetUserID(userID string) error {
+	if err := s.expect(NeedsUserID); err != nil {
+		return err
+	}
+	if err := s.r.checkUserID(userID); err != nil {
+		return err
+	}
+	s.userID = userID
+	s.step = NeedsTimestamp
+	return nil
+}
+
+// SetTimestamp sets the timestamp from the original 'trezor-gpg init'.
+func (s *Session) SetTimestamp(timestamp time.Time) error {
+	if err := s.expect(NeedsTimestamp); err != nil {
+		return err
+	}
+	if err := s.r.checkTimestamp(timestamp); err != nil {
+		return err
+	}
+	s.timestamp = timestamp
+	s.step = NeedsWords
+	return nil
+}
+
+// SetWords sets the 12, 18 or 24 words of the recovery seed. The words are
+// copied into locked memory, so the caller should wipe its copy once this
+// returns.
+func (s *Session) SetWords(words [][]byte) error {
+	if err := s.expect(NeedsWords); err != nil {
+		return err
+	}
+	if n := len(words); n != 12 && n != 18 && n != 24 {
+		return fmt.Errorf("%w: invalid seed length %d, must be 12, 18 or 24", ErrInvalidMnemonic, n)
+	}
+	if err := s.r.checkMnemonic(words); err != nil {
+		return err
+	}
+	s.words = make([][]byte, len(words))
+	for i, word := range words {
+		s.words[i] = s.r.mem.copy(word)
+	}
+	s.step = NeedsPassphrase
+	return nil
+}
+
+// SetPassphrase sets the passphrase (empty if none is used) and derives the
+// identity, moving the session to Done.
+func (s *Session) SetPassphrase(passphrase string) error {
+	if err := s.expect(NeedsPassphrase); err != nil {
+		return err
+	}
+	seed := &mnemonicSeed{mnemonic: s.r.mem.join(s.words, ' ')}
+	defer seed.Wipe()
+	entity, err := s.r.search(seed, []string{passphrase}, []string{s.userID}, []time.Time{s.timestamp})
+	if mismatch, ok := err.(*mismatchError); ok && mismatch.entity != nil {
+		wipeEntity(mismatch.entity)
+		return mismatch
+	} else if err != nil {
+		return err
+	}
+	if err := checkEncryption(entity); err != nil {
+		wipeEntity(entity)
+		return fmt.Errorf("encryption self-test failed: %s", err)
+	}
+	s.entity = entity
+	s.step = Done
+	return nil
+}
+
+// Entity returns the recovered identity (including its private keys) once
+// the session is Done, which remains valid until the session is closed.
+func (s *Session) Entity() (*openpgp.Entity, error) {
+	if err := s.expect(Done); err != nil {
+		return nil, err
+	}
+	return s.entity, nil
+}
+
+// Close wipes the secrets held by the session, including the private keys of
+// the recovered identity.
+func (s *Session) Close() {
+	if s.entity != nil {
+		wipeEntity(s.entity)
+	}
+	wipeWords(s.words)
+	s.r.mem.free()
+}
@@ -0,0 +1,85 @@
+package recovery
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSession(t *testing.T) {
+	s, err := NewSession(WithFingerprint(demoVector.PrimaryFingerprint))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// check answers are only accepted at their step
+	if err := s.SetUserID(demoVector.UserID); err == nil || !strings.Contains(err.Error(), "at step NeedsConfirmation") {
+		t.Fatalf("expected an error giving the user ID early, got %v", err)
+	}
+	if err := s.Confirm(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetUserID(demoVector.UserID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetTimestamp(time.Unix(demoVector.Timestamp, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if s.Step() != NeedsWords {
+		t.Fatalf("expected step NeedsWords, got %s", s.Step())
+	}
+
+	// check invalid words can be retried
+	words := make([][]byte, 12)
+	for i := range words {
+		words[i] = []byte("zoo")
+	}
+	if err := s.SetWords(words); err == nil {
+		t.Fatal("expected an error for an invalid seed")
+	}
+	for i := range words {
+		words[i] = []byte("all")
+	}
+	if err := s.SetWords(words); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetPassphrase(demoVector.Passphrase); err != nil {
+		t.Fatal(err)
+	}
+	if s.Step() != Done {
+		t.Fatalf("expected step Done, got %s", s.Step())
+	}
+	entity, err := s.Entity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fp := formatFingerprint(entity.PrimaryKey); fp != demoVector.PrimaryFingerprint {
+		t.Fatalf("unexpected fingerprint %s", fp)
+	}
+}
+
+func TestSessionStrict(t *testing.T) {
+	s, err := NewSession(WithStrict(), WithFingerprint(demoVector.PrimaryFingerprint))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.Confirm(true)
+	if err := s.SetUserID(" Alice"); err == nil {
+		t.Fatal("expected an error for a user ID with whitespace in strict mode")
+	}
+	s.SetUserID(demoVector.UserID)
+	s.SetTimestamp(time.Unix(demoVector.Timestamp, 0))
+	words := make([][]byte, 12)
+	for i := range words {
+		words[i] = []byte("all")
+	}
+	s.SetWords(words)
+	if err := s.SetPassphrase("wrong"); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("expected a fingerprint mismatch, got %v", err)
+	}
+	if s.Step() != NeedsPassphrase {
+		t.Fatalf("expected the passphrase to be retryable, got step %s", s.Step())
+	}
+}
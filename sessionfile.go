@@ -0,0 +1,170 @@
+package recovery
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// maxSessionFileSize caps the size of a session file read into memory.
+const maxSessionFileSize = 1 << 16
+
+// WithSessionFile configures the recovery to save its non-secret answers
+// (the user ID and timestamp) and key parameters (the curve and index) to the
+// file at path as they're entered, and to resume with the answers saved
+// there if the file exists, so an interrupted recovery only needs the
+// recovery seed and passphrase to be entered again. options are saved with
+// them for frontends to restore (e.g. the command's output flags), and can be
+// read back with ReadSessionFile. The file is removed once the recovery
+// succeeds.
+func WithSessionFile(path string, options map[string]string) Option {
+	return func(r *Recovery) {
+		r.sessionPath = path
+		r.sessionOptions = options
+	}
+}
+
+// SessionFile is the contents of a session file, which never contains
+// secrets.
+type SessionFile struct {
+	UserID    string            `json:"user_id,omitempty"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Curve     string            `json:"curve"`
+	Index     uint32            `json:"index"`
+	Options   map[string]string `json:"options,omitempty"`
+}
+
+// ReadSessionFile reads the session saved at path by WithSessionFile,
+// returning an error satisfying errors.Is(err, os.ErrNotExist) if there is
+// none.
+func ReadSessionFile(path string) (*SessionFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readSessionFile(f)
+}
+
+// readSessionFile reads a session from f, returning os.ErrNotExist if it's
+// empty.
+func readSessionFile(f *os.File) (*SessionFile, error) {
+	data, err := readLimited(f, maxSessionFileSize)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the session file %s: %s", f.Name(), err)
+	} else if len(data) == 0 {
+		return nil, os.ErrNotExist
+	}
+	var session SessionFile
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("could not read the session file %s (delete it to start again): %s", f.Name(), err)
+	}
+	return &session, nil
+}
+
+// openSessionFile opens the session file before prompting for anything (and
+// so before the process is sandboxed), resuming the session saved in it if
+// there is one, and returns a function which closes it.
+func (r *Recovery) openSessionFile() (func(), error) {
+	if r.sessionPath == "" {
+		return func() {}, nil
+	}
+	if r.demo {
+		return nil, errors.New("a session file cannot be used in a practice run, which always uses the demo answers")
+	}
+	f, err := os.OpenFile(r.sessionPath, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("could not open the session file: %s", err)
+	}
+	keys := r.keyParams()
+	session, err := readSessionFile(f)
+	if errors.Is(err, os.ErrNotExist) {
+		session = &SessionFile{}
+	} else if err != nil {
+		f.Close()
+		return nil, err
+	} else if session.Curve != keys.curve || session.Index != keys.index {
+		f.Close()
+		return nil, fmt.Errorf("the session in %s was saved with curve %s and index %d (use the same options to resume it, or delete it to start again)", r.sessionPath, session.Curve, session.Index)
+	} else {
+		r.info(fmt.Sprintf("Resuming the session saved in %s (delete it to enter the answers again)", r.sessionPath))
+	}
+	session.Curve = keys.curve
+	session.Index = keys.index
+	session.Options = r.sessionOptions
+	r.sessionFile = f
+	r.session = session
+	if err := r.saveSessionFile(); err != nil {
+		f.Close()
+		r.sessionFile = nil
+		return nil, fmt.Errorf("could not save the session file: %s", err)
+	}
+	return func() {
+		f.Close()
+		r.sessionFile = nil
+	}, nil
+}
+
+// saveSessionFile writes the session to the session file.
+func (r *Recovery) saveSessionFile() error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r.session); err != nil {
+		return err
+	}
+	return rewriteFile(r.sessionFile, buf.Bytes())
+}
+
+// saveAnswer saves an answer with set if there's a session file, warning if
+// it can't be written since the recovery can still continue.
+func (r *Recovery) saveAnswer(set func(*SessionFile)) {
+	if r.sessionFile == nil {
+		return
+	}
+	set(r.session)
+	if err := r.saveSessionFile(); err != nil {
+		r.activeLogger().Warn(fmt.Sprintf("could not save the session file: %s", err))
+	}
+}
+
+// readResumed reads the answer to a prompt like readConfigured, unless the
+// answer saved in the session file is given.
+func (r *Recovery) readResumed(prompt, help, demoAnswer, configured, saved string) (string, error) {
+	if saved == "" {
+		return r.readConfigured(prompt, help, demoAnswer, configured)
+	}
+	r.info(fmt.Sprintf("Using %s from the session file", saved), LogField{"value", saved})
+	return saved, nil
+}
+
+// sessionUserID returns the user ID saved in the session file, if any.
+func (r *Recovery) sessionUserID() string {
+	if r.sessionFile == nil {
+		return ""
+	}
+	return r.session.UserID
+}
+
+// sessionTimestamp returns the timestamp saved in the session file, if any.
+func (r *Recovery) sessionTimestamp() string {
+	if r.sessionFile == nil || r.session.Timestamp == 0 {
+		return ""
+	}
+	return strconv.FormatInt(r.session.Timestamp, 10)
+}
+
+// removeSessionFile removes the session file once the recovery has
+// succeeded.
+func (r *Recovery) removeSessionFile() {
+	if r.sessionFile == nil {
+		return
+	}
+	if err := os.Remove(r.sessionPath); err != nil {
+		r.activeLogger().Warn(fmt.Sprintf("could not remove the session file: %s", err))
+	}
+}
@@ -0,0 +1,59 @@
+package recovery
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSessionFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	options := map[string]string{"output": "alice.asc"}
+
+	// interrupt the recovery at the seed length prompt
+	input := strings.SplitAfter(aliceInput, "\n")
+	err := Run(
+		WithStdin(strings.NewReader(strings.Join(input[:3], ""))),
+		WithStdout(&bytes.Buffer{}),
+		WithStderr(&bytes.Buffer{}),
+		WithSessionFile(path, options),
+	)
+	if !errors.Is(err, ErrEndOfInput) {
+		t.Fatalf("expected ErrEndOfInput, got %v", err)
+	}
+	session, err := ReadSessionFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.UserID != "Alice <alice@example.com>" || session.Timestamp != 1523060353 || session.Curve != CurveNIST256P1 || session.Index != 0 || session.Options["output"] != "alice.asc" {
+		t.Fatalf("unexpected session %+v", session)
+	}
+
+	// the session can't be resumed with different key parameters
+	err = Run(
+		WithStdin(strings.NewReader("yes\n")),
+		WithStdout(&bytes.Buffer{}),
+		WithStderr(&bytes.Buffer{}),
+		WithSessionFile(path, options),
+		WithIndex(1),
+	)
+	if err == nil || !strings.Contains(err.Error(), "was saved with curve nist256p1 and index 0") {
+		t.Fatalf("expected an error resuming with a different index, got %v", err)
+	}
+
+	// resuming only prompts for the seed and passphrase, and removes the
+	// file once the recovery succeeds
+	entity := recoverEntity(t, input[0]+strings.Join(input[3:], ""), WithSessionFile(path, options))
+	if fingerprint := formatFingerprint(entity.PrimaryKey); fingerprint != aliceFingerprint {
+		t.Fatalf("expected fingerprint %s, got %s", aliceFingerprint, fingerprint)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected the session file to be removed, got %v", err)
+	}
+	if _, err := ReadSessionFile(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected os.ErrNotExist, got %v", err)
+	}
+}
@@ -0,0 +1,269 @@
+package recovery
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+// shareBlockType is the armor block type of a private key share.
+const shareBlockType = "TREZOR GPG RECOVERY KEY SHARE"
+
+// maxSharesSize is the most read from each reader passed to CombineShares,
+// enough for all 255 shares of a split.
+const maxSharesSize = 1 << 20
+
+// WithShamirShares configures the recovery to split the private key into
+// Shamir shares, writing one share to each of the given writers rather than
+// writing the private key to stdout. Any threshold of the shares can be
+// combined with CombineShares to get the private key back, but fewer reveal
+// nothing about it. The shares are in this package's own armored format and
+// are not SLIP-39 shares, so no wallet can import them.
+func WithShamirShares(threshold int, outputs ...io.Writer) Option {
+	return func(r *Recovery) {
+		r.shareThreshold = threshold
+		r.shareOutputs = outputs
+	}
+}
+
+// checkShares checks the share options are consistent before prompting for
+// anything.
+func (r *Recovery) checkShares() error {
+	if r.shareOutputs == nil {
+		return nil
+	}
+	return checkThreshold(r.shareThreshold, len(r.shareOutputs))
+}
+
+func checkThreshold(threshold, shares int) error {
+	if threshold < 2 || threshold > shares || shares > 255 {
+		return fmt.Errorf("invalid %d-of-%d shares: the threshold must be at least 2 and at most the number of shares (which must be at most 255)", threshold, shares)
+	}
+	return nil
+}
+
+// writeShares splits the private key of entity into shares, writing one
+// armored share to each of the configured outputs.
+func (r *Recovery) writeShares(entity *openpgp.Entity) error {
+	// allocate the buffer up front as serializePrivate does, so the private
+	// key isn't copied as it grows
+	privKey := bytes.NewBuffer(make([]byte, 0, armoredPrivateKeySize))
+	defer func() { wipe(privKey.Bytes()) }()
+	if err := entity.SerializePrivate(privKey, nil); err != nil {
+		return err
+	}
+	shares, err := splitSecret(privKey.Bytes(), r.shareThreshold, len(r.shareOutputs), rand.Reader)
+	if err != nil {
+		return err
+	}
+	n := len(shares)
+	for i, share := range shares {
+		headers := map[string]string{
+			"Comment":     fmt.Sprintf("Share %d of %d, any %d of which recover the private key", i+1, n, r.shareThreshold),
+			"Threshold":   strconv.Itoa(r.shareThreshold),
+			"Fingerprint": formatFingerprint(entity.PrimaryKey),
+		}
+		if r.demo {
+			headers["Comment"] = demoComment + ": " + headers["Comment"]
+		}
+		err := writeShare(r.shareOutputs[i], share, headers)
+		wipe(share)
+		if err != nil {
+			return err
+		}
+	}
+	r.info(fmt.Sprintf("Split the private key into %d shares, any %d of which recover it", n, r.shareThreshold), LogField{"shares", n}, LogField{"threshold", r.shareThreshold})
+	return nil
+}
+
+func writeShare(w io.Writer, share []byte, headers map[string]string) error {
+	enc, err := armor.Encode(w, shareBlockType, headers)
+	if err != nil {
+		return err
+	}
+	if _, err := enc.Write(share); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n\n")
+	return err
+}
+
+// CombineShares combines the armored private key shares written with
+// WithShamirShares, writing the armored private key to w. Each reader may
+// contain one or more shares.
+func CombineShares(w io.Writer, readers ...io.Reader) error {
+	var (
+		shares      [][]byte
+		threshold   int
+		fingerprint string
+	)
+	defer func() {
+		for _, share := range shares {
+			wipe(share)
+		}
+	}()
+	for _, r := range readers {
+		data, err := readLimited(r, maxSharesSize)
+		if err != nil {
+			return fmt.Errorf("could not read shares: %s", err)
+		}
+		defer wipe(data)
+
+		// decode each block separately since armor.Decode reads ahead
+		begin := []byte("-----BEGIN ")
+		for {
+			start := bytes.Index(data, begin)
+			if start == -1 {
+				break
+			}
+			data = data[start:]
+			end := bytes.Index(data[len(begin):], begin)
+			if end == -1 {
+				end = len(data)
+			} else {
+				end += len(begin)
+			}
+			block, err := armor.Decode(bytes.NewReader(data[:end]))
+			data = data[end:]
+			if err != nil {
+				return fmt.Errorf("could not read share: %s", err)
+			}
+			if block.Type != shareBlockType {
+				return fmt.Errorf("expected %s, got %s", shareBlockType, block.Type)
+			}
+			share, err := ioutil.ReadAll(block.Body)
+			if err != nil {
+				return fmt.Errorf("could not read share: %s", err)
+			}
+			shares = append(shares, share)
+
+			// check the shares are from the same split
+			t, err := strconv.Atoi(block.Header["Threshold"])
+			if err != nil {
+				return fmt.Errorf("invalid share threshold %q", block.Header["Threshold"])
+			}
+			if threshold == 0 {
+				threshold, fingerprint = t, block.Header["Fingerprint"]
+			} else if t != threshold || block.Header["Fingerprint"] != fingerprint {
+				return errors.New("the shares are from different keys or splits")
+			}
+		}
+	}
+	if len(shares) < threshold {
+		return fmt.Errorf("need at least %d shares to recover the private key, got %d", threshold, len(shares))
+	}
+	privKey, err := combineShares(shares)
+	if err != nil {
+		return err
+	}
+	defer wipe(privKey)
+
+	// check the combined shares give the expected key
+	entities, err := openpgp.ReadKeyRing(bytes.NewReader(privKey))
+	if err != nil || len(entities) != 1 || entities[0].PrivateKey == nil {
+		return errors.New("the combined shares are not a private key, check they are all from the same split")
+	}
+	entity := entities[0]
+	defer wipeEntity(entity)
+	if actual := formatFingerprint(entity.PrimaryKey); actual != fingerprint {
+		return fmt.Errorf("the combined private key has fingerprint %s, expected %s", actual, fingerprint)
+	}
+	return writePrivate(w, entity, nil)
+}
+
+// splitSecret splits secret into n Shamir shares over GF(256), any threshold
+// of which can be combined to recover it. Each share is its x-coordinate
+// followed by the evaluation of a random polynomial for each byte of secret.
+func splitSecret(secret []byte, threshold, n int, random io.Reader) ([][]byte, error) {
+	if err := checkThreshold(threshold, n); err != nil {
+		return nil, err
+	}
+	shares := make([][]byte, n)
+	for i := range shares {
+		shares[i] = make([]byte, len(secret)+1)
+		shares[i][0] = byte(i + 1)
+	}
+	coeffs := make([]byte, threshold)
+	defer wipe(coeffs)
+	for j, b := range secret {
+		coeffs[0] = b
+		if _, err := io.ReadFull(random, coeffs[1:]); err != nil {
+			return nil, err
+		}
+		for _, share := range shares {
+			// evaluate the polynomial at x using Horner's method
+			x := share[0]
+			var y byte
+			for k := threshold - 1; k >= 0; k-- {
+				y = gfMul(y, x) ^ coeffs[k]
+			}
+			share[j+1] = y
+		}
+	}
+	return shares, nil
+}
+
+// combineShares recovers the secret from the given shares by Lagrange
+// interpolation at x = 0.
+func combineShares(shares [][]byte) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, errors.New("no shares to combine")
+	}
+	size := len(shares[0])
+	seen := make(map[byte]bool, len(shares))
+	for _, share := range shares {
+		if len(share) != size || size < 2 {
+			return nil, errors.New("the shares have different lengths")
+		}
+		if share[0] == 0 || seen[share[0]] {
+			return nil, fmt.Errorf("invalid or duplicate share %d", share[0])
+		}
+		seen[share[0]] = true
+	}
+	secret := make([]byte, size-1)
+	for i, share := range shares {
+		// compute the Lagrange basis polynomial for this share at x = 0
+		basis := byte(1)
+		for j, other := range shares {
+			if i != j {
+				basis = gfMul(basis, gfDiv(other[0], other[0]^share[0]))
+			}
+		}
+		for k := range secret {
+			secret[k] ^= gfMul(basis, share[k+1])
+		}
+	}
+	return secret, nil
+}
+
+// gfMul multiplies a and b in GF(256) with the AES polynomial, in constant
+// time.
+func gfMul(a, b byte) byte {
+	var p byte
+	for i := 0; i < 8; i++ {
+		p ^= -(b & 1) & a
+		a = (a << 1) ^ (0x1b & -(a >> 7))
+		b >>= 1
+	}
+	return p
+}
+
+// gfDiv divides a by b (which must not be zero) in GF(256), using b^254 as
+// the inverse of b.
+func gfDiv(a, b byte) byte {
+	inv := b
+	for i := 0; i < 6; i++ {
+		inv = gfMul(gfMul(inv, inv), b)
+	}
+	return gfMul(a, gfMul(inv, inv))
+}
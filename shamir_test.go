@@ -0,0 +1,107 @@
+package recovery
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+func TestSplitSecret(t *testing.T) {
+	secret := []byte("Trezor GPG Recovery")
+	shares, err := splitSecret(secret, 3, 5, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// check any 3 shares recover the secret
+	for _, subset := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}, {0, 1, 2, 3, 4}} {
+		var s [][]byte
+		for _, i := range subset {
+			s = append(s, shares[i])
+		}
+		combined, err := combineShares(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(combined, secret) {
+			t.Fatalf("shares %v: expected %q, got %q", subset, secret, combined)
+		}
+	}
+
+	// check 2 shares don't
+	combined, err := combineShares(shares[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(combined, secret) {
+		t.Fatal("expected 2 shares not to recover the secret")
+	}
+
+	// check duplicate shares are rejected
+	if _, err := combineShares([][]byte{shares[0], shares[0], shares[1]}); err == nil {
+		t.Fatal("expected duplicate shares to be rejected")
+	}
+
+	// check invalid thresholds are rejected
+	for _, test := range [][2]int{{1, 3}, {4, 3}, {2, 256}} {
+		if _, err := splitSecret(secret, test[0], test[1], rand.Reader); err == nil {
+			t.Fatalf("expected %d-of-%d to be rejected", test[0], test[1])
+		}
+	}
+}
+
+func TestGFDiv(t *testing.T) {
+	for a := 0; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			if q := gfDiv(byte(a), byte(b)); gfMul(q, byte(b)) != byte(a) {
+				t.Fatalf("%d / %d = %d, but %d * %d != %d", a, b, q, q, b, a)
+			}
+		}
+	}
+}
+
+func TestShamirShares(t *testing.T) {
+	var stdout bytes.Buffer
+	outputs := make([]*bytes.Buffer, 3)
+	var writers []io.Writer
+	for i := range outputs {
+		outputs[i] = &bytes.Buffer{}
+		writers = append(writers, outputs[i])
+	}
+	err := Run(
+		WithStdin(strings.NewReader(aliceInput)),
+		WithStdout(&stdout),
+		WithStderr(&bytes.Buffer{}),
+		WithShamirShares(2, writers...),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout.Len() > 0 {
+		t.Fatalf("expected the private key not to be written to stdout, got:\n%s", stdout.String())
+	}
+
+	// check two shares (including from the same reader) recover the key
+	var privKey bytes.Buffer
+	both := strings.NewReader(outputs[2].String() + outputs[0].String())
+	if err := CombineShares(&privKey, both); err != nil {
+		t.Fatal(err)
+	}
+	entities, err := openpgp.ReadArmoredKeyRing(&privKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fpr := formatFingerprint(entities[0].PrimaryKey); fpr != aliceFingerprint {
+		t.Fatalf("unexpected fingerprint %s", fpr)
+	}
+
+	// check one share isn't enough
+	err = CombineShares(&bytes.Buffer{}, bytes.NewReader(outputs[1].Bytes()))
+	if err == nil || !strings.Contains(err.Error(), "need at least 2 shares") {
+		t.Fatalf("expected one share to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,25 @@
+package recovery
+
+// WithSleepInhibitor configures the recovery to stop the machine suspending
+// or hibernating until it finishes, since hibernation writes memory
+// (including the seed) to disk and a long seed entry session could otherwise
+// trigger idle suspend. It uses systemd-inhibit on Linux, caffeinate on macOS
+// and SetThreadExecutionState on Windows.
+func WithSleepInhibitor() Option {
+	return func(r *Recovery) {
+		r.preventSleep = true
+	}
+}
+
+// holdSleepInhibitor stops the machine sleeping, returning a function which
+// allows it again. A failure is a warning (an error in strict mode), unless
+// inhibiting sleep isn't supported on this platform.
+func (r *Recovery) holdSleepInhibitor() (func(), error) {
+	release, err := inhibitSleep()
+	if err == errNotSupported {
+		return func() {}, nil
+	} else if err != nil {
+		return func() {}, r.warn("could not prevent the machine sleeping during the recovery: %s", err)
+	}
+	return release, nil
+}
@@ -0,0 +1,6 @@
+package recovery
+
+// sleepInhibitor is the command which inhibits sleep while the command it is
+// given runs, a variable so tests can fake it. -i prevents idle sleep and -s
+// system sleep while on AC power.
+var sleepInhibitor = []string{"caffeinate", "-i", "-s"}
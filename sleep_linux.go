@@ -0,0 +1,11 @@
+package recovery
+
+// sleepInhibitor is the command which inhibits sleep while the command it is
+// given runs, a variable so tests can fake it.
+var sleepInhibitor = []string{
+	"systemd-inhibit",
+	"--what=sleep:idle:handle-lid-switch",
+	"--who=trezor-gpg-recovery",
+	"--why=Recovering private keys",
+	"--mode=block",
+}
@@ -0,0 +1,8 @@
+//go:build !darwin && !linux && !windows
+// +build !darwin,!linux,!windows
+
+package recovery
+
+func inhibitSleep() (func(), error) {
+	return nil, errNotSupported
+}
@@ -0,0 +1,50 @@
+//go:build darwin || linux
+// +build darwin linux
+
+package recovery
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// inhibitSleep runs a process holding sleepInhibitor (e.g. systemd-inhibit)
+// until the returned function closes its stdin. The held process prints a
+// line once the inhibitor is held so that a failure is noticed, and exits
+// by itself if the recovery exits without releasing it.
+func inhibitSleep() (func(), error) {
+	if _, err := exec.LookPath(sleepInhibitor[0]); err != nil {
+		return nil, err
+	}
+	args := append(append([]string{}, sleepInhibitor[1:]...), "sh", "-c", "echo; exec cat")
+	cmd := exec.Command(sleepInhibitor[0], args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	if _, err := bufio.NewReader(stdout).ReadString('\n'); err != nil {
+		stdin.Close()
+		cmd.Wait()
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %s", sleepInhibitor[0], msg)
+		}
+		return nil, errors.New(sleepInhibitor[0] + " exited")
+	}
+	return func() {
+		stdin.Close()
+		go cmd.Wait()
+	}, nil
+}
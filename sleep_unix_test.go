@@ -0,0 +1,35 @@
+//go:build darwin || linux
+// +build darwin linux
+
+package recovery
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestInhibitSleep(t *testing.T) {
+	defer func(inhibitor []string) { sleepInhibitor = inhibitor }(sleepInhibitor)
+
+	// env runs the held process as an inhibitor would
+	sleepInhibitor = []string{"env"}
+	release, err := inhibitSleep()
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+
+	// a failing inhibitor is reported with its error
+	sleepInhibitor = []string{"sh", "-c", "echo 'Failed to inhibit: no logind' >&2; exit 1"}
+	if _, err := inhibitSleep(); err == nil || !strings.Contains(err.Error(), "no logind") {
+		t.Fatalf("expected an inhibit error, got %v", err)
+	}
+
+	// which the recovery warns about
+	var stderr bytes.Buffer
+	recoverEntity(t, aliceInput, WithSleepInhibitor(), WithStderr(&stderr))
+	if !strings.Contains(stderr.String(), "WARNING: could not prevent the machine sleeping") {
+		t.Fatalf("expected a sleep warning, got:\n%s", stderr.String())
+	}
+}
@@ -0,0 +1,38 @@
+package recovery
+
+import (
+	"runtime"
+	"syscall"
+)
+
+const (
+	esContinuous     = 0x80000000
+	esSystemRequired = 0x00000001
+)
+
+// inhibitSleep stops Windows sleeping until the returned function is called,
+// with SetThreadExecutionState. The execution state belongs to a thread, so
+// it is set and cleared on a locked thread which waits in between.
+func inhibitSleep() (func(), error) {
+	proc := syscall.NewLazyDLL("kernel32.dll").NewProc("SetThreadExecutionState")
+	if err := proc.Find(); err != nil {
+		return nil, err
+	}
+	held := make(chan error)
+	done := make(chan struct{})
+	go func() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		if ret, _, err := proc.Call(esContinuous | esSystemRequired); ret == 0 {
+			held <- err
+			return
+		}
+		held <- nil
+		<-done
+		proc.Call(esContinuous)
+	}()
+	if err := <-held; err != nil {
+		return nil, err
+	}
+	return func() { close(done) }, nil
+}
@@ -0,0 +1,129 @@
+package recovery
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// errAgentReadOnly is returned to SSH agent clients which try to change the
+// served keys.
+var errAgentReadOnly = errors.New("the recovered identity's agent is read-only")
+
+// ServeSSHAgent serves the primary key of the recovered identity as an SSH
+// agent on l (usually a unix socket, see SSH_AUTH_SOCK) until ctx is done,
+// giving emergency SSH access without writing the key to disk. The key is the
+// one 'gpg --export-ssh-key' exports. Clients can only list the key and sign
+// with it; adding, removing and locking keys are refused.
+//
+// onSign, if not nil, is called with the fingerprint of the key whenever a
+// client signs with it (e.g. so the user can notice unexpected requests).
+// The listener is closed on return, while result remains the caller's to
+// wipe.
+func ServeSSHAgent(ctx context.Context, l net.Listener, result *Result, onSign func(fingerprint string)) error {
+	key, ok := result.Entity.PrivateKey.PrivateKey.(*ecdsa.PrivateKey)
+	if !ok {
+		return fmt.Errorf("unexpected private key type %T", result.Entity.PrivateKey.PrivateKey)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		return err
+	}
+	a := &sshAgent{
+		signer:  signer,
+		comment: "openpgp:0x" + formatShortKeyID(result.Entity.PrimaryKey),
+		onSign:  onSign,
+	}
+
+	// close the listener and any connections when ctx is done
+	var (
+		mu    sync.Mutex
+		conns = make(map[net.Conn]struct{})
+		wg    sync.WaitGroup
+	)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		l.Close()
+		mu.Lock()
+		for conn := range conns {
+			conn.Close()
+		}
+		mu.Unlock()
+	}()
+	defer wg.Wait()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		mu.Lock()
+		conns[conn] = struct{}{}
+		mu.Unlock()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			agent.ServeAgent(a, conn)
+			mu.Lock()
+			delete(conns, conn)
+			mu.Unlock()
+			conn.Close()
+		}()
+	}
+}
+
+// sshAgent is a read-only SSH agent serving a single key.
+type sshAgent struct {
+	signer  ssh.Signer
+	comment string
+	onSign  func(fingerprint string)
+}
+
+func (a *sshAgent) List() ([]*agent.Key, error) {
+	pub := a.signer.PublicKey()
+	return []*agent.Key{{Format: pub.Type(), Blob: pub.Marshal(), Comment: a.comment}}, nil
+}
+
+func (a *sshAgent) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	return a.SignWithFlags(key, data, 0)
+}
+
+func (a *sshAgent) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	pub := a.signer.PublicKey()
+	if string(key.Marshal()) != string(pub.Marshal()) {
+		return nil, errors.New("unknown key")
+	}
+	if a.onSign != nil {
+		a.onSign(ssh.FingerprintSHA256(pub))
+	}
+	return a.signer.Sign(rand.Reader, data)
+}
+
+func (a *sshAgent) Signers() ([]ssh.Signer, error) {
+	return []ssh.Signer{a.signer}, nil
+}
+
+func (a *sshAgent) Extension(extensionType string, contents []byte) ([]byte, error) {
+	return nil, agent.ErrExtensionUnsupported
+}
+
+func (a *sshAgent) Add(key agent.AddedKey) error   { return errAgentReadOnly }
+func (a *sshAgent) Remove(key ssh.PublicKey) error { return errAgentReadOnly }
+func (a *sshAgent) RemoveAll() error               { return errAgentReadOnly }
+func (a *sshAgent) Lock(passphrase []byte) error   { return errAgentReadOnly }
+func (a *sshAgent) Unlock(passphrase []byte) error { return errAgentReadOnly }
@@ -0,0 +1,92 @@
+package recovery
+
+import (
+	"context"
+	"net"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestServeSSHAgent(t *testing.T) {
+	v := demoVector
+	entity, err := newEntity(v.Mnemonic, v.Passphrase, v.UserID, time.Unix(v.Timestamp, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := newResult(entity)
+	defer result.Wipe()
+
+	path := filepath.Join(t.TempDir(), "agent.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	signed := make(chan string, 1)
+	served := make(chan error, 1)
+	go func() {
+		served <- ServeSSHAgent(ctx, l, result, func(fingerprint string) { signed <- fingerprint })
+	}()
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := agent.NewClient(conn)
+
+	// the agent lists the same key as 'gpg --export-ssh-key'
+	keys, err := client.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sshKey, err := result.Identity().SSHPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].String() != sshKey {
+		t.Fatalf("unexpected keys %v, expected %s", keys, sshKey)
+	}
+
+	// signatures verify with the key
+	data := []byte("session")
+	sig, err := client.Sign(keys[0], data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keys[0].Verify(data, sig); err != nil {
+		t.Fatal(err)
+	}
+	if fp := <-signed; fp != ssh.FingerprintSHA256(keys[0]) {
+		t.Fatalf("unexpected signing fingerprint %s", fp)
+	}
+
+	// the keys can't be changed
+	if err := client.RemoveAll(); err == nil {
+		t.Fatal("expected removing keys to fail")
+	}
+
+	// ssh-add sees the key too
+	if _, err := exec.LookPath("ssh-add"); err == nil {
+		cmd := exec.Command("ssh-add", "-L")
+		cmd.Env = []string{"SSH_AUTH_SOCK=" + path}
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.TrimSpace(string(out)) != sshKey {
+			t.Fatalf("unexpected ssh-add output %q", out)
+		}
+	}
+
+	cancel()
+	if err := <-served; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,76 @@
+package recovery
+
+import (
+	"fmt"
+	"strings"
+)
+
+// swapArea is an active swap partition or file.
+type swapArea struct {
+	path      string
+	encrypted bool
+}
+
+// unencryptedSwaps returns the paths of the active swap areas which aren't
+// encrypted.
+func unencryptedSwaps() ([]string, error) {
+	swaps, err := activeSwaps()
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, swap := range swaps {
+		if !swap.encrypted {
+			paths = append(paths, swap.path)
+		}
+	}
+	return paths, nil
+}
+
+// warnSwap prominently warns if unencrypted swap is active, since a swapped
+// out page containing the seed defeats the point of an air-gapped recovery.
+func (r *Recovery) warnSwap() error {
+	swaps, err := unencryptedSwaps()
+	if err == errNotSupported {
+		return nil
+	} else if err != nil {
+		return r.warn("could not check for unencrypted swap: %s", err)
+	}
+	if len(swaps) == 0 {
+		return nil
+	}
+	if !r.strict {
+		r.display(strings.Repeat("!", 77))
+	}
+	err = r.warn("unencrypted swap is enabled (%s), so secrets could be written to disk if memory is swapped out; run 'swapoff -a' before entering your recovery seed", strings.Join(swaps, ", "))
+	if err != nil {
+		return err
+	}
+	r.display(strings.Repeat("!", 77))
+	return nil
+}
+
+// checkSwap checks for unencrypted swap for the doctor command.
+func checkSwap() (string, error) {
+	swaps, err := activeSwaps()
+	if err == errNotSupported {
+		return "", err
+	} else if err != nil {
+		return "", fmt.Errorf("could not check swap: %s", err)
+	}
+	var encrypted, unencrypted []string
+	for _, swap := range swaps {
+		if swap.encrypted {
+			encrypted = append(encrypted, swap.path)
+		} else {
+			unencrypted = append(unencrypted, swap.path)
+		}
+	}
+	if len(unencrypted) > 0 {
+		return "", fmt.Errorf("unencrypted swap is enabled (%s), run 'swapoff -a'", strings.Join(unencrypted, ", "))
+	}
+	if len(encrypted) > 0 {
+		return fmt.Sprintf("only encrypted swap is enabled: %s", strings.Join(encrypted, ", ")), nil
+	}
+	return "", nil
+}
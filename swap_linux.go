@@ -0,0 +1,80 @@
+package recovery
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// procSwaps and sysDevBlock are variables so tests can fake the active swap
+// areas and their devices.
+var (
+	procSwaps   = "/proc/swaps"
+	sysDevBlock = "/sys/dev/block"
+)
+
+// activeSwaps returns the active swap areas listed in /proc/swaps, checking
+// whether each is on an encrypted (dm-crypt) or RAM backed (zram) device.
+func activeSwaps() ([]swapArea, error) {
+	f, err := os.Open(procSwaps)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var swaps []swapArea
+	s := newLineScanner(f)
+	s.Scan() // skip the header
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		swap := swapArea{path: fields[0]}
+		if dir, err := swapDevice(swap.path); err == nil {
+			swap.encrypted = encryptedDevice(dir)
+		}
+		swaps = append(swaps, swap)
+	}
+	return swaps, scanErr(s, procSwaps)
+}
+
+// swapDevice returns the sysfs directory of the block device the given swap
+// partition or file is on.
+func swapDevice(path string) (string, error) {
+	var st syscall.Stat_t
+	if err := syscall.Stat(path, &st); err != nil {
+		return "", err
+	}
+	dev := st.Dev
+	if st.Mode&syscall.S_IFMT == syscall.S_IFBLK {
+		dev = st.Rdev
+	}
+	major := (dev>>8)&0xfff | (dev>>32)&^0xfff
+	minor := dev&0xff | (dev>>12)&^0xff
+	return filepath.Join(sysDevBlock, fmt.Sprintf("%d:%d", major, minor)), nil
+}
+
+// encryptedDevice returns whether the block device with the given sysfs
+// directory is a dm-crypt or zram device, or is only backed by such devices
+// (e.g. an LVM volume on top of LUKS).
+func encryptedDevice(dir string) bool {
+	if dir, err := filepath.EvalSymlinks(dir); err == nil && strings.HasPrefix(filepath.Base(dir), "zram") {
+		return true
+	}
+	if uuid, err := ioutil.ReadFile(filepath.Join(dir, "dm", "uuid")); err == nil && strings.HasPrefix(string(uuid), "CRYPT-") {
+		return true
+	}
+	slaves, err := ioutil.ReadDir(filepath.Join(dir, "slaves"))
+	if err != nil || len(slaves) == 0 {
+		return false
+	}
+	for _, slave := range slaves {
+		if !encryptedDevice(filepath.Join(dir, "slaves", slave.Name())) {
+			return false
+		}
+	}
+	return true
+}
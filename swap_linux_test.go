@@ -0,0 +1,93 @@
+package recovery
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func init() {
+	// stop the swap of the machine running the tests failing strict mode
+	procSwaps = os.DevNull
+}
+
+func TestSwap(t *testing.T) {
+	defer func(swaps, dev string) { procSwaps, sysDevBlock = swaps, dev }(procSwaps, sysDevBlock)
+	dir := t.TempDir()
+	procSwaps = filepath.Join(dir, "swaps")
+	sysDevBlock = filepath.Join(dir, "sys")
+	setSwaps := func(paths ...string) {
+		swaps := "Filename\t\t\t\tType\t\tSize\t\tUsed\t\tPriority\n"
+		for _, path := range paths {
+			swaps += path + "                               partition\t2097148\t\t0\t\t-2\n"
+		}
+		if err := ioutil.WriteFile(procSwaps, []byte(swaps), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// check no swap passes
+	setSwaps()
+	if swaps, err := unencryptedSwaps(); err != nil || len(swaps) != 0 {
+		t.Fatalf("expected no unencrypted swap, got %v, %v", swaps, err)
+	}
+	if _, err := checkSwap(); err != nil {
+		t.Fatal(err)
+	}
+
+	// check a swap file on an unencrypted device is detected
+	swapFile := filepath.Join(dir, "swapfile")
+	if err := ioutil.WriteFile(swapFile, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	setSwaps("/dev/sda2", swapFile)
+	if swaps, err := unencryptedSwaps(); err != nil || strings.Join(swaps, ",") != "/dev/sda2,"+swapFile {
+		t.Fatalf("expected unencrypted swap, got %v, %v", swaps, err)
+	}
+	if _, err := checkSwap(); err == nil || !strings.Contains(err.Error(), "/dev/sda2") {
+		t.Fatalf("expected a swap error, got %v", err)
+	}
+
+	// check the recovery warns before the seed is entered, and fails in
+	// strict mode
+	var stderr bytes.Buffer
+	recoverEntity(t, aliceInput, WithStderr(&stderr))
+	warning := strings.Index(stderr.String(), "WARNING: unencrypted swap is enabled")
+	if warning == -1 || warning > strings.Index(stderr.String(), "Recovery Seed?") {
+		t.Fatalf("expected a swap warning before the seed prompt, got:\n%s", stderr.String())
+	}
+	err := Run(
+		WithStdin(strings.NewReader(aliceInput)),
+		WithStdout(&bytes.Buffer{}),
+		WithStderr(&bytes.Buffer{}),
+		WithStrict(),
+	)
+	if err == nil || !strings.Contains(err.Error(), "unencrypted swap") {
+		t.Fatalf("expected a swap error in strict mode, got %v", err)
+	}
+
+	// check a swap file on a dm-crypt device is considered encrypted
+	var st syscall.Stat_t
+	if err := syscall.Stat(swapFile, &st); err != nil {
+		t.Fatal(err)
+	}
+	device := filepath.Join(sysDevBlock, fmt.Sprintf("%d:%d", (st.Dev>>8)&0xfff|(st.Dev>>32)&^0xfff, st.Dev&0xff|(st.Dev>>12)&^0xff))
+	if err := os.MkdirAll(filepath.Join(device, "dm"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(device, "dm", "uuid"), []byte("CRYPT-LUKS2-0123-luks\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	setSwaps(swapFile)
+	if swaps, err := unencryptedSwaps(); err != nil || len(swaps) != 0 {
+		t.Fatalf("expected no unencrypted swap, got %v, %v", swaps, err)
+	}
+	if detail, err := checkSwap(); err != nil || !strings.Contains(detail, swapFile) {
+		t.Fatalf("expected only encrypted swap, got %q, %v", detail, err)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+// +build !linux
+
+package recovery
+
+func activeSwaps() ([]swapArea, error) {
+	return nil, errNotSupported
+}
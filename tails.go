@@ -0,0 +1,126 @@
+package recovery
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// osRelease and tailsPersistence are variables so tests can fake running on
+// Tails.
+var (
+	osRelease        = "/etc/os-release"
+	tailsPersistence = "/live/persistence/TailsData_unlocked"
+)
+
+// WithTailsPersistence configures the recovery to offer to import the
+// recovered identity into the GnuPG home directory home which is kept on
+// Tails' persistent storage (see TailsGnuPGHome), provisioning it like
+// WithGPGAgent and then giving any files created by root to the owner of
+// home, so that the identity is still there after rebooting Tails.
+func WithTailsPersistence(home string) Option {
+	return func(r *Recovery) {
+		r.tailsHome = home
+	}
+}
+
+// checkTails checks the Tails persistence options before prompting for
+// anything.
+func (r *Recovery) checkTails() error {
+	if r.tailsHome == "" {
+		return nil
+	}
+	if r.gpgAgent {
+		return errors.New("WithTailsPersistence can't be combined with WithGPGAgent or WithYubiKey")
+	}
+	if r.demo {
+		return errors.New("the demo identity must never be written to persistent storage")
+	}
+	return nil
+}
+
+// offerTailsPersistence asks whether to import entity into the persistent
+// GnuPG home directory, doing so if confirmed and returning whether it was.
+func (r *Recovery) offerTailsPersistence(entity *openpgp.Entity) (bool, error) {
+	r.display(`
+-----------------------------------------------------------------------------
+ Tails persistent storage
+
+ You are running Tails with the GnuPG persistence feature, so the recovered
+ identity can be imported into the GnuPG keyring on the persistent storage,
+ where it stays encrypted with your persistent storage passphrase.
+-----------------------------------------------------------------------------`)
+	if ok, err := r.confirm(fmt.Sprintf("Import the recovered identity into the persistent GnuPG keyring in %s?", r.tailsHome), importHelp); err != nil || !ok {
+		return false, err
+	}
+	if err := ProvisionGPGAgent(r.ctx, entity, r.tailsHome, nil); err != nil {
+		return false, fmt.Errorf("could not import the identity into %s: %s", r.tailsHome, err)
+	}
+	if err := fixOwnership(r.tailsHome); err != nil {
+		return false, fmt.Errorf("could not fix the ownership of %s: %s", r.tailsHome, err)
+	}
+	r.info(fmt.Sprintf("Imported the recovered identity into %s", r.tailsHome), LogField{"home", r.tailsHome})
+	r.audit(auditEntry{Step: "tails persistence"})
+	return true, nil
+}
+
+// TailsGnuPGHome returns the GnuPG home directory which is kept on Tails'
+// persistent storage (usually /home/amnesia/.gnupg) when running on Tails
+// with the persistent storage unlocked and its GnuPG feature turned on.
+// Otherwise the error satisfies os.ErrNotExist.
+func TailsGnuPGHome() (string, error) {
+	tails, err := isTails()
+	if err != nil {
+		return "", err
+	} else if !tails {
+		return "", fmt.Errorf("not running on Tails: %w", os.ErrNotExist)
+	}
+
+	// persistence.conf lists the persistent directories, one per line with
+	// the mount point followed by its options, e.g.
+	// "/home/amnesia/.gnupg	source=gnupg"
+	f, err := os.Open(filepath.Join(tailsPersistence, "persistence.conf"))
+	if err != nil {
+		return "", fmt.Errorf("the persistent storage is not unlocked: %w", os.ErrNotExist)
+	}
+	defer f.Close()
+	s := newLineScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		for _, option := range strings.Split(fields[1], ",") {
+			if option == "source=gnupg" {
+				return fields[0], nil
+			}
+		}
+	}
+	if err := scanErr(s, f.Name()); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("the GnuPG persistence feature is not turned on: %w", os.ErrNotExist)
+}
+
+// isTails returns whether os-release says the system is Tails.
+func isTails() (bool, error) {
+	f, err := os.Open(osRelease)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	s := newLineScanner(f)
+	for s.Scan() {
+		kv := strings.SplitN(s.Text(), "=", 2)
+		if len(kv) == 2 && kv[0] == "ID" && strings.Trim(kv[1], `"'`) == "tails" {
+			return true, nil
+		}
+	}
+	return false, scanErr(s, osRelease)
+}
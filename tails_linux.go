@@ -0,0 +1,31 @@
+package recovery
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// fixOwnership gives the files under dir to the owner of dir, since files
+// gpg created when run as root (e.g. with sudo) would otherwise be unreadable
+// by the Tails user.
+func fixOwnership(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	owner := info.Sys().(*syscall.Stat_t)
+	if int(owner.Uid) == os.Geteuid() {
+		return nil
+	}
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		st := info.Sys().(*syscall.Stat_t)
+		if st.Uid == owner.Uid && st.Gid == owner.Gid {
+			return nil
+		}
+		return os.Lchown(path, int(owner.Uid), int(owner.Gid))
+	})
+}
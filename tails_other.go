@@ -0,0 +1,9 @@
+//go:build !linux
+// +build !linux
+
+package recovery
+
+// fixOwnership is a no-op since Tails only runs on Linux.
+func fixOwnership(dir string) error {
+	return nil
+}
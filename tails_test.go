@@ -0,0 +1,77 @@
+package recovery
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeTails fakes running on Tails with the given persistence.conf, which
+// isn't written if empty.
+func fakeTails(t *testing.T, conf string) {
+	t.Helper()
+	prevRelease, prevPersistence := osRelease, tailsPersistence
+	t.Cleanup(func() { osRelease, tailsPersistence = prevRelease, prevPersistence })
+	dir := t.TempDir()
+	osRelease = filepath.Join(dir, "os-release")
+	tailsPersistence = filepath.Join(dir, "TailsData_unlocked")
+	release := "TAILS_PRODUCT_NAME=\"Tails\"\nNAME=\"Tails\"\nID=\"tails\"\n"
+	if err := ioutil.WriteFile(osRelease, []byte(release), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if conf == "" {
+		return
+	}
+	if err := os.Mkdir(tailsPersistence, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tailsPersistence, "persistence.conf"), []byte(conf), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTailsGnuPGHome(t *testing.T) {
+	// the test machine isn't running Tails
+	if _, err := TailsGnuPGHome(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected a not exist error, got %v", err)
+	}
+
+	// nor is the persistent storage unlocked
+	fakeTails(t, "")
+	if _, err := TailsGnuPGHome(); err == nil || !strings.Contains(err.Error(), "not unlocked") || !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected a not unlocked error, got %v", err)
+	}
+
+	// nor the GnuPG feature turned on
+	fakeTails(t, "/home/amnesia/Persistent\tsource=Persistent\n")
+	if _, err := TailsGnuPGHome(); err == nil || !strings.Contains(err.Error(), "not turned on") || !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected a not turned on error, got %v", err)
+	}
+
+	fakeTails(t, "/home/amnesia/Persistent\tsource=Persistent\n/home/amnesia/.gnupg\tsource=gnupg,link\n")
+	home, err := TailsGnuPGHome()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if home != "/home/amnesia/.gnupg" {
+		t.Fatalf("unexpected GnuPG home %q", home)
+	}
+}
+
+func TestWithTailsPersistence(t *testing.T) {
+	gpg := newAgentHome(t, "")
+
+	// declining leaves the keyring as it is
+	recoverEntity(t, aliceInput+"no\n", WithTailsPersistence(gpg.home))
+	if _, err := gpg.run(nil, "--list-secret-keys", aliceFingerprint); err == nil {
+		t.Fatal("expected the identity not to be imported")
+	}
+
+	recoverEntity(t, aliceInput+"yes\n", WithTailsPersistence(gpg.home))
+	if _, err := gpg.run(nil, "--list-secret-keys", aliceFingerprint); err != nil {
+		t.Fatal(err)
+	}
+}
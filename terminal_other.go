@@ -0,0 +1,15 @@
+//go:build !windows
+// +build !windows
+
+package recovery
+
+import (
+	"fmt"
+	"io"
+)
+
+// clearTerminal clears the terminal w writes to with clearScreen.
+func clearTerminal(w io.Writer) error {
+	_, err := fmt.Fprint(w, clearScreen)
+	return err
+}
@@ -0,0 +1,77 @@
+package recovery
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const enableVirtualTerminalProcessing = 0x0004
+
+type consoleCoord struct {
+	x, y int16
+}
+
+// pack returns the COORD as passed by value to the console functions.
+func (c consoleCoord) pack() uintptr {
+	return uintptr(uint16(c.x)) | uintptr(uint16(c.y))<<16
+}
+
+type consoleScreenBufferInfo struct {
+	size              consoleCoord
+	cursorPosition    consoleCoord
+	attributes        uint16
+	window            [4]int16
+	maximumWindowSize consoleCoord
+}
+
+// clearTerminal clears the console w writes to. Consoles which interpret
+// escape sequences (e.g. Windows Terminal, or conhost once virtual terminal
+// processing is enabled) are sent clearScreen, and older ones are cleared by
+// filling their screen buffer, which is also the scrollback, with spaces.
+// Anything other than a console (e.g. mintty's pipes) is sent clearScreen.
+func clearTerminal(w io.Writer) error {
+	f, ok := w.(*os.File)
+	if !ok {
+		_, err := fmt.Fprint(w, clearScreen)
+		return err
+	}
+	h := syscall.Handle(f.Fd())
+	var mode uint32
+	if err := syscall.GetConsoleMode(h, &mode); err != nil {
+		_, err := fmt.Fprint(w, clearScreen)
+		return err
+	}
+	if mode&enableVirtualTerminalProcessing != 0 {
+		_, err := fmt.Fprint(w, clearScreen)
+		return err
+	}
+	kernel32 := syscall.NewLazyDLL("kernel32.dll")
+	setConsoleMode := kernel32.NewProc("SetConsoleMode")
+	if ret, _, _ := setConsoleMode.Call(uintptr(h), uintptr(mode|enableVirtualTerminalProcessing)); ret != 0 {
+		defer setConsoleMode.Call(uintptr(h), uintptr(mode))
+		_, err := fmt.Fprint(w, clearScreen)
+		return err
+	}
+
+	// fill the whole screen buffer rather than just the window
+	var info consoleScreenBufferInfo
+	if ret, _, err := kernel32.NewProc("GetConsoleScreenBufferInfo").Call(uintptr(h), uintptr(unsafe.Pointer(&info))); ret == 0 {
+		return err
+	}
+	size := uintptr(info.size.x) * uintptr(info.size.y)
+	origin := consoleCoord{}.pack()
+	var written uint32
+	if ret, _, err := kernel32.NewProc("FillConsoleOutputCharacterW").Call(uintptr(h), ' ', size, origin, uintptr(unsafe.Pointer(&written))); ret == 0 {
+		return err
+	}
+	if ret, _, err := kernel32.NewProc("FillConsoleOutputAttribute").Call(uintptr(h), uintptr(info.attributes), size, origin, uintptr(unsafe.Pointer(&written))); ret == 0 {
+		return err
+	}
+	if ret, _, err := kernel32.NewProc("SetConsoleCursorPosition").Call(uintptr(h), origin); ret == 0 {
+		return err
+	}
+	return nil
+}
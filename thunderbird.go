@@ -0,0 +1,149 @@
+package recovery
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WithThunderbirdBundle configures the recovery to write a bundle for
+// importing the recovered identity into Thunderbird to the directory dir,
+// which must not exist (see WriteThunderbirdBundle).
+//
+// The private key is still printed if WithStdout is also given.
+func WithThunderbirdBundle(dir string) Option {
+	return func(r *Recovery) {
+		r.thunderbirdDir = dir
+	}
+}
+
+// The files in a Thunderbird bundle.
+const (
+	thunderbirdSecretKey    = "secret-key.asc"
+	thunderbirdPublicKey    = "public-key.asc"
+	thunderbirdInstructions = "README.txt"
+)
+
+// checkThunderbird checks the Thunderbird bundle options before prompting
+// for anything.
+func (r *Recovery) checkThunderbird() error {
+	if r.thunderbirdDir == "" {
+		return nil
+	}
+	if r.demo {
+		return errors.New("the demo identity must never be imported into Thunderbird")
+	}
+	if r.stdout == nil && r.passphraseTTY != nil {
+		return errors.New("the private key can only be encrypted with a one-time passphrase if it is also written to an output")
+	}
+	if _, err := os.Lstat(r.thunderbirdDir); err == nil {
+		return fmt.Errorf("%s already exists", r.thunderbirdDir)
+	}
+	return nil
+}
+
+// writeThunderbirdBundle writes the configured Thunderbird bundle.
+func (r *Recovery) writeThunderbirdBundle(result *Result) error {
+	if err := WriteThunderbirdBundle(r.thunderbirdDir, result); err != nil {
+		return fmt.Errorf("could not write the Thunderbird bundle: %s", err)
+	}
+	r.info(fmt.Sprintf("Wrote the Thunderbird import bundle to %s, see %s", r.thunderbirdDir, filepath.Join(r.thunderbirdDir, thunderbirdInstructions)), LogField{"dir", r.thunderbirdDir})
+	r.audit(auditEntry{Step: "private key written", Output: "thunderbird"})
+	return nil
+}
+
+// WriteThunderbirdBundle creates the directory dir containing what
+// Thunderbird's "Import an existing OpenPGP Key" needs for the recovered
+// identity: the armored secret key (unprotected, so Thunderbird doesn't ask
+// for a passphrase and protects it with its own), the armored public key and
+// step by step instructions in README.txt. If writing fails the directory is
+// removed.
+func WriteThunderbirdBundle(dir string, result *Result) (err error) {
+	if err := os.Mkdir(dir, 0700); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(dir)
+		}
+	}()
+
+	secretKey, err := result.ArmoredPrivateKey()
+	if err != nil {
+		return err
+	}
+	defer wipe(secretKey)
+	publicKey, err := result.ArmoredPublicKey()
+	if err != nil {
+		return err
+	}
+	files := []struct {
+		name string
+		data []byte
+		perm os.FileMode
+	}{
+		{thunderbirdSecretKey, secretKey, 0600},
+		{thunderbirdPublicKey, []byte(publicKey), 0644},
+		{thunderbirdInstructions, []byte(thunderbirdReadme(result)), 0644},
+	}
+	for _, f := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, f.name), f.data, f.perm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// thunderbirdReadme returns the instructions for importing the bundle of
+// result, following Thunderbird's OpenPGP key manager.
+func thunderbirdReadme(result *Result) string {
+	email := userIDEmail(result.UserID)
+	account := "the account for " + email
+	if email == "" {
+		account = "your account (Thunderbird only lets you use a key for an account whose email address is in its user ID, which this key's isn't)"
+	}
+	return fmt.Sprintf(`Importing your recovered OpenPGP key into Thunderbird
+=====================================================
+
+User ID:      %s
+Fingerprint:  %s
+
+1. In Thunderbird, open Account Settings from the menu and select
+   "End-To-End Encryption" under %s.
+
+2. Click "Add Key...", choose "Import an existing OpenPGP Key" and click
+   "Continue".
+
+3. Click "Select File to Import" and choose %s from this folder.
+   It isn't protected with a passphrase, so Thunderbird doesn't ask for one
+   and protects it with its own.
+
+4. Check the fingerprint Thunderbird shows is the one above, click "Continue"
+   and then select the key under End-To-End Encryption to use it for the
+   account.
+
+Your old encrypted mail can then be read again, and new mail can be signed.
+%s is your public key, which you can send to people who
+want to email you encrypted.
+
+Once the key is imported, delete %s (e.g. with
+'shred -u %s'): anyone who has it can read your encrypted mail.
+`, result.UserID, spacedFingerprint(result.PrimaryFingerprint), account, thunderbirdSecretKey, thunderbirdPublicKey, thunderbirdSecretKey, thunderbirdSecretKey)
+}
+
+// spacedFingerprint groups a fingerprint in blocks of four characters, as
+// Thunderbird and gpg display it.
+func spacedFingerprint(fingerprint string) string {
+	var blocks []string
+	for i := 0; i < len(fingerprint); i += 4 {
+		end := i + 4
+		if end > len(fingerprint) {
+			end = len(fingerprint)
+		}
+		blocks = append(blocks, fingerprint[i:end])
+	}
+	return strings.Join(blocks, " ")
+}
@@ -0,0 +1,82 @@
+package recovery
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+func TestWriteThunderbirdBundle(t *testing.T) {
+	v := demoVector
+	entity, err := newEntity(v.Mnemonic, v.Passphrase, v.UserID, time.Unix(v.Timestamp, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := newResult(entity)
+	defer result.Wipe()
+	dir := filepath.Join(t.TempDir(), "thunderbird")
+	if err := WriteThunderbirdBundle(dir, result); err != nil {
+		t.Fatal(err)
+	}
+
+	// the secret key is only readable by the user, and unprotected
+	info, err := os.Stat(filepath.Join(dir, thunderbirdSecretKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("unexpected secret key permissions %s", info.Mode())
+	}
+	f, err := os.Open(filepath.Join(dir, thunderbirdSecretKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	keyring, err := openpgp.ReadArmoredKeyRing(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keyring) != 1 || keyring[0].PrivateKey == nil || keyring[0].PrivateKey.Encrypted {
+		t.Fatal("expected an unprotected secret key")
+	}
+	if fingerprint := formatFingerprint(keyring[0].PrimaryKey); fingerprint != v.PrimaryFingerprint {
+		t.Fatalf("unexpected fingerprint %s", fingerprint)
+	}
+
+	// the instructions name the account and fingerprint to check
+	readme, err := ioutil.ReadFile(filepath.Join(dir, thunderbirdInstructions))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"the account for alice@example.com", spacedFingerprint(v.PrimaryFingerprint), "secret-key.asc"} {
+		if !strings.Contains(string(readme), s) {
+			t.Fatalf("expected the instructions to contain %q:\n%s", s, readme)
+		}
+	}
+
+	// an existing directory isn't overwritten
+	if err := WriteThunderbirdBundle(dir, result); !os.IsExist(err) {
+		t.Fatalf("expected an exists error, got %v", err)
+	}
+}
+
+func TestWithThunderbirdBundle(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "thunderbird")
+	if err := Run(WithStdin(strings.NewReader(aliceInput)), WithThunderbirdBundle(dir)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, thunderbirdPublicKey)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the directory is checked before prompting
+	err := Run(WithStdin(strings.NewReader("")), WithThunderbirdBundle(dir))
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected an exists error, got %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package recovery
+
+// translations are the translations of error and warning messages (keyed by
+// their English format) into each of Languages. They cover the problems a
+// user can fix themselves, such as a seed word or timestamp entered wrongly,
+// rather than every message. Each translation must have the same verbs in the
+// same order as its format.
+var translations = map[string]map[string]string{
+	"de": {
+		"aborting at user's request": "Abbruch auf Wunsch des Benutzers",
+		"unexpected end of input":    "unerwartetes Ende der Eingabe",
+		"%w at prompt %q":            "%w bei der Eingabeaufforderung %q",
+		"the answer is longer than 4096 bytes (check a whole key or file wasn't pasted)": "die Antwort ist länger als 4096 Bytes (prüfen Sie, ob nicht ein ganzer Schlüssel oder eine ganze Datei eingefügt wurde)",
+		"%w at seed word %d":    "%w bei Wort %d des Wiederherstellungsschlüssels",
+		"invalid recovery seed": "ungültiger Wiederherstellungsschlüssel",
+		"invalid timestamp":     "ungültiger Zeitstempel",
+		"fingerprint mismatch":  "Fingerabdruck stimmt nicht überein",
+		"checksum incorrect":    "die Prüfsumme ist falsch",
+
+		"invalid mnemonic length %d":        "ungültige Anzahl von Wörtern: %d",
+		"word %d is not in the %s wordlist": "Wort %d ist nicht in der Wortliste %s",
+		"%w: this looks like an Electrum (%s) seed rather than a BIP39 seed, and will not derive the keys of a Trezor":    "%w: dies scheint ein Electrum-Schlüssel (%s) statt eines BIP39-Schlüssels zu sein, aus dem sich die Schlüssel eines Trezor nicht ableiten lassen",
+		"%w: %s (check the words were entered correctly and in the right order)":                                          "%w: %s (prüfen Sie, ob die Wörter richtig und in der richtigen Reihenfolge eingegeben wurden)",
+		"%w: these look like words from the %s BIP39 wordlist, but Trezor recovery seeds always use the English wordlist": "%w: diese Wörter scheinen aus der BIP39-Wortliste %s zu stammen, aber Trezor-Wiederherstellungsschlüssel verwenden immer die englische Wortliste",
+		"%w: word %d is not in the BIP39 English wordlist":                                                                "%w: Wort %d ist nicht in der englischen BIP39-Wortliste",
+		"%w: word %d (%q) is not in the BIP39 English wordlist":                                                           "%w: Wort %d (%q) ist nicht in der englischen BIP39-Wortliste",
+		"%w: %q is not a Unix timestamp in seconds":                                                                       "%w: %q ist kein Unix-Zeitstempel in Sekunden",
+		"%w: %d is outside the range of OpenPGP timestamps (0 to %d)":                                                     "%w: %d liegt außerhalb des Bereichs von OpenPGP-Zeitstempeln (0 bis %d)",
+		"%w: invalid seed length %q, must be 12, 18 or 24":                                                                "%w: ungültige Anzahl von Wörtern %q, erlaubt sind 12, 18 oder 24",
+		"%w: %d words are missing, but at most %d can be searched for":                                                    "%w: %d Wörter fehlen, aber es kann nach höchstens %d gesucht werden",
+		"%w: the recovery seed has missing words":                                                                         "%w: im Wiederherstellungsschlüssel fehlen Wörter",
+		"primary key fingerprint %s does not match expected fingerprint %s":                                               "der Fingerabdruck %s des Hauptschlüssels stimmt nicht mit dem erwarteten Fingerabdruck %s überein",
+		"the timestamp is zero, which is almost certainly not the timestamp used by 'trezor-gpg init'":                    "der Zeitstempel ist null, was mit ziemlicher Sicherheit nicht der von 'trezor-gpg init' verwendete Zeitstempel ist",
+		"the timestamp is in the future, check it is in seconds rather than milliseconds":                                 "der Zeitstempel liegt in der Zukunft, prüfen Sie, ob er in Sekunden statt in Millisekunden angegeben ist",
+		"the timestamp is in the future (%s)":                                                                             "der Zeitstempel liegt in der Zukunft (%s)",
+		"the timestamp (%s) is from before Trezor devices existed":                                                        "der Zeitstempel (%s) stammt aus der Zeit vor den ersten Trezor-Geräten",
+		"the user ID is empty":                                      "die User-ID ist leer",
+		"the user ID is not valid UTF-8":                            "die User-ID ist kein gültiges UTF-8",
+		"the user ID has leading or trailing whitespace":            "die User-ID beginnt oder endet mit Leerzeichen",
+		"the user ID contains repeated spaces":                      "die User-ID enthält mehrere Leerzeichen hintereinander",
+		"the user ID contains non-printable characters":             "die User-ID enthält nicht druckbare Zeichen",
+		`the user ID does not look like "Name <email@example.com>"`: `die User-ID sieht nicht wie "Name <email@example.com>" aus`,
+		"the recovery seed is a valid BIP39 seed but also passes Electrum's seed version check; if it was generated by Electrum rather than a Trezor the derived keys will be wrong": "der Wiederherstellungsschlüssel ist ein gültiger BIP39-Schlüssel, besteht aber auch Electrums Versionsprüfung; wurde er von Electrum statt von einem Trezor erzeugt, sind die abgeleiteten Schlüssel falsch",
+		"could not lock memory, secrets may be swapped to disk: %s": "der Speicher konnte nicht gesperrt werden, Geheimnisse könnten auf die Festplatte ausgelagert werden: %s",
+		"running as root: root's shell history, auditd and core dump settings make it more likely secrets are accidentally written to disk, so run the recovery as an unprivileged user if you can": "Ausführung als root: durch den Shell-Verlauf, auditd und die Core-Dump-Einstellungen von root ist es wahrscheinlicher, dass Geheimnisse versehentlich auf die Festplatte geschrieben werden, führen Sie die Wiederherstellung daher wenn möglich als normaler Benutzer aus",
+		"unencrypted swap is enabled (%s), so secrets could be written to disk if memory is swapped out; run 'swapoff -a' before entering your recovery seed":                                       "unverschlüsselter Auslagerungsspeicher ist aktiviert (%s), daher könnten Geheimnisse auf die Festplatte geschrieben werden; führen Sie 'swapoff -a' aus, bevor Sie Ihren Wiederherstellungsschlüssel eingeben",
+
+		"English":               "Englisch",
+		"Spanish":               "Spanisch",
+		"Italian":               "Italienisch",
+		"Japanese":              "Japanisch",
+		"Korean":                "Koreanisch",
+		"Chinese (Simplified)":  "Chinesisch (vereinfacht)",
+		"Chinese (Traditional)": "Chinesisch (traditionell)",
+		"French":                "Französisch",
+	},
+	"es": {
+		"aborting at user's request": "cancelado a petición del usuario",
+		"unexpected end of input":    "fin inesperado de la entrada",
+		"%w at prompt %q":            "%w en la pregunta %q",
+		"the answer is longer than 4096 bytes (check a whole key or file wasn't pasted)": "la respuesta ocupa más de 4096 bytes (compruebe que no se pegó una clave o un archivo entero)",
+		"%w at seed word %d":    "%w en la palabra %d de la semilla",
+		"invalid recovery seed": "semilla de recuperación no válida",
+		"invalid timestamp":     "marca de tiempo no válida",
+		"fingerprint mismatch":  "la huella digital no coincide",
+		"checksum incorrect":    "la suma de verificación es incorrecta",
+
+		"invalid mnemonic length %d":        "número de palabras no válido: %d",
+		"word %d is not in the %s wordlist": "la palabra %d no está en la lista de palabras en %s",
+		"%w: this looks like an Electrum (%s) seed rather than a BIP39 seed, and will not derive the keys of a Trezor":    "%w: parece una semilla de Electrum (%s) en lugar de una semilla BIP39, y no derivará las claves de un Trezor",
+		"%w: %s (check the words were entered correctly and in the right order)":                                          "%w: %s (compruebe que las palabras se introdujeron correctamente y en el orden correcto)",
+		"%w: these look like words from the %s BIP39 wordlist, but Trezor recovery seeds always use the English wordlist": "%w: parecen palabras de la lista BIP39 en %s, pero las semillas de recuperación de Trezor siempre usan la lista en inglés",
+		"%w: word %d is not in the BIP39 English wordlist":                                                                "%w: la palabra %d no está en la lista BIP39 en inglés",
+		"%w: word %d (%q) is not in the BIP39 English wordlist":                                                           "%w: la palabra %d (%q) no está en la lista BIP39 en inglés",
+		"%w: %q is not a Unix timestamp in seconds":                                                                       "%w: %q no es una marca de tiempo Unix en segundos",
+		"%w: %d is outside the range of OpenPGP timestamps (0 to %d)":                                                     "%w: %d está fuera del rango de las marcas de tiempo de OpenPGP (de 0 a %d)",
+		"%w: invalid seed length %q, must be 12, 18 or 24":                                                                "%w: número de palabras %q no válido, debe ser 12, 18 o 24",
+		"%w: %d words are missing, but at most %d can be searched for":                                                    "%w: faltan %d palabras, pero solo se pueden buscar %d como máximo",
+		"%w: the recovery seed has missing words":                                                                         "%w: a la semilla de recuperación le faltan palabras",
+		"primary key fingerprint %s does not match expected fingerprint %s":                                               "la huella digital %s de la clave principal no coincide con la huella digital esperada %s",
+		"the timestamp is zero, which is almost certainly not the timestamp used by 'trezor-gpg init'":                    "la marca de tiempo es cero, que casi seguro no es la marca de tiempo usada por 'trezor-gpg init'",
+		"the timestamp is in the future, check it is in seconds rather than milliseconds":                                 "la marca de tiempo está en el futuro, compruebe que está en segundos y no en milisegundos",
+		"the timestamp is in the future (%s)":                                                                             "la marca de tiempo está en el futuro (%s)",
+		"the timestamp (%s) is from before Trezor devices existed":                                                        "la marca de tiempo (%s) es anterior a la existencia de los dispositivos Trezor",
+		"the user ID is empty":                                      "el ID de usuario está vacío",
+		"the user ID is not valid UTF-8":                            "el ID de usuario no es UTF-8 válido",
+		"the user ID has leading or trailing whitespace":            "el ID de usuario empieza o termina con espacios",
+		"the user ID contains repeated spaces":                      "el ID de usuario contiene espacios repetidos",
+		"the user ID contains non-printable characters":             "el ID de usuario contiene caracteres no imprimibles",
+		`the user ID does not look like "Name <email@example.com>"`: `el ID de usuario no tiene la forma "Nombre <email@example.com>"`,
+		"the recovery seed is a valid BIP39 seed but also passes Electrum's seed version check; if it was generated by Electrum rather than a Trezor the derived keys will be wrong": "la semilla de recuperación es una semilla BIP39 válida pero también pasa la comprobación de versión de Electrum; si la generó Electrum y no un Trezor, las claves derivadas serán incorrectas",
+		"could not lock memory, secrets may be swapped to disk: %s": "no se pudo bloquear la memoria, los secretos podrían escribirse en el disco de intercambio: %s",
+		"running as root: root's shell history, auditd and core dump settings make it more likely secrets are accidentally written to disk, so run the recovery as an unprivileged user if you can": "ejecutando como root: el historial de la shell, auditd y la configuración de volcados de memoria de root hacen más probable que los secretos se escriban en el disco por accidente, así que ejecute la recuperación como un usuario sin privilegios si puede",
+		"unencrypted swap is enabled (%s), so secrets could be written to disk if memory is swapped out; run 'swapoff -a' before entering your recovery seed":                                       "hay un área de intercambio sin cifrar activada (%s), así que los secretos podrían escribirse en el disco; ejecute 'swapoff -a' antes de introducir su semilla de recuperación",
+
+		"English":               "inglés",
+		"Spanish":               "español",
+		"Italian":               "italiano",
+		"Japanese":              "japonés",
+		"Korean":                "coreano",
+		"Chinese (Simplified)":  "chino simplificado",
+		"Chinese (Traditional)": "chino tradicional",
+		"French":                "francés",
+	},
+	"fr": {
+		"aborting at user's request": "abandon à la demande de l'utilisateur",
+		"unexpected end of input":    "fin inattendue de l'entrée",
+		"%w at prompt %q":            "%w à la question %q",
+		"the answer is longer than 4096 bytes (check a whole key or file wasn't pasted)": "la réponse dépasse 4096 octets (vérifiez qu'une clé ou un fichier entier n'a pas été collé)",
+		"%w at seed word %d":    "%w au mot %d de la phrase de récupération",
+		"invalid recovery seed": "phrase de récupération invalide",
+		"invalid timestamp":     "horodatage invalide",
+		"fingerprint mismatch":  "l'empreinte ne correspond pas",
+		"checksum incorrect":    "la somme de contrôle est incorrecte",
+
+		"invalid mnemonic length %d":        "nombre de mots invalide : %d",
+		"word %d is not in the %s wordlist": "le mot %d n'est pas dans la liste de mots %s",
+		"%w: this looks like an Electrum (%s) seed rather than a BIP39 seed, and will not derive the keys of a Trezor":    "%w : cela ressemble à une phrase Electrum (%s) plutôt qu'à une phrase BIP39, qui ne permettra pas de dériver les clés d'un Trezor",
+		"%w: %s (check the words were entered correctly and in the right order)":                                          "%w : %s (vérifiez que les mots ont été saisis correctement et dans le bon ordre)",
+		"%w: these look like words from the %s BIP39 wordlist, but Trezor recovery seeds always use the English wordlist": "%w : ces mots semblent provenir de la liste BIP39 %s, mais les phrases de récupération Trezor utilisent toujours la liste anglaise",
+		"%w: word %d is not in the BIP39 English wordlist":                                                                "%w : le mot %d n'est pas dans la liste BIP39 anglaise",
+		"%w: word %d (%q) is not in the BIP39 English wordlist":                                                           "%w : le mot %d (%q) n'est pas dans la liste BIP39 anglaise",
+		"%w: %q is not a Unix timestamp in seconds":                                                                       "%w : %q n'est pas un horodatage Unix en secondes",
+		"%w: %d is outside the range of OpenPGP timestamps (0 to %d)":                                                     "%w : %d est en dehors de la plage des horodatages OpenPGP (de 0 à %d)",
+		"%w: invalid seed length %q, must be 12, 18 or 24":                                                                "%w : nombre de mots %q invalide, il doit être de 12, 18 ou 24",
+		"%w: %d words are missing, but at most %d can be searched for":                                                    "%w : %d mots manquent, mais on ne peut en rechercher que %d au maximum",
+		"%w: the recovery seed has missing words":                                                                         "%w : il manque des mots à la phrase de récupération",
+		"primary key fingerprint %s does not match expected fingerprint %s":                                               "l'empreinte %s de la clé principale ne correspond pas à l'empreinte attendue %s",
+		"the timestamp is zero, which is almost certainly not the timestamp used by 'trezor-gpg init'":                    "l'horodatage est zéro, ce qui n'est presque certainement pas l'horodatage utilisé par 'trezor-gpg init'",
+		"the timestamp is in the future, check it is in seconds rather than milliseconds":                                 "l'horodatage est dans le futur, vérifiez qu'il est en secondes et non en millisecondes",
+		"the timestamp is in the future (%s)":                                                                             "l'horodatage est dans le futur (%s)",
+		"the timestamp (%s) is from before Trezor devices existed":                                                        "l'horodatage (%s) est antérieur à l'existence des appareils Trezor",
+		"the user ID is empty":                                      "l'identifiant utilisateur est vide",
+		"the user ID is not valid UTF-8":                            "l'identifiant utilisateur n'est pas en UTF-8 valide",
+		"the user ID has leading or trailing whitespace":            "l'identifiant utilisateur commence ou se termine par des espaces",
+		"the user ID contains repeated spaces":                      "l'identifiant utilisateur contient des espaces répétés",
+		"the user ID contains non-printable characters":             "l'identifiant utilisateur contient des caractères non imprimables",
+		`the user ID does not look like "Name <email@example.com>"`: `l'identifiant utilisateur ne ressemble pas à "Nom <email@example.com>"`,
+		"the recovery seed is a valid BIP39 seed but also passes Electrum's seed version check; if it was generated by Electrum rather than a Trezor the derived keys will be wrong": "la phrase de récupération est une phrase BIP39 valide mais passe aussi la vérification de version d'Electrum ; si elle a été générée par Electrum plutôt que par un Trezor, les clés dérivées seront fausses",
+		"could not lock memory, secrets may be swapped to disk: %s": "impossible de verrouiller la mémoire, les secrets pourraient être écrits dans l'espace d'échange : %s",
+		"running as root: root's shell history, auditd and core dump settings make it more likely secrets are accidentally written to disk, so run the recovery as an unprivileged user if you can": "exécution en tant que root : l'historique du shell, auditd et les paramètres de vidage mémoire de root rendent plus probable l'écriture accidentelle de secrets sur le disque, exécutez donc la récupération en tant qu'utilisateur non privilégié si possible",
+		"unencrypted swap is enabled (%s), so secrets could be written to disk if memory is swapped out; run 'swapoff -a' before entering your recovery seed":                                       "un espace d'échange non chiffré est activé (%s), les secrets pourraient donc être écrits sur le disque ; exécutez 'swapoff -a' avant de saisir votre phrase de récupération",
+
+		"English":               "anglais",
+		"Spanish":               "espagnol",
+		"Italian":               "italien",
+		"Japanese":              "japonais",
+		"Korean":                "coréen",
+		"Chinese (Simplified)":  "chinois simplifié",
+		"Chinese (Traditional)": "chinois traditionnel",
+		"French":                "français",
+	},
+}
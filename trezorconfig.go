@@ -0,0 +1,262 @@
+package recovery
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// TrezorConfig is the configuration 'trezor-gpg init' leaves in its GnuPG
+// home directory (~/.gnupg/trezor by default), which pre-fills the recovery's
+// prompts when recovering onto the machine the identity was created on.
+type TrezorConfig struct {
+	// Dir is the directory the configuration was read from.
+	Dir string
+
+	// UserID is the user ID the identity was created with, from the
+	// default-key in gpg.conf or the public key.
+	UserID string
+
+	// Timestamp is the creation time of the identity's public key, which
+	// is zero if the public keyring is missing.
+	Timestamp time.Time
+
+	// Curve is the curve of the identity's public key (e.g.
+	// CurveNIST256P1), which is empty if the public keyring is missing.
+	Curve string
+
+	// PublicKey is the identity's public key from the keyring, if found.
+	PublicKey *openpgp.Entity
+}
+
+// DefaultTrezorHome returns the GnuPG home directory 'trezor-gpg init'
+// creates by default, ~/.gnupg/trezor.
+func DefaultTrezorHome() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".gnupg", "trezor"), nil
+}
+
+// ReadTrezorConfig reads the trezor-agent configuration in dir, returning an
+// error satisfying errors.Is(err, os.ErrNotExist) if there is none. The user
+// ID is read from the default-key in gpg.conf, and the public key (and so
+// the timestamp and curve) from pubring.kbx or pubring.gpg.
+func ReadTrezorConfig(dir string) (*TrezorConfig, error) {
+	config := &TrezorConfig{Dir: dir}
+	userID, err := readDefaultKey(filepath.Join(dir, "gpg.conf"))
+	if err != nil {
+		return nil, err
+	}
+	config.UserID = userID
+
+	keyring, err := readTrezorKeyring(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the trezor-agent public keyring: %s", err)
+	}
+	if keyring != nil {
+		config.PublicKey = findIdentity(keyring, userID)
+		if config.PublicKey == nil {
+			return nil, fmt.Errorf("the trezor-agent public keyring has no key for %q", userID)
+		}
+		if config.UserID == "" {
+			config.UserID = entityUserID(config.PublicKey)
+		}
+		config.Timestamp = config.PublicKey.PrimaryKey.CreationTime
+		config.Curve = keyCurve(config.PublicKey.PrimaryKey)
+	}
+	return config, nil
+}
+
+// readDefaultKey returns the default-key of a gpg.conf, which 'trezor-gpg
+// init' sets to the user ID.
+func readDefaultKey(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	scanner := newLineScanner(f)
+	for scanner.Scan() {
+		fields := strings.SplitN(strings.TrimSpace(scanner.Text()), " ", 2)
+		if len(fields) != 2 || fields[0] != "default-key" {
+			continue
+		}
+		value := strings.TrimSpace(fields[1])
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		if _, err := parseFingerprint(value); err == nil {
+			// a fingerprint rather than the user ID, so rely on the
+			// public key instead
+			return "", nil
+		}
+		return value, nil
+	}
+	return "", scanErr(scanner, path)
+}
+
+// readTrezorKeyring reads the public keyring in dir, which is a keybox file
+// for GnuPG 2.1 and later or a plain OpenPGP keyring before that, returning
+// nil if there is neither.
+func readTrezorKeyring(dir string) (openpgp.EntityList, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, "pubring.kbx"))
+	if err == nil {
+		if data, err = keyboxKeyblocks(data); err != nil {
+			return nil, err
+		}
+	} else if os.IsNotExist(err) {
+		data, err = ioutil.ReadFile(filepath.Join(dir, "pubring.gpg"))
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return openpgp.ReadKeyRing(bytes.NewReader(data))
+}
+
+// keyboxKeyblocks returns the concatenated OpenPGP keyblocks of a GnuPG
+// keybox file, which is a sequence of blobs each starting with its length,
+// type, version, flags and the offset and length of its keyblock.
+func keyboxKeyblocks(data []byte) ([]byte, error) {
+	const (
+		blobHeaderLength = 16
+		blobTypeOpenPGP  = 2
+	)
+	var keyblocks []byte
+	for len(data) > 0 {
+		if len(data) < blobHeaderLength {
+			return nil, errors.New("truncated keybox blob")
+		}
+		length := binary.BigEndian.Uint32(data[0:4])
+		if length < blobHeaderLength || uint64(length) > uint64(len(data)) {
+			return nil, fmt.Errorf("invalid keybox blob length %d", length)
+		}
+		blob := data[:length]
+		data = data[length:]
+		if blob[4] != blobTypeOpenPGP {
+			continue
+		}
+		offset := uint64(binary.BigEndian.Uint32(blob[8:12]))
+		size := uint64(binary.BigEndian.Uint32(blob[12:16]))
+		if offset+size > uint64(len(blob)) {
+			return nil, errors.New("invalid keybox keyblock")
+		}
+		keyblocks = append(keyblocks, blob[offset:offset+size]...)
+	}
+	return keyblocks, nil
+}
+
+// findIdentity returns the key in keyring with the given user ID, or the
+// only key if userID is empty.
+func findIdentity(keyring openpgp.EntityList, userID string) *openpgp.Entity {
+	if userID == "" {
+		if len(keyring) == 1 {
+			return keyring[0]
+		}
+		return nil
+	}
+	for _, entity := range keyring {
+		if _, ok := entity.Identities[userID]; ok {
+			return entity
+		}
+	}
+	return nil
+}
+
+// keyCurve returns the trezor-agent name of the curve of key, or the
+// algorithm if it isn't an ECDSA key.
+func keyCurve(key *packet.PublicKey) string {
+	pub, err := publicECDSAKey(key)
+	if err != nil {
+		return fmt.Sprintf("public key algorithm %d", key.PubKeyAlgo)
+	}
+	if pub.Curve.Params().Name == "P-256" {
+		return CurveNIST256P1
+	}
+	return pub.Curve.Params().Name
+}
+
+// WithTrezorConfig configures the trezor-agent configuration read with
+// ReadTrezorConfig, which pre-fills the user ID and timestamp prompts (an
+// empty answer accepts them) and checks the recovered identity against the
+// configuration's public key, unless another key or fingerprint is given.
+// It is ignored in demo mode.
+func WithTrezorConfig(config *TrezorConfig) Option {
+	return func(r *Recovery) {
+		r.trezorConfig = config
+	}
+}
+
+// applyTrezorConfig checks the identity will be recovered with the curve
+// of the trezor-agent configuration, and sets the expected public key from it
+// if none is given.
+func (r *Recovery) applyTrezorConfig() error {
+	config := r.trezorConfig
+	if config == nil || r.demo {
+		return nil
+	}
+	if config.Curve != "" && config.Curve != r.keyParams().curve {
+		return fmt.Errorf("the trezor-agent identity in %s uses %s, but %s keys are being recovered", config.Dir, config.Curve, r.keyParams().curve)
+	}
+	if config.PublicKey != nil && r.pubEntity == nil && r.fingerprint == "" {
+		r.pubEntity = config.PublicKey
+		r.fingerprint = formatFingerprint(config.PublicKey.PrimaryKey)
+	}
+	return nil
+}
+
+// readConfigured reads the answer to a prompt like readInput, pre-filling it
+// with the answer from the trezor-agent configuration if it has one.
+func (r *Recovery) readConfigured(prompt, help, demoAnswer, configured string) (string, error) {
+	if r.demo || configured == "" {
+		return r.readInput(prompt, help, demoAnswer)
+	}
+	answer, err := r.readLine(fmt.Sprintf("%s [%s]", prompt, configured), help)
+	if err != nil {
+		return "", err
+	}
+	if answer == "" {
+		source := "the trezor-agent configuration"
+		if r.fromPublicKey {
+			source = "the public key"
+		}
+		r.info(fmt.Sprintf("Using %s from %s", configured, source), LogField{"value", configured})
+		return configured, nil
+	}
+	return answer, nil
+}
+
+// configuredUserID returns the user ID from the trezor-agent configuration
+// or the public key given with WithFromPublicKey, if any.
+func (r *Recovery) configuredUserID() string {
+	if r.trezorConfig == nil {
+		return r.publicKeyUserID()
+	}
+	return r.trezorConfig.UserID
+}
+
+// configuredTimestamp returns the timestamp from the trezor-agent
+// configuration or the public key given with WithFromPublicKey, if any.
+func (r *Recovery) configuredTimestamp() string {
+	if r.trezorConfig == nil {
+		return r.publicKeyTimestamp()
+	}
+	if r.trezorConfig.Timestamp.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(r.trezorConfig.Timestamp.Unix(), 10)
+}
@@ -0,0 +1,116 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTrezorHome writes a trezor-agent configuration for Alice to a
+// temporary directory, with her public key in pubring.gpg.
+func writeTrezorHome(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	conf := "# Hardware-based GPG configuration\nagent-program \"" + dir + "/run-agent.sh\"\npersonal-digest-preferences SHA512\ndefault-key \"Alice <alice@example.com>\"\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "gpg.conf"), []byte(conf), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var pub bytes.Buffer
+	if err := recoverEntity(t, aliceInput).Serialize(&pub); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "pubring.gpg"), pub.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestReadTrezorConfig(t *testing.T) {
+	dir := writeTrezorHome(t)
+	config, err := ReadTrezorConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.UserID != "Alice <alice@example.com>" || config.Timestamp.Unix() != 1523060353 || config.Curve != CurveNIST256P1 {
+		t.Fatalf("unexpected config: %+v", config)
+	}
+	if fp := formatFingerprint(config.PublicKey.PrimaryKey); fp != aliceFingerprint {
+		t.Fatalf("unexpected fingerprint %s", fp)
+	}
+
+	// there is no config in an empty directory
+	if _, err := ReadTrezorConfig(t.TempDir()); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected a not exist error, got %v", err)
+	}
+}
+
+func TestReadTrezorConfigKeybox(t *testing.T) {
+	gpg, err := exec.LookPath("gpg")
+	if err != nil {
+		t.Skip("gpg not found in PATH")
+	}
+	dir := writeTrezorHome(t)
+	pub, err := ioutil.ReadFile(filepath.Join(dir, "pubring.gpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "pubring.gpg")); err != nil {
+		t.Fatal(err)
+	}
+
+	// import the public key into a keybox along with another key
+	other, err := newGnuPG(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if _, err := other.run(nil, "--passphrase", "", "--quick-generate-key", "Bob <bob@example.com>", "nistp256"); err != nil {
+		t.Fatal(err)
+	}
+	bob, err := other.run(nil, "--export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(gpg, "--homedir", dir, "--batch", "--quiet", "--no-autostart", "--import")
+	cmd.Stdin = bytes.NewReader(append(bob, pub...))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%s: %s", err, out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pubring.kbx")); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := ReadTrezorConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fp := formatFingerprint(config.PublicKey.PrimaryKey); fp != aliceFingerprint {
+		t.Fatalf("unexpected fingerprint %s", fp)
+	}
+}
+
+func TestWithTrezorConfig(t *testing.T) {
+	config, err := ReadTrezorConfig(writeTrezorHome(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// empty answers accept the configured user ID and timestamp
+	input := strings.Replace(aliceInput, "Alice <alice@example.com>\n1523060353\n", "\n\n", 1)
+	if fp := formatFingerprint(recoverEntity(t, input, WithTrezorConfig(config)).PrimaryKey); fp != aliceFingerprint {
+		t.Fatalf("unexpected fingerprint %s", fp)
+	}
+
+	// the recovered identity is checked against the configured public key
+	input = strings.Replace(aliceInput, "s3cr3t", "secret", 1)
+	err = Run(WithStdin(strings.NewReader(input)), WithStdout(&bytes.Buffer{}), WithTrezorConfig(config), WithStrict())
+	if !errors.Is(err, ErrFingerprintMismatch) {
+		t.Fatalf("expected a fingerprint mismatch, got %v", err)
+	}
+}
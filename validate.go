@@ -0,0 +1,104 @@
+package recovery
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// WithStrict configures validation issues (implausible timestamps, unusual
+// user IDs) to be fatal rather than just warnings. Verification failures
+// (fingerprint and public key mismatches, a failed test decryption) are
+// always fatal.
+func WithStrict() Option {
+	return func(r *Recovery) {
+		r.strict = true
+	}
+}
+
+// trezorLaunch is roughly when the first Trezor devices shipped, so no
+// Trezor GPG identity can have been created before it.
+var trezorLaunch = time.Date(2014, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// ParseTimestamp parses a Unix timestamp in seconds as given to 'trezor-gpg
+// init', ignoring surrounding whitespace. OpenPGP creation times are unsigned
+// 32 bit integers, so timestamps outside that range (which would otherwise
+// silently wrap to a different fingerprint) are rejected.
+func ParseTimestamp(s string) (time.Time, error) {
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return time.Time{}, errorf("%w: %q is not a Unix timestamp in seconds", ErrInvalidTimestamp, s)
+	}
+	timestamp := time.Unix(n, 0)
+	if err := checkTimestampRange(timestamp); err != nil {
+		return time.Time{}, err
+	}
+	return timestamp, nil
+}
+
+// checkTimestampRange checks timestamp fits in an OpenPGP creation time.
+func checkTimestampRange(timestamp time.Time) error {
+	if n := timestamp.Unix(); n < 0 || n > math.MaxUint32 {
+		return errorf("%w: %d is outside the range of OpenPGP timestamps (0 to %d)", ErrInvalidTimestamp, n, uint32(math.MaxUint32))
+	}
+	return nil
+}
+
+// parseSeedLength parses the number of words in the recovery seed.
+func parseSeedLength(s string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || (n != 12 && n != 18 && n != 24) {
+		return 0, errorf("%w: invalid seed length %q, must be 12, 18 or 24", ErrInvalidMnemonic, s)
+	}
+	return n, nil
+}
+
+// checkTimestamp warns about timestamps which are unlikely to have come from
+// 'trezor-gpg init', since a wrong timestamp otherwise just silently produces
+// the wrong fingerprint.
+func (r *Recovery) checkTimestamp(timestamp time.Time) error {
+	var err error
+	switch {
+	case timestamp.Unix() == 0:
+		err = r.warn("the timestamp is zero, which is almost certainly not the timestamp used by 'trezor-gpg init'")
+	case timestamp.After(r.now()):
+		if timestamp.Unix() > 1e11 {
+			err = r.warn("the timestamp is in the future, check it is in seconds rather than milliseconds")
+		} else {
+			err = r.warn("the timestamp is in the future (%s)", timestamp.UTC().Format(time.RFC3339))
+		}
+	case timestamp.Before(trezorLaunch):
+		err = r.warn("the timestamp (%s) is from before Trezor devices existed", timestamp.UTC().Format(time.RFC3339))
+	}
+	if err != nil {
+		return errorf("%w: %s", ErrInvalidTimestamp, err)
+	}
+	return nil
+}
+
+// userIDPattern matches the conventional "Name <email>" user ID form.
+var userIDPattern = regexp.MustCompile(`^[^<>]+ <[^<>@\s]+@[^<>@\s]+>$`)
+
+// checkUserID warns about user IDs which are unusual enough that they may
+// have been mistyped, since the user ID feeds the key derivation.
+func (r *Recovery) checkUserID(userID string) error {
+	switch {
+	case userID == "":
+		return r.warn("the user ID is empty")
+	case !utf8.ValidString(userID):
+		return r.warn("the user ID is not valid UTF-8")
+	case strings.TrimSpace(userID) != userID:
+		return r.warn("the user ID has leading or trailing whitespace")
+	case strings.Contains(userID, "  "):
+		return r.warn("the user ID contains repeated spaces")
+	case strings.IndexFunc(userID, func(c rune) bool { return !unicode.IsPrint(c) }) != -1:
+		return r.warn("the user ID contains non-printable characters")
+	case !userIDPattern.MatchString(userID):
+		return r.warn(`the user ID does not look like "Name <email@example.com>"`)
+	}
+	return nil
+}
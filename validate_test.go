@@ -0,0 +1,71 @@
+package recovery
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckTimestamp(t *testing.T) {
+	for _, test := range []struct {
+		timestamp int64
+		warning   string
+	}{
+		{1523060353, ""},
+		{0, "the timestamp is zero"},
+		{1523060353000, "rather than milliseconds"},
+		{time.Now().Add(time.Hour).Unix(), "the timestamp is in the future"},
+		{1234567890, "from before Trezor devices existed"},
+	} {
+		var stderr bytes.Buffer
+		r := &Recovery{stderr: &stderr}
+		if err := r.checkTimestamp(time.Unix(test.timestamp, 0)); err != nil {
+			t.Fatal(err)
+		}
+		if test.warning == "" {
+			if stderr.Len() > 0 {
+				t.Errorf("unexpected warning for %d: %s", test.timestamp, stderr.String())
+			}
+			continue
+		}
+		if !strings.Contains(stderr.String(), test.warning) {
+			t.Errorf("expected warning %q for %d, got %q", test.warning, test.timestamp, stderr.String())
+		}
+
+		// check the warning is an error in strict mode
+		r = &Recovery{stderr: &stderr, strict: true}
+		if err := r.checkTimestamp(time.Unix(test.timestamp, 0)); err == nil || !strings.Contains(err.Error(), test.warning) {
+			t.Errorf("expected strict error %q for %d, got %v", test.warning, test.timestamp, err)
+		}
+	}
+}
+
+func TestCheckUserID(t *testing.T) {
+	for _, test := range []struct {
+		userID  string
+		warning string
+	}{
+		{"Alice <alice@example.com>", ""},
+		{"Alice Smith (work) <alice@example.com>", ""},
+		{"", "the user ID is empty"},
+		{"Alice <alice@example.com> ", "leading or trailing whitespace"},
+		{"Alice  <alice@example.com>", "repeated spaces"},
+		{"Alice\t<alice@example.com>", "non-printable characters"},
+		{"alice@example.com", "does not look like"},
+		{"Alice <alice>", "does not look like"},
+	} {
+		r := &Recovery{stderr: ioutil.Discard, strict: true}
+		err := r.checkUserID(test.userID)
+		if test.warning == "" {
+			if err != nil {
+				t.Errorf("unexpected warning for %q: %s", test.userID, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.warning) {
+			t.Errorf("expected warning %q for %q, got %v", test.warning, test.userID, err)
+		}
+	}
+}
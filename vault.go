@@ -0,0 +1,317 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// WithVault configures the recovery to write the recovered key into the
+// HashiCorp Vault server at addr (e.g. https://vault.example.com:8200) with
+// token, which is prompted for if empty. target is where the key is written:
+//
+//   - "kv:PATH" writes a secret holding the encoded private key (see
+//     WithEncoder), the public key, the user ID and the fingerprint to PATH
+//     (including the mount, e.g. "kv:secret/trezor/alice") of a KV version 1
+//     or 2 secrets engine, refusing to overwrite an existing secret
+//   - "transit:MOUNT/NAME" imports the primary key as the ecdsa-p256 key
+//     NAME of the transit secrets engine at MOUNT (Vault 1.11 or later), for
+//     signing with Vault
+//   - "ssh:MOUNT" configures the primary key as the CA key of the SSH
+//     secrets engine at MOUNT, which Vault refuses if it already has one
+//
+// The private key is still printed if WithStdout is also given.
+func WithVault(addr, token, target string) Option {
+	return func(r *Recovery) {
+		r.vaultAddr = addr
+		r.vaultToken = token
+		r.vaultTarget = target
+	}
+}
+
+// vaultTarget is a parsed WithVault target.
+type vaultTarget struct {
+	backend string
+	path    string
+}
+
+// parseVaultTarget parses a WithVault target.
+func parseVaultTarget(target string) (*vaultTarget, error) {
+	parts := strings.SplitN(target, ":", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid Vault target %q: must be kv:PATH, transit:MOUNT/NAME or ssh:MOUNT", target)
+	}
+	t := &vaultTarget{backend: parts[0], path: strings.Trim(parts[1], "/")}
+	switch {
+	case t.backend != "kv" && t.backend != "transit" && t.backend != "ssh":
+		return nil, fmt.Errorf("unknown Vault backend %q: must be kv, transit or ssh", t.backend)
+	case t.path == "":
+		return nil, fmt.Errorf("invalid Vault target %q: missing path", target)
+	case t.backend == "kv" && !strings.Contains(t.path, "/"):
+		return nil, fmt.Errorf("invalid Vault target %q: the path must include the mount, e.g. kv:secret/trezor", target)
+	case t.backend == "transit" && !strings.Contains(t.path, "/"):
+		return nil, fmt.Errorf("invalid Vault target %q: must be transit:MOUNT/NAME", target)
+	}
+	return t, nil
+}
+
+// checkVault checks the Vault options before prompting for anything.
+func (r *Recovery) checkVault() error {
+	if r.vaultAddr == "" {
+		return nil
+	}
+	if _, err := parseVaultTarget(r.vaultTarget); err != nil {
+		return err
+	}
+	if r.demo {
+		return errors.New("the demo identity must never be written to Vault")
+	}
+	if r.stdout == nil && r.passphraseTTY != nil {
+		return errors.New("the private key can only be encrypted with a one-time passphrase if it is also written to an output")
+	}
+	return nil
+}
+
+// writeVault writes result to the configured Vault target, prompting for
+// the token if not given.
+func (r *Recovery) writeVault(result *Result) error {
+	target, err := parseVaultTarget(r.vaultTarget)
+	if err != nil {
+		return err
+	}
+	token := r.vaultToken
+	if token == "" {
+		secret, err := r.readSecret("Please enter the Vault token to write the key with:")
+		if err != nil {
+			return err
+		}
+		token = string(secret)
+		wipe(secret)
+	}
+	v := &vaultClient{ctx: r.ctx, addr: strings.TrimSuffix(r.vaultAddr, "/"), token: token}
+	switch target.backend {
+	case "kv":
+		encoder := r.encoder
+		if encoder == nil {
+			encoder = ArmoredEncoder()
+		}
+		err = v.writeKV(target.path, result, encoder)
+	case "transit":
+		i := strings.LastIndex(target.path, "/")
+		err = v.importTransit(target.path[:i], target.path[i+1:], result)
+	case "ssh":
+		err = v.configureSSHCA(target.path, result)
+	}
+	if err != nil {
+		return fmt.Errorf("could not write the key to Vault: %s", err)
+	}
+	r.info(fmt.Sprintf("Wrote the key to Vault at %s", r.vaultTarget), LogField{"target", r.vaultTarget})
+	r.audit(auditEntry{Step: "private key written", Output: "vault"})
+	return nil
+}
+
+// vaultClient makes requests to Vault's HTTP API.
+type vaultClient struct {
+	ctx   context.Context
+	addr  string
+	token string
+}
+
+// request makes a request to path (without the /v1/ prefix), decoding the
+// response's data into out if not nil. It returns a vaultStatusError for
+// error responses.
+func (v *vaultClient) request(method, path string, body, out interface{}) error {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		if reqBody, err = json.Marshal(body); err != nil {
+			return err
+		}
+		defer wipe(reqBody)
+	}
+	req, err := http.NewRequestWithContext(v.ctx, method, v.addr+"/v1/"+path, bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	req.Header.Set("X-Vault-Request", "true")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode/100 != 2 {
+		var errRes struct {
+			Errors []string `json:"errors"`
+		}
+		msg := strings.TrimSpace(string(resBody))
+		if err := json.Unmarshal(resBody, &errRes); err == nil && len(errRes.Errors) > 0 {
+			msg = strings.Join(errRes.Errors, ", ")
+		}
+		return &vaultStatusError{status: res.StatusCode, msg: fmt.Sprintf("%s %s: %s: %s", method, path, res.Status, msg)}
+	}
+	if out == nil || len(resBody) == 0 {
+		return nil
+	}
+	return json.Unmarshal(resBody, &struct {
+		Data interface{} `json:"data"`
+	}{out})
+}
+
+// vaultStatusError is an error response from Vault.
+type vaultStatusError struct {
+	status int
+	msg    string
+}
+
+func (e *vaultStatusError) Error() string {
+	return e.msg
+}
+
+// writeKV writes result as a new secret at path of a KV secrets engine,
+// looking up which version the engine is as the vault CLI does.
+func (v *vaultClient) writeKV(path string, result *Result, encoder Encoder) error {
+	var mount struct {
+		Path    string            `json:"path"`
+		Type    string            `json:"type"`
+		Options map[string]string `json:"options"`
+	}
+	if err := v.request("GET", "sys/internal/ui/mounts/"+path, nil, &mount); err != nil {
+		return fmt.Errorf("could not look up the mount of %s: %s", path, err)
+	}
+	if mount.Type != "kv" && mount.Type != "generic" {
+		return fmt.Errorf("%s is in a %s secrets engine rather than kv", path, mount.Type)
+	}
+
+	var privateKey bytes.Buffer
+	defer func() { wipe(privateKey.Bytes()) }()
+	if err := encoder.Encode(&privateKey, result); err != nil {
+		return err
+	}
+	publicKey, err := result.ArmoredPublicKey()
+	if err != nil {
+		return err
+	}
+	data := map[string]string{
+		"user_id":     result.UserID,
+		"fingerprint": result.PrimaryFingerprint,
+		"private_key": privateKey.String(),
+		"public_key":  publicKey,
+	}
+
+	// version 2 refuses to overwrite the secret with a check-and-set
+	// version of 0, version 1 has to be checked first
+	rest := strings.TrimPrefix(path, mount.Path)
+	if mount.Options["version"] == "2" {
+		body := map[string]interface{}{"data": data, "options": map[string]int{"cas": 0}}
+		err := v.request("POST", mount.Path+"data/"+rest, body, nil)
+		if e, ok := err.(*vaultStatusError); ok && e.status == http.StatusBadRequest && strings.Contains(e.msg, "check-and-set") {
+			return fmt.Errorf("%s already exists", path)
+		}
+		return err
+	}
+	err = v.request("GET", path, nil, nil)
+	if err == nil {
+		return fmt.Errorf("%s already exists", path)
+	} else if e, ok := err.(*vaultStatusError); !ok || e.status != http.StatusNotFound {
+		return err
+	}
+	return v.request("POST", path, data, nil)
+}
+
+// importTransit imports the primary key of result as the key name of the
+// transit secrets engine at mount, wrapping it with the engine's wrapping
+// key as Vault's BYOK import requires: the PKCS #8 key is wrapped with a
+// random AES key using AES key wrap with padding, which is encrypted with
+// the RSA wrapping key using OAEP with SHA-256.
+func (v *vaultClient) importTransit(mount, name string, result *Result) error {
+	var wrapping struct {
+		PublicKey string `json:"public_key"`
+	}
+	if err := v.request("GET", mount+"/wrapping_key", nil, &wrapping); err != nil {
+		return fmt.Errorf("could not get the wrapping key: %s", err)
+	}
+	block, _ := pem.Decode([]byte(wrapping.PublicKey))
+	if block == nil {
+		return errors.New("could not decode the wrapping key")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("could not parse the wrapping key: %s", err)
+	}
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("unexpected wrapping key type %T", pub)
+	}
+
+	key, err := privateECDSAKey(result.Entity.PrivateKey)
+	if err != nil {
+		return err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	defer wipe(der)
+	aesKey := make([]byte, 32)
+	if _, err := rand.Read(aesKey); err != nil {
+		return err
+	}
+	defer wipe(aesKey)
+	wrappedKey, err := aesKeyWrapPad(aesKey, der)
+	if err != nil {
+		return err
+	}
+	encryptedAESKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, rsaPub, aesKey, nil)
+	if err != nil {
+		return err
+	}
+	return v.request("POST", fmt.Sprintf("%s/keys/%s/import", mount, name), map[string]interface{}{
+		"ciphertext":    base64.StdEncoding.EncodeToString(append(encryptedAESKey, wrappedKey...)),
+		"type":          "ecdsa-p256",
+		"hash_function": "SHA256",
+	}, nil)
+}
+
+// configureSSHCA configures the primary key of result as the CA key of the
+// SSH secrets engine at mount.
+func (v *vaultClient) configureSSHCA(mount string, result *Result) error {
+	key, err := privateECDSAKey(result.Entity.PrivateKey)
+	if err != nil {
+		return err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	defer wipe(der)
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	defer wipe(privateKey)
+	publicKey, err := result.Identity().SSHPublicKey()
+	if err != nil {
+		return err
+	}
+	return v.request("POST", mount+"/config/ca", map[string]interface{}{
+		"private_key":          string(privateKey),
+		"public_key":           publicKey,
+		"generate_signing_key": false,
+	}, nil)
+}
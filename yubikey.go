@@ -0,0 +1,278 @@
+package recovery
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// WithYubiKey configures a guided flow which, once the identity has been
+// recovered, provisions it into the gpg-agent of home like WithGPGAgent and
+// then moves its keys onto the OpenPGP applet of a YubiKey (or any OpenPGP
+// card) with gpg-agent and scdaemon, leaving only stubs in the agent's
+// private-keys-v1.d (see MoveToCard).
+//
+// Since the card has to be reached through gpg-agent, this is meant to be run
+// on a clean machine rather than an air-gapped one.
+func WithYubiKey(home string) Option {
+	return func(r *Recovery) {
+		r.gpgAgent = true
+		r.gpgAgentHome = home
+		r.yubiKey = true
+	}
+}
+
+// Card is an OpenPGP card, such as the OpenPGP applet of a YubiKey.
+type Card struct {
+	// Serial is the card's application serial number, as shown by 'gpg
+	// --card-status'.
+	Serial string
+
+	// Fingerprints are the fingerprints of the keys in the card's
+	// signature, encryption and authentication slots, which are empty if
+	// the slot is.
+	Fingerprints [3]string
+
+	// Version is the version of the OpenPGP card specification the card
+	// implements (e.g. "3.4"), and Manufacturer its manufacturer's ID (e.g.
+	// 0x0006 for Yubico), both taken from the serial number.
+	Version      string
+	Manufacturer uint16
+
+	// AdminPINRetries is how many more times the admin PIN can be entered
+	// before the card blocks it, or -1 if unknown.
+	AdminPINRetries int
+}
+
+// cardSlots are the card slots the primary key and subkey are moved to.
+var cardSlots = []string{"OPENPGP.1", "OPENPGP.2"}
+
+// moveToYubiKey guides the user through moving the keys of entity from
+// gpg-agent onto a YubiKey.
+func (r *Recovery) moveToYubiKey(entity *openpgp.Entity) error {
+	r.display(`
+-----------------------------------------------------------------------------
+ Moving the keys onto a YubiKey
+
+ The primary key is moved to the YubiKey's signature slot and the subkey to
+ its encryption slot, which needs firmware 5.2 or later for NIST P-256 keys.
+ gpg-agent then only keeps stubs pointing at the YubiKey, so the recovery
+ seed is the only other copy of the keys.
+-----------------------------------------------------------------------------`)
+	card, err := r.selectCard("YubiKey", "", nil)
+	if err != nil {
+		return err
+	}
+	pin, err := r.readSecret("Please enter the YubiKey's admin PIN (12345678 if it hasn't been changed):")
+	if err != nil {
+		return err
+	}
+	defer wipe(pin)
+	if err := MoveToCard(r.ctx, entity, r.gpgAgentHome, card.Serial, pin); err != nil {
+		return fmt.Errorf("could not move the keys onto the YubiKey: %s", err)
+	}
+	r.info(fmt.Sprintf("Moved the keys onto YubiKey %s", card.Serial), LogField{"serial", card.Serial})
+	r.audit(auditEntry{Step: "keys moved to card"})
+	return nil
+}
+
+// selectCard asks the user to insert the card, which the user calls name
+// (e.g. "YubiKey"), reads it (adding hint, if not empty, to the error if it
+// can't be), calls check (if not nil) to return an error if the card can't
+// be used and asks for confirmation before its slots are overwritten.
+func (r *Recovery) selectCard(name, hint string, check func(*Card) error) (*Card, error) {
+	if _, err := r.readLine(fmt.Sprintf("Insert the %s and press enter:", name), insertCardHelp); err != nil {
+		return nil, err
+	}
+	card, err := ReadCard(r.ctx, r.gpgAgentHome)
+	if err != nil && hint != "" {
+		return nil, fmt.Errorf("could not read the %s: %s (%s)", name, err, hint)
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read the %s: %s", name, err)
+	}
+	r.display("%-25s%s", name+":", card.Serial)
+	if check != nil {
+		if err := check(card); err != nil {
+			return nil, err
+		}
+	}
+	for i, slot := range []string{"Signature", "Encryption"} {
+		if card.Fingerprints[i] == "" {
+			continue
+		}
+		r.display("%-25s%s", slot+" key:", card.Fingerprints[i])
+		if err := r.warn("the %s's %s slot already has a key, which will be overwritten", name, strings.ToLower(slot)); err != nil {
+			return nil, err
+		}
+	}
+
+	// a wrong admin PIN can't be retried forever, and some cards have to
+	// be reset (wiping them) once it is blocked
+	switch {
+	case card.AdminPINRetries == 0:
+		return nil, fmt.Errorf("the %s's admin PIN is blocked, so it has to be reset before keys can be moved onto it", name)
+	case card.AdminPINRetries > 0 && card.AdminPINRetries < 3:
+		if err := r.warn("only %d admin PIN attempt(s) are left before the %s blocks it", card.AdminPINRetries, name); err != nil {
+			return nil, err
+		}
+	}
+	if ok, err := r.confirm(fmt.Sprintf("Move the keys onto %s %s?", name, card.Serial), moveKeysHelp); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, ErrAborted
+	}
+	return card, nil
+}
+
+// ReadCard returns the OpenPGP card inserted in the machine, as seen by the
+// gpg-agent of home (GNUPGHOME or ~/.gnupg if empty).
+func ReadCard(ctx context.Context, home string) (*Card, error) {
+	_, agent, err := connectGPGAgent(ctx, home)
+	if err != nil {
+		return nil, err
+	}
+	defer agent.Close()
+	return agent.readCard()
+}
+
+// MoveToCard moves the private keys of entity from the gpg-agent of home
+// (where ProvisionGPGAgent put them) onto the OpenPGP card with the given
+// serial number, writing the primary key to the signature slot and the
+// subkey to the encryption slot, overwriting any keys already there. The
+// agent writes stubs in place of the private keys, which is checked before
+// returning. adminPIN is the card's admin PIN.
+func MoveToCard(ctx context.Context, entity *openpgp.Entity, home, serial string, adminPIN []byte) error {
+	_, agent, err := connectGPGAgent(ctx, home)
+	if err != nil {
+		return err
+	}
+	defer agent.Close()
+	return agent.moveToCard(entity, serial, adminPIN)
+}
+
+// readCard asks scdaemon, through the agent, for the inserted card's serial
+// number and key fingerprints.
+func (c *assuanConn) readCard() (*Card, error) {
+	_, status, err := c.transactStatus("SCD SERIALNO openpgp", nil)
+	if err != nil {
+		return nil, err
+	}
+	card := &Card{AdminPINRetries: -1}
+	for _, line := range status {
+		if fields := strings.Fields(line); len(fields) >= 2 && fields[0] == "SERIALNO" {
+			card.Serial = fields[1]
+		}
+	}
+	if card.Serial == "" {
+		return nil, errors.New("no OpenPGP card found")
+	}
+
+	// the serial number is the card's application identifier: the RID and
+	// PIX (D27600012401), then the version, manufacturer and serial number
+	if aid, err := hex.DecodeString(card.Serial); err == nil && len(aid) >= 10 {
+		card.Version = fmt.Sprintf("%x.%x", aid[6], aid[7])
+		card.Manufacturer = binary.BigEndian.Uint16(aid[8:10])
+	}
+	_, status, err = c.transactStatus("SCD GETATTR KEY-FPR", nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range status {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[0] != "KEY-FPR" {
+			continue
+		}
+		var slot int
+		if _, err := fmt.Sscanf(fields[1], "%d", &slot); err != nil || slot < 1 || slot > len(card.Fingerprints) {
+			continue
+		}
+		if strings.Trim(fields[2], "0") != "" {
+			card.Fingerprints[slot-1] = strings.ToUpper(fields[2])
+		}
+	}
+
+	// CHV-STATUS is e.g. "+1+127+127+127+3+0+3": whether the PIN is only
+	// valid for one signature, the maximum PIN, reset code and admin PIN
+	// lengths and then their retry counters
+	_, status, err = c.transactStatus("SCD GETATTR CHV-STATUS", nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range status {
+		fields := strings.FieldsFunc(line, func(r rune) bool { return r == ' ' || r == '+' })
+		if len(fields) != 8 || fields[0] != "CHV-STATUS" {
+			continue
+		}
+		if retries, err := strconv.Atoi(fields[7]); err == nil {
+			card.AdminPINRetries = retries
+		}
+	}
+	return card, nil
+}
+
+// moveToCard moves the keys of entity onto the card with the given serial
+// number with the agent's KEYTOCARD command, as gpg's keytocard does.
+func (c *assuanConn) moveToCard(entity *openpgp.Entity, serial string, adminPIN []byte) error {
+	primaryGrip, subkeyGrip, err := NewIdentity(entity).Keygrips()
+	if err != nil {
+		return err
+	}
+
+	// the card stores the creation time and, for the ECDH subkey, the KDF
+	// parameters so that it computes the same fingerprints
+	ecdh, err := readECDHParams(entity.Subkeys[0].PublicKey)
+	if err != nil {
+		return err
+	}
+	commands := []string{
+		fmt.Sprintf("KEYTOCARD --force %s %s %s %s", primaryGrip, serial, cardSlots[0], isoTime(entity.PrimaryKey.CreationTime)),
+		fmt.Sprintf("KEYTOCARD --force %s %s %s %s %X", subkeyGrip, serial, cardSlots[1], isoTime(entity.Subkeys[0].PublicKey.CreationTime), ecdh.kdf),
+	}
+
+	// answer the admin PIN inquiry rather than the agent running pinentry
+	if _, err := c.transact("OPTION pinentry-mode=loopback", nil); err != nil {
+		return err
+	}
+	for _, command := range commands {
+		_, err := c.transact(command, func(keyword string) ([]byte, error) {
+			if keyword != "PASSPHRASE" {
+				return nil, fmt.Errorf("unexpected inquiry %s", keyword)
+			}
+			return adminPIN, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// check the private keys have been replaced with stubs
+	for _, grip := range []string{primaryGrip, subkeyGrip} {
+		_, status, err := c.transactStatus("KEYINFO "+grip, nil)
+		if err != nil {
+			return err
+		}
+		onCard := false
+		for _, line := range status {
+			fields := strings.Fields(line)
+			if len(fields) >= 4 && fields[0] == "KEYINFO" && fields[2] == "T" && fields[3] == serial {
+				onCard = true
+			}
+		}
+		if !onCard {
+			return fmt.Errorf("key %s was not moved onto card %s", grip, serial)
+		}
+	}
+	return nil
+}
+
+// isoTime formats t as gpg does in the Assuan protocol.
+func isoTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405")
+}
@@ -0,0 +1,176 @@
+package recovery
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testCardSerial = "D2760001240103040006123456780000"
+
+// fakeCardAgent serves a gpg-agent with an OpenPGP card inserted, recording
+// the commands it is sent and moving keys onto the card if given the admin
+// PIN.
+type fakeCardAgent struct {
+	serial   string
+	commands []string
+	onCard   map[string]bool
+}
+
+// readPIN reads the response to a PASSPHRASE inquiry.
+func readPIN(r *bufio.Reader) (string, error) {
+	var pin string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		if line == "END\n" {
+			return pin, nil
+		}
+		pin += strings.TrimPrefix(strings.TrimSuffix(line, "\n"), "D ")
+	}
+}
+
+func (a *fakeCardAgent) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	fmt.Fprint(conn, "OK Pleased to meet you\n")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.TrimSuffix(line, "\n")
+		a.commands = append(a.commands, command)
+		fields := strings.Fields(command)
+		switch fields[0] {
+		case "SCD":
+			switch fields[1] {
+			case "SERIALNO":
+				fmt.Fprintf(conn, "S SERIALNO %s\nOK\n", a.serial)
+			case "GETATTR":
+				switch fields[2] {
+				case "KEY-FPR":
+					fmt.Fprintf(conn, "S KEY-FPR 1 %s\nS KEY-FPR 3 %s\nOK\n", strings.Repeat("0", 40), strings.Repeat("AB", 20))
+				case "CHV-STATUS":
+					fmt.Fprint(conn, "S CHV-STATUS +1+127+127+127+3+0+2\nOK\n")
+				}
+			case "SETATTR":
+				fmt.Fprint(conn, "INQUIRE PASSPHRASE\n")
+				if _, err := readPIN(r); err != nil {
+					return
+				}
+				fmt.Fprint(conn, "OK\n")
+			}
+		case "KEYTOCARD":
+			fmt.Fprint(conn, "INQUIRE PASSPHRASE\n")
+			pin, err := readPIN(r)
+			if err != nil {
+				return
+			}
+			if pin != "12345678" {
+				fmt.Fprint(conn, "ERR 100663379 Bad PIN <SCD>\n")
+				continue
+			}
+			a.onCard[fields[2]] = true
+			fmt.Fprint(conn, "OK\n")
+		case "KEYINFO":
+			if a.onCard[fields[1]] {
+				fmt.Fprintf(conn, "S KEYINFO %s T %s OPENPGP.1 - - - - -\nOK\n", fields[1], a.serial)
+			} else {
+				fmt.Fprintf(conn, "S KEYINFO %s D - - - - - - -\nOK\n", fields[1])
+			}
+		case "BYE":
+			fmt.Fprint(conn, "OK closing connection\n")
+			return
+		default:
+			fmt.Fprint(conn, "OK\n")
+		}
+	}
+}
+
+// dialFakeCardAgent returns a connection to a new fakeCardAgent with the
+// card with the given serial number inserted.
+func dialFakeCardAgent(t *testing.T, serial string) (*assuanConn, *fakeCardAgent) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "S.gpg-agent")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	agent := &fakeCardAgent{serial: serial, onCard: make(map[string]bool)}
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		agent.serve(conn)
+	}()
+	conn, err := dialAssuan(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, agent
+}
+
+func TestReadCard(t *testing.T) {
+	conn, _ := dialFakeCardAgent(t, testCardSerial)
+	card, err := conn.readCard()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if card.Serial != testCardSerial {
+		t.Fatalf("unexpected serial %s", card.Serial)
+	}
+	if card.Fingerprints != [3]string{"", "", strings.Repeat("AB", 20)} {
+		t.Fatalf("unexpected fingerprints %q", card.Fingerprints)
+	}
+	if card.Version != "3.4" || card.Manufacturer != 0x0006 {
+		t.Fatalf("unexpected version %s and manufacturer %04X", card.Version, card.Manufacturer)
+	}
+	if card.AdminPINRetries != 2 {
+		t.Fatalf("unexpected admin PIN retries %d", card.AdminPINRetries)
+	}
+}
+
+func TestMoveToCard(t *testing.T) {
+	v := demoVector
+	entity, err := newEntity(v.Mnemonic, v.Passphrase, v.UserID, time.Unix(v.Timestamp, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wipeEntity(entity)
+
+	// a bad PIN is reported
+	conn, _ := dialFakeCardAgent(t, testCardSerial)
+	if err := conn.moveToCard(entity, testCardSerial, []byte("123456")); err == nil || !strings.Contains(err.Error(), "Bad PIN") {
+		t.Fatalf("expected a bad PIN error, got %v", err)
+	}
+
+	conn, agent := dialFakeCardAgent(t, testCardSerial)
+	if err := conn.moveToCard(entity, testCardSerial, []byte("12345678")); err != nil {
+		t.Fatal(err)
+	}
+	// the demo keygrips, creation time and KDF parameters (SHA256, AES128)
+	// are those gpg passes for the demo identity
+	for _, expected := range []string{
+		"KEYTOCARD --force A5DB24D96E62046DE6E5E5D6EAB66F65CDC182D1 " + testCardSerial + " OPENPGP.1 20180407T001913",
+		"KEYTOCARD --force B5649A746CCB8ECCB057BA0D620A18B2CAA8755E " + testCardSerial + " OPENPGP.2 20180407T001913 03010807",
+	} {
+		found := false
+		for _, command := range agent.commands {
+			found = found || command == expected
+		}
+		if !found {
+			t.Fatalf("expected command %q, got %q", expected, agent.commands)
+		}
+	}
+}